@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"cyclone/internal/bot"
+	"cyclone/internal/review"
+)
+
+// actionEvent holds the fields of a pull_request workflow event Cyclone needs
+type actionEvent struct {
+	PullRequest struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// runActionCommand implements "cyclone action", which reviews the pull request of a GitHub
+// Actions workflow run and posts the review. It returns the process exit code, which is 1 if
+// the review failed or found issues in one of the -fail-on categories.
+func runActionCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone action", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	failOn := flags.String("fail-on", "blocking", "comma-separated comment categories that fail the run, empty never fails")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	owner, repoName, prNumber, err := readActionEvent(os.Getenv("GITHUB_EVENT_NAME"), os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	cycloneBot, err := newCLIBot(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	result, err := cycloneBot.ReviewPullRequest(context.Background(), owner, repoName, prNumber, true)
+	if errors.Is(err, bot.ErrReviewSkipped) {
+		fmt.Fprintf(stdout, "Not reviewed: %v\n", err)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if err := writeStepSummary(os.Getenv("GITHUB_STEP_SUMMARY"), result); err != nil {
+		log.Printf("Error writing the job summary: %v", err)
+	}
+
+	failing := failingComments(result.Comments, *failOn)
+	for _, comment := range failing {
+		// Workflow command that annotates the line in the PR's "Files changed" tab
+		fmt.Fprintf(stdout, "::error file=%s,line=%d::%s\n", comment.Path, comment.Line, escapeWorkflowCommand(comment.Body))
+	}
+	if len(failing) > 0 {
+		fmt.Fprintf(stderr, "✗ %d of %d findings are %s\n", len(failing), len(result.Comments), *failOn)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "✓ reviewed %s/%s#%d with %d findings, none %s\n", owner, repoName, prNumber, len(result.Comments), *failOn)
+	return 0
+}
+
+// readActionEvent returns the pull request a workflow run was triggered for
+func readActionEvent(eventName, eventPath string) (string, string, int, error) {
+	if eventName != "pull_request" && eventName != "pull_request_target" {
+		return "", "", 0, fmt.Errorf("cyclone action runs on pull_request or pull_request_target events, not %q", eventName)
+	}
+
+	data, err := os.ReadFile(eventPath)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to read GITHUB_EVENT_PATH: %w", err)
+	}
+
+	var event actionEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", "", 0, fmt.Errorf("failed to parse workflow event: %w", err)
+	}
+	if event.PullRequest.Number == 0 || event.Repository.Name == "" || event.Repository.Owner.Login == "" {
+		return "", "", 0, fmt.Errorf("workflow event has no pull request")
+	}
+
+	return event.Repository.Owner.Login, event.Repository.Name, event.PullRequest.Number, nil
+}
+
+// failingComments returns the comments in any of the comma-separated categories
+func failingComments(comments []review.ReviewComment, categories string) []review.ReviewComment {
+	failOn := make(map[string]bool)
+	for _, category := range strings.Split(categories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			failOn[strings.ToLower(category)] = true
+		}
+	}
+
+	var failing []review.ReviewComment
+	for _, comment := range comments {
+		if failOn[strings.ToLower(comment.Category)] {
+			failing = append(failing, comment)
+		}
+	}
+	return failing
+}
+
+// writeStepSummary appends the review summary to the job summary, if the runner provides one
+func writeStepSummary(path string, result review.ReviewResult) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\n", result.Summary)
+	return err
+}
+
+// escapeWorkflowCommand escapes a message for use in a workflow command
+func escapeWorkflowCommand(message string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+}
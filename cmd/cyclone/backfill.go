@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"cyclone/internal/bot"
+	"cyclone/internal/logging"
+)
+
+// runBackfillCommand implements "cyclone backfill", which reviews the existing PRs of a
+// repository, and returns the process exit code
+func runBackfillCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone backfill", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone backfill [flags] owner/repo")
+		flags.PrintDefaults()
+	}
+	since := flags.String("since", "", "only review PRs created on or after this date (YYYY-MM-DD), required")
+	state := flags.String("state", "open", "state of the PRs to review: open, closed or all")
+	limit := flags.Int("limit", 0, "review at most this many PRs, 0 for no limit")
+	dryRun := flags.Bool("dry-run", false, "generate and store the reviews without posting them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Flags may also follow the repository, e.g. "cyclone backfill owner/repo -since 2024-01-01"
+	var repo string
+	if flags.NArg() > 0 {
+		repo = flags.Arg(0)
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if repo == "" || flags.NArg() > 0 || *since == "" {
+		flags.Usage()
+		return 2
+	}
+
+	owner, repoName, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || repoName == "" {
+		fmt.Fprintf(stderr, "✗ repository must be owner/repo, got %q\n", repo)
+		return 2
+	}
+	sinceDate, err := time.Parse(time.DateOnly, *since)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ -since must be a date like 2024-01-01, got %q\n", *since)
+		return 2
+	}
+	if *state != "open" && *state != "closed" && *state != "all" {
+		fmt.Fprintf(stderr, "✗ -state must be open, closed or all, got %q\n", *state)
+		return 2
+	}
+
+	log.SetOutput(logging.NewWriter(stderr))
+
+	cycloneBot, err := newCLIBot(*dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	reviewed, err := cycloneBot.Backfill(context.Background(), owner, repoName, bot.BackfillOptions{
+		Since: sinceDate,
+		State: *state,
+		Limit: *limit,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "✓ reviewed %d PRs of %s\n", reviewed, repo)
+	return 0
+}
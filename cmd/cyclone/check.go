@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/logging"
+)
+
+// runCheckCommand implements "cyclone check", which reviews the changes of the current git
+// branch against its upstream before they are pushed and prints the findings in the -fail-on
+// categories. It returns the process exit code, which is 1 if there are any.
+func runCheckCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	base := flags.String("base", "@{upstream}", "branch or commit to diff the working tree against")
+	repo := flags.String("repo", "", "review with the configuration of this owner/repo, defaults to the origin remote")
+	failOn := flags.String("fail-on", "blocking,issue", "comma-separated comment categories to report and fail on")
+	model := flags.String("model", "", "Claude model to review with, defaults to CLAUDE_MODEL")
+	verbose := flags.Bool("v", false, "also print the summary and all other comments")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Progress goes to stderr so stdout only carries the findings
+	log.SetOutput(logging.NewWriter(stderr))
+
+	root, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	mergeBase, err := git("merge-base", "HEAD", *base)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ no merge base with %s (set -base): %v\n", *base, err)
+		return 1
+	}
+	// Diffing against the merge base covers commits and uncommitted changes, but not what
+	// happened upstream since the branch was created
+	patch, err := gitOutput("diff", "--no-color", "--no-ext-diff", mergeBase)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	if len(bytes.TrimSpace(patch)) == 0 {
+		fmt.Fprintf(stdout, "✓ no changes against %s\n", *base)
+		return 0
+	}
+
+	if *repo == "" {
+		*repo = originRepository()
+	}
+
+	// The working tree's in-repo configuration applies, including uncommitted edits
+	repoConfigFile, err := os.ReadFile(filepath.Join(root, config.REPO_CONFIG_FILE))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	title, _ := git("log", "-1", "--format=%s")
+	result, err := reviewPatch(patch, repoConfigFile, *repo, title, "", *model)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if *verbose {
+		printReview(result, stdout)
+		fmt.Fprintln(stdout)
+	}
+
+	failing := failingComments(result.Comments, *failOn)
+	for _, comment := range failing {
+		fmt.Fprintf(stdout, "%s:%d: %s\n\n", comment.Path, comment.Line, comment.Body)
+	}
+	if len(failing) > 0 {
+		fmt.Fprintf(stderr, "✗ %d of %d findings are %s\n", len(failing), len(result.Comments), *failOn)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "✓ %d findings, none %s\n", len(result.Comments), *failOn)
+	return 0
+}
+
+// git runs a git command in the working directory and returns its trimmed output
+func git(args ...string) (string, error) {
+	out, err := gitOutput(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitOutput runs a git command in the working directory and returns its output
+func gitOutput(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// originRepository returns owner/repo of a GitHub origin remote, empty if there is none
+func originRepository() string {
+	url, err := git("remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+
+	for _, prefix := range []string{"git@github.com:", "ssh://git@github.com/", "https://github.com/"} {
+		if path, ok := strings.CutPrefix(url, prefix); ok {
+			repo := strings.TrimSuffix(path, ".git")
+			if strings.Count(repo, "/") == 1 {
+				return repo
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cyclone/internal/review"
+)
+
+// codeClimateIssue is an issue of a Code Climate report, printed with -format codeclimate.
+// GitLab ingests the report as a code quality artifact and shows it in the merge request.
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Content     codeClimateContent  `json:"content"`
+	Categories  []string            `json:"categories"`
+	Location    codeClimateLocation `json:"location"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+}
+
+type codeClimateContent struct {
+	Body string `json:"body"` // Markdown
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeClimateSeverity maps a comment category to a Code Climate severity
+func codeClimateSeverity(category string) string {
+	switch strings.ToLower(category) {
+	case "blocking":
+		return "critical"
+	case "issue":
+		return "major"
+	case "suggestion":
+		return "minor"
+	}
+	return "info"
+}
+
+// codeClimateDescription returns the first line of a comment's text, without the category header
+func codeClimateDescription(body string) string {
+	if _, content, ok := strings.Cut(body, "\n\n"); ok {
+		body = content
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	return line
+}
+
+// codeClimateFingerprint identifies an issue across reports, which GitLab uses to tell new
+// findings from ones the target branch already had
+func codeClimateFingerprint(comment review.ReviewComment) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", comment.Path, comment.Line, comment.Body)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// printReviewCodeClimate writes the line comments of a review as a Code Climate report and
+// returns the process exit code. The summary has no location, so it is left out.
+func printReviewCodeClimate(result review.ReviewResult, stdout, stderr io.Writer) int {
+	issues := make([]codeClimateIssue, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		checkName := comment.Category
+		if checkName == "" {
+			checkName = "comment"
+		}
+		issues = append(issues, codeClimateIssue{
+			Type:        "issue",
+			CheckName:   "cyclone/" + checkName,
+			Description: codeClimateDescription(comment.Body),
+			Content:     codeClimateContent{Body: comment.Body},
+			Categories:  []string{"Bug Risk"},
+			Location: codeClimateLocation{
+				Path:  comment.Path,
+				Lines: codeClimateLines{Begin: comment.Line},
+			},
+			Severity:    codeClimateSeverity(comment.Category),
+			Fingerprint: codeClimateFingerprint(comment),
+		})
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(issues); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"text/tabwriter"
+
+	"cyclone/internal/logging"
+	"cyclone/internal/review"
+)
+
+// runEstimateCommand implements "cyclone estimate", which predicts the tokens and cost of
+// reviewing a pull request without running the review, and returns the process exit code
+func runEstimateCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone estimate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone estimate owner/repo#123")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	owner, repoName, prNumber, err := parsePullRequestRef(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 2
+	}
+
+	log.SetOutput(logging.NewWriter(stderr))
+
+	cycloneBot, err := newCLIBot(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	estimates, err := cycloneBot.EstimateReview(context.Background(), owner, repoName, prNumber)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tMODEL\tINPUT\tOUTPUT\tCOST")
+	var total float64
+	unpriced := false
+	for _, estimate := range estimates {
+		usage := estimate.Usage
+		model := usage.Model
+		if usage.Batch {
+			model += " (batch)"
+		}
+		cost := "unknown model"
+		if _, ok := review.GetModelPricing(usage.Model); ok {
+			cost = fmt.Sprintf("$%.4f", usage.Cost())
+			total += usage.Cost()
+		} else {
+			unpriced = true
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t~%d\t%s\n", estimate.Stage, model, usage.InputTokens, usage.OutputTokens, cost)
+	}
+	fmt.Fprintf(tw, "total\t\t\t\t$%.4f\n", total)
+	tw.Flush()
+
+	fmt.Fprintln(stdout)
+	for _, estimate := range estimates {
+		if estimate.History > 0 {
+			fmt.Fprintf(stdout, "%s output averaged from %d past calls in %s/%s\n", estimate.Stage, estimate.History, owner, repoName)
+		} else {
+			fmt.Fprintf(stdout, "%s output is a default guess - no usage history for %s/%s yet\n", estimate.Stage, owner, repoName)
+		}
+	}
+	if unpriced {
+		fmt.Fprintln(stdout, "The total leaves out models without known pricing")
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cyclone/internal/bot"
+	"cyclone/internal/config"
+	"cyclone/internal/store"
+)
+
+// runExportCommand implements "cyclone export", which writes the stored review history - or with
+// -usage the monthly usage of each organization - as CSV or JSON lines, and returns the process
+// exit code. It reads DATA_DIR and needs no credentials.
+func runExportCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", bot.ExportFormatCSV, "csv or jsonl")
+	org := flags.String("org", "", "only export reviews in this organization")
+	repo := flags.String("repo", "", "only export reviews in this repository")
+	since := flags.String("since", "", "only export reviews on or after this date (YYYY-MM-DD)")
+	until := flags.String("until", "", "only export reviews on or before this date (YYYY-MM-DD)")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	usage := flags.Bool("usage", false, "export the monthly usage and cost of each organization instead of reviews")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != bot.ExportFormatCSV && *format != bot.ExportFormatJSONL {
+		fmt.Fprintf(stderr, "✗ -format must be csv or jsonl, got %q\n", *format)
+		return 2
+	}
+
+	filter := store.UsageFilter{Org: *org, Repo: *repo}
+	if *since != "" {
+		t, err := time.Parse(time.DateOnly, *since)
+		if err != nil {
+			fmt.Fprintf(stderr, "✗ -since must be a date like 2024-01-01, got %q\n", *since)
+			return 2
+		}
+		filter.Since = t
+	}
+	if *until != "" {
+		t, err := time.Parse(time.DateOnly, *until)
+		if err != nil {
+			fmt.Fprintf(stderr, "✗ -until must be a date like 2024-01-31, got %q\n", *until)
+			return 2
+		}
+		// The until date is inclusive
+		filter.Until = t.AddDate(0, 0, 1)
+	}
+
+	cfg, err := config.LoadAppConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	st, err := store.Open(cfg.DataDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "✗ %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *usage {
+		tenants := bot.TenantUsageRollup(st, filter)
+		if err := bot.ExportUsage(w, *format, tenants); err != nil {
+			fmt.Fprintf(stderr, "✗ %v\n", err)
+			return 1
+		}
+		if *output != "" {
+			fmt.Fprintf(stderr, "✓ exported the usage of %d organizations to %s\n", len(tenants), *output)
+		}
+		return 0
+	}
+
+	records := st.ListReviews(filter)
+	if err := bot.ExportReviews(w, *format, records); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	if *output != "" {
+		fmt.Fprintf(stderr, "✓ exported %d reviews to %s\n", len(records), *output)
+	}
+	return 0
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+
+	"gopkg.in/yaml.v3"
+)
+
+// initVerifyTimeout bounds each credential check of "cyclone init"
+const initVerifyTimeout = 30 * time.Second
+
+// initRepository and initOrganization are the review configuration written by "cyclone init".
+// They only carry what the wizard asks for, so the file doesn't list every setting's default.
+type initRepository struct {
+	Name      string                 `yaml:"name"`
+	Precision config.ReviewPrecision `yaml:"precision"`
+}
+
+type initOrganization struct {
+	Name         string           `yaml:"name"`
+	Repositories []initRepository `yaml:"repositories"`
+}
+
+// runInitCommand implements "cyclone init", which asks for credentials and review settings,
+// verifies the GitHub and Anthropic credentials and writes .env and review-config.yaml.
+// It returns the process exit code.
+func runInitCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("dir", ".", "directory to write .env and review-config.yaml to")
+	force := flags.Bool("force", false, "overwrite existing files without asking")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	envPath := filepath.Join(*dir, ".env")
+	configPath := filepath.Join(*dir, "review-config.yaml")
+	p := &prompter{in: bufio.NewReader(stdin), out: stdout}
+
+	if err := runInitWizard(p, envPath, configPath, *force); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runInitWizard asks the questions of "cyclone init" and writes the resulting files
+func runInitWizard(p *prompter, envPath, configPath string, force bool) error {
+	if !force {
+		for _, path := range []string{envPath, configPath} {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			overwrite, err := p.confirm(fmt.Sprintf("%s already exists. Overwrite it?", path), false)
+			if err != nil {
+				return err
+			}
+			if !overwrite {
+				return fmt.Errorf("%s already exists (use -force to overwrite)", path)
+			}
+		}
+	}
+
+	fmt.Fprintln(p.out, "GitHub")
+	githubToken, err := p.require("  Personal access token")
+	if err != nil {
+		return err
+	}
+	login, err := verifyGitHubToken(githubToken)
+	if err := p.reportCheck(fmt.Sprintf("authenticated as %s", login), err); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.out, "Anthropic")
+	anthropicKey, err := p.require("  API key")
+	if err != nil {
+		return err
+	}
+	model, err := p.ask("  Model", config.DEFAULT_MODEL)
+	if err != nil {
+		return err
+	}
+	err = verifyAnthropicKey(anthropicKey, model)
+	if err := p.reportCheck(fmt.Sprintf("%s is available", model), err); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.out, "Server")
+	port, err := p.ask("  Port", "8080")
+	if err != nil {
+		return err
+	}
+	webhookSecret, err := p.ask("  Webhook secret", randomSecret())
+	if err != nil {
+		return err
+	}
+	dataDir, err := p.ask("  Data directory", "data")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.out, "Reviews")
+	org, err := p.ask("  Organization or user", login)
+	if err != nil {
+		return err
+	}
+	if org == "" {
+		return fmt.Errorf("an organization is required")
+	}
+	repos, err := p.ask("  Repositories, comma-separated (* for all)", "*")
+	if err != nil {
+		return err
+	}
+	precision, err := p.askPrecision()
+	if err != nil {
+		return err
+	}
+
+	env := strings.Join([]string{
+		"GITHUB_TOKEN=" + githubToken,
+		"ANTHROPIC_API_KEY=" + anthropicKey,
+		"CLAUDE_MODEL=" + model,
+		"PORT=" + port,
+		"WEBHOOK_SECRET=" + webhookSecret,
+		"DATA_DIR=" + dataDir,
+	}, "\n") + "\n"
+	// The file holds credentials, so only the owner may read it
+	if err := os.WriteFile(envPath, []byte(env), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", envPath, err)
+	}
+	fmt.Fprintf(p.out, "✓ wrote %s\n", envPath)
+
+	var reviewConfig bytes.Buffer
+	encoder := yaml.NewEncoder(&reviewConfig)
+	encoder.SetIndent(2)
+	err = encoder.Encode(map[string][]initOrganization{
+		"organizations": {{Name: org, Repositories: initRepositories(repos, precision)}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, reviewConfig.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	if _, err := config.LoadReviewConfigFile(configPath, ""); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.out, "✓ wrote %s\n", configPath)
+
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Start the server from %s so it picks up both files.\n", filepath.Dir(envPath))
+	fmt.Fprintf(p.out, "Then add a webhook to %s pointing at https://<your-host>/webhook with the secret above,\n", org)
+	fmt.Fprintln(p.out, "sending \"Pull requests\" events as application/json.")
+	return nil
+}
+
+// initRepositories turns the comma-separated repository answer into configuration entries
+func initRepositories(answer string, precision config.ReviewPrecision) []initRepository {
+	var repos []initRepository
+	for _, name := range strings.Split(answer, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			repos = append(repos, initRepository{Name: name, Precision: precision})
+		}
+	}
+	if len(repos) == 0 {
+		repos = append(repos, initRepository{Name: "*", Precision: precision})
+	}
+	return repos
+}
+
+// verifyGitHubToken returns the login of the token's user
+func verifyGitHubToken(token string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), initVerifyTimeout)
+	defer cancel()
+
+	githubClient, err := review.NewGitHubClient(token)
+	if err != nil {
+		return "", err
+	}
+	return githubClient.AuthenticatedUser(ctx)
+}
+
+// verifyAnthropicKey checks that the key is valid and the model exists by counting the
+// tokens of a tiny request, which generates nothing and isn't billed
+func verifyAnthropicKey(apiKey, model string) error {
+	aiClient := review.NewAIClient(apiKey, model, "")
+	_, err := aiClient.CountTokens(review.ClaudeRequest{
+		Model:    model,
+		Messages: []review.ClaudeMessage{{Role: "user", Content: "Hello"}},
+	})
+	return err
+}
+
+// randomSecret returns a random webhook secret
+func randomSecret() string {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(secret)
+}
+
+// prompter asks questions on the terminal, reading one answer per line
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask asks a question and returns the answer, or def if the answer is empty
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(p.out)
+		return "", fmt.Errorf("no answer to %q: %w", strings.TrimSpace(question), err)
+	}
+
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// require asks a question that has no default and must be answered
+func (p *prompter) require(question string) (string, error) {
+	answer, err := p.ask(question, "")
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return "", fmt.Errorf("%s is required", strings.TrimSpace(question))
+	}
+	return answer, nil
+}
+
+// confirm asks a yes/no question
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	options := "y/N"
+	if def {
+		options = "Y/n"
+	}
+	answer, err := p.ask(fmt.Sprintf("%s (%s)", question, options), "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// askPrecision asks for a built-in precision level until a valid one is given
+func (p *prompter) askPrecision() (config.ReviewPrecision, error) {
+	for {
+		answer, err := p.ask("  Precision (minor, medium, strict)", string(config.PrecisionMedium))
+		if err != nil {
+			return "", err
+		}
+		switch precision := config.ReviewPrecision(strings.ToLower(answer)); precision {
+		case config.PrecisionMinor, config.PrecisionMedium, config.PrecisionStrict:
+			return precision, nil
+		}
+		fmt.Fprintf(p.out, "  %q is not a precision level\n", answer)
+	}
+}
+
+// reportCheck prints the outcome of a credential check. A failed check asks whether to
+// continue anyway, e.g. to finish the setup offline, and returns an error if not.
+func (p *prompter) reportCheck(success string, err error) error {
+	if err == nil {
+		fmt.Fprintf(p.out, "  ✓ %s\n", success)
+		return nil
+	}
+
+	fmt.Fprintf(p.out, "  ✗ %v\n", err)
+	proceed, confirmErr := p.confirm("  Continue anyway?", false)
+	if confirmErr != nil {
+		return confirmErr
+	}
+	if !proceed {
+		return fmt.Errorf("credential check failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// runConfigCommand implements "cyclone config <subcommand>" and returns the process exit code
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "lint" {
+		fmt.Fprintln(stderr, "Usage: cyclone config lint [flags]")
+		return 2
+	}
+	return runConfigLint(args[1:], stdout, stderr)
+}
+
+// runConfigLint validates a review configuration file and optionally prints the
+// effective configuration of one repository
+func runConfigLint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone config lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", config.ReviewConfigFile(), "review configuration file to validate")
+	repo := flags.String("repo", "", "print the effective configuration of this owner/repo")
+	repoConfigPath := flags.String("repo-config", "", "local "+config.REPO_CONFIG_FILE+" to merge over the repository's configuration")
+	fetchRepoConfig := flags.Bool("fetch-repo-config", false, "fetch the repository's "+config.REPO_CONFIG_FILE+" from GitHub (needs GITHUB_TOKEN)")
+	env := flags.String("env", os.Getenv("CYCLONE_ENV"), "apply the overlay of this environment, e.g. staging")
+	translations := flags.String("translations", os.Getenv("TRANSLATIONS_FILE"), "also validate this translations file for Cyclone's notices")
+	dataDir := flags.String("data-dir", "", "apply organizations managed through the admin API in this data directory")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	reviewCfg, err := config.LoadReviewConfigFile(*file, *env)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if *translations != "" {
+		if err := notices.Load(*translations); err != nil {
+			fmt.Fprintf(stderr, "✗ %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "✓ %s is valid\n", *translations)
+	}
+
+	if *dataDir != "" {
+		st, err := store.Open(*dataDir)
+		if err != nil {
+			fmt.Fprintf(stderr, "✗ %v\n", err)
+			return 1
+		}
+		reviewCfg = reviewCfg.WithManagedOrganizations(st.ManagedOrganizations())
+		if err := reviewCfg.Validate(); err != nil {
+			fmt.Fprintf(stderr, "✗ with managed organizations from %s: %v\n", *dataDir, err)
+			return 1
+		}
+	}
+
+	name := *file
+	if overlay := config.OverlayFile(*file, *env); overlay != "" {
+		name += " with overlay " + overlay
+	}
+	fmt.Fprintf(stdout, "✓ %s is valid (%d organizations)\n", name, len(reviewCfg.Organizations))
+	for _, warning := range reviewCfg.Warnings() {
+		fmt.Fprintf(stderr, "⚠ %s\n", warning)
+	}
+	if *repo == "" {
+		return 0
+	}
+
+	owner, repoName, ok := strings.Cut(*repo, "/")
+	if !ok || owner == "" || repoName == "" {
+		fmt.Fprintf(stderr, "✗ -repo must be owner/repo, got %q\n", *repo)
+		return 2
+	}
+
+	if reviewCfg.IsExcluded(owner, repoName) {
+		fmt.Fprintf(stdout, "%s is excluded - its pull requests are not reviewed\n", *repo)
+		return 0
+	}
+
+	repoConfig := reviewCfg.GetRepositoryConfig(owner, repoName)
+	if repoConfig == nil {
+		fmt.Fprintf(stdout, "%s has no matching entry - default settings apply\n", *repo)
+		repoConfig = config.DefaultRepositoryConfig(repoName)
+	} else {
+		fmt.Fprintf(stdout, "%s matches entry %q\n", *repo, repoConfig.Name)
+	}
+
+	content, source, err := readRepoConfigFile(owner, repoName, *repoConfigPath, *fetchRepoConfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	if content != nil {
+		repoFile, err := reviewCfg.ParseRepoConfigFile(content)
+		if err != nil {
+			fmt.Fprintf(stderr, "✗ %s: %v\n", source, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Merged %s\n", source)
+		repoConfig = repoConfig.WithRepoConfigFile(repoFile)
+	}
+
+	if profile := reviewCfg.GetPrecisionProfile(repoConfig.Precision); profile != nil {
+		fmt.Fprintf(stdout, "Precision profile %q:\n%s\n", profile.Name, strings.TrimSpace(profile.Guidelines))
+	}
+	if flags := reviewCfg.FeatureFlags(owner, repoName); len(flags) > 0 {
+		var features []string
+		for feature, enabled := range flags {
+			state := "off"
+			if enabled {
+				state = "on"
+			}
+			features = append(features, fmt.Sprintf("%s=%s", feature, state))
+		}
+		sort.Strings(features)
+		fmt.Fprintf(stdout, "Feature flags: %s\n", strings.Join(features, ", "))
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(repoConfig); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readRepoConfigFile reads the in-repo configuration from a local path or from GitHub.
+// It returns nil content if none was requested or the repository has none.
+func readRepoConfigFile(owner, repoName, localPath string, fetch bool) ([]byte, string, error) {
+	if localPath != "" {
+		content, err := os.ReadFile(localPath)
+		if err != nil {
+			return nil, localPath, fmt.Errorf("failed to read %s: %w", localPath, err)
+		}
+		return content, localPath, nil
+	}
+	if !fetch {
+		return nil, "", nil
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, "", fmt.Errorf("-fetch-repo-config needs GITHUB_TOKEN")
+	}
+	githubClient, err := review.NewGitHubClient(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	source := fmt.Sprintf("%s/%s:%s", owner, repoName, config.REPO_CONFIG_FILE)
+	content, err := githubClient.GetFileContent(context.Background(), owner, repoName, config.REPO_CONFIG_FILE)
+	if err != nil {
+		return nil, source, err
+	}
+	return content, source, nil
+}
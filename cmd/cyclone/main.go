@@ -3,26 +3,115 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 
 	"cyclone/internal/bot"
 	"cyclone/internal/config"
+	"cyclone/internal/logging"
+	"cyclone/internal/notices"
+	"cyclone/internal/store"
 )
 
 func main() {
+	// Keep credentials and code out of the logs of the server and all subcommands
+	log.SetOutput(logging.NewWriter(os.Stderr))
+
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "init":
+			os.Exit(runInitCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "review":
+			os.Exit(runReviewCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "action":
+			os.Exit(runActionCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "check":
+			os.Exit(runCheckCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "replay":
+			os.Exit(runReplayCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "backfill":
+			os.Exit(runBackfillCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "prompt":
+			os.Exit(runPromptCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "estimate":
+			os.Exit(runEstimateCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "export":
+			os.Exit(runExportCommand(os.Args[2:], os.Stdout, os.Stderr))
+		case "serve":
+			os.Exit(runServeCommand(os.Args[2:], os.Stderr))
+		case "worker":
+			os.Exit(runWorkerCommand(os.Args[2:], os.Stderr))
+		}
+	}
+
+	// Without a subcommand, one process ingests webhooks and runs the reviews they trigger
+	cycloneBot, cfg := startBot()
+	cycloneBot.ResumeBatches()
+	go cycloneBot.PruneStoredData()
+	go cycloneBot.SendDigests()
+	go cycloneBot.MonitorOutages()
+	listen(cycloneBot, cfg)
+}
+
+// startBot loads the configuration, creates the bot and starts watching for configuration
+// and secret changes. It exits the process if anything fails.
+func startBot() (*bot.CycloneBot, *config.Config) {
 	// Load configuration (returns both app config and review config)
 	cfg, reviewCfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Translations of Cyclone's own notices for repositories reviewed in other languages
+	if cfg.TranslationsFile != "" {
+		if err := notices.Load(cfg.TranslationsFile); err != nil {
+			log.Fatalf("Failed to load translations: %v", err)
+		}
+	}
+
+	// Open the persistent store for conversations and review state
+	st, err := store.Open(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+
 	// Create bot with both configurations
-	cycloneBot, err := bot.New(cfg, reviewCfg)
+	cycloneBot, err := bot.New(cfg, reviewCfg, st)
 	if err != nil {
 		log.Fatalf("Failed to create bot: %v", err)
 	}
 
-	// Setup routes and start server
-	cycloneBot.SetupRoutes()
+	// Pick up review configuration changes without a restart
+	go cycloneBot.WatchReviewConfig()
+	go cycloneBot.WatchSecrets()
+
+	return cycloneBot, cfg
+}
+
+// listen serves the webhook and API routes, and the admin routes on ADMIN_ADDR if it is set
+func listen(cycloneBot *bot.CycloneBot, cfg *config.Config) {
+	if admin := cycloneBot.SetupRoutes(); admin != nil {
+		go func() {
+			log.Printf("Starting admin server on %s", cfg.AdminAddr)
+			log.Fatal(newServer(cfg.AdminAddr, admin, cfg.HTTP).ListenAndServe())
+		}()
+	}
 	log.Printf("Starting server on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, nil))
+	log.Fatal(newServer(":"+cfg.Port, nil, cfg.HTTP).ListenAndServe())
+}
+
+// newServer returns a server for addr with the configured timeouts and header limit; a nil
+// handler serves the default mux
+func newServer(addr string, handler http.Handler, limits config.HTTPServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		ReadTimeout:       limits.ReadTimeout,
+		WriteTimeout:      limits.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
+	}
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"cyclone/internal/logging"
+	"cyclone/internal/review"
+)
+
+// runPromptCommand implements "cyclone prompt", which prints the fully rendered review
+// request for a pull request without generating a review, and returns the process exit code
+func runPromptCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone prompt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone prompt [flags] owner/repo#123")
+		flags.PrintDefaults()
+	}
+	jsonOutput := flags.Bool("json", false, "print the request body exactly as sent to the Messages API")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	owner, repoName, prNumber, err := parsePullRequestRef(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 2
+	}
+
+	// Progress goes to stderr so stdout only carries the prompt
+	log.SetOutput(logging.NewWriter(stderr))
+
+	cycloneBot, err := newCLIBot(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	reqBody, tokens, err := cycloneBot.PreviewReviewRequest(context.Background(), owner, repoName, prNumber)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(reqBody); err != nil {
+			fmt.Fprintf(stderr, "✗ %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printPrompt(reqBody, tokens, stdout)
+	return 0
+}
+
+// printPrompt writes the settings and messages of a review request in a readable form
+func printPrompt(reqBody review.ClaudeRequest, tokens int, w io.Writer) {
+	fmt.Fprintf(w, "Model:          %s\n", reqBody.Model)
+	fmt.Fprintf(w, "Input tokens:   %d\n", tokens)
+	fmt.Fprintf(w, "Max tokens:     %d\n", reqBody.MaxTokens)
+	if reqBody.Thinking != nil {
+		fmt.Fprintf(w, "Thinking:       %d tokens\n", reqBody.Thinking.BudgetTokens)
+	}
+	fmt.Fprintf(w, "Prompt version: %s\n", reqBody.PromptVersion)
+	if reqBody.PromptVariant != "" {
+		fmt.Fprintf(w, "Prompt variant: %s\n", reqBody.PromptVariant)
+	}
+
+	fmt.Fprintf(w, "\n════ system ════\n%s\n", reqBody.System)
+	for _, message := range reqBody.Messages {
+		fmt.Fprintf(w, "\n════ %s ════\n%s\n", message.Role, message.Content)
+	}
+}
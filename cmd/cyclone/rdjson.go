@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cyclone/internal/review"
+)
+
+// rdjsonResult is reviewdog's Diagnostic Format (rdjson), printed with -format rdjson so
+// findings can be piped into "reviewdog -f=rdjson"
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity"`
+	Code     *rdjsonCode    `json:"code,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string      `json:"path"`
+	Range rdjsonRange `json:"range"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition `json:"start"`
+}
+
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+// rdjsonSeverity maps a comment category to a reviewdog severity, so reviewdog's -level and
+// -fail-level options work on Cyclone's findings
+func rdjsonSeverity(category string) string {
+	switch strings.ToLower(category) {
+	case "blocking":
+		return "ERROR"
+	case "issue":
+		return "WARNING"
+	}
+	return "INFO"
+}
+
+// printReviewRDJSON writes the line comments of a review in rdjson and returns the process
+// exit code. The summary has no location, so it is left out.
+func printReviewRDJSON(result review.ReviewResult, stdout, stderr io.Writer) int {
+	output := rdjsonResult{
+		Source:      rdjsonSource{Name: "cyclone", URL: "https://github.com/ThomasPokorny/cyclone-community"},
+		Diagnostics: make([]rdjsonDiagnostic, 0, len(result.Comments)),
+	}
+	for _, comment := range result.Comments {
+		diagnostic := rdjsonDiagnostic{
+			Message: comment.Body,
+			Location: rdjsonLocation{
+				Path:  comment.Path,
+				Range: rdjsonRange{Start: rdjsonPosition{Line: comment.Line}},
+			},
+			Severity: rdjsonSeverity(comment.Category),
+		}
+		if comment.Category != "" {
+			diagnostic.Code = &rdjsonCode{Value: comment.Category}
+		}
+		output.Diagnostics = append(output.Diagnostics, diagnostic)
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"cyclone/internal/bot"
+	"cyclone/internal/logging"
+	"cyclone/internal/store"
+)
+
+// runReplayCommand implements "cyclone replay", which processes a webhook delivery again:
+// either one captured with CAPTURE_WEBHOOKS, by delivery ID, or a payload file. It returns
+// the process exit code.
+func runReplayCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone replay [flags] <file|delivery-id>")
+		flags.PrintDefaults()
+	}
+	event := flags.String("event", "pull_request", "event of a plain payload file (X-GitHub-Event)")
+	dryRun := flags.Bool("dry-run", false, "log reviews and replies instead of posting them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	target := flags.Arg(0)
+
+	log.SetOutput(logging.NewWriter(stderr))
+
+	cycloneBot, err := newCLIBot(*dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	if _, statErr := os.Stat(target); statErr == nil {
+		var delivery store.WebhookDelivery
+		if delivery, err = readWebhookFile(target, *event); err == nil {
+			err = cycloneBot.ReplayWebhook(delivery)
+		}
+	} else {
+		err = cycloneBot.ReplayCapturedWebhook(target)
+	}
+
+	if errors.Is(err, bot.ErrWebhookIgnored) {
+		fmt.Fprintf(stdout, "Delivery %s triggers no work\n", target)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "✓ replayed %s\n", target)
+	return 0
+}
+
+// readWebhookFile reads a captured delivery file, or a plain payload of the given event
+func readWebhookFile(path, event string) (store.WebhookDelivery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return store.WebhookDelivery{}, err
+	}
+
+	var delivery store.WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return store.WebhookDelivery{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if delivery.Event != "" && len(delivery.Payload) > 0 {
+		return delivery, nil
+	}
+
+	return store.WebhookDelivery{ID: path, Event: event, Payload: data}, nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"cyclone/internal/bot"
+	"cyclone/internal/config"
+	"cyclone/internal/logging"
+	"cyclone/internal/notices"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// reviewOutput is the JSON form of a review printed with -json
+type reviewOutput struct {
+	Summary  string          `json:"summary"`
+	Comments []reviewComment `json:"comments"`
+	Usage    reviewUsage     `json:"usage"`
+}
+
+type reviewUsage struct {
+	Model        string `json:"model"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+}
+
+type reviewComment struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Category string `json:"category,omitempty"`
+	Body     string `json:"body"`
+}
+
+// runReviewCommand implements "cyclone review", which reviews a local diff or, given
+// owner/repo#123, a pull request and prints the result. It returns the process exit code.
+func runReviewCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone review", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone review [flags] [owner/repo#123]")
+		flags.PrintDefaults()
+	}
+	diffPath := flags.String("diff", "-", `unified diff to review (e.g. from git diff), "-" reads stdin`)
+	repo := flags.String("repo", "", "review the diff with the configuration of this owner/repo")
+	title := flags.String("title", "", "pull request title passed to the model with the diff")
+	body := flags.String("body", "", "pull request description passed to the model with the diff")
+	model := flags.String("model", "", "Claude model to review the diff with, defaults to CLAUDE_MODEL")
+	post := flags.Bool("post", false, "post the review to the pull request")
+	format := flags.String("format", "text", "output format: text, json, rdjson (reviewdog) or codeclimate (GitLab code quality)")
+	jsonOutput := flags.Bool("json", false, "print the review as JSON, same as -format json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Flags may also follow the pull request, e.g. "cyclone review owner/repo#123 -post"
+	var pullRequest string
+	if flags.NArg() > 0 {
+		pullRequest = flags.Arg(0)
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	if *jsonOutput {
+		*format = "json"
+	}
+	switch *format {
+	case "text", "json", "rdjson", "codeclimate":
+	default:
+		fmt.Fprintf(stderr, "✗ unknown -format %q, use text, json, rdjson or codeclimate\n", *format)
+		return 2
+	}
+
+	// Progress goes to stderr so stdout only carries the review
+	log.SetOutput(logging.NewWriter(stderr))
+
+	var result review.ReviewResult
+	var err error
+	if pullRequest != "" {
+		result, err = reviewPullRequest(pullRequest, *post)
+	} else if *post {
+		err = fmt.Errorf("-post needs a pull request (owner/repo#123)")
+	} else {
+		result, err = reviewDiff(*diffPath, *repo, *title, *body, *model, stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		return printReviewJSON(result, stdout, stderr)
+	case "rdjson":
+		return printReviewRDJSON(result, stdout, stderr)
+	case "codeclimate":
+		return printReviewCodeClimate(result, stdout, stderr)
+	}
+	printReview(result, stdout)
+	return 0
+}
+
+// reviewDiff reviews a diff file, or stdin for "-"
+func reviewDiff(diffPath, repo, title, body, model string, stdin io.Reader) (review.ReviewResult, error) {
+	patch, err := readDiff(diffPath, stdin)
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+	return reviewPatch(patch, nil, repo, title, body, model)
+}
+
+// reviewPatch reviews unified diff output without GitHub; only the Anthropic key is required.
+// repoConfigFile holds the contents of a local in-repo config file, nil for none.
+func reviewPatch(patch, repoConfigFile []byte, repo, title, body, model string) (review.ReviewResult, error) {
+	cfg, err := config.LoadAppConfig()
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+	if cfg.AnthropicToken == "" {
+		return review.ReviewResult{}, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required")
+	}
+	if cfg.TranslationsFile != "" {
+		if err := notices.Load(cfg.TranslationsFile); err != nil {
+			return review.ReviewResult{}, err
+		}
+	}
+
+	repoConfig, err := reviewRepositoryConfig(cfg, repo, repoConfigFile)
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+
+	diff := review.FormatUnifiedDiff(string(patch), repoConfig.IgnorePaths)
+	if diff == "" {
+		return review.ReviewResult{}, fmt.Errorf("the diff contains no reviewable changes")
+	}
+
+	if model == "" {
+		model = cfg.Model
+	}
+	aiClient := review.NewAIClient(cfg.AnthropicToken, model, cfg.PromptsDir)
+	return generateLocalReview(aiClient, diff, title, body, repoConfig), nil
+}
+
+// reviewPullRequest runs the bot's review pipeline on owner/repo#123, posting the review if asked
+func reviewPullRequest(ref string, post bool) (review.ReviewResult, error) {
+	owner, repoName, prNumber, err := parsePullRequestRef(ref)
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+
+	cycloneBot, err := newCLIBot(false)
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+
+	return cycloneBot.ReviewPullRequest(context.Background(), owner, repoName, prNumber, post)
+}
+
+// newCLIBot creates a bot for one-off reviews outside the server. Unlike the server, it
+// falls back to the default settings when no review configuration is set up. With dryRun,
+// nothing is posted regardless of the configuration.
+func newCLIBot(dryRun bool) (*bot.CycloneBot, error) {
+	cfg, err := config.LoadAppConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.RequireCredentials(); err != nil {
+		return nil, err
+	}
+
+	reviewCfg := &config.ReviewConfig{}
+	if cfg.HasReviewConfig() {
+		if reviewCfg, err = config.LoadReviewConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		reviewCfg.DryRun = true
+	}
+
+	if cfg.TranslationsFile != "" {
+		if err := notices.Load(cfg.TranslationsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	st, err := store.Open(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	return bot.New(cfg, reviewCfg, st)
+}
+
+// parsePullRequestRef splits a pull request reference of the form owner/repo#123
+func parsePullRequestRef(ref string) (string, string, int, error) {
+	repo, number, ok := strings.Cut(ref, "#")
+	owner, repoName, repoOK := strings.Cut(repo, "/")
+	prNumber, err := strconv.Atoi(number)
+	if !ok || !repoOK || owner == "" || repoName == "" || err != nil || prNumber <= 0 {
+		return "", "", 0, fmt.Errorf("pull request must be owner/repo#123, got %q", ref)
+	}
+	return owner, repoName, prNumber, nil
+}
+
+// reviewRepositoryConfig returns the configuration a local review uses: the entry of the
+// given owner/repo if a review configuration is set up, the defaults otherwise. The contents
+// of a local in-repo config file, if any, are merged over it.
+func reviewRepositoryConfig(cfg *config.Config, repo string, repoConfigFile []byte) (*config.RepositoryConfig, error) {
+	reviewCfg := &config.ReviewConfig{}
+	if cfg.HasReviewConfig() {
+		var err error
+		if reviewCfg, err = config.LoadReviewConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	repoConfig := config.DefaultRepositoryConfig(repo)
+	if repo != "" {
+		owner, repoName, ok := strings.Cut(repo, "/")
+		if !ok || owner == "" || repoName == "" {
+			return nil, fmt.Errorf("-repo must be owner/repo, got %q", repo)
+		}
+		if entry := reviewCfg.GetRepositoryConfig(owner, repoName); entry != nil {
+			repoConfig = entry
+		}
+	}
+
+	if repoConfigFile != nil {
+		file, err := reviewCfg.ParseRepoConfigFile(repoConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", config.REPO_CONFIG_FILE, err)
+		}
+		repoConfig = repoConfig.WithRepoConfigFile(file)
+	}
+	return reviewCfg.WithPrecisionProfile(repoConfig), nil
+}
+
+// readDiff reads a diff from a file, or from stdin for "-"
+func readDiff(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read diff from stdin: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	return data, nil
+}
+
+// generateLocalReview runs the review pipeline without GitHub: the review itself and, if the
+// repository enables it, the self-critique pass
+func generateLocalReview(aiClient *review.AIClient, diff, title, body string, repoConfig *config.RepositoryConfig) review.ReviewResult {
+	result := aiClient.GenerateReview(diff, title, body, repoConfig)
+
+	if repoConfig.SelfCritique && len(result.Comments) > 0 {
+		critiqued, usage, err := aiClient.CritiqueComments(diff, result)
+		if err != nil {
+			log.Printf("Error running self-critique - keeping unvetted comments: %v", err)
+		} else {
+			critiqued.Usage.InputTokens += usage.InputTokens
+			critiqued.Usage.OutputTokens += usage.OutputTokens
+			result = critiqued
+		}
+	}
+	if result.Err == nil {
+		result.Comments = append(result.Comments, review.ImportRuleComments(review.CheckImports(diff, repoConfig.ImportRules), repoConfig)...)
+	}
+	return result
+}
+
+// printReview writes a review in a readable form: the summary followed by the line comments
+func printReview(result review.ReviewResult, w io.Writer) {
+	fmt.Fprintln(w, result.Summary)
+	for _, comment := range result.Comments {
+		fmt.Fprintf(w, "\n──── %s:%d ────\n%s\n", comment.Path, comment.Line, comment.Body)
+	}
+}
+
+// printReviewJSON writes a review as JSON and returns the process exit code
+func printReviewJSON(result review.ReviewResult, stdout, stderr io.Writer) int {
+	output := reviewOutput{
+		Summary:  result.Summary,
+		Comments: make([]reviewComment, 0, len(result.Comments)),
+		Usage: reviewUsage{
+			Model:        result.Usage.Model,
+			InputTokens:  result.Usage.InputTokens,
+			OutputTokens: result.Usage.OutputTokens,
+		},
+	}
+	for _, comment := range result.Comments {
+		output.Comments = append(output.Comments, reviewComment{
+			Path:     comment.Path,
+			Line:     comment.Line,
+			Category: comment.Category,
+			Body:     comment.Body,
+		})
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		fmt.Fprintf(stderr, "✗ %v\n", err)
+		return 1
+	}
+	return 0
+}
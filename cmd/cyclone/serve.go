@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+)
+
+// runServeCommand implements "cyclone serve", which ingests webhooks and serves the API but
+// queues the reviews webhooks trigger for "cyclone worker" processes sharing DATA_DIR
+func runServeCommand(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cyclone serve")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cycloneBot, cfg := startBot()
+	cycloneBot.QueueWebhooks()
+	log.Printf("Queueing webhook work in %s for cyclone worker", cfg.DataDir)
+	go cycloneBot.MonitorOutages()
+	listen(cycloneBot, cfg)
+	return 0
+}
+
+// runWorkerCommand implements "cyclone worker", which runs the reviews, replies and reloads
+// queued by "cyclone serve". Any number of workers can share a DATA_DIR.
+func runWorkerCommand(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cyclone worker", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	concurrency := flags.Int("concurrency", 4, "webhook deliveries processed at the same time")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *concurrency < 1 {
+		fmt.Fprintln(stderr, "✗ -concurrency must be at least 1")
+		return 2
+	}
+
+	cycloneBot, cfg := startBot()
+	cycloneBot.ResumeBatches()
+	// Workers own the review history, so they prune and summarize it rather than the server
+	go cycloneBot.PruneStoredData()
+	go cycloneBot.SendDigests()
+	go cycloneBot.MonitorOutages()
+	log.Printf("Worker processing queued webhooks from %s with concurrency %d", cfg.DataDir, *concurrency)
+	cycloneBot.RunWorker(*concurrency)
+	return 0
+}
@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// TrackAcceptance records, for each review of a merged pull request, which line comments were
+// acted upon: those whose lines changed between the reviewed commit and the merge
+func (bot *CycloneBot) TrackAcceptance(repo *review.Repository, pr *review.PullRequest) {
+	owner, repoName, prNumber := repo.Owner.Login, repo.Name, pr.Number
+	mergedHead := pr.Head.SHA
+
+	for _, rec := range bot.store.ListPullRequestReviews(owner, repoName, prNumber) {
+		// Dry-run comments weren't posted, and reviews recorded before head commits were, or
+		// whose content was pruned, have nothing to follow
+		if rec.DryRun || rec.Outcome != nil || rec.HeadSHA == "" || len(rec.DraftComments) == 0 {
+			continue
+		}
+
+		changes := map[string]fileChange{}
+		if rec.HeadSHA != mergedHead {
+			comparison, err := bot.githubClientFor(owner).CompareCommits(context.Background(), owner, repoName, rec.HeadSHA, mergedHead)
+			if err != nil {
+				log.Printf("Error tracking acted-upon comments of PR #%d: %v", prNumber, err)
+				continue
+			}
+			// After a force push, the comparison starts at a common ancestor and its line
+			// numbers don't match the reviewed commit's
+			if comparison.Status == "diverged" {
+				log.Printf("Not tracking acted-upon comments of review %d: PR #%d was rewritten since", rec.ID, prNumber)
+				continue
+			}
+			changes = fileChanges(comparison.Files)
+		}
+
+		outcome := store.ReviewOutcome{
+			MergedAt:  pr.MergedAt,
+			Tracked:   make(map[string]int),
+			ActedUpon: make(map[string]int),
+		}
+		var signals []store.ConventionSignal
+		for _, comment := range rec.DraftComments {
+			category := acceptanceCategory(comment.Category)
+			change, changed := changes[comment.Path]
+			if change.unknown {
+				continue
+			}
+			outcome.Tracked[category]++
+			kind := store.SignalDismissed
+			if changed && change.touches(comment.Line) {
+				outcome.ActedUpon[category]++
+				kind = store.SignalAccepted
+			}
+			signals = append(signals, store.ConventionSignal{
+				Kind:     kind,
+				PRNumber: prNumber,
+				Path:     comment.Path,
+				Category: comment.Category,
+				Comment:  comment.Body,
+			})
+		}
+
+		if err := bot.store.SetReviewOutcome(rec.ID, outcome); err != nil {
+			log.Printf("Error recording outcome of review %d: %v", rec.ID, err)
+			continue
+		}
+		log.Printf("PR #%d merged: %d of %d tracked comments of review %d acted upon",
+			prNumber, sumCounts(outcome.ActedUpon), sumCounts(outcome.Tracked), rec.ID)
+		bot.recordConventionSignals(owner, repoName, signals...)
+	}
+}
+
+// fileChange is how a file changed after a review
+type fileChange struct {
+	lines   []int // Changed lines of the reviewed version, see review.ChangedLines
+	removed bool
+	unknown bool // GitHub left out the patch, e.g. for large files
+}
+
+// touches reports whether a change is within config.ACCEPTANCE_LINE_TOLERANCE lines of a line
+func (c fileChange) touches(line int) bool {
+	if c.removed {
+		return true
+	}
+	for _, changed := range c.lines {
+		if changed >= line-config.ACCEPTANCE_LINE_TOLERANCE && changed <= line+config.ACCEPTANCE_LINE_TOLERANCE {
+			return true
+		}
+	}
+	return false
+}
+
+// fileChanges indexes the changed files of a comparison by their path in the reviewed commit
+func fileChanges(files []review.ChangedFile) map[string]fileChange {
+	changes := make(map[string]fileChange, len(files))
+	for _, file := range files {
+		path := file.Filename
+		if file.PreviousFilename != "" {
+			path = file.PreviousFilename
+		}
+
+		switch {
+		case file.Status == "removed":
+			changes[path] = fileChange{removed: true}
+		case file.Patch == "" && file.Changes() > 0:
+			changes[path] = fileChange{unknown: true}
+		default:
+			changes[path] = fileChange{lines: review.ChangedLines(file.Patch)}
+		}
+	}
+	return changes
+}
+
+// acceptanceCategory normalizes a comment's category, counting uncategorized comments as "other"
+func acceptanceCategory(category string) string {
+	if category == "" {
+		return "other"
+	}
+	return strings.ToLower(category)
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// CategoryAcceptance is how many tracked comments of a category were acted upon
+type CategoryAcceptance struct {
+	Category  string  `json:"category,omitempty"`
+	Tracked   int     `json:"tracked"`
+	ActedUpon int     `json:"acted_upon"`
+	Rate      float64 `json:"rate"` // ActedUpon / Tracked
+}
+
+// AcceptanceResponse is the JSON body returned by GET /api/acceptance
+type AcceptanceResponse struct {
+	MergedReviews int                  `json:"merged_reviews"` // Reviews of merged PRs with tracked comments
+	Totals        CategoryAcceptance   `json:"totals"`
+	Categories    []CategoryAcceptance `json:"categories"` // Most tracked comments first
+}
+
+// acceptanceReport sums up the outcomes of reviews
+func acceptanceReport(records []store.ReviewRecord) AcceptanceResponse {
+	resp := AcceptanceResponse{Categories: []CategoryAcceptance{}}
+	byCategory := make(map[string]*CategoryAcceptance)
+	for _, rec := range records {
+		if rec.Outcome == nil {
+			continue
+		}
+		resp.MergedReviews++
+		for category, tracked := range rec.Outcome.Tracked {
+			entry := byCategory[category]
+			if entry == nil {
+				entry = &CategoryAcceptance{Category: category}
+				byCategory[category] = entry
+			}
+			entry.Tracked += tracked
+			entry.ActedUpon += rec.Outcome.ActedUpon[category]
+			resp.Totals.Tracked += tracked
+			resp.Totals.ActedUpon += rec.Outcome.ActedUpon[category]
+		}
+	}
+
+	for _, entry := range byCategory {
+		entry.Rate = acceptanceRate(entry.ActedUpon, entry.Tracked)
+		resp.Categories = append(resp.Categories, *entry)
+	}
+	sort.Slice(resp.Categories, func(i, j int) bool {
+		if resp.Categories[i].Tracked != resp.Categories[j].Tracked {
+			return resp.Categories[i].Tracked > resp.Categories[j].Tracked
+		}
+		return resp.Categories[i].Category < resp.Categories[j].Category
+	})
+	resp.Totals.Rate = acceptanceRate(resp.Totals.ActedUpon, resp.Totals.Tracked)
+	return resp
+}
+
+func acceptanceRate(actedUpon, tracked int) float64 {
+	if tracked == 0 {
+		return 0
+	}
+	return float64(actedUpon) / float64(tracked)
+}
+
+// handleAcceptanceAPI serves GET /api/acceptance, the per-category rates of comments acted upon
+// before merge, for reviews filtered like the usage API
+func (bot *CycloneBot) handleAcceptanceAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, acceptanceReport(bot.store.ListReviews(filter)))
+}
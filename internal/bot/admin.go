@@ -0,0 +1,392 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/store"
+)
+
+// redactedAPIKey replaces organization API keys in admin API responses. Sending it back
+// in an update keeps the stored key.
+const redactedAPIKey = "********"
+
+// AdminConfigResponse is the JSON body returned by GET /api/admin/config
+type AdminConfigResponse struct {
+	Organizations        []config.OrganizationConfig `json:"organizations"`
+	ManagedOrganizations []string                    `json:"managed_organizations"` // Configured through the admin API
+}
+
+// requireToken lets requests through that carry the admin token or the session of a GitHub
+// sign-in. Sessions are limited to the organizations the user signed in through, see
+// requestTenants. Without ADMIN_TOKEN and GitHub sign-in, the endpoints it protects are disabled.
+func (bot *CycloneBot) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if bot.config.AdminToken == "" && bot.oauth == nil {
+			http.Error(w, errAPIDisabled.Error(), http.StatusForbidden)
+			return
+		}
+
+		if bot.config.AdminToken != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(bot.config.AdminToken)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+		if bot.oauth != nil {
+			if login, orgs := bot.oauth.session(r); login != "" {
+				next(w, withTenants(r, orgs))
+				return
+			}
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// requireAdminToken is requireToken for endpoints that span all organizations, such as /metrics,
+// which only the admin token may use
+func (bot *CycloneBot) requireAdminToken(next http.HandlerFunc) http.HandlerFunc {
+	return bot.requireToken(func(w http.ResponseWriter, r *http.Request) {
+		if requestTenants(r) != nil {
+			http.Error(w, "This endpoint covers all organizations and requires ADMIN_TOKEN", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// errAPIDisabled is returned by endpoints that need authentication while none is configured
+var errAPIDisabled = errors.New("this endpoint is disabled - set ADMIN_TOKEN or GITHUB_OAUTH_CLIENT_ID to enable it")
+
+// tenantKey is the request context key of the organizations a request is limited to
+type tenantKey struct{}
+
+// requireTenantToken is requireToken that also lets requests through that carry an
+// organization's API token, limited to that organization's data, see requestTenants
+func (bot *CycloneBot) requireTenantToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != "" {
+			if org := bot.tokenTenant(token); org != "" {
+				next(w, withTenants(r, []string{org}))
+				return
+			}
+		}
+		bot.requireToken(next)(w, r)
+	}
+}
+
+// tokenTenant returns the organization whose API token a token is, "" if it is none
+func (bot *CycloneBot) tokenTenant(token string) string {
+	for _, org := range bot.currentReviewConfig().Organizations {
+		orgToken := org.GetAPIToken()
+		if orgToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(orgToken)) == 1 {
+			return org.Name
+		}
+	}
+	return ""
+}
+
+// withTenants limits a request to the data of the given organizations
+func withTenants(r *http.Request, orgs []string) *http.Request {
+	if orgs == nil {
+		orgs = []string{}
+	}
+	return r.WithContext(context.WithValue(r.Context(), tenantKey{}, orgs))
+}
+
+// requestTenants returns the organizations a request is limited to, nil for requests with the
+// admin token, which have access to all organizations
+func requestTenants(r *http.Request) []string {
+	orgs, _ := r.Context().Value(tenantKey{}).([]string)
+	return orgs
+}
+
+// tenantAllowed reports whether a request may see an organization's data
+func tenantAllowed(r *http.Request, org string) bool {
+	tenants := requestTenants(r)
+	if tenants == nil {
+		return true
+	}
+	for _, tenant := range tenants {
+		if strings.EqualFold(tenant, org) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAdminConfig serves the effective review configuration
+func (bot *CycloneBot) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := AdminConfigResponse{ManagedOrganizations: []string{}}
+	for _, org := range bot.currentReviewConfig().Organizations {
+		if tenantAllowed(r, org.Name) {
+			resp.Organizations = append(resp.Organizations, redactOrganization(org))
+		}
+	}
+	for _, org := range bot.store.ManagedOrganizations() {
+		if tenantAllowed(r, org.Name) {
+			resp.ManagedOrganizations = append(resp.ManagedOrganizations, org.Name)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAdminOrganizations serves /api/admin/orgs/{org} and /api/admin/orgs/{org}/repos/{repo}
+func (bot *CycloneBot) handleAdminOrganizations(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/admin/orgs/"), "/")
+	if !tenantAllowed(r, parts[0]) {
+		http.Error(w, errOtherTenant.Error(), http.StatusForbidden)
+		return
+	}
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		bot.handleAdminOrganization(w, r, parts[0])
+	case len(parts) == 3 && parts[0] != "" && parts[1] == "repos" && parts[2] != "":
+		bot.handleAdminRepository(w, r, parts[0], parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleAdminOrganization reads, replaces or deletes a whole organization
+func (bot *CycloneBot) handleAdminOrganization(w http.ResponseWriter, r *http.Request, orgName string) {
+	// An organization's credentials, code host and isolation are the operator's to set, so
+	// signed-in users only manage its repositories
+	if r.Method != http.MethodGet && requestTenants(r) != nil {
+		http.Error(w, "Replacing or removing an organization requires ADMIN_TOKEN", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		org := bot.currentReviewConfig().GetOrganizationConfig(orgName)
+		if org == nil {
+			http.Error(w, fmt.Sprintf("organization %q is not configured", orgName), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, redactOrganization(*org))
+
+	case http.MethodPut:
+		var org config.OrganizationConfig
+		if err := decodeAdminBody(r, &org); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if org.Name != "" && org.Name != orgName {
+			http.Error(w, fmt.Sprintf("organization name %q doesn't match the URL", org.Name), http.StatusBadRequest)
+			return
+		}
+		org.Name = orgName
+
+		bot.updateManagedOrganization(w, r, orgName, "", "update organization", func(current *config.OrganizationConfig) error {
+			if org.AnthropicAPIKey == redactedAPIKey {
+				org.AnthropicAPIKey = current.AnthropicAPIKey
+			}
+			if org.GitHubToken == redactedAPIKey {
+				org.GitHubToken = current.GitHubToken
+			}
+			if org.GitLab != nil && org.GitLab.Token == redactedAPIKey && current.GitLab != nil {
+				org.GitLab.Token = current.GitLab.Token
+			}
+			if org.AzureDevOps != nil && org.AzureDevOps.Token == redactedAPIKey && current.AzureDevOps != nil {
+				org.AzureDevOps.Token = current.AzureDevOps.Token
+			}
+			if org.Gerrit != nil && org.Gerrit.Token == redactedAPIKey && current.Gerrit != nil {
+				org.Gerrit.Token = current.Gerrit.Token
+			}
+			*current = org
+			return nil
+		})
+
+	case http.MethodDelete:
+		bot.configMu.Lock()
+		defer bot.configMu.Unlock()
+
+		before := auditOrganization(bot.reviewConfig.GetOrganizationConfig(orgName))
+		deleted, err := bot.store.DeleteManagedOrganization(orgName)
+		if err != nil {
+			log.Printf("Error deleting managed organization %s: %v", orgName, err)
+			http.Error(w, "Failed to save configuration", http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, fmt.Sprintf("organization %q is not managed through the admin API", orgName), http.StatusNotFound)
+			return
+		}
+
+		bot.reviewConfig = bot.fileConfig.WithManagedOrganizations(bot.store.ManagedOrganizations())
+		log.Printf("Admin API: removed managed configuration of organization %s", orgName)
+		// The organization falls back to its entry in the config file, if it has one
+		after := auditOrganization(bot.reviewConfig.GetOrganizationConfig(orgName))
+		bot.recordAudit(store.AuditSourceAdminAPI, bot.requestActor(r), orgName, "", "delete organization", before, after)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminRepository reads, adds, updates or deletes a single repository entry of an organization
+func (bot *CycloneBot) handleAdminRepository(w http.ResponseWriter, r *http.Request, orgName, repoName string) {
+	switch r.Method {
+	case http.MethodGet:
+		if org := bot.currentReviewConfig().GetOrganizationConfig(orgName); org != nil {
+			if i := repositoryIndex(org, repoName); i >= 0 {
+				writeJSON(w, http.StatusOK, org.Repositories[i])
+				return
+			}
+		}
+		http.Error(w, fmt.Sprintf("repository %q is not configured in %q", repoName, orgName), http.StatusNotFound)
+
+	case http.MethodPut:
+		var repo config.RepositoryConfig
+		if err := decodeAdminBody(r, &repo); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if repo.Name != "" && repo.Name != repoName {
+			http.Error(w, fmt.Sprintf("repository name %q doesn't match the URL", repo.Name), http.StatusBadRequest)
+			return
+		}
+		repo.Name = repoName
+
+		bot.updateManagedOrganization(w, r, orgName, repoName, "update repository", func(org *config.OrganizationConfig) error {
+			if i := repositoryIndex(org, repoName); i >= 0 {
+				org.Repositories[i] = repo
+			} else {
+				org.Repositories = append(org.Repositories, repo)
+			}
+			return nil
+		})
+
+	case http.MethodDelete:
+		bot.updateManagedOrganization(w, r, orgName, repoName, "delete repository", func(org *config.OrganizationConfig) error {
+			i := repositoryIndex(org, repoName)
+			if i < 0 {
+				return errNotFound
+			}
+			org.Repositories = append(org.Repositories[:i], org.Repositories[i+1:]...)
+			return nil
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// errNotFound is returned by admin update functions when the entry to change doesn't exist
+var errNotFound = errors.New("not found")
+
+// updateManagedOrganization applies a change to an organization, validates the resulting
+// configuration and persists the organization as managed. Organizations that only exist in the
+// config file are copied on their first change, so later file edits no longer affect them.
+// The change is recorded in the audit log as action, on repoName if it changes a single repository.
+func (bot *CycloneBot) updateManagedOrganization(w http.ResponseWriter, r *http.Request, orgName, repoName, action string, update func(*config.OrganizationConfig) error) {
+	bot.configMu.Lock()
+	defer bot.configMu.Unlock()
+
+	org := config.OrganizationConfig{Name: orgName}
+	current := bot.reviewConfig.GetOrganizationConfig(orgName)
+	if current != nil {
+		org = *current
+		org.Repositories = append([]config.RepositoryConfig(nil), current.Repositories...)
+	}
+
+	if err := update(&org); err != nil {
+		if err == errNotFound {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated := bot.reviewConfig.WithManagedOrganizations([]config.OrganizationConfig{org})
+	if err := updated.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := bot.store.SaveManagedOrganization(org); err != nil {
+		log.Printf("Error saving managed organization %s: %v", orgName, err)
+		http.Error(w, "Failed to save configuration", http.StatusInternalServerError)
+		return
+	}
+
+	bot.reviewConfig = updated
+	log.Printf("Admin API: updated configuration of organization %s", orgName)
+	bot.recordAudit(store.AuditSourceAdminAPI, bot.requestActor(r), orgName, repoName, action, auditOrganization(current), auditOrganization(&org))
+
+	// The organization's credentials may have changed
+	bot.clientsMu.Lock()
+	delete(bot.orgClients, orgName)
+	delete(bot.orgGitHubClients, orgName)
+	delete(bot.gitlabClients, orgName)
+	delete(bot.azureDevOpsClients, orgName)
+	delete(bot.gerritClients, orgName)
+	bot.clientsMu.Unlock()
+
+	writeJSON(w, http.StatusOK, redactOrganization(org))
+}
+
+// decodeAdminBody decodes a JSON request body, rejecting unknown fields like the config file does
+func decodeAdminBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// repositoryIndex returns the index of the entry with exactly this name, or -1
+func repositoryIndex(org *config.OrganizationConfig, repoName string) int {
+	for i, repo := range org.Repositories {
+		if repo.Name == repoName {
+			return i
+		}
+	}
+	return -1
+}
+
+// redactOrganization hides the organization's API key and GitHub token
+func redactOrganization(org config.OrganizationConfig) config.OrganizationConfig {
+	if org.AnthropicAPIKey != "" {
+		org.AnthropicAPIKey = redactedAPIKey
+	}
+	if org.GitHubToken != "" {
+		org.GitHubToken = redactedAPIKey
+	}
+	if org.GitLab != nil && org.GitLab.Token != "" {
+		gitlab := *org.GitLab
+		gitlab.Token = redactedAPIKey
+		org.GitLab = &gitlab
+	}
+	if org.AzureDevOps != nil && org.AzureDevOps.Token != "" {
+		azureDevOps := *org.AzureDevOps
+		azureDevOps.Token = redactedAPIKey
+		org.AzureDevOps = &azureDevOps
+	}
+	if org.Gerrit != nil && org.Gerrit.Token != "" {
+		gerrit := *org.Gerrit
+		gerrit.Token = redactedAPIKey
+		org.Gerrit = &gerrit
+	}
+	return org
+}
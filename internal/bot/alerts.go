@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failureAlerts posts to a webhook when reviews of a repository fail repeatedly, and again once
+// they succeed, instead of each PR failing on its own
+type failureAlerts struct {
+	webhookURL string
+	threshold  int // Consecutive failures that trigger an alert
+	httpClient *http.Client
+	queue      chan FailureAlert // Sent one at a time, in order
+
+	mu      sync.Mutex
+	streaks map[string]*failureStreak // By "org/repo"
+}
+
+// failureStreak counts the failed reviews of a repository since its last successful one
+type failureStreak struct {
+	failures int
+	classes  map[string]int // Failures by error class, see errorClass
+	alerted  bool
+}
+
+// FailureAlert is the JSON body posted to ALERT_WEBHOOK_URL. Text makes it a valid Slack
+// message; other services can use the remaining fields.
+type FailureAlert struct {
+	Text       string         `json:"text"`
+	Status     string         `json:"status"` // "failing" or "recovered"
+	Org        string         `json:"org"`
+	Repo       string         `json:"repo"`
+	Failures   int            `json:"failures"`              // Consecutive failed reviews
+	ErrorClass string         `json:"error_class,omitempty"` // Class of the latest failure
+	Classes    map[string]int `json:"error_classes,omitempty"`
+	PRNumber   int            `json:"pr_number,omitempty"` // PR of the latest failure
+	Error      string         `json:"error,omitempty"`
+}
+
+// newFailureAlerts returns nil without a webhook URL, which drops all alerts
+func newFailureAlerts(webhookURL string, threshold int) *failureAlerts {
+	if webhookURL == "" {
+		return nil
+	}
+	alerts := &failureAlerts{
+		webhookURL: webhookURL,
+		threshold:  threshold,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan FailureAlert, 100),
+		streaks:    make(map[string]*failureStreak),
+	}
+	go func() {
+		for alert := range alerts.queue {
+			alerts.send(alert)
+		}
+	}()
+	return alerts
+}
+
+// failure counts a failed review of a repository, alerting once its streak reaches the threshold
+func (a *failureAlerts) failure(owner, repoName string, prNumber int, class string, err error) {
+	// Failures that aren't about a repository, such as a batch that couldn't be submitted, show
+	// up as failed reviews of its PRs
+	if a == nil || owner == "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := owner + "/" + repoName
+	streak := a.streaks[key]
+	if streak == nil {
+		streak = &failureStreak{classes: make(map[string]int)}
+		a.streaks[key] = streak
+	}
+	streak.failures++
+	streak.classes[class]++
+	if streak.failures < a.threshold || streak.alerted {
+		return
+	}
+
+	streak.alerted = true
+	a.enqueue(FailureAlert{
+		Text: fmt.Sprintf(":rotating_light: Cyclone reviews of %s failed %d times in a row (%s). Latest: PR #%d - %v",
+			key, streak.failures, formatCounts(streak.classes), prNumber, err),
+		Status:     "failing",
+		Org:        owner,
+		Repo:       repoName,
+		Failures:   streak.failures,
+		ErrorClass: class,
+		Classes:    copyCounts(streak.classes),
+		PRNumber:   prNumber,
+		Error:      err.Error(),
+	})
+}
+
+// success ends the failure streak of a repository, announcing the recovery if it was alerted
+func (a *failureAlerts) success(owner, repoName string) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := owner + "/" + repoName
+	streak := a.streaks[key]
+	delete(a.streaks, key)
+	if streak == nil || !streak.alerted {
+		return
+	}
+	a.enqueue(FailureAlert{
+		Text:     fmt.Sprintf(":white_check_mark: Cyclone reviews of %s succeed again after %d failures", key, streak.failures),
+		Status:   "recovered",
+		Org:      owner,
+		Repo:     repoName,
+		Failures: streak.failures,
+	})
+}
+
+// enqueue schedules an alert to be sent, dropping it if the webhook can't keep up. It is called
+// with mu held so alerts are queued in the order of the failures and successes causing them.
+func (a *failureAlerts) enqueue(alert FailureAlert) {
+	select {
+	case a.queue <- alert:
+	default:
+		log.Printf("Dropping %s alert for %s/%s: too many alerts pending", alert.Status, alert.Org, alert.Repo)
+	}
+}
+
+// send posts an alert, logging failures since there is nowhere else to report them
+func (a *failureAlerts) send(alert FailureAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("Error encoding alert: %v", err)
+		return
+	}
+
+	resp, err := a.httpClient.Post(a.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error sending alert for %s/%s: %v", alert.Org, alert.Repo, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("Error sending alert for %s/%s: status %d", alert.Org, alert.Repo, resp.StatusCode)
+		return
+	}
+	log.Printf("Sent %s alert for %s/%s", alert.Status, alert.Org, alert.Repo)
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for key, n := range counts {
+		copied[key] = n
+	}
+	return copied
+}
+
+// formatCounts lists counts as "claude_529: 2, other: 1", most frequent first
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %d", key, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
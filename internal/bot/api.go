@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// UsageResponse is the JSON body returned by GET /api/usage
+type UsageResponse struct {
+	Org       string              `json:"org,omitempty"`
+	Repo      string              `json:"repo,omitempty"`
+	Since     *time.Time          `json:"since,omitempty"`
+	Until     *time.Time          `json:"until,omitempty"`
+	GroupBy   string              `json:"group_by,omitempty"`
+	Totals    store.UsageTotals   `json:"totals"`
+	Breakdown []store.UsageTotals `json:"breakdown,omitempty"`
+	Skips     map[string]int      `json:"skips"`
+}
+
+// handleUsageAPI serves usage and cost breakdowns filtered by org, repo and date range
+func (bot *CycloneBot) handleUsageAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case store.GroupByNone, store.GroupByOrg, store.GroupByRepo, store.GroupByModel, store.GroupByDay, store.GroupByMonth:
+	default:
+		http.Error(w, fmt.Sprintf("invalid group_by %q (use org, repo, model, day or month)", groupBy), http.StatusBadRequest)
+		return
+	}
+
+	resp := UsageResponse{
+		Org:     filter.Org,
+		Repo:    filter.Repo,
+		GroupBy: groupBy,
+		Totals:  store.UsageTotals{Key: "total"},
+		Skips:   bot.store.CountSkips(filter),
+	}
+	if !filter.Since.IsZero() {
+		resp.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		resp.Until = &filter.Until
+	}
+
+	if totals := bot.store.SumUsage(filter, store.GroupByNone); len(totals) > 0 {
+		resp.Totals = totals[0]
+	}
+	if groupBy != store.GroupByNone {
+		resp.Breakdown = bot.store.SumUsage(filter, groupBy)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// errOtherTenant is returned for requests limited to some organizations that ask for another's data
+var errOtherTenant = errors.New("the request only grants access to the data of its own organizations")
+
+// parseUsageFilter reads the org, repo, since and until query parameters.
+// Dates are either RFC 3339 timestamps or YYYY-MM-DD; a date-only "until" includes that whole day.
+// Requests limited to some organizations are filtered by them, see requestTenants.
+func parseUsageFilter(r *http.Request) (store.UsageFilter, error) {
+	query := r.URL.Query()
+	filter := store.UsageFilter{
+		Org:  query.Get("org"),
+		Repo: query.Get("repo"),
+	}
+	if tenants := requestTenants(r); tenants != nil {
+		if filter.Org != "" && !tenantAllowed(r, filter.Org) {
+			return filter, errOtherTenant
+		}
+		filter.Orgs = tenants
+	}
+
+	if since := query.Get("since"); since != "" {
+		t, _, err := parseDate(since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %w", err)
+		}
+		filter.Since = t
+	}
+
+	if until := query.Get("until"); until != "" {
+		t, dateOnly, err := parseDate(until)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until: %w", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.Until = t
+	}
+
+	return filter, nil
+}
+
+// filterErrorStatus returns the HTTP status of an error of parseUsageFilter
+func filterErrorStatus(err error) int {
+	if errors.Is(err, errOtherTenant) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// parseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC)
+func parseDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	return t, true, nil
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
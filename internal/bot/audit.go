@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// defaultAuditLimit is how many records GET /api/admin/audit returns without a limit parameter
+const defaultAuditLimit = 100
+
+// maxDiffEdits bounds the changed lines diffed; snapshots differing in more are recorded
+// without a diff, as their before and after are kept anyway
+const maxDiffEdits = 5000
+
+// recordAudit adds a configuration change to the audit log, unless nothing changed
+func (bot *CycloneBot) recordAudit(source, actor, org, repo, action, before, after string) {
+	if before == after {
+		return
+	}
+
+	rec := store.AuditRecord{
+		Source: source,
+		Actor:  actor,
+		Org:    org,
+		Repo:   repo,
+		Action: action,
+		Before: before,
+		After:  after,
+	}
+	if diff, ok := review.UnifiedDiff(before, after, maxDiffEdits); ok {
+		rec.Diff = diff
+	}
+	if err := bot.store.RecordAudit(rec); err != nil {
+		log.Printf("Error recording audit log entry %q by %s: %v", action, actor, err)
+		return
+	}
+	log.Printf("Audit: %s by %s", action, actor)
+}
+
+// requestActor identifies who sent an admin API request
+func (bot *CycloneBot) requestActor(r *http.Request) string {
+	if bot.oauth != nil {
+		if login := bot.oauth.sessionUser(r); login != "" {
+			return "github:" + login
+		}
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if bot.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(bot.config.AdminToken)) == 1 {
+		return "admin-token"
+	}
+	return "anonymous"
+}
+
+// auditOrganization renders an organization for the audit log. Credentials are replaced by a
+// short hash, so the log shows that they changed without containing them.
+func auditOrganization(org *config.OrganizationConfig) string {
+	if org == nil {
+		return ""
+	}
+	redacted := *org
+	redacted.AnthropicAPIKey = credentialFingerprint(org.AnthropicAPIKey)
+	redacted.GitHubToken = credentialFingerprint(org.GitHubToken)
+	redacted.GitLab = auditGitLab(org.GitLab)
+	redacted.AzureDevOps = auditAzureDevOps(org.AzureDevOps)
+	redacted.Gerrit = auditGerrit(org.Gerrit)
+	return auditSnapshot(redacted)
+}
+
+// auditReviewConfig renders a whole review configuration for the audit log, see auditOrganization
+func auditReviewConfig(reviewCfg *config.ReviewConfig) string {
+	redacted := *reviewCfg
+	redacted.Organizations = make([]config.OrganizationConfig, len(reviewCfg.Organizations))
+	for i, org := range reviewCfg.Organizations {
+		org.AnthropicAPIKey = credentialFingerprint(org.AnthropicAPIKey)
+		org.GitHubToken = credentialFingerprint(org.GitHubToken)
+		org.GitLab = auditGitLab(org.GitLab)
+		org.AzureDevOps = auditAzureDevOps(org.AzureDevOps)
+		org.Gerrit = auditGerrit(org.Gerrit)
+		redacted.Organizations[i] = org
+	}
+	return auditSnapshot(redacted)
+}
+
+// auditGitLab fingerprints the token of GitLab settings
+func auditGitLab(gitlab *config.GitLabConfig) *config.GitLabConfig {
+	if gitlab == nil {
+		return nil
+	}
+	redacted := *gitlab
+	redacted.Token = credentialFingerprint(gitlab.Token)
+	return &redacted
+}
+
+// auditAzureDevOps fingerprints the token of Azure DevOps settings
+func auditAzureDevOps(azureDevOps *config.AzureDevOpsConfig) *config.AzureDevOpsConfig {
+	if azureDevOps == nil {
+		return nil
+	}
+	redacted := *azureDevOps
+	redacted.Token = credentialFingerprint(azureDevOps.Token)
+	return &redacted
+}
+
+// auditGerrit fingerprints the HTTP password of Gerrit settings
+func auditGerrit(gerrit *config.GerritConfig) *config.GerritConfig {
+	if gerrit == nil {
+		return nil
+	}
+	redacted := *gerrit
+	redacted.Token = credentialFingerprint(gerrit.Token)
+	return &redacted
+}
+
+func credentialFingerprint(credential string) string {
+	if credential == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(credential))
+	return fmt.Sprintf("%s (sha256 %x)", redactedAPIKey, sum[:4])
+}
+
+// auditSnapshot renders a configuration as indented JSON, which diffs line by line
+func auditSnapshot(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data) + "\n"
+}
+
+// repoConfigAuditMu keeps concurrent reviews of a repository from recording the same change
+// of its config file twice
+var repoConfigAuditMu sync.Mutex
+
+// auditRepoConfigFile records a change of a repository's config file since it was last seen.
+// content is nil if the repository has no config file.
+func (bot *CycloneBot) auditRepoConfigFile(ctx context.Context, owner, repoName string, content []byte) {
+	repoConfigAuditMu.Lock()
+	defer repoConfigAuditMu.Unlock()
+
+	before := ""
+	if latest := bot.store.LatestAudit(store.AuditSourceRepoConfigFile, owner, repoName); latest != nil {
+		before = latest.After
+	}
+	after := string(content)
+	if before == after {
+		return
+	}
+
+	action := "change " + config.REPO_CONFIG_FILE
+	switch {
+	case before == "":
+		action = "add " + config.REPO_CONFIG_FILE
+	case after == "":
+		action = "remove " + config.REPO_CONFIG_FILE
+	}
+
+	// Only GitHub tells who changed the file
+	actor := "repository"
+	if provider, err := bot.scmProviderFor(owner); err == nil {
+		if client, ok := provider.(*review.GitHubClient); ok {
+			if author, err := client.LastCommitAuthor(ctx, owner, repoName, config.REPO_CONFIG_FILE); err != nil {
+				log.Printf("Error looking up who changed %s in %s/%s: %v", config.REPO_CONFIG_FILE, owner, repoName, err)
+			} else {
+				actor = "github:" + author
+			}
+		}
+	}
+
+	bot.recordAudit(store.AuditSourceRepoConfigFile, actor, owner, repoName, action, before, after)
+}
+
+// AuditResponse is the JSON body returned by GET /api/admin/audit
+type AuditResponse struct {
+	Total   int                 `json:"total"` // Matching records, before limit and offset
+	Records []store.AuditRecord `json:"records"`
+}
+
+// handleAuditAPI serves GET /api/admin/audit, the configuration changes newest first, filtered
+// by org, repo, date range and source
+func (bot *CycloneBot) handleAuditAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	query := r.URL.Query()
+	source := query.Get("source")
+	switch source {
+	case "", store.AuditSourceAdminAPI, store.AuditSourceReload, store.AuditSourceRepoConfigFile:
+	default:
+		http.Error(w, fmt.Sprintf("invalid source %q (use admin_api, reload or repo_config_file)", source), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseCount(query.Get("limit"), defaultAuditLimit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid limit: %v", err), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseCount(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid offset: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	records := bot.store.ListAudit(filter)
+	var matching []store.AuditRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		if source == "" || records[i].Source == source {
+			matching = append(matching, records[i])
+		}
+	}
+
+	resp := AuditResponse{Total: len(matching), Records: []store.AuditRecord{}}
+	if offset < len(matching) {
+		matching = matching[offset:]
+		if len(matching) > limit {
+			matching = matching[:limit]
+		}
+		resp.Records = matching
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
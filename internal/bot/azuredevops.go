@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/logging"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// Event types of the Azure DevOps service hooks Cyclone handles
+const (
+	azurePullRequestCreated = "git.pullrequest.created"
+	azurePullRequestUpdated = "git.pullrequest.updated"
+)
+
+// ServiceHookPayload represents an Azure DevOps pull request service hook payload
+type ServiceHookPayload struct {
+	ID        string `json:"id"`
+	EventType string `json:"eventType"` // e.g. "git.pullrequest.created"
+	Resource  struct {
+		PullRequestID int    `json:"pullRequestId"`
+		Status        string `json:"status"` // e.g. "active", "completed"
+		IsDraft       bool   `json:"isDraft"`
+		Labels        []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Repository struct {
+			Name    string `json:"name"`
+			URL     string `json:"url"`
+			Project struct {
+				Name string `json:"name"`
+			} `json:"project"`
+		} `json:"repository"`
+	} `json:"resource"`
+	ResourceContainers struct {
+		Account struct {
+			BaseURL string `json:"baseUrl"`
+		} `json:"account"`
+		Collection struct {
+			BaseURL string `json:"baseUrl"`
+		} `json:"collection"`
+	} `json:"resourceContainers"`
+}
+
+// pullRequest identifies the payload's pull request by organization, repository name - the
+// project and repository, e.g. "Fabrikam/api" - and ID. The organization is the configured
+// Azure DevOps organization whose URL the payload's URLs start with, "" if none does.
+func (p *ServiceHookPayload) pullRequest(reviewCfg *config.ReviewConfig) (string, string, int) {
+	repoName := p.Resource.Repository.Project.Name + "/" + p.Resource.Repository.Name
+	for _, org := range reviewCfg.Organizations {
+		if org.GetProvider() != config.ProviderAzureDevOps || org.AzureDevOps == nil {
+			continue
+		}
+		orgURL := strings.ToLower(strings.TrimSuffix(org.AzureDevOps.GetURL(org.Name), "/")) + "/"
+		for _, u := range []string{p.ResourceContainers.Account.BaseURL, p.ResourceContainers.Collection.BaseURL, p.Resource.Repository.URL} {
+			if strings.HasPrefix(strings.ToLower(u), orgURL) {
+				return org.Name, repoName, p.Resource.PullRequestID
+			}
+		}
+	}
+	return "", repoName, p.Resource.PullRequestID
+}
+
+// handleAzureDevOpsWebhook processes incoming Azure DevOps service hooks. With WEBHOOK_SECRET
+// set, the service hook must send it as the basic authentication password.
+func (bot *CycloneBot) handleAzureDevOpsWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if secret := bot.webhookSecret(); secret != "" {
+		_, password, _ := r.BasicAuth()
+		if subtle.ConstantTimeCompare([]byte(password), []byte(secret)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Basic")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading webhook body: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Service hooks name their event and notification in the payload rather than in headers
+	var payload ServiceHookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		log.Printf("Error decoding service hook payload: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	bot.acceptDelivery(w, store.WebhookDelivery{ID: deliveryID(payload.ID), Event: payload.EventType, Payload: body})
+}
+
+// serviceHookJob triggers reviews for Azure DevOps pull request events: when a pull request is
+// created, and when an update makes one Cyclone hasn't reviewed reviewable - e.g. a published
+// draft, or one too large to review that was labeled for a forced review
+func (bot *CycloneBot) serviceHookJob(event string, body []byte) (func() error, string, error) {
+	var payload ServiceHookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+
+	owner, repoName, id := payload.pullRequest(bot.currentReviewConfig())
+	if owner == "" {
+		return nil, "ignored: not from a configured Azure DevOps organization", nil
+	}
+	pr := payload.Resource
+	if pr.IsDraft {
+		return nil, "ignored: draft PRs aren't reviewed", nil
+	}
+	if pr.Status != "active" {
+		return nil, fmt.Sprintf("ignored: %s PRs aren't reviewed", pr.Status), nil
+	}
+
+	forceLabeled := false
+	if event == azurePullRequestUpdated {
+		if bot.store.HasReview(owner, repoName, id) {
+			return nil, "ignored: updates of reviewed PRs don't trigger a review", nil
+		}
+		// PRs skipped before are only reviewed again once labeled for a forced review
+		if bot.store.HasSkip(owner, repoName, id) {
+			for _, label := range pr.Labels {
+				forceLabeled = forceLabeled || label.Name == config.FORCE_REVIEW_LABEL
+			}
+			if !forceLabeled {
+				return nil, "ignored: updates of skipped PRs don't trigger a review", nil
+			}
+		}
+	}
+
+	log.Printf("Processing PR %d of %s/%s: %s", id, owner, repoName, event)
+	return func() error { return bot.ProcessAzurePullRequest(owner, repoName, id, forceLabeled) }, "review", nil
+}
+
+// azureDevOpsClientFor returns the Azure DevOps client of an organization on Azure DevOps
+func (bot *CycloneBot) azureDevOpsClientFor(owner string) (*review.AzureDevOpsClient, error) {
+	orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner)
+	if orgConfig == nil || orgConfig.GetProvider() != config.ProviderAzureDevOps || orgConfig.AzureDevOps == nil {
+		return nil, fmt.Errorf("organization %s isn't on Azure DevOps", owner)
+	}
+
+	bot.clientsMu.Lock()
+	defer bot.clientsMu.Unlock()
+
+	if client, ok := bot.azureDevOpsClients[owner]; ok {
+		return client, nil
+	}
+
+	token := orgConfig.AzureDevOps.GetToken()
+	if token == "" {
+		return nil, fmt.Errorf("no Azure DevOps token for organization %s", owner)
+	}
+	logging.AddSecrets(token)
+	client := review.NewAzureDevOpsClient(orgConfig.AzureDevOps.GetURL(owner), token)
+	bot.azureDevOpsClients[owner] = client
+	return client, nil
+}
+
+// ProcessAzurePullRequest reviews an Azure Repos pull request, diffing the latest iteration
+// against the target branch. repoName is the project and repository, e.g. "Fabrikam/api". The
+// returned error wraps ErrReviewSkipped if the PR was deliberately not reviewed.
+func (bot *CycloneBot) ProcessAzurePullRequest(owner, repoName string, id int, forceLabeled bool) error {
+	return bot.processHostedChange(owner, repoName, id, fmt.Sprintf("pull request %d", id), forceLabeled)
+}
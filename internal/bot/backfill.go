@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// BackfillOptions selects the existing PRs Backfill reviews
+type BackfillOptions struct {
+	Since time.Time // Only PRs created at or after Since
+	State string    // "open", "closed" or "all"
+	Limit int       // Maximum number of PRs to review, 0 for no limit
+}
+
+// Backfill reviews existing PRs of a repository that Cyclone hasn't reviewed yet, e.g. when
+// a team first adopts Cyclone. Size limits and batch mode apply as for new PRs, and batched
+// reviews are waited for. The backfill stops once a usage quota is exhausted, counting the
+// predicted usage of batch reviews still awaiting results, rather than posting quota notices
+// on old PRs. It returns the number of PRs reviewed.
+func (bot *CycloneBot) Backfill(ctx context.Context, owner, repoName string, opts BackfillOptions) (int, error) {
+	prs, err := bot.githubClientFor(owner).ListPullRequests(ctx, owner, repoName, opts.State, opts.Since)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Backfill of %s/%s: %d %s PRs since %s", owner, repoName, len(prs), opts.State, opts.Since.Format(time.DateOnly))
+
+	// Batch reviews only record their usage once their batch ends, so until then their
+	// predicted usage counts against the quota
+	predicted := make(map[int]store.UsageTotals)
+
+	reviewed := 0
+	for i := len(prs) - 1; i >= 0; i-- { // Oldest first
+		pr := prs[i]
+		if opts.Limit > 0 && reviewed >= opts.Limit {
+			log.Printf("Backfill limit of %d PRs reached", opts.Limit)
+			break
+		}
+		if pr.Draft {
+			continue
+		}
+		if bot.store.HasReview(owner, repoName, pr.Number) {
+			log.Printf("PR #%d was already reviewed - skipping", pr.Number)
+			continue
+		}
+		repoConfig := bot.repositoryConfig(owner, repoName)
+		if quota := bot.checkQuotaPending(owner, repoName, repoConfig, bot.pendingBatchUsage(owner, repoName, predicted)); quota.Exceeded {
+			log.Printf("Quota of the %s is exhausted - stopping the backfill", quota.Scope)
+			break
+		}
+
+		var prediction store.UsageTotals
+		if repoConfig.BatchMode {
+			prediction = bot.predictUsage(ctx, pr)
+		}
+		_, err := bot.reviewPullRequest(ctx, pr.Base.Repo, pr, reviewOptions{post: true, batch: true})
+		if errors.Is(err, ErrReviewSkipped) {
+			log.Printf("PR #%d not reviewed: %v", pr.Number, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error reviewing PR #%d: %v", pr.Number, err)
+			continue
+		}
+		reviewed++
+		if bot.batches.queued(owner, repoName, pr.Number) {
+			predicted[pr.Number] = prediction
+		}
+	}
+
+	bot.WaitForBatches()
+	return reviewed, nil
+}
+
+// pendingBatchUsage sums the predicted usage of the PRs whose batch reviews haven't ended yet
+func (bot *CycloneBot) pendingBatchUsage(owner, repoName string, predicted map[int]store.UsageTotals) store.UsageTotals {
+	var pending store.UsageTotals
+	for prNumber, prediction := range predicted {
+		if !bot.batches.queued(owner, repoName, prNumber) {
+			// Posted, so its actual usage is recorded
+			delete(predicted, prNumber)
+			continue
+		}
+		pending.InputTokens += prediction.InputTokens
+		pending.OutputTokens += prediction.OutputTokens
+		pending.CostUSD += prediction.CostUSD
+	}
+	return pending
+}
+
+// predictUsage estimates the usage of reviewing a PR like cyclone estimate does. A PR whose
+// review can't be prepared, e.g. because it is too large, is predicted to use nothing.
+func (bot *CycloneBot) predictUsage(ctx context.Context, pr *review.PullRequest) store.UsageTotals {
+	var prediction store.UsageTotals
+	prepared, err := bot.preparePullRequestReview(ctx, pr.Base.Repo, pr, reviewOptions{preview: true})
+	if err != nil {
+		return prediction
+	}
+	for _, estimate := range bot.estimatePreparedReview(prepared) {
+		prediction.InputTokens += estimate.Usage.InputTokens
+		prediction.OutputTokens += estimate.Usage.OutputTokens
+		prediction.CostUSD += estimate.Usage.Cost()
+	}
+	return prediction
+}
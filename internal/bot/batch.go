@@ -0,0 +1,461 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// batchWaitInterval is how often WaitForBatches checks whether all batches are done
+const batchWaitInterval = 10 * time.Second
+
+// batchItem tracks a PR whose review was queued for the Message Batches API
+type batchItem struct {
+	owner          string
+	repoName       string
+	prNumber       int
+	diff           string
+	warningMessage string
+	promptVariant  string
+	headSHA        string
+	startedAt      time.Time
+	diffFetch      time.Duration
+	context        time.Duration // How long building the request took
+	dryRun         bool
+	ruleComments   []review.ReviewComment // Import rule violations, posted with the review
+	reviewers      *reviewerSuggestions   // Reviewers named at the end of the summary, nil if none
+	request        review.BatchRequest
+	batchID        string // Empty until the request is submitted
+}
+
+// inFlightBatch is a submitted batch awaiting results. Batches must be polled
+// with the client (and therefore API key) that submitted them.
+type inFlightBatch struct {
+	id     string
+	client *review.AIClient
+}
+
+// batchQueue collects non-urgent reviews and tracks submitted batches until their results are
+// posted. Its items are also kept in the store, so a restarted process resumes them.
+type batchQueue struct {
+	mu       sync.Mutex
+	pending  map[*review.AIClient][]review.BatchRequest // grouped by API key
+	items    map[string]batchItem                       // keyed by custom ID
+	inFlight []inFlightBatch
+	seq      int
+	start    sync.Once
+	resume   bool // Take over the batch reviews of stopped processes, see ResumeBatches
+}
+
+func newBatchQueue() *batchQueue {
+	return &batchQueue{
+		pending: make(map[*review.AIClient][]review.BatchRequest),
+		items:   make(map[string]batchItem),
+	}
+}
+
+// queueBatchReview queues a PR review for the next batch submission
+func (bot *CycloneBot) queueBatchReview(item batchItem, title, body string, repoConfig *config.RepositoryConfig) {
+	q := bot.batches
+	bot.startBatchLoop()
+
+	// Processes sharing the data directory store their requests side by side, so the ID
+	// carries a random part besides the time and sequence number
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	q.mu.Lock()
+	q.seq++
+	customID := fmt.Sprintf("review-%d-%d-%x", time.Now().Unix(), q.seq, suffix)
+	q.mu.Unlock()
+
+	// Pending requests are grouped by the API key's client; the variant only changes the prompt
+	client := bot.aiClientFor(item.owner)
+	started := time.Now()
+	item.request = client.WithPromptVariant(item.promptVariant).NewBatchRequest(customID, item.diff, title, body, repoConfig)
+	item.context = time.Since(started)
+	bot.saveBatchItem(item)
+
+	q.mu.Lock()
+	q.items[customID] = item
+	q.pending[client] = append(q.pending[client], item.request)
+	queued := len(q.pending[client])
+	q.mu.Unlock()
+
+	log.Printf("Queued PR #%d in %s/%s for batch review (%d pending)", item.prNumber, item.owner, item.repoName, queued)
+
+	if queued >= config.MAX_BATCH_SIZE {
+		bot.flushBatch()
+	}
+}
+
+// idle reports whether no reviews are pending or awaiting batch results
+func (q *batchQueue) idle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending) == 0 && len(q.inFlight) == 0
+}
+
+// queued reports whether a review of a PR is queued or awaiting its batch's results
+func (q *batchQueue) queued(owner, repoName string, prNumber int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.owner == owner && item.repoName == repoName && item.prNumber == prNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForBatches submits queued reviews right away and blocks until the results of all
+// submitted batches have been posted. The batch loop keeps polling in the background.
+func (bot *CycloneBot) WaitForBatches() {
+	bot.flushBatch()
+	for !bot.batches.idle() {
+		time.Sleep(batchWaitInterval)
+	}
+}
+
+// ResumeBatches resumes the batch reviews queued or submitted by processes that stopped, e.g.
+// before a restart, once their claim times out, and keeps taking over those of processes
+// sharing the data directory that stop later. Only long-running processes should call it.
+func (bot *CycloneBot) ResumeBatches() {
+	bot.batches.mu.Lock()
+	bot.batches.resume = true
+	bot.batches.mu.Unlock()
+
+	bot.claimStaleBatches()
+	bot.startBatchLoop()
+}
+
+// startBatchLoop starts the batch loop and the renewal of this process' batch reviews, once
+func (bot *CycloneBot) startBatchLoop() {
+	bot.batches.start.Do(func() {
+		go bot.runBatchLoop()
+		go bot.renewBatchClaims()
+	})
+}
+
+// runBatchLoop periodically submits pending reviews and polls in-flight batches
+func (bot *CycloneBot) runBatchLoop() {
+	flushTicker := time.NewTicker(config.BATCH_FLUSH_INTERVAL)
+	pollTicker := time.NewTicker(config.BATCH_POLL_INTERVAL)
+	defer flushTicker.Stop()
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-flushTicker.C:
+			bot.flushBatch()
+		case <-pollTicker.C:
+			bot.claimStaleBatches()
+			bot.pollBatches()
+		}
+	}
+}
+
+// renewBatchClaims keeps the stored batch reviews of this process from being taken over by
+// another one. It runs apart from the batch loop, which can be busy posting reviews for a while.
+func (bot *CycloneBot) renewBatchClaims() {
+	q := bot.batches
+	ticker := time.NewTicker(config.BATCH_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		q.mu.Lock()
+		customIDs := make([]string, 0, len(q.items))
+		for customID := range q.items {
+			customIDs = append(customIDs, customID)
+		}
+		q.mu.Unlock()
+
+		if err := bot.store.TouchBatchItems(customIDs); err != nil {
+			log.Printf("Error renewing batch reviews: %v", err)
+		}
+	}
+}
+
+// claimStaleBatches takes over the stored batch reviews no process renewed within
+// BATCH_CLAIM_TIMEOUT, queueing those not submitted yet and polling the batches of the others
+func (bot *CycloneBot) claimStaleBatches() {
+	q := bot.batches
+	q.mu.Lock()
+	resume := q.resume
+	q.mu.Unlock()
+	if !resume {
+		return
+	}
+
+	stored, err := bot.store.ClaimStaleBatchItems(config.BATCH_CLAIM_TIMEOUT)
+	if err != nil {
+		log.Printf("Error resuming batch reviews: %v", err)
+	}
+	if len(stored) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, s := range stored {
+		item, err := batchItemFromStore(s)
+		if err != nil {
+			log.Printf("Dropping stored batch review %s: %v", s.CustomID, err)
+			bot.store.DeleteBatchItem(s.CustomID)
+			continue
+		}
+
+		// Batches must be polled with the client of the API key that submitted them
+		client := bot.aiClientFor(item.owner)
+		q.items[s.CustomID] = item
+		if item.batchID == "" {
+			q.pending[client] = append(q.pending[client], item.request)
+			continue
+		}
+		if !slices.ContainsFunc(q.inFlight, func(b inFlightBatch) bool { return b.id == item.batchID }) {
+			q.inFlight = append(q.inFlight, inFlightBatch{id: item.batchID, client: client})
+		}
+	}
+
+	log.Printf("Resumed %d batch reviews of a stopped process", len(stored))
+}
+
+// flushBatch submits all pending reviews, one batch per API key
+func (bot *CycloneBot) flushBatch() {
+	q := bot.batches
+
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = make(map[*review.AIClient][]review.BatchRequest)
+	q.mu.Unlock()
+
+	for client, requests := range pending {
+		batch, err := client.SubmitBatch(requests)
+		if err != nil {
+			log.Printf("Error submitting review batch: %v", err)
+			bot.reportError(errorKindBatchFailed, err, "", "", 0)
+			// Put the requests back so they are retried on the next flush
+			q.mu.Lock()
+			q.pending[client] = append(requests, q.pending[client]...)
+			q.mu.Unlock()
+			continue
+		}
+
+		q.mu.Lock()
+		q.inFlight = append(q.inFlight, inFlightBatch{id: batch.ID, client: client})
+		var submitted []batchItem
+		for _, request := range requests {
+			if item, ok := q.items[request.CustomID]; ok {
+				item.batchID = batch.ID
+				q.items[request.CustomID] = item
+				submitted = append(submitted, item)
+			}
+		}
+		q.mu.Unlock()
+
+		for _, item := range submitted {
+			bot.saveBatchItem(item)
+		}
+
+		log.Printf("Submitted review batch %s with %d requests", batch.ID, len(requests))
+	}
+}
+
+// pollBatches checks in-flight batches and posts the reviews of ended ones
+func (bot *CycloneBot) pollBatches() {
+	q := bot.batches
+
+	q.mu.Lock()
+	batches := append([]inFlightBatch(nil), q.inFlight...)
+	q.mu.Unlock()
+
+	for _, inFlight := range batches {
+		batch, err := inFlight.client.GetBatch(inFlight.id)
+		if err != nil {
+			log.Printf("Error polling batch: %v", err)
+			continue
+		}
+
+		if batch.ProcessingStatus != review.BatchStatusEnded {
+			continue
+		}
+
+		results, err := inFlight.client.GetBatchResults(batch)
+		if err != nil {
+			log.Printf("Error fetching results for batch %s: %v", inFlight.id, err)
+			bot.reportError(errorKindBatchFailed, err, "", "", 0)
+			continue
+		}
+
+		for _, result := range results {
+			bot.postBatchResult(inFlight.client, result)
+		}
+
+		q.mu.Lock()
+		for i, b := range q.inFlight {
+			if b.id == inFlight.id {
+				q.inFlight = append(q.inFlight[:i], q.inFlight[i+1:]...)
+				break
+			}
+		}
+		q.mu.Unlock()
+
+		log.Printf("Finished processing batch %s (%d results)", inFlight.id, len(results))
+	}
+}
+
+// postBatchResult posts the review for a single batch result
+func (bot *CycloneBot) postBatchResult(client *review.AIClient, result review.BatchResult) {
+	q := bot.batches
+
+	q.mu.Lock()
+	item, ok := q.items[result.CustomID]
+	delete(q.items, result.CustomID)
+	q.mu.Unlock()
+
+	if !ok {
+		log.Printf("Ignoring batch result for unknown request %s", result.CustomID)
+		return
+	}
+	// Removed once handled, so a review interrupted by a restart is posted after it
+	defer func() {
+		if err := bot.store.DeleteBatchItem(result.CustomID); err != nil {
+			log.Printf("Error deleting batch review: %v", err)
+		}
+	}()
+
+	reviewResult, err := client.ParseBatchResult(result, item.request, item.diff)
+	if err != nil {
+		log.Printf("Batch review failed for PR #%d in %s/%s: %v", item.prNumber, item.owner, item.repoName, err)
+		bot.reportError(errorKindBatchReviewFailed, err, item.owner, item.repoName, item.prNumber)
+		bot.recordSkip(item.owner, item.repoName, item.prNumber, store.SkipReasonReviewFailed)
+		return
+	}
+	bot.recordUsage(item.owner, item.repoName, item.prNumber, store.UsageKindBatchReview, reviewResult.Usage)
+	if reviewResult.Err != nil {
+		bot.reportError(errorKindGenerationFailed, reviewResult.Err, item.owner, item.repoName, item.prNumber)
+	} else {
+		reviewResult.Comments = append(reviewResult.Comments, item.ruleComments...)
+	}
+
+	if item.warningMessage != "" {
+		reviewResult.Summary = item.warningMessage + reviewResult.Summary
+	}
+	if item.reviewers != nil && reviewResult.Err == nil {
+		reviewResult.Summary += "\n\n" + bot.reviewerNote(context.Background(), item.owner, item.repoName, item.prNumber, item.reviewers, true, item.dryRun)
+	}
+	reviewResult.HeadSHA = item.headSHA
+	reviewResult.StartedAt = item.startedAt
+	reviewResult.Timings.DiffFetch = item.diffFetch
+	reviewResult.Timings.Context = item.context
+
+	if err := bot.publishReview(context.Background(), item.owner, item.repoName, item.prNumber, reviewResult, item.dryRun); err != nil {
+		log.Printf("Error posting batch review for PR #%d: %v", item.prNumber, err)
+		bot.reportError(errorKindBatchReviewFailed, err, item.owner, item.repoName, item.prNumber)
+		bot.recordSkip(item.owner, item.repoName, item.prNumber, store.SkipReasonReviewFailed)
+		return
+	}
+	if !item.dryRun {
+		log.Printf("Successfully posted batch review for PR #%d", item.prNumber)
+	}
+}
+
+// saveBatchItem stores a batch review so it survives a restart. A review that can't be
+// stored is still processed, it just isn't resumed.
+func (bot *CycloneBot) saveBatchItem(item batchItem) {
+	stored, err := item.toStore()
+	if err == nil {
+		err = bot.store.SaveBatchItem(stored)
+	}
+	if err != nil {
+		log.Printf("Error storing batch review for PR #%d in %s/%s: %v", item.prNumber, item.owner, item.repoName, err)
+	}
+}
+
+// toStore converts the item to its stored form
+func (item batchItem) toStore() (store.BatchItem, error) {
+	request, err := json.Marshal(item.request)
+	if err != nil {
+		return store.BatchItem{}, fmt.Errorf("failed to encode batch request: %w", err)
+	}
+
+	stored := store.BatchItem{
+		CustomID:       item.request.CustomID,
+		BatchID:        item.batchID,
+		Org:            item.owner,
+		Repo:           item.repoName,
+		PRNumber:       item.prNumber,
+		Diff:           item.diff,
+		WarningMessage: item.warningMessage,
+		PromptVariant:  item.promptVariant,
+		HeadSHA:        item.headSHA,
+		StartedAt:      item.startedAt,
+		DiffFetch:      item.diffFetch,
+		Context:        item.context,
+		DryRun:         item.dryRun,
+		Request:        request,
+	}
+	for _, comment := range item.ruleComments {
+		stored.RuleComments = append(stored.RuleComments, store.BatchComment{
+			Path:     comment.Path,
+			Line:     comment.Line,
+			Body:     comment.Body,
+			Side:     comment.Side,
+			Category: comment.Category,
+		})
+	}
+	if item.reviewers != nil {
+		stored.Reviewers = &store.BatchReviewers{Request: item.reviewers.request, Language: item.reviewers.language}
+		for _, reviewer := range item.reviewers.reviewers {
+			stored.Reviewers.Reviewers = append(stored.Reviewers.Reviewers, store.BatchReviewer(reviewer))
+		}
+	}
+	return stored, nil
+}
+
+// batchItemFromStore converts a stored batch review back to a queue item
+func batchItemFromStore(stored store.BatchItem) (batchItem, error) {
+	item := batchItem{
+		owner:          stored.Org,
+		repoName:       stored.Repo,
+		prNumber:       stored.PRNumber,
+		diff:           stored.Diff,
+		warningMessage: stored.WarningMessage,
+		promptVariant:  stored.PromptVariant,
+		headSHA:        stored.HeadSHA,
+		startedAt:      stored.StartedAt,
+		diffFetch:      stored.DiffFetch,
+		context:        stored.Context,
+		dryRun:         stored.DryRun,
+		batchID:        stored.BatchID,
+	}
+	if err := json.Unmarshal(stored.Request, &item.request); err != nil {
+		return batchItem{}, fmt.Errorf("failed to decode batch request: %w", err)
+	}
+	for _, comment := range stored.RuleComments {
+		item.ruleComments = append(item.ruleComments, review.ReviewComment{
+			Path:     comment.Path,
+			Line:     comment.Line,
+			Body:     comment.Body,
+			Side:     comment.Side,
+			Category: comment.Category,
+		})
+	}
+	if stored.Reviewers != nil {
+		item.reviewers = &reviewerSuggestions{request: stored.Reviewers.Request, language: stored.Reviewers.Language}
+		for _, reviewer := range stored.Reviewers.Reviewers {
+			item.reviewers.reviewers = append(item.reviewers.reviewers, review.SuggestedReviewer(reviewer))
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// usageExportColumns are the CSV header and the order of UsageExportRow's fields
+var usageExportColumns = []string{"org", "month", "reviews", "calls", "input_tokens", "output_tokens", "cost_usd"}
+
+// BillingResponse is the JSON body returned by GET /api/billing
+type BillingResponse struct {
+	Since   *time.Time        `json:"since,omitempty"`
+	Until   *time.Time        `json:"until,omitempty"`
+	Totals  store.UsageTotals `json:"totals"`
+	Tenants []TenantUsage     `json:"tenants"`
+}
+
+// TenantUsage is an organization's usage with monthly rollups, keyed YYYY-MM (UTC), for
+// charging its Anthropic costs back to the team that owns it
+type TenantUsage struct {
+	Org    string              `json:"org"`
+	Totals store.UsageTotals   `json:"totals"`
+	Months []store.UsageTotals `json:"months"`
+}
+
+// UsageExportRow is an organization's usage in a month, one JSON line or CSV row each
+type UsageExportRow struct {
+	Org          string  `json:"org"`
+	Month        string  `json:"month"`
+	Reviews      int     `json:"reviews"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// TenantUsageRollup aggregates the usage ledger matching the filter per organization and
+// month, organizations sorted by name and months oldest first
+func TenantUsageRollup(st *store.Store, filter store.UsageFilter) []TenantUsage {
+	tenants := []TenantUsage{}
+	for _, totals := range st.SumUsage(filter, store.GroupByOrg) {
+		orgFilter := filter
+		orgFilter.Org = totals.Key
+		tenants = append(tenants, TenantUsage{
+			Org:    totals.Key,
+			Totals: totals,
+			Months: st.SumUsage(orgFilter, store.GroupByMonth),
+		})
+	}
+	return tenants
+}
+
+// ExportUsage writes the monthly usage of organizations as CSV or JSON lines, one row per
+// organization and month
+func ExportUsage(w io.Writer, format string, tenants []TenantUsage) error {
+	var rows []UsageExportRow
+	for _, tenant := range tenants {
+		for _, month := range tenant.Months {
+			rows = append(rows, UsageExportRow{
+				Org:          tenant.Org,
+				Month:        month.Key,
+				Reviews:      month.Reviews,
+				Calls:        month.Calls,
+				InputTokens:  month.InputTokens,
+				OutputTokens: month.OutputTokens,
+				CostUSD:      month.CostUSD,
+			})
+		}
+	}
+
+	switch format {
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(usageExportColumns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err := writer.Write([]string{
+				row.Org,
+				row.Month,
+				strconv.Itoa(row.Reviews),
+				strconv.Itoa(row.Calls),
+				strconv.Itoa(row.InputTokens),
+				strconv.Itoa(row.OutputTokens),
+				strconv.FormatFloat(row.CostUSD, 'f', 4, 64),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case ExportFormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, row := range rows {
+			if err := encoder.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q (use csv or jsonl)", format)
+}
+
+// handleBillingAPI serves GET /api/billing, the usage of each organization with monthly
+// rollups, filtered like the usage API
+func (bot *CycloneBot) handleBillingAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	resp := BillingResponse{
+		Totals:  store.UsageTotals{Key: "total"},
+		Tenants: TenantUsageRollup(bot.store, filter),
+	}
+	if !filter.Since.IsZero() {
+		resp.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		resp.Until = &filter.Until
+	}
+	if totals := bot.store.SumUsage(filter, store.GroupByNone); len(totals) > 0 {
+		resp.Totals = totals[0]
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleUsageExport serves GET /api/export/usage, the monthly usage of each organization as
+// CSV or JSON lines filtered like the usage API
+func (bot *CycloneBot) handleUsageExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	contentType := "text/csv"
+	switch format {
+	case ExportFormatCSV:
+	case ExportFormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		http.Error(w, fmt.Sprintf("invalid format %q (use csv or jsonl)", format), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="cyclone-usage.%s"`, format))
+	if err := ExportUsage(w, format, TenantUsageRollup(bot.store, filter)); err != nil {
+		// Headers are already sent, so the client only sees a truncated file
+		log.Printf("Error exporting usage: %v", err)
+	}
+}
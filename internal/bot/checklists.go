@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// runChecklists runs the specialized checklist passes turned on for a repository, such as
+// the migration checklist, and returns their findings to post with the review's comments.
+// plan fetches the terraform plan output of the change for the passes that use it; it's nil
+// where there is none. A failed pass is logged and skipped so it never holds up the review.
+func (bot *CycloneBot) runChecklists(aiClient *review.AIClient, owner, repoName string, prNumber int, diff string, repoConfig *config.RepositoryConfig, plan func() string) []review.ReviewComment {
+	var comments []review.ReviewComment
+	for _, checklist := range review.Checklists {
+		if !bot.featureEnabled(owner, repoName, checklist.Feature) || !checklist.Applies(diff) {
+			continue
+		}
+		checklistPlan := ""
+		if checklist.UsesPlan && plan != nil {
+			checklistPlan = plan()
+		}
+		found, usage, err := aiClient.RunChecklist(checklist, diff, checklistPlan, repoConfig)
+		bot.recordUsage(owner, repoName, prNumber, store.UsageKindChecklist, usage)
+		if err != nil {
+			log.Printf("Error running the %s checklist for %s/%s#%d: %v", checklist.Name, owner, repoName, prNumber, err)
+			continue
+		}
+		comments = append(comments, found...)
+	}
+	return comments
+}
+
+// terraformPlan returns the terraform plan output posted on a GitHub pull request, "" if
+// there is none or the comments can't be listed
+func (bot *CycloneBot) terraformPlan(ctx context.Context, owner, repoName string, prNumber int) string {
+	comments, err := bot.githubClientFor(owner).ListComments(ctx, owner, repoName, prNumber)
+	if err != nil {
+		log.Printf("Error listing comments of PR #%d for its terraform plan: %v", prNumber, err)
+		return ""
+	}
+	plan := review.TerraformPlan(comments)
+	if plan != "" {
+		log.Printf("Found terraform plan output on PR #%d in %s/%s", prNumber, owner, repoName)
+	}
+	return plan
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cyclone/internal/review"
+)
+
+// configStatusContext is the commit status Cyclone sets on config repository pull requests;
+// branch protection can require it so only valid configuration gets merged
+const configStatusContext = "cyclone/config"
+
+// ValidateConfigChange lints the review configuration proposed by a pull request against the
+// GitHub config repository, like a reload would, and reports the result as the cyclone/config
+// commit status of the PR's head. Invalid changes also get a comment listing the problems.
+// Merging the PR then reloads the configuration through the push to the config branch.
+func (bot *CycloneBot) ValidateConfigChange(repo *review.Repository, pr *review.PullRequest) error {
+	ctx := context.Background()
+	owner, repoName := repo.Owner.Login, repo.Name
+	source := bot.config.ReviewConfigSource
+	client := bot.githubClientFor(owner)
+
+	data, err := client.GetFileContentAt(ctx, owner, repoName, source.Path, pr.Head.SHA)
+	if err != nil {
+		return err
+	}
+	problem := bot.lintConfigChange(source.Path, data)
+
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not reporting the validation of config PR #%d in %s/%s: %v", pr.Number, owner, repoName, problem)
+		return nil
+	}
+
+	state, description := "success", fmt.Sprintf("%s is valid", source.Path)
+	if problem != nil {
+		state, description = "failure", fmt.Sprintf("%s is invalid - see the PR's comments", source.Path)
+	}
+	if err := client.SetCommitStatus(ctx, owner, repoName, pr.Head.SHA, configStatusContext, state, description); err != nil {
+		return err
+	}
+	if problem != nil {
+		if err := client.PostComment(ctx, owner, repoName, pr.Number, configProblemComment(pr.Head.SHA, problem)); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Validated config PR #%d in %s/%s at %s: %s", pr.Number, owner, repoName, shortSHA(pr.Head.SHA), state)
+	return nil
+}
+
+// lintConfigChange returns why a proposed version of the review configuration would be
+// rejected, nil if it's valid. Organizations managed through the admin API are merged in,
+// as they are on reload, so conflicts with them surface before the merge.
+func (bot *CycloneBot) lintConfigChange(path string, data []byte) error {
+	if data == nil {
+		return fmt.Errorf("%s is deleted", path)
+	}
+	proposed, err := bot.config.ReviewConfigSource.Parse(path, data)
+	if err != nil {
+		return err
+	}
+	if err := proposed.WithManagedOrganizations(bot.store.ManagedOrganizations()).Validate(); err != nil {
+		return fmt.Errorf("%s with the organizations managed through the admin API: %w", path, err)
+	}
+	return nil
+}
+
+// configProblemComment renders the PR comment on an invalid configuration change
+func configProblemComment(sha string, problem error) string {
+	return fmt.Sprintf("### Cyclone can't load this configuration\n\nAt %s:\n\n```\n%v\n```\n\n"+
+		"_Merging it would keep the current configuration in place. Push a fix to run the validation again._",
+		shortSHA(sha), problem)
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"log"
+	"sync"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// generateConsensusReview reviews the diff with the primary and consensus models in parallel and merges the results
+func (bot *CycloneBot) generateConsensusReview(aiClient *review.AIClient, owner, repoName string, prNumber int, diff, title, body string, repoConfig *config.RepositoryConfig) review.ReviewResult {
+	secondaryClient := aiClient.WithModel(repoConfig.ConsensusModel)
+
+	var primary, secondary review.ReviewResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		primary = aiClient.GenerateReview(diff, title, body, repoConfig)
+	}()
+	go func() {
+		defer wg.Done()
+		secondary = secondaryClient.GenerateReview(diff, title, body, repoConfig)
+	}()
+	wg.Wait()
+
+	bot.recordUsage(owner, repoName, prNumber, store.UsageKindReview, primary.Usage)
+	bot.recordUsage(owner, repoName, prNumber, store.UsageKindConsensus, secondary.Usage)
+
+	// A model that failed leaves the review to the other one alone
+	if secondary.Err != nil {
+		log.Printf("Consensus model %s failed for PR #%d - posting the primary review only: %v", repoConfig.ConsensusModel, prNumber, secondary.Err)
+		return primary
+	}
+	if primary.Err != nil {
+		log.Printf("Primary model failed for PR #%d - posting the consensus model's review only: %v", prNumber, primary.Err)
+		return secondary
+	}
+
+	merged := review.MergeConsensus(primary, secondary, repoConfig.ConsensusModel, repoConfig.ConsensusMode, repoConfig.Language)
+	// The models ran in parallel, so the slower one held up each stage
+	merged.Timings = primary.Timings.Slowest(secondary.Timings)
+	log.Printf("Consensus review for PR #%d: %d primary and %d secondary comments merged into %d",
+		prNumber, len(primary.Comments), len(secondary.Comments), len(merged.Comments))
+	return merged
+}
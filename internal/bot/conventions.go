@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// conventionsLearnEvery is how many new feedback signals make a repository's conventions be
+// learned again
+const conventionsLearnEvery = 20
+
+// maintainerAssociations are the author associations of comments written by maintainers
+var maintainerAssociations = map[string]bool{"OWNER": true, "MEMBER": true, "COLLABORATOR": true}
+
+// isMaintainerComment reports whether a review comment was written by a maintainer rather than
+// an outside contributor or an app
+func isMaintainerComment(comment *review.PullRequestComment) bool {
+	return maintainerAssociations[comment.AuthorAssociation] && comment.User.Type != "Bot"
+}
+
+// applyLearnedConventions returns a copy of the repository configuration with the conventions
+// its reviews learned, if the learned_conventions feature is on
+func (bot *CycloneBot) applyLearnedConventions(owner, repoName string, repoConfig *config.RepositoryConfig) *config.RepositoryConfig {
+	if !bot.featureEnabled(owner, repoName, config.FeatureLearnedConventions) {
+		return repoConfig
+	}
+	conventions := bot.store.GetConventions(owner, repoName)
+	if conventions == nil || len(conventions.Promoted)+len(conventions.Demoted) == 0 {
+		return repoConfig
+	}
+
+	merged := *repoConfig
+	merged.PromotedConventions = conventions.Promoted
+	merged.DemotedConventions = conventions.Demoted
+	return &merged
+}
+
+// recordConventionSignals adds feedback to a repository's conventions memory, if the
+// learned_conventions feature is on, and learns from it each time another
+// conventionsLearnEvery signals are pending, so a failure isn't retried with every signal
+func (bot *CycloneBot) recordConventionSignals(owner, repoName string, signals ...store.ConventionSignal) {
+	if len(signals) == 0 || !bot.featureEnabled(owner, repoName, config.FeatureLearnedConventions) {
+		return
+	}
+
+	pending, err := bot.store.AddConventionSignals(owner, repoName, signals...)
+	if err != nil {
+		log.Printf("Error recording feedback signals of %s/%s: %v", owner, repoName, err)
+		return
+	}
+	if pending/conventionsLearnEvery > (pending-len(signals))/conventionsLearnEvery {
+		bot.learnConventions(owner, repoName)
+	}
+}
+
+// recordMaintainerComment adds a maintainer's own review comment to the conventions memory
+func (bot *CycloneBot) recordMaintainerComment(repo *review.Repository, pr *review.PullRequest, comment *review.PullRequestComment) {
+	bot.recordConventionSignals(repo.Owner.Login, repo.Name, store.ConventionSignal{
+		Kind:     store.SignalMaintainer,
+		PRNumber: pr.Number,
+		Path:     comment.Path,
+		Comment:  comment.Body,
+	})
+}
+
+// learnConventions updates a repository's conventions memory from its recent feedback signals.
+// Nothing is learned while the repository's quota is exhausted.
+func (bot *CycloneBot) learnConventions(owner, repoName string) {
+	conventions := bot.store.GetConventions(owner, repoName)
+	if conventions == nil {
+		return
+	}
+	if quota := bot.checkQuota(owner, repoName, bot.repositoryConfig(owner, repoName)); quota.Exceeded {
+		log.Printf("Not learning conventions of %s/%s: monthly quota of the %s is exhausted", owner, repoName, quota.Scope)
+		return
+	}
+
+	feedback := make([]review.ConventionFeedback, 0, len(conventions.Signals))
+	for _, signal := range conventions.Signals {
+		feedback = append(feedback, review.ConventionFeedback{
+			Kind:     signal.Kind,
+			Path:     signal.Path,
+			Category: signal.Category,
+			Comment:  signal.Comment,
+			Reply:    signal.Reply,
+		})
+	}
+	current := review.LearnedConventions{Promoted: conventions.Promoted, Demoted: conventions.Demoted}
+
+	learned, usage, err := bot.aiClientFor(owner).LearnConventions(current, feedback)
+	bot.recordUsage(owner, repoName, 0, store.UsageKindConventions, usage)
+	if err != nil {
+		log.Printf("Error learning conventions of %s/%s: %v", owner, repoName, err)
+		return
+	}
+	if err := bot.store.SetLearnedConventions(owner, repoName, learned.Promoted, learned.Demoted, conventions.Pending); err != nil {
+		log.Printf("Error saving learned conventions of %s/%s: %v", owner, repoName, err)
+		return
+	}
+	log.Printf("Learned conventions of %s/%s from %d feedback signals: %d promoted, %d demoted",
+		owner, repoName, len(feedback), len(learned.Promoted), len(learned.Demoted))
+}
+
+// ConventionsResponse is the JSON body returned by GET /api/conventions
+type ConventionsResponse struct {
+	Org       string     `json:"org"`
+	Repo      string     `json:"repo"`
+	Enabled   bool       `json:"enabled"` // Whether the learned_conventions feature is on for the repository
+	Promoted  []string   `json:"promoted"`
+	Demoted   []string   `json:"demoted"`
+	Signals   int        `json:"signals"` // Feedback signals kept to learn from
+	Pending   int        `json:"pending"` // Signals recorded since the conventions were last learned
+	LearnedAt *time.Time `json:"learned_at,omitempty"`
+}
+
+// handleConventionsAPI serves GET /api/conventions?org=...&repo=..., what a repository's reviews
+// learned from the team's feedback, and DELETE, which forgets it to start over
+func (bot *CycloneBot) handleConventionsAPI(w http.ResponseWriter, r *http.Request) {
+	org, repoName := r.URL.Query().Get("org"), r.URL.Query().Get("repo")
+	if org == "" || repoName == "" {
+		http.Error(w, "org and repo are required", http.StatusBadRequest)
+		return
+	}
+	if !tenantAllowed(r, org) {
+		http.Error(w, errOtherTenant.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := ConventionsResponse{
+			Org:      org,
+			Repo:     repoName,
+			Enabled:  bot.featureEnabled(org, repoName, config.FeatureLearnedConventions),
+			Promoted: []string{},
+			Demoted:  []string{},
+		}
+		if conventions := bot.store.GetConventions(org, repoName); conventions != nil {
+			resp.Promoted = append(resp.Promoted, conventions.Promoted...)
+			resp.Demoted = append(resp.Demoted, conventions.Demoted...)
+			resp.Signals = len(conventions.Signals)
+			resp.Pending = conventions.Pending
+			if !conventions.LearnedAt.IsZero() {
+				resp.LearnedAt = &conventions.LearnedAt
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case http.MethodDelete:
+		deleted, err := bot.store.DeleteConventions(org, repoName)
+		if err != nil {
+			log.Printf("Error deleting conventions memory of %s/%s: %v", org, repoName, err)
+			http.Error(w, "Failed to delete conventions memory", http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, fmt.Sprintf("no conventions memory for %s/%s", org, repoName), http.StatusNotFound)
+			return
+		}
+		log.Printf("Conventions API: forgot the conventions memory of %s/%s", org, repoName)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
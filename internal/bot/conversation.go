@@ -0,0 +1,274 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// cycloneReplyPrefix marks replies posted by Cyclone so they never trigger follow-ups themselves
+const cycloneReplyPrefix = "🌪️ **Cyclone**: "
+
+// followUpCommand is the PR comment prefix for asking Cyclone a question about its review
+const followUpCommand = "/cyclone"
+
+// followUpInstructions are appended to the review system prompt when answering follow-ups
+const followUpInstructions = `
+
+You already posted a review of this PR, which the first message recalls. You are now answering follow-up questions about it from the PR participants.
+Reply conversationally in concise markdown. Do NOT use the SUMMARY, POEM or PR_COMMENT formats.
+If the participant makes a good point, acknowledge it and correct your earlier feedback.`
+
+// saveReviewConversation stores the context of a posted review so thread replies and follow-ups
+// can reuse it: its summary and line comments with the diff hunks they are on, not the whole diff
+func (bot *CycloneBot) saveReviewConversation(owner, repoName string, prNumber int, reviewID int64, result review.ReviewResult) {
+	comments := make([]store.ReviewedComment, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		comments = append(comments, store.ReviewedComment{
+			Path: comment.Path,
+			Line: comment.Line,
+			Body: comment.Body,
+			Hunk: review.DiffHunk(result.Conversation.Diff, comment.Path, comment.Line, comment.Side),
+		})
+	}
+
+	err := bot.store.SaveConversation(store.Conversation{
+		Key:      store.ReviewKey(owner, repoName, prNumber),
+		ReviewID: reviewID,
+		System:   result.Conversation.System,
+		Summary:  result.Summary,
+		Comments: comments,
+	})
+	if err != nil {
+		log.Printf("Error saving review conversation for PR #%d: %v", prNumber, err)
+	}
+}
+
+// HandleThreadReply answers a reply in a thread started by one of Cyclone's review comments.
+// Replies in other threads return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) HandleThreadReply(repo *review.Repository, pr *review.PullRequest, comment *review.PullRequestComment) error {
+	ctx := context.Background()
+
+	owner := repo.Owner.Login
+	repoName := repo.Name
+	prNumber := pr.Number
+	rootID := comment.InReplyTo
+
+	reviewKey := store.ReviewKey(owner, repoName, prNumber)
+	reviewConv := bot.store.GetConversation(reviewKey)
+	if reviewConv == nil {
+		log.Printf("No review conversation for PR #%d in %s/%s - ignoring thread reply", prNumber, owner, repoName)
+		return fmt.Errorf("%w: no review conversation for PR #%d", ErrWebhookIgnored, prNumber)
+	}
+
+	root, err := bot.githubClientFor(owner).GetReviewComment(ctx, owner, repoName, rootID)
+	if err != nil {
+		log.Printf("Error fetching thread root comment: %v", err)
+		return fmt.Errorf("failed to fetch thread root comment: %w", err)
+	}
+
+	// Only answer threads started by the review Cyclone posted
+	if root.PullRequestReviewID != reviewConv.ReviewID {
+		return fmt.Errorf("%w: thread wasn't started by Cyclone's review", ErrWebhookIgnored)
+	}
+
+	// Maintainers replying to a comment often correct it, which the conventions memory learns from
+	if isMaintainerComment(comment) {
+		bot.recordConventionSignals(owner, repoName, store.ConventionSignal{
+			Kind:     store.SignalPushback,
+			PRNumber: prNumber,
+			Path:     root.Path,
+			Comment:  root.Body,
+			Reply:    comment.Body,
+		})
+	}
+
+	threadKey := store.ThreadKey(reviewKey, rootID)
+	question := fmt.Sprintf("@%s replied:\n\n%s", comment.User.Login, comment.Body)
+	if bot.store.GetConversation(threadKey) == nil {
+		// First reply in this thread - tell the model which of its comments is being discussed
+		question = fmt.Sprintf("This is about your comment on %s line %d:\n\n%s\n\n%s",
+			root.Path, root.Line, quote(root.Body), question)
+	}
+
+	answer, err := bot.continueConversation(owner, repoName, prNumber, reviewConv, threadKey, question, root)
+	if err != nil {
+		log.Printf("Error answering thread reply on PR #%d: %v", prNumber, err)
+		bot.reportError(errorKindFollowUpFailed, err, owner, repoName, prNumber)
+		return err
+	}
+
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not posting thread reply on PR #%d:\n%s", prNumber, answer)
+		return nil
+	}
+	if err := bot.githubClientFor(owner).ReplyToReviewComment(ctx, owner, repoName, prNumber, rootID, cycloneReplyPrefix+answer); err != nil {
+		log.Printf("Error posting thread reply: %v", err)
+		bot.reportError(errorKindFollowUpFailed, err, owner, repoName, prNumber)
+		return fmt.Errorf("failed to post thread reply: %w", err)
+	}
+
+	log.Printf("Replied in review thread %d on PR #%d", rootID, prNumber)
+	return nil
+}
+
+// HandleFollowUpCommand answers a "/cyclone <question>" comment on a reviewed PR. Commands
+// without a question or review return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) HandleFollowUpCommand(repo *review.Repository, issue *review.Issue, comment *review.IssueComment) error {
+	ctx := context.Background()
+
+	owner := repo.Owner.Login
+	repoName := repo.Name
+	prNumber := issue.Number
+
+	question := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment.Body), followUpCommand))
+	if question == "" {
+		return fmt.Errorf("%w: no question asked", ErrWebhookIgnored)
+	}
+
+	reviewKey := store.ReviewKey(owner, repoName, prNumber)
+	reviewConv := bot.store.GetConversation(reviewKey)
+	if reviewConv == nil {
+		log.Printf("No review conversation for PR #%d in %s/%s - ignoring follow-up", prNumber, owner, repoName)
+		return fmt.Errorf("%w: no review conversation for PR #%d", ErrWebhookIgnored, prNumber)
+	}
+
+	prompt := fmt.Sprintf("@%s asks:\n\n%s", comment.User.Login, question)
+	answer, err := bot.continueConversation(owner, repoName, prNumber, reviewConv, store.ThreadKey(reviewKey, 0), prompt, nil)
+	if err != nil {
+		log.Printf("Error answering follow-up on PR #%d: %v", prNumber, err)
+		bot.reportError(errorKindFollowUpFailed, err, owner, repoName, prNumber)
+		return err
+	}
+
+	body := fmt.Sprintf("%s\n%s\n\n%s", cycloneReplyPrefix, quote(question), answer)
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not posting follow-up answer on PR #%d:\n%s", prNumber, body)
+		return nil
+	}
+	if err := bot.githubClientFor(owner).PostComment(ctx, owner, repoName, prNumber, body); err != nil {
+		log.Printf("Error posting follow-up answer: %v", err)
+		bot.reportError(errorKindFollowUpFailed, err, owner, repoName, prNumber)
+		return fmt.Errorf("failed to post follow-up answer: %w", err)
+	}
+
+	log.Printf("Answered follow-up on PR #%d", prNumber)
+	return nil
+}
+
+// continueConversation recalls the review, replays the thread history, asks the new question and
+// persists the exchange. root is the review comment a thread is on, nil for PR follow-ups.
+func (bot *CycloneBot) continueConversation(owner, repoName string, prNumber int, reviewConv *store.Conversation, threadKey, question string, root *review.PullRequestComment) (string, error) {
+	if quota := bot.checkQuota(owner, repoName, bot.repositoryConfig(owner, repoName)); quota.Exceeded {
+		return "", fmt.Errorf("monthly quota of the %s is exhausted", quota.Scope)
+	}
+
+	var messages []review.ClaudeMessage
+	if thread := bot.store.GetConversation(threadKey); thread != nil {
+		messages = toClaudeMessages(thread.Messages)
+	}
+	messages = append(messages, review.ClaudeMessage{Role: "user", Content: question})
+	messages[0].Content = reviewContext(reviewConv, root) + "\n\n" + messages[0].Content
+
+	answer, usage, err := bot.aiClientFor(owner).Converse(reviewConv.System+followUpInstructions, messages)
+	bot.recordUsage(owner, repoName, prNumber, store.UsageKindFollowUp, usage)
+	if err != nil {
+		return "", err
+	}
+
+	err = bot.store.AppendMessages(threadKey,
+		store.Message{Role: "user", Content: question},
+		store.Message{Role: "assistant", Content: answer},
+	)
+	if err != nil {
+		log.Printf("Error saving thread conversation: %v", err)
+	}
+
+	return answer, nil
+}
+
+// reviewContext recalls a review for a follow-up: its summary, its line comments and, for a
+// thread on a line comment, the diff hunk of that comment
+func reviewContext(conv *store.Conversation, root *review.PullRequestComment) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Your review of this PR:\n\n%s", conv.Summary)
+	if len(conv.Comments) > 0 {
+		text.WriteString("\n\nYour line comments:")
+		for _, comment := range conv.Comments {
+			fmt.Fprintf(&text, "\n\n%s line %d:\n%s", comment.Path, comment.Line, quote(comment.Body))
+		}
+	}
+	if root == nil {
+		return text.String()
+	}
+
+	// The line of an outdated thread no longer matches, the file still does
+	var hunk string
+	for _, comment := range conv.Comments {
+		if comment.Path == root.Path && comment.Hunk != "" && (hunk == "" || comment.Line == root.Line) {
+			hunk = comment.Hunk
+		}
+	}
+	if hunk != "" {
+		fmt.Fprintf(&text, "\n\nThe diff of %s the thread is about:\n```diff\n%s\n```", root.Path, hunk)
+	}
+	return text.String()
+}
+
+// toClaudeMessages converts persisted messages back into Claude messages
+func toClaudeMessages(messages []store.Message) []review.ClaudeMessage {
+	converted := make([]review.ClaudeMessage, 0, len(messages))
+	for _, m := range messages {
+		converted = append(converted, review.ClaudeMessage{Role: m.Role, Content: m.Content})
+	}
+	return converted
+}
+
+// isCommand reports whether a comment is the given command, e.g. "/cyclone defer", with or
+// without arguments
+func isCommand(body, command string) bool {
+	body = strings.TrimSpace(body)
+	return body == command || strings.HasPrefix(body, command+" ") || strings.HasPrefix(body, command+"\n")
+}
+
+// commandArgs returns what follows a command in a comment
+func commandArgs(body, command string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(body), command))
+}
+
+// cycloneThreadRoot returns the review comment starting a thread, checking that it belongs to
+// the review Cyclone posted on the PR. Other threads return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) cycloneThreadRoot(ctx context.Context, owner, repoName string, prNumber int, rootID int64) (*review.PullRequestComment, error) {
+	reviewConv := bot.store.GetConversation(store.ReviewKey(owner, repoName, prNumber))
+	if reviewConv == nil {
+		return nil, fmt.Errorf("%w: no review conversation for PR #%d", ErrWebhookIgnored, prNumber)
+	}
+	root, err := bot.githubClientFor(owner).GetReviewComment(ctx, owner, repoName, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch thread root comment: %w", err)
+	}
+	if root.PullRequestReviewID != reviewConv.ReviewID {
+		return nil, fmt.Errorf("%w: thread wasn't started by Cyclone's review", ErrWebhookIgnored)
+	}
+	return root, nil
+}
+
+// replyInThread posts a Cyclone reply in a review thread, logging failures
+func (bot *CycloneBot) replyInThread(ctx context.Context, owner, repoName string, prNumber int, rootID int64, text string) {
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not posting thread reply on PR #%d:\n%s", prNumber, text)
+		return
+	}
+	if err := bot.githubClientFor(owner).ReplyToReviewComment(ctx, owner, repoName, prNumber, rootID, cycloneReplyPrefix+text); err != nil {
+		log.Printf("Error posting thread reply: %v", err)
+	}
+}
+
+// quote formats text as a markdown blockquote
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n> ")
+}
@@ -2,26 +2,51 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-
-	"github.com/google/go-github/v57/github"
+	"strings"
+	"sync"
+	"time"
 
 	"cyclone/internal/config"
+	"cyclone/internal/jira"
+	"cyclone/internal/linear"
+	"cyclone/internal/logging"
+	"cyclone/internal/notices"
 	"cyclone/internal/review"
+	"cyclone/internal/sentry"
+	"cyclone/internal/store"
 )
 
 // CycloneBot handles GitHub operations and AI integration
 type CycloneBot struct {
-	githubClient *review.GitHubClient
-	aiClient     *review.AIClient
-	config       *config.Config
-	reviewConfig *config.ReviewConfig
+	githubClient       *review.GitHubClient
+	aiClient           *review.AIClient
+	orgClients         map[string]*review.AIClient          // AI clients for organizations with their own API key
+	orgGitHubClients   map[string]*review.GitHubClient      // GitHub clients for organizations with their own credentials
+	gitlabClients      map[string]*review.GitLabClient      // GitLab clients of organizations on GitLab
+	azureDevOpsClients map[string]*review.AzureDevOpsClient // Azure DevOps clients of organizations on Azure DevOps
+	gerritClients      map[string]*review.GerritClient      // Gerrit clients of organizations on Gerrit
+	clientsMu          sync.Mutex
+	config             *config.Config
+	fileConfig         *config.ReviewConfig // As loaded from the config file or remote source
+	reviewConfig       *config.ReviewConfig // fileConfig with admin API changes applied, read through currentReviewConfig
+	configMu           sync.RWMutex
+	batches            *batchQueue
+	store              *store.Store
+	queueWebhooks      bool           // Queue webhook work for "cyclone worker" instead of running it, see QueueWebhooks
+	oauth              *oauthLogin    // GitHub sign-in for the dashboard and APIs, nil if not configured
+	errorTracker       *sentry.Client // Error and panic reporting, nil if not configured
+	alerts             *failureAlerts // Alerts about repeatedly failing reviews, nil if not configured
+	slack              *slackNotifier // Review and skip notifications, nil if not configured
+	jira               *jira.Client   // Tickets for deferred findings, nil if not configured
+	linear             *linear.Client // Issues for tracked findings, nil if not configured
 }
 
 // New creates a new Cyclone bot instance
-func New(cfg *config.Config, reviewCfg *config.ReviewConfig) (*CycloneBot, error) {
+func New(cfg *config.Config, reviewCfg *config.ReviewConfig, st *store.Store) (*CycloneBot, error) {
 	// Initialize GitHub client
 	githubClient, err := review.NewGitHubClient(cfg.GitHubToken)
 	if err != nil {
@@ -29,181 +54,597 @@ func New(cfg *config.Config, reviewCfg *config.ReviewConfig) (*CycloneBot, error
 	}
 
 	// Initialize AI client
-	aiClient := review.NewAIClient(cfg.AnthropicToken, "claude-sonnet-4-20250514")
+	aiClient := review.NewAIClient(cfg.AnthropicToken, cfg.Model, cfg.PromptsDir)
+
+	var oauth *oauthLogin
+	if cfg.OAuth != nil {
+		if oauth, err = newOAuthLogin(cfg.OAuth); err != nil {
+			return nil, err
+		}
+	}
+
+	var errorTracker *sentry.Client
+	if cfg.SentryDSN != "" {
+		if errorTracker, err = sentry.New(cfg.SentryDSN, cfg.Env, cfg.SentryRelease); err != nil {
+			return nil, err
+		}
+	}
+
+	var jiraClient *jira.Client
+	if cfg.Jira != nil {
+		if jiraClient, err = jira.New(cfg.Jira.BaseURL, cfg.Jira.Email, cfg.Jira.APIToken); err != nil {
+			return nil, err
+		}
+	}
+
+	var linearClient *linear.Client
+	if cfg.LinearAPIKey != "" {
+		linearClient = linear.New(cfg.LinearAPIKey)
+	}
 
 	return &CycloneBot{
-		githubClient: githubClient,
-		aiClient:     aiClient,
-		config:       cfg,
-		reviewConfig: reviewCfg,
+		githubClient:       githubClient,
+		aiClient:           aiClient,
+		orgClients:         make(map[string]*review.AIClient),
+		orgGitHubClients:   make(map[string]*review.GitHubClient),
+		gitlabClients:      make(map[string]*review.GitLabClient),
+		azureDevOpsClients: make(map[string]*review.AzureDevOpsClient),
+		gerritClients:      make(map[string]*review.GerritClient),
+		config:             cfg,
+		fileConfig:         reviewCfg,
+		reviewConfig:       reviewCfg.WithManagedOrganizations(st.ManagedOrganizations()),
+		batches:            newBatchQueue(),
+		store:              st,
+		oauth:              oauth,
+		errorTracker:       errorTracker,
+		alerts:             newFailureAlerts(cfg.AlertWebhookURL, cfg.AlertAfterFailures),
+		slack:              newSlackNotifier(cfg.SlackBotToken),
+		jira:               jiraClient,
+		linear:             linearClient,
 	}, nil
 }
 
-// SetupRoutes configures HTTP routes for the bot
-func (bot *CycloneBot) SetupRoutes() {
-	http.HandleFunc("/webhook", bot.handleWebhook)
-	http.HandleFunc("/health", bot.healthCheck)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "Cyclone AI Code Review Bot\nEndpoints:\n- POST /webhook (GitHub webhooks)\n- GET /health (health check)")
+// SetupRoutes configures HTTP routes for the bot on the default mux. With ADMIN_ADDR set, the
+// admin API, metrics and runtime profiles are left out of them and served by the returned
+// handler on that address instead, along with the dashboard and read APIs; it returns nil
+// otherwise.
+func (bot *CycloneBot) SetupRoutes() http.Handler {
+	if bot.config.AdminToken == "" && bot.oauth == nil {
+		log.Printf("Neither ADMIN_TOKEN nor GitHub sign-in is configured - the dashboard's data, the admin API, /stats and /metrics are disabled; only organization API tokens can read their data")
+	}
+	if bot.config.AdminAddr == "" {
+		bot.registerRoutes(http.DefaultServeMux, true, true)
+		return nil
+	}
+
+	bot.registerRoutes(http.DefaultServeMux, true, false)
+	admin := http.NewServeMux()
+	bot.registerRoutes(admin, false, true)
+	return admin
+}
+
+// registerRoutes registers the webhook receivers if webhooks is set, the admin API, metrics
+// and runtime profiles if admin is set, and the health checks, dashboard and read APIs always
+func (bot *CycloneBot) registerRoutes(mux *http.ServeMux, webhooks, admin bool) {
+	if webhooks {
+		mux.HandleFunc("/webhook", bot.handleWebhook)
+		mux.HandleFunc("/webhook/gitlab", bot.handleGitLabWebhook)
+		mux.HandleFunc("/webhook/azure-devops", bot.handleAzureDevOpsWebhook)
+		mux.HandleFunc("/webhook/gerrit", bot.handleGerritWebhook)
+	}
+	mux.HandleFunc("/health", bot.healthCheck)
+	mux.HandleFunc("/ready", bot.handleReady)
+	mux.HandleFunc("/stats", bot.requireAdminToken(bot.handleStats))
+	mux.HandleFunc("/dashboard", bot.handleDashboard)
+	if bot.oauth != nil {
+		mux.HandleFunc("/auth/login", bot.handleLogin)
+		mux.HandleFunc("/auth/callback", bot.handleAuthCallback)
+		mux.HandleFunc("/auth/logout", bot.handleLogout)
+	}
+	mux.HandleFunc("/api/usage", bot.requireTenantToken(bot.handleUsageAPI))
+	mux.HandleFunc("/api/billing", bot.requireTenantToken(bot.handleBillingAPI))
+	mux.HandleFunc("/api/reviews", bot.requireTenantToken(bot.handleReviewsAPI))
+	mux.HandleFunc("/api/reviews/", bot.requireTenantToken(bot.handleReviewAPI))
+	mux.HandleFunc("/api/export/reviews", bot.requireTenantToken(bot.handleReviewExport))
+	mux.HandleFunc("/api/export/usage", bot.requireTenantToken(bot.handleUsageExport))
+	mux.HandleFunc("/api/acceptance", bot.requireTenantToken(bot.handleAcceptanceAPI))
+	mux.HandleFunc("/api/conventions", bot.requireTenantToken(bot.handleConventionsAPI))
+	if admin {
+		mux.HandleFunc("/metrics", bot.requireAdminToken(bot.handleMetrics))
+		mux.HandleFunc("/api/admin/config", bot.requireToken(bot.handleAdminConfig))
+		mux.HandleFunc("/api/admin/orgs/", bot.requireToken(bot.handleAdminOrganizations))
+		mux.HandleFunc("/api/admin/webhooks", bot.requireToken(bot.handleDeliveriesAPI))
+		mux.HandleFunc("/api/admin/webhooks/", bot.requireToken(bot.handleAdminWebhooks))
+		mux.HandleFunc("/api/admin/audit", bot.requireToken(bot.handleAuditAPI))
+		if bot.config.Debug {
+			mux.HandleFunc("/debug/pprof/", bot.requireAdminToken(bot.handleProfile))
+		}
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Cyclone AI Code Review Bot\nEndpoints:\n- POST /webhook (GitHub webhooks)\n- POST /webhook/gitlab (GitLab webhooks)\n- POST /webhook/azure-devops (Azure DevOps service hooks)\n- POST /webhook/gerrit (Gerrit events)\n- GET /health (health check)\n- GET /ready (readiness check)\n- GET /stats (review counts, latency and error rates)\n- GET /metrics (token usage for Prometheus)\n- GET /dashboard (web dashboard)\n- GET /api/usage (usage and cost breakdown)\n- GET /api/reviews (review history)\n- GET /api/export/reviews (review history as CSV or JSON lines)\n- GET /api/acceptance (rates of review comments acted upon)\n- /api/admin/... (review configuration management)")
 	})
 }
 
-// ProcessPullRequest handles the main logic for reviewing a PR
-func (bot *CycloneBot) ProcessPullRequest(repo *github.Repository, pr *github.PullRequest) {
-	ctx := context.Background()
+// ProcessPullRequest handles the main logic for reviewing a PR. The returned error wraps
+// ErrReviewSkipped if the PR was deliberately not reviewed.
+func (bot *CycloneBot) ProcessPullRequest(repo *review.Repository, pr *review.PullRequest) error {
+	_, err := bot.reviewPullRequest(context.Background(), repo, pr, reviewOptions{post: true, batch: true})
+	if err != nil {
+		log.Printf("PR #%d not reviewed: %v", pr.Number, err)
+		if !errors.Is(err, ErrReviewSkipped) {
+			bot.recordSkip(repo.Owner.Login, repo.Name, pr.Number, store.SkipReasonReviewFailed)
+			bot.reportError(errorKindReviewFailed, err, repo.Owner.Login, repo.Name, pr.Number)
+		}
+	}
+	return err
+}
+
+// ErrReviewSkipped is wrapped by errors of PRs that were deliberately not reviewed,
+// e.g. because they are too large, as opposed to reviews that failed
+var ErrReviewSkipped = errors.New("review skipped")
+
+// reviewOptions controls how reviewPullRequest delivers a review
+type reviewOptions struct {
+	post  bool // Post the review or skip notice to the PR, and record it
+	batch bool // Allow queueing for the Message Batches API, whose results are posted later
 
-	owner := repo.GetOwner().GetLogin()
-	repoName := repo.GetName()
-	prNumber := pr.GetNumber()
+	preview bool // Only prepare the review, without calling the AI, e.g. to estimate its cost
+
+	forceLabeled bool // The PR was just labeled for a forced review, which only matters if it's too large
+}
+
+// preparedReview is a PR ready to be sent to the AI, see preparePullRequestReview
+type preparedReview struct {
+	owner         string
+	repoName      string
+	pr            *review.PullRequest
+	provider      review.SCMProvider
+	repoConfig    *config.RepositoryConfig // Effective configuration, downgraded if the quota is exceeded
+	aiClient      *review.AIClient
+	diff          string
+	diffFetch     time.Duration // How long fetching the diff took
+	sizeCheck     review.PRSizeCheck
+	quota         quotaCheck
+	summaryOnly   bool // Line comments are left out by design, see review.ReviewResult
+	promptVariant string
+	dryRun        bool
+}
+
+// preparePullRequestReview resolves everything a review of a PR needs: configuration, size
+// and quota checks, prompt experiment and diff. PRs that are not reviewed return an error
+// wrapping ErrReviewSkipped; with opts.post, their skip notice is posted. PRs of code hosts
+// that don't report their size are sized by their files.
+func (bot *CycloneBot) preparePullRequestReview(ctx context.Context, repo *review.Repository, pr *review.PullRequest, opts reviewOptions) (*preparedReview, error) {
+	owner := repo.Owner.Login
+	repoName := repo.Name
+	prNumber := pr.Number
 
 	log.Printf("Processing PR #%d in %s/%s", prNumber, owner, repoName)
 
-	// Get repository-specific configuration
-	repoConfig := bot.reviewConfig.GetRepositoryConfig(owner, repoName)
-	if repoConfig == nil {
-		log.Printf("No dedicated review configuration found for repository %s/%s - using default settings", owner, repoName)
-		repoConfig = &config.RepositoryConfig{
-			Name:         repoName,
-			Precision:    config.PrecisionMedium,
-			CustomPrompt: "",
+	if bot.currentReviewConfig().IsExcluded(owner, repoName) {
+		return nil, fmt.Errorf("%w: repository %s/%s is excluded from reviews", ErrReviewSkipped, owner, repoName)
+	}
+
+	provider, err := bot.scmProviderFor(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get repository-specific configuration, including the repository's own config file
+	repoConfig := bot.applyRepoConfigFile(ctx, owner, repoName, bot.repositoryConfig(owner, repoName))
+	repoConfig = bot.currentReviewConfig().WithPrecisionProfile(repoConfig)
+	repoConfig = bot.applyLearnedConventions(owner, repoName, repoConfig)
+	dryRun := bot.currentReviewConfig().IsDryRun(repoConfig)
+
+	if opts.post && repoConfig.ConflictNotice && onGitHub(provider) {
+		bot.postConflictNotice(ctx, owner, repoName, pr, repoConfig.Language, dryRun)
+	}
+
+	// The files of PRs without a size are listed to count it, and make up the diff later
+	var files []review.ChangedFile
+	var diffFetch time.Duration
+	if pr.ChangedFiles == 0 {
+		fetchStarted := time.Now()
+		if files, err = provider.ListFiles(ctx, owner, repoName, prNumber); err != nil {
+			return nil, fmt.Errorf("failed to list PR files: %w", err)
+		}
+		diffFetch = time.Since(fetchStarted)
+		pr.ChangedFiles = len(files)
+		for _, file := range files {
+			pr.Additions += file.Additions
+			pr.Deletions += file.Deletions
 		}
 	}
 
 	// Check PR size before proceeding
-	sizeCheck := bot.checkPRSize(pr)
+	summaryOnly := false
+	sizeCheck := bot.checkPRSize(pr, repoConfig.Language)
+	if opts.forceLabeled && sizeCheck.ShouldReview {
+		return nil, fmt.Errorf("%w: PR #%d isn't too large - it was reviewed when opened", ErrWebhookIgnored, prNumber)
+	}
+	if !sizeCheck.ShouldReview && pr.HasLabel(config.FORCE_REVIEW_LABEL) {
+		log.Printf("PR #%d is too large but labeled %s - summary-only review", prNumber, config.FORCE_REVIEW_LABEL)
+		repoConfig = forcedReviewConfig(repoConfig)
+		summaryOnly = true
+		sizeCheck = review.PRSizeCheck{ShouldReview: true, WarningMessage: forcedReviewWarning(repoConfig.Language)}
+	}
 	if !sizeCheck.ShouldReview {
-		log.Printf("PR #%d is too large - posting skip message instead of review", prNumber)
+		if opts.post {
+			log.Printf("PR #%d is too large - posting skip message instead of review", prNumber)
+			bot.recordSkip(owner, repoName, prNumber, sizeCheck.SkipReason)
+
+			// Post skip message as a regular comment
+			if dryRun {
+				log.Printf("Dry run - not posting skip message for PR #%d", prNumber)
+			} else if err := provider.PostComment(ctx, owner, repoName, prNumber, sizeCheck.SkipMessage+forceReviewHint(repoConfig.Language)); err != nil {
+				log.Printf("Error posting skip message: %v", err)
+			}
+		}
+		return nil, fmt.Errorf("%w: PR is too large (%s)", ErrReviewSkipped, sizeCheck.SkipReason)
+	}
 
-		// Post skip message as a regular comment
-		if err := bot.githubClient.PostComment(ctx, owner, repoName, prNumber, sizeCheck.SkipMessage); err != nil {
-			log.Printf("Error posting skip message: %v", err)
+	// Enforce monthly usage quotas
+	aiClient := bot.aiClientFor(owner)
+	quota := bot.checkQuota(owner, repoName, repoConfig)
+	if quota.Exceeded {
+		if quota.Action == config.QuotaActionSkip {
+			if opts.post {
+				log.Printf("Quota exceeded for %s of %s/%s - skipping PR #%d", quota.Scope, owner, repoName, prNumber)
+				bot.recordSkip(owner, repoName, prNumber, store.SkipReasonQuotaExceeded)
+				if dryRun {
+					log.Printf("Dry run - not posting quota message for PR #%d", prNumber)
+				} else if err := provider.PostComment(ctx, owner, repoName, prNumber, quotaSkipMessage(quota, repoConfig.Language)); err != nil {
+					log.Printf("Error posting quota message: %v", err)
+				}
+			}
+			return nil, fmt.Errorf("%w: quota exceeded for %s", ErrReviewSkipped, quota.Scope)
 		}
-		return
+
+		log.Printf("Quota exceeded for %s of %s/%s - summary-only review on %s", quota.Scope, owner, repoName, quota.Quota.GetDowngradeModel())
+		aiClient = aiClient.WithModel(quota.Quota.GetDowngradeModel())
+		repoConfig = downgradeForQuota(repoConfig)
+		summaryOnly = true
+		sizeCheck.WarningMessage = quotaDowngradeWarning(quota, repoConfig.Language) + sizeCheck.WarningMessage
+	}
+
+	// Assign the PR to a prompt experiment arm, if the repository runs one
+	promptVariant := repoConfig.PromptExperiment.AssignPromptVariant(store.ReviewKey(owner, repoName, prNumber))
+	if promptVariant != "" {
+		aiClient = aiClient.WithPromptVariant(promptVariant)
 	}
 
 	log.Printf("Using precision: %s for repository: %s", repoConfig.Precision, repoName)
 
 	// Get the PR diff
-	diff, err := bot.githubClient.GetPRDiff(ctx, owner, repoName, prNumber)
+	var diff string
+	if files != nil {
+		diff = review.FormatDiff(files, fmt.Sprintf("PR #%d", prNumber), repoConfig.IgnorePaths)
+	} else {
+		fetchStarted := time.Now()
+		if diff, err = provider.GetDiff(ctx, owner, repoName, prNumber, repoConfig.IgnorePaths); err != nil {
+			return nil, fmt.Errorf("failed to get PR diff: %w", err)
+		}
+		diffFetch = time.Since(fetchStarted)
+	}
+	if diff == "" && len(repoConfig.IgnorePaths) > 0 {
+		if opts.post {
+			bot.recordSkip(owner, repoName, prNumber, store.SkipReasonAllFilesIgnored)
+		}
+		return nil, fmt.Errorf("%w: all files are ignored", ErrReviewSkipped)
+	}
+
+	// Summary-only reviews have no line comments for the style guide to shape
+	if !summaryOnly {
+		repoConfig = bot.applyStyleGuides(ctx, aiClient, owner, repoName, prNumber, repoConfig, opts.preview)
+	}
+
+	return &preparedReview{
+		owner:         owner,
+		repoName:      repoName,
+		pr:            pr,
+		provider:      provider,
+		repoConfig:    repoConfig,
+		aiClient:      aiClient,
+		diff:          diff,
+		sizeCheck:     sizeCheck,
+		quota:         quota,
+		summaryOnly:   summaryOnly,
+		promptVariant: promptVariant,
+		diffFetch:     diffFetch,
+		dryRun:        dryRun,
+	}, nil
+}
+
+// reviewPullRequest reviews a PR on any code host and returns the review. Without opts.post
+// nothing is written to the code host; token usage is recorded either way.
+func (bot *CycloneBot) reviewPullRequest(ctx context.Context, repo *review.Repository, pr *review.PullRequest, opts reviewOptions) (review.ReviewResult, error) {
+	started := time.Now()
+	prepared, err := bot.preparePullRequestReview(ctx, repo, pr, opts)
 	if err != nil {
-		log.Printf("Error getting PR diff: %v", err)
-		return
+		return review.ReviewResult{}, err
+	}
+	owner, repoName, prNumber := prepared.owner, prepared.repoName, pr.Number
+	repoConfig, aiClient, diff := prepared.repoConfig, prepared.aiClient, prepared.diff
+	sizeCheck, quota, dryRun := prepared.sizeCheck, prepared.quota, prepared.dryRun
+
+	// Import rules are checked without the AI, so batch reviews carry their violations along
+	var ruleComments []review.ReviewComment
+	if !prepared.summaryOnly {
+		ruleComments = checkImportRules(owner, repoName, prNumber, diff, repoConfig)
+	}
+
+	// Reviewers are suggested from CODEOWNERS and history, so batch reviews carry them along too
+	var reviewers *reviewerSuggestions
+	if !opts.preview && onGitHub(prepared.provider) {
+		reviewers = bot.suggestReviewers(ctx, owner, repoName, pr, repoConfig)
+	}
+
+	// Non-urgent repositories are reviewed through the cheaper Message Batches API
+	if repoConfig.BatchMode && !quota.Exceeded && opts.batch {
+		bot.queueBatchReview(batchItem{
+			owner:          owner,
+			repoName:       repoName,
+			prNumber:       prNumber,
+			diff:           diff,
+			warningMessage: sizeCheck.WarningMessage,
+			promptVariant:  prepared.promptVariant,
+			headSHA:        pr.Head.SHA,
+			startedAt:      started,
+			diffFetch:      prepared.diffFetch,
+			dryRun:         dryRun,
+			ruleComments:   ruleComments,
+			reviewers:      reviewers,
+		}, pr.Title, pr.Body, repoConfig)
+		return review.ReviewResult{}, nil
+	}
+
+	// Duplicates of existing code are found by comparing tokens, before and apart from the AI
+	var duplicates []review.ReviewComment
+	if !prepared.summaryOnly && onGitHub(prepared.provider) && bot.featureEnabled(owner, repoName, config.FeatureDuplicateDetection) {
+		duplicates = bot.findDuplicateCode(ctx, owner, repoName, pr, diff, repoConfig.Language)
 	}
 
 	// Get AI review with repository-specific configuration
-	reviewResult := bot.aiClient.GenerateReview(diff, pr.GetTitle(), pr.GetBody(), repoConfig)
+	var reviewResult review.ReviewResult
+	if repoConfig.ConsensusModel != "" && !quota.Exceeded {
+		reviewResult = bot.generateConsensusReview(aiClient, owner, repoName, prNumber, diff, pr.Title, pr.Body, repoConfig)
+	} else {
+		reviewResult = aiClient.GenerateReview(diff, pr.Title, pr.Body, repoConfig)
+		bot.recordUsage(owner, repoName, prNumber, store.UsageKindReview, reviewResult.Usage)
+	}
+	if reviewResult.Err != nil {
+		bot.reportError(errorKindGenerationFailed, reviewResult.Err, owner, repoName, prNumber)
+	}
+	if quota.Exceeded {
+		// Summary-only: drop any line comments the model wrote anyway
+		reviewResult.Comments = nil
+	}
+	if reviewResult.Err == nil && !prepared.summaryOnly {
+		var plan func() string
+		if onGitHub(prepared.provider) {
+			plan = func() string { return bot.terraformPlan(ctx, owner, repoName, prNumber) }
+		}
+		reviewResult.Comments = append(reviewResult.Comments, bot.runChecklists(aiClient, owner, repoName, prNumber, diff, repoConfig, plan)...)
+	}
 
-	// Prepend size warning if applicable
+	// Let a second pass vet the drafted comments before they are posted
+	if repoConfig.SelfCritique && len(reviewResult.Comments) > 0 {
+		critiqueStarted := time.Now()
+		critiqued, usage, err := aiClient.CritiqueComments(diff, reviewResult)
+		critiqueTime := time.Since(critiqueStarted)
+		bot.recordUsage(owner, repoName, prNumber, store.UsageKindCritique, usage)
+		if err != nil {
+			log.Printf("Error running self-critique for PR #%d - posting unvetted comments: %v", prNumber, err)
+		} else {
+			reviewResult = critiqued
+		}
+		reviewResult.Timings.Critique = critiqueTime
+	}
+	if reviewResult.Err == nil {
+		reviewResult.Comments = append(append(reviewResult.Comments, duplicates...), ruleComments...)
+	}
+
+	// Prepend size and quota warnings if applicable
 	if sizeCheck.WarningMessage != "" {
 		reviewResult.Summary = sizeCheck.WarningMessage + reviewResult.Summary
 	}
+	if reviewers != nil && reviewResult.Err == nil {
+		reviewResult.Summary += "\n\n" + bot.reviewerNote(ctx, owner, repoName, prNumber, reviewers, opts.post, dryRun)
+	}
+	reviewResult.HeadSHA = pr.Head.SHA
+	reviewResult.SummaryOnly = prepared.summaryOnly
+	reviewResult.StartedAt = started
+	reviewResult.Timings.DiffFetch = prepared.diffFetch
+
+	if !opts.post {
+		return reviewResult, nil
+	}
 
 	// Post the review with line-specific comments
-	if err := bot.githubClient.PostReview(ctx, owner, repoName, prNumber, reviewResult); err != nil {
-		log.Printf("Error posting PR review: %v", err)
-		return
+	if err := bot.publishReview(ctx, owner, repoName, prNumber, reviewResult, dryRun); err != nil {
+		return reviewResult, err
+	}
+	if !dryRun {
+		log.Printf("Successfully posted AI review for PR #%d", prNumber)
+	}
+	return reviewResult, nil
+}
+
+// aiClientFor returns the AI client billed for an organization's reviews. Isolated
+// organizations never fall back to the global key.
+func (bot *CycloneBot) aiClientFor(owner string) *review.AIClient {
+	orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner)
+	if orgConfig == nil {
+		return bot.aiClient
+	}
+
+	apiKey := orgConfig.GetAnthropicAPIKey()
+	if apiKey == "" && !orgConfig.Isolated {
+		return bot.aiClient
 	}
 
-	log.Printf("Successfully posted AI review for PR #%d", prNumber)
+	bot.clientsMu.Lock()
+	defer bot.clientsMu.Unlock()
+
+	client, ok := bot.orgClients[owner]
+	if !ok {
+		logging.AddSecrets(apiKey)
+		client = bot.aiClient.WithAPIKey(apiKey)
+		bot.orgClients[owner] = client
+	}
+	return client
 }
 
-// checkPRSize evaluates if a PR is too large for review
-func (bot *CycloneBot) checkPRSize(pr *github.PullRequest) review.PRSizeCheck {
-	files := pr.GetChangedFiles()
-	additions := pr.GetAdditions()
-	deletions := pr.GetDeletions()
-	totalChanges := additions + deletions
+// githubClientFor returns the GitHub client authenticated for an organization. Isolated
+// organizations never fall back to the global token.
+func (bot *CycloneBot) githubClientFor(owner string) *review.GitHubClient {
+	orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner)
+	if orgConfig == nil {
+		return bot.githubClient
+	}
 
-	// Hard limits - skip review entirely
-	if files > config.MAX_FILES_FOR_REVIEW {
-		return review.PRSizeCheck{
-			ShouldReview: false,
-			SkipMessage: fmt.Sprintf(`## 🌪️ Cyclone Notice
+	token := orgConfig.GetGitHubToken()
+	if token == "" && orgConfig.GitHubApp == nil && !orgConfig.Isolated {
+		return bot.githubClient
+	}
 
-**PR Too Large for Automated Review**
+	bot.clientsMu.Lock()
+	defer bot.clientsMu.Unlock()
 
-This PR modifies **%d files**, which exceeds our limit of %d files for automated review.
+	if client, ok := bot.orgGitHubClients[owner]; ok {
+		return client
+	}
 
-**Why we skip large PRs:**
-- 🎯 **Review Quality**: Large PRs are harder to review thoroughly
-- 🧠 **Cognitive Load**: Smaller PRs are easier for humans to understand
-- 🐛 **Bug Detection**: Issues are easier to spot in focused changes
-- 🚀 **Faster Iteration**: Smaller PRs get merged faster
+	logging.AddSecrets(token)
+	client, err := newOrgGitHubClient(token, orgConfig.GitHubApp)
+	if err != nil && orgConfig.Isolated {
+		// Unauthenticated, so its requests fail instead of using another tenant's access
+		log.Printf("Error creating GitHub client for isolated organization %s: %v", owner, err)
+		client, _ = review.NewGitHubClient("")
+	} else if err != nil {
+		log.Printf("Error creating GitHub client for organization %s - using the global token: %v", owner, err)
+		return bot.githubClient
+	}
+	bot.orgGitHubClients[owner] = client
+	return client
+}
 
-**Suggestions:**
-- Consider breaking this into smaller, focused PRs
-- Each PR should ideally change < 15 files and < 400 lines
-- Group related changes together (e.g., "Add user authentication", "Update API endpoints")
+// scmProviderFor returns the client of the code host an organization's repositories are on
+func (bot *CycloneBot) scmProviderFor(owner string) (review.SCMProvider, error) {
+	provider := config.ProviderGitHub
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		provider = orgConfig.GetProvider()
+	}
 
-*Happy to review once split into smaller chunks!* 🌪️`, files, config.MAX_FILES_FOR_REVIEW),
-		}
+	switch provider {
+	case config.ProviderGitLab:
+		return bot.gitlabClientFor(owner)
+	case config.ProviderAzureDevOps:
+		return bot.azureDevOpsClientFor(owner)
+	case config.ProviderGerrit:
+		return bot.gerritClientFor(owner)
 	}
+	return bot.githubClientFor(owner), nil
+}
 
-	if additions > config.MAX_ADDITIONS_FOR_REVIEW {
-		return review.PRSizeCheck{
-			ShouldReview: false,
-			SkipMessage: fmt.Sprintf(`## 🌪️ Cyclone Notice
+// onGitHub reports whether a code host is GitHub, whose API alone has what conflict notices,
+// reviewer suggestions, duplicate detection and terraform plans need
+func onGitHub(provider review.SCMProvider) bool {
+	_, ok := provider.(*review.GitHubClient)
+	return ok
+}
 
-**PR Too Large for Automated Review**
+// newOrgGitHubClient creates a GitHub client from an organization's token or App installation
+func newOrgGitHubClient(token string, app *config.GitHubAppConfig) (*review.GitHubClient, error) {
+	if app == nil {
+		return review.NewGitHubClient(token)
+	}
 
-This PR adds **%d lines**, which exceeds our limit of %d lines for automated review.
+	privateKey, err := app.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return review.NewGitHubAppClient(app.AppID, app.InstallationID, privateKey)
+}
 
-**Large PRs are challenging because:**
-- 🔍 **Review Thoroughness**: Hard to catch all issues in large changes
-- ⏱️ **Review Time**: Takes much longer to review properly  
-- 🤔 **Context Switching**: Difficult to keep all changes in mind
-- 🔄 **Merge Conflicts**: Larger PRs are more likely to conflict
+// repositoryConfig returns the review configuration for a repository, falling back to defaults
+func (bot *CycloneBot) repositoryConfig(owner, repoName string) *config.RepositoryConfig {
+	repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName)
+	if repoConfig == nil {
+		log.Printf("No dedicated review configuration found for repository %s/%s - using default settings", owner, repoName)
+		repoConfig = config.DefaultRepositoryConfig(repoName)
+	}
+	return repoConfig
+}
 
-**Best Practices:**
-- Aim for PRs with < 400 lines of additions
-- Split features into logical, reviewable chunks
-- Consider feature flags for large features
+// noticeSeparator separates a notice prepended to a review from the review itself
+const noticeSeparator = "\n\n---\n\n"
+
+// checkPRSize evaluates if a PR is too large for review, with notices in the given language
+func (bot *CycloneBot) checkPRSize(pr *review.PullRequest, language string) review.PRSizeCheck {
+	return checkChangeSize(pr.ChangedFiles, pr.Additions, pr.Deletions, language)
+}
+
+// checkChangeSize evaluates if a change of the given size is too large for review
+func checkChangeSize(files, additions, deletions int, language string) review.PRSizeCheck {
+	totalChanges := additions + deletions
+
+	// Hard limits - skip review entirely
+	if files > config.MAX_FILES_FOR_REVIEW {
+		return review.PRSizeCheck{
+			ShouldReview: false,
+			SkipReason:   store.SkipReasonTooManyFiles,
+			SkipMessage: notices.Render(language, "size_skip_files", notices.Data{
+				"Files": files,
+				"Limit": config.MAX_FILES_FOR_REVIEW,
+			}),
+		}
+	}
 
-*Ready to provide detailed feedback on smaller PRs!* 🌪️`, additions, config.MAX_ADDITIONS_FOR_REVIEW),
+	if additions > config.MAX_ADDITIONS_FOR_REVIEW {
+		return review.PRSizeCheck{
+			ShouldReview: false,
+			SkipReason:   store.SkipReasonTooManyAdditions,
+			SkipMessage: notices.Render(language, "size_skip_additions", notices.Data{
+				"Additions": additions,
+				"Limit":     config.MAX_ADDITIONS_FOR_REVIEW,
+			}),
 		}
 	}
 
 	if totalChanges > config.MAX_TOTAL_CHANGES {
 		return review.PRSizeCheck{
 			ShouldReview: false,
-			SkipMessage: fmt.Sprintf(`## 🌪️ Cyclone Notice
-
-**PR Too Large for Automated Review**
-
-This PR has **%d total changes** (+%d, -%d), exceeding our limit of %d changes.
-
-**Recommendation**: Break this into smaller, focused PRs for better review quality and faster merge times.
-
-*Each PR should tell a focused story about one specific change.* 🌪️`, totalChanges, additions, deletions, config.MAX_TOTAL_CHANGES),
+			SkipReason:   store.SkipReasonTooManyChanges,
+			SkipMessage: notices.Render(language, "size_skip_changes", notices.Data{
+				"Total":     totalChanges,
+				"Additions": additions,
+				"Deletions": deletions,
+				"Limit":     config.MAX_TOTAL_CHANGES,
+			}),
 		}
 	}
 
 	// Warning thresholds - review but warn
 	var warnings []string
 	if files > config.WARN_FILES_THRESHOLD {
-		warnings = append(warnings, fmt.Sprintf("📁 **%d files changed** (consider < %d)", files, config.WARN_FILES_THRESHOLD))
+		warnings = append(warnings, notices.Render(language, "size_warning_files", notices.Data{
+			"Files": files,
+			"Limit": config.WARN_FILES_THRESHOLD,
+		}))
 	}
 	if additions > config.WARN_ADDITIONS_THRESHOLD {
-		warnings = append(warnings, fmt.Sprintf("📈 **%d lines added** (consider < %d)", additions, config.WARN_ADDITIONS_THRESHOLD))
+		warnings = append(warnings, notices.Render(language, "size_warning_additions", notices.Data{
+			"Additions": additions,
+			"Limit":     config.WARN_ADDITIONS_THRESHOLD,
+		}))
 	}
 
 	var warningMessage string
 	if len(warnings) > 0 {
-		warningMessage = fmt.Sprintf(`**⚠️ Large PR Warning:**
-%s
-
-*Smaller PRs are easier to review thoroughly and merge faster.*
-
----`, fmt.Sprintf("%s\n", warnings[0]))
-		if len(warnings) > 1 {
-			warningMessage = fmt.Sprintf(`**⚠️ Large PR Warning:**
-%s
-%s
-
-*Smaller PRs are easier to review thoroughly and merge faster.*
-
----`, warnings[0], warnings[1])
-		}
+		warningMessage = notices.Render(language, "size_warning", notices.Data{
+			"Warnings": strings.Join(warnings, "\n"),
+		}) + noticeSeparator
 	}
 
 	return review.PRSizeCheck{
@@ -216,4 +657,9 @@ This PR has **%d total changes** (+%d, -%d), exceeding our limit of %d changes.
 func (bot *CycloneBot) healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Cyclone AI Code Review Bot is running!")
+	if bot.queueWebhooks {
+		if queued, err := bot.store.QueuedWebhookCount(); err == nil {
+			fmt.Fprintf(w, " %d webhook deliveries are waiting for a worker.", queued)
+		}
+	}
 }
@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// dashboardHTML is the web dashboard. It only holds the page; the data is loaded from the
+// usage, reviews and admin APIs with the API token or the session of a GitHub sign-in, so it
+// is protected like them.
+//
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// dashboardSessionPlaceholder marks where the signed-in user and the sign-out link go
+var dashboardSessionPlaceholder = []byte("<!--session-->")
+
+// handleDashboard serves GET /dashboard, sending visitors to the GitHub sign-in first if it is
+// configured
+func (bot *CycloneBot) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page := dashboardHTML
+	if bot.oauth != nil {
+		login := bot.oauth.sessionUser(r)
+		if login == "" {
+			http.Redirect(w, r, "/auth/login", http.StatusFound)
+			return
+		}
+		session := fmt.Sprintf(`<span>%s</span> <a href="/auth/logout">Sign out</a>`, html.EscapeString(login))
+		page = bytes.Replace(page, dashboardSessionPlaceholder, []byte(session), 1)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
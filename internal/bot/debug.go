@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxProfileDuration bounds how long a CPU profile or execution trace may record
+const maxProfileDuration = 5 * time.Minute
+
+// handleProfile serves the runtime profiles of /debug/pprof/ in the format of net/http/pprof,
+// which isn't imported since it registers itself without authentication on the default mux
+// all routes are served from:
+//   - /debug/pprof/ lists the profiles
+//   - /debug/pprof/<name> writes a profile such as heap, allocs or goroutine; ?debug=1 as text
+//   - /debug/pprof/profile records a CPU profile for ?seconds=30
+//   - /debug/pprof/trace records an execution trace for ?seconds=1
+//   - /debug/pprof/cmdline writes the command line
+func (bot *CycloneBot) handleProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	switch name {
+	case "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "Profiles:")
+		for _, profile := range pprof.Profiles() {
+			fmt.Fprintf(w, "- %s (%d)\n", profile.Name(), profile.Count())
+		}
+		fmt.Fprintln(w, "- profile (CPU profile, ?seconds=30)\n- trace (execution trace, ?seconds=1)\n- cmdline")
+	case "cmdline":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+	case "profile":
+		duration, err := profileDuration(r, 30*time.Second)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+		if err := pprof.StartCPUProfile(w); err != nil {
+			// Only one CPU profile can be recorded at a time
+			http.Error(w, fmt.Sprintf("Could not start CPU profile: %v", err), http.StatusConflict)
+			return
+		}
+		sleep(r, duration)
+		pprof.StopCPUProfile()
+	case "trace":
+		duration, err := profileDuration(r, time.Second)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+		if err := trace.Start(w); err != nil {
+			http.Error(w, fmt.Sprintf("Could not start trace: %v", err), http.StatusConflict)
+			return
+		}
+		sleep(r, duration)
+		trace.Stop()
+	default:
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			http.Error(w, fmt.Sprintf("Unknown profile %q", name), http.StatusNotFound)
+			return
+		}
+		debug, _ := strconv.Atoi(r.URL.Query().Get("debug"))
+		if debug > 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		}
+		profile.WriteTo(w, debug)
+	}
+}
+
+// profileDuration reads the seconds parameter of a recording
+func profileDuration(r *http.Request, fallback time.Duration) (time.Duration, error) {
+	value := r.URL.Query().Get("seconds")
+	if value == "" {
+		return fallback, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	duration := time.Duration(seconds * float64(time.Second))
+	if err != nil || duration <= 0 || duration > maxProfileDuration {
+		return 0, fmt.Errorf("invalid seconds %q (at most %d)", value, int(maxProfileDuration.Seconds()))
+	}
+	return duration, nil
+}
+
+// sleep waits for a duration, or until the client disconnects
+func sleep(r *http.Request, duration time.Duration) {
+	select {
+	case <-time.After(duration):
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,292 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// defaultDeliveriesLimit is how many deliveries GET /api/admin/webhooks returns without a limit parameter
+const defaultDeliveriesLimit = 100
+
+// deliveryID returns the ID of a delivery, generating one for hand-crafted requests (e.g. from
+// curl), which don't carry an X-GitHub-Delivery header
+func deliveryID(header string) string {
+	if header != "" {
+		return header
+	}
+	return fmt.Sprintf("manual-%d", time.Now().UnixNano())
+}
+
+// deliverySubject is the part of any webhook payload the delivery log shows
+type deliverySubject struct {
+	Action     string `json:"action"`
+	Repository *struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+	PullRequest *struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+	Issue *struct {
+		Number int `json:"number"`
+	} `json:"issue"`
+	Installation *struct {
+		Account struct {
+			Login string `json:"login"`
+		} `json:"account"`
+	} `json:"installation"`
+
+	// GitLab merge request events
+	Project *struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes *struct {
+		IID    int    `json:"iid"`
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+
+	// Azure DevOps service hooks
+	EventType string `json:"eventType"`
+
+	// Gerrit events
+	Type string `json:"type"`
+}
+
+// recordDelivery adds a delivery and the decision taken on it to the delivery log. A delivery
+// recorded before, e.g. by the server of a worker or when it is replayed, is updated.
+func (bot *CycloneBot) recordDelivery(delivery store.WebhookDelivery, decision string, triggered bool) {
+	if !store.ValidDeliveryID(delivery.ID) {
+		// Payload files replayed by "cyclone replay" are named by their path
+		return
+	}
+	outcome := store.DeliveryIgnored
+	if triggered {
+		outcome = store.DeliveryPending
+	}
+
+	found, err := bot.store.UpdateDelivery(delivery.ID, func(rec *store.DeliveryRecord) {
+		rec.Decision = decision
+		rec.Outcome = outcome
+		rec.Detail = ""
+		rec.FinishedAt = nil
+	})
+	if err != nil {
+		log.Printf("Error updating delivery log entry %s: %v", delivery.ID, err)
+		return
+	}
+	if found {
+		return
+	}
+
+	rec := store.DeliveryRecord{
+		ID:       delivery.ID,
+		Time:     delivery.Time,
+		Event:    delivery.Event,
+		Decision: decision,
+		Outcome:  outcome,
+	}
+	rec.Org, rec.Repo, rec.Action, rec.PRNumber = bot.describeDelivery(delivery.Payload)
+	if err := bot.store.SaveDelivery(rec); err != nil {
+		log.Printf("Error adding delivery %s to the delivery log: %v", delivery.ID, err)
+	}
+}
+
+// describeDelivery returns the organization, repository, action and PR or issue number of a
+// webhook payload of any code host, as far as it has them
+func (bot *CycloneBot) describeDelivery(payload []byte) (org, repo, action string, number int) {
+	var subject deliverySubject
+	if json.Unmarshal(payload, &subject) != nil {
+		return "", "", "", 0
+	}
+
+	action = subject.Action
+	if subject.Repository != nil {
+		org, repo = subject.Repository.Owner.Login, subject.Repository.Name
+	} else if subject.Installation != nil {
+		org = subject.Installation.Account.Login
+	}
+	switch {
+	case subject.PullRequest != nil:
+		number = subject.PullRequest.Number
+	case subject.Issue != nil:
+		number = subject.Issue.Number
+	}
+	if subject.Project != nil && subject.ObjectAttributes != nil {
+		org, repo = splitGitLabProject(subject.Project.PathWithNamespace)
+		action = subject.ObjectAttributes.Action
+		number = subject.ObjectAttributes.IID
+	}
+	if strings.HasPrefix(subject.EventType, "git.pullrequest.") {
+		var hook ServiceHookPayload
+		if json.Unmarshal(payload, &hook) == nil {
+			org, repo, number = hook.pullRequest(bot.currentReviewConfig())
+			action = strings.TrimPrefix(subject.EventType, "git.pullrequest.")
+		}
+	}
+	switch subject.Type {
+	case gerritPatchSetCreated, gerritWipStateChanged, gerritHashtagsChanged:
+		var event GerritEvent
+		if json.Unmarshal(payload, &event) == nil {
+			org, repo, number = event.change(bot.currentReviewConfig())
+			action = subject.Type
+		}
+	}
+	return org, repo, action, number
+}
+
+// runDeliveryJob runs the work a delivery triggered and records its outcome
+func (bot *CycloneBot) runDeliveryJob(delivery store.WebhookDelivery, job func() error) error {
+	err := bot.runJob(delivery.Event, job)
+	bot.finishDelivery(delivery, err)
+	return err
+}
+
+// finishDelivery records the outcome of a delivery's work. The payloads of failed deliveries
+// are kept, so they can be replayed without CAPTURE_WEBHOOKS.
+func (bot *CycloneBot) finishDelivery(delivery store.WebhookDelivery, err error) {
+	if !store.ValidDeliveryID(delivery.ID) {
+		return
+	}
+	now := time.Now()
+	_, updateErr := bot.store.UpdateDelivery(delivery.ID, func(rec *store.DeliveryRecord) {
+		rec.FinishedAt = &now
+		rec.Payload = nil
+		rec.Detail = ""
+		switch {
+		case err == nil:
+			rec.Outcome = store.DeliveryCompleted
+		case errors.Is(err, ErrReviewSkipped):
+			rec.Outcome = store.DeliverySkipped
+			rec.Detail = err.Error()
+		case errors.Is(err, ErrWebhookIgnored):
+			rec.Outcome = store.DeliveryIgnored
+			rec.Detail = err.Error()
+		default:
+			rec.Outcome = store.DeliveryFailed
+			rec.Detail = err.Error()
+			rec.Payload = delivery.Payload
+		}
+	})
+	if updateErr != nil {
+		log.Printf("Error recording the outcome of delivery %s: %v", delivery.ID, updateErr)
+	}
+}
+
+// DeliveriesResponse is the JSON body returned by GET /api/admin/webhooks
+type DeliveriesResponse struct {
+	Total      int                    `json:"total"` // Matching deliveries, before limit and offset
+	Deliveries []store.DeliveryRecord `json:"deliveries"`
+}
+
+// handleDeliveriesAPI serves GET /api/admin/webhooks, the delivery log newest first without
+// payloads, filtered by org, repo, date range, PR and outcome
+func (bot *CycloneBot) handleDeliveriesAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	query := r.URL.Query()
+	outcome := query.Get("outcome")
+	switch outcome {
+	case "", store.DeliveryIgnored, store.DeliveryPending, store.DeliveryCompleted, store.DeliverySkipped, store.DeliveryFailed:
+	default:
+		http.Error(w, fmt.Sprintf("invalid outcome %q (use ignored, pending, completed, skipped or failed)", outcome), http.StatusBadRequest)
+		return
+	}
+	prNumber := 0
+	if pr := query.Get("pr"); pr != "" {
+		if prNumber, err = strconv.Atoi(pr); err != nil || prNumber <= 0 {
+			http.Error(w, fmt.Sprintf("invalid pr %q", pr), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := parseCount(query.Get("limit"), defaultDeliveriesLimit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid limit: %v", err), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseCount(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid offset: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	records, err := bot.store.ListDeliveries(filter)
+	if err != nil {
+		log.Printf("Error listing delivery log: %v", err)
+		http.Error(w, "Failed to read delivery log", http.StatusInternalServerError)
+		return
+	}
+	var matching []store.DeliveryRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if (outcome != "" && rec.Outcome != outcome) || (prNumber != 0 && rec.PRNumber != prNumber) {
+			continue
+		}
+		// The payload is only returned by GET /api/admin/webhooks/{delivery-id}
+		rec.Payload = nil
+		matching = append(matching, rec)
+	}
+
+	resp := DeliveriesResponse{Total: len(matching), Deliveries: []store.DeliveryRecord{}}
+	if offset < len(matching) {
+		matching = matching[offset:]
+		if len(matching) > limit {
+			matching = matching[:limit]
+		}
+		resp.Deliveries = matching
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAdminWebhooks serves GET /api/admin/webhooks/{delivery-id}, a delivery log entry, and
+// POST /api/admin/webhooks/{delivery-id}/replay
+func (bot *CycloneBot) handleAdminWebhooks(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/webhooks/")
+	if deliveryID, ok := strings.CutSuffix(path, "/replay"); ok {
+		bot.handleAdminWebhookReplay(w, r, deliveryID)
+		return
+	}
+	if path == "" || strings.Contains(path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rec, err := bot.store.GetDelivery(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rec != nil && !tenantAllowed(r, rec.Org) {
+		// Deliveries of other organizations don't exist for the request
+		rec = nil
+	}
+	if rec == nil {
+		http.Error(w, fmt.Sprintf("No delivery %s in the delivery log", path), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/store"
+)
+
+// SendDigests periodically emails organizations with a digest configured a summary of their
+// review activity, once their daily or weekly period has ended, and posts the weekly health
+// digests of repositories. It never returns.
+func (bot *CycloneBot) SendDigests() {
+	if bot.config.SMTP == nil {
+		for _, org := range bot.currentReviewConfig().Organizations {
+			if org.Digest != nil {
+				log.Printf("Not sending email digests: SMTP_HOST is not set")
+				break
+			}
+		}
+	}
+
+	ticker := time.NewTicker(config.DIGEST_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		if bot.config.SMTP != nil {
+			bot.sendDueDigests(time.Now())
+		}
+		bot.postDueHealthDigests(time.Now())
+		<-ticker.C
+	}
+}
+
+// sendDueDigests sends the digests of the periods that ended last, unless they were sent before
+func (bot *CycloneBot) sendDueDigests(now time.Time) {
+	for _, org := range bot.currentReviewConfig().Organizations {
+		if org.Digest == nil {
+			continue
+		}
+
+		frequency := org.Digest.GetFrequency()
+		start, end := digestPeriod(frequency, now)
+		claimed, err := bot.store.ClaimDigest(org.Name, string(frequency), start)
+		if err != nil {
+			log.Printf("Error claiming %s digest of %s: %v", frequency, org.Name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		subject, body := bot.buildDigest(org.Name, frequency, start, end)
+		if err := sendMail(bot.config.SMTP, org.Digest.Recipients, subject, body); err != nil {
+			log.Printf("Error sending %s digest of %s: %v", frequency, org.Name, err)
+			// Try again at the next check
+			if err := bot.store.ReleaseDigest(org.Name, string(frequency), start); err != nil {
+				log.Printf("Error releasing %s digest of %s: %v", frequency, org.Name, err)
+			}
+			continue
+		}
+		log.Printf("Sent %s digest of %s to %d recipients", frequency, org.Name, len(org.Digest.Recipients))
+	}
+}
+
+// digestPeriod returns the last period of a frequency that ended before now. Days end at
+// midnight UTC, weeks on Monday.
+func digestPeriod(frequency config.DigestFrequency, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if frequency == config.DigestDaily {
+		return end.AddDate(0, 0, -1), end
+	}
+	end = end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+	return end.AddDate(0, 0, -7), end
+}
+
+// digestRepo is a repository's line in a digest
+type digestRepo struct {
+	reviews  int
+	blocking int
+	costUSD  float64
+}
+
+// buildDigest writes the subject and plain text body of an organization's digest: reviews,
+// blocking findings, skipped PRs and cost between start and end
+func (bot *CycloneBot) buildDigest(org string, frequency config.DigestFrequency, start, end time.Time) (string, string) {
+	filter := store.UsageFilter{Org: org, Since: start, Until: end}
+
+	repos := make(map[string]*digestRepo)
+	repo := func(name string) *digestRepo {
+		if repos[name] == nil {
+			repos[name] = &digestRepo{}
+		}
+		return repos[name]
+	}
+
+	reviews := bot.store.ListReviews(filter)
+	dryRuns, blocking := 0, 0
+	var blockingPRs []string
+	for _, rec := range reviews {
+		if rec.DryRun {
+			dryRuns++
+		}
+		repo(rec.Repo).reviews++
+		if n := rec.Categories["blocking"]; n > 0 {
+			repo(rec.Repo).blocking += n
+			blocking += n
+			blockingPRs = append(blockingPRs, fmt.Sprintf("https://github.com/%s/%s/pull/%d", rec.Org, rec.Repo, rec.PRNumber))
+		}
+	}
+
+	skips := bot.store.CountSkips(filter)
+	skipped := 0
+	for _, n := range skips {
+		skipped += n
+	}
+
+	var costUSD float64
+	var tokens int
+	for _, totals := range bot.store.SumUsage(filter, store.GroupByRepo) {
+		// Keys are "org/repo"
+		repo(strings.TrimPrefix(totals.Key, org+"/")).costUSD += totals.CostUSD
+		costUSD += totals.CostUSD
+		tokens += totals.InputTokens + totals.OutputTokens
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Cyclone %s digest for %s\n", frequency, org)
+	if frequency == config.DigestDaily {
+		fmt.Fprintf(&body, "%s (UTC)\n\n", start.Format("Mon, Jan 2 2006"))
+	} else {
+		fmt.Fprintf(&body, "%s - %s (UTC)\n\n", start.Format("Jan 2"), end.AddDate(0, 0, -1).Format("Jan 2 2006"))
+	}
+
+	fmt.Fprintf(&body, "Reviews:           %d", len(reviews))
+	if dryRuns > 0 {
+		fmt.Fprintf(&body, " (%d in dry run)", dryRuns)
+	}
+	fmt.Fprintf(&body, "\nBlocking findings: %d in %d PRs\n", blocking, len(blockingPRs))
+	fmt.Fprintf(&body, "Skipped PRs:       %d", skipped)
+	if skipped > 0 {
+		fmt.Fprintf(&body, " (%s)", formatCounts(skips))
+	}
+	fmt.Fprintf(&body, "\nCost:              $%.2f (%d tokens)\n", costUSD, tokens)
+
+	if len(repos) > 0 {
+		names := make([]string, 0, len(repos))
+		for name := range repos {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		body.WriteString("\nBy repository:\n")
+		tw := tabwriter.NewWriter(&body, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "  repository\treviews\tblocking\tcost")
+		for _, name := range names {
+			r := repos[name]
+			fmt.Fprintf(tw, "  %s\t%d\t%d\t$%.2f\n", name, r.reviews, r.blocking, r.costUSD)
+		}
+		tw.Flush()
+	}
+
+	if len(blockingPRs) > 0 {
+		body.WriteString("\nPRs with blocking findings:\n")
+		for _, link := range blockingPRs {
+			fmt.Fprintf(&body, "  - %s\n", link)
+		}
+	}
+
+	subject := fmt.Sprintf("Cyclone %s digest for %s: %d reviews, %d blocking findings", frequency, org, len(reviews), blocking)
+	return subject, body.String()
+}
+
+// sendMail sends a plain text email. The connection is upgraded with STARTTLS if the server
+// offers it, which it must for authentication unless it is on localhost.
+func sendMail(cfg *config.SMTPConfig, to []string, subject, body string) error {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", cfg.From, err)
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	recipients := make([]string, len(to))
+	for i, address := range to {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", address, err)
+		}
+		recipients[i] = parsed.Address
+	}
+	return smtp.SendMail(net.JoinHostPort(cfg.Host, cfg.Port), auth, from.Address, recipients, []byte(msg.String()))
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cyclone/internal/review"
+)
+
+// isDryRun reports whether nothing may be posted to a repository
+func (bot *CycloneBot) isDryRun(owner, repoName string) bool {
+	return bot.currentReviewConfig().IsDryRun(bot.repositoryConfig(owner, repoName))
+}
+
+// publishReview posts a review and records it. In dry run the review is logged and
+// recorded with its content instead of being posted.
+func (bot *CycloneBot) publishReview(ctx context.Context, owner, repoName string, prNumber int, result review.ReviewResult, dryRun bool) error {
+	if dryRun {
+		logDryRunReview(owner, repoName, prNumber, result)
+		logReviewTimings(prNumber, result.Timings)
+		bot.recordDryRunReview(owner, repoName, prNumber, result)
+		if result.Err == nil {
+			bot.alerts.success(owner, repoName)
+		}
+		return nil
+	}
+
+	provider, err := bot.scmProviderFor(owner)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	reviewID, err := provider.PostReview(ctx, owner, repoName, prNumber, result)
+	if err != nil {
+		return fmt.Errorf("failed to post PR review: %w", err)
+	}
+	result.Timings.Posting = time.Since(started)
+	logReviewTimings(prNumber, result.Timings)
+
+	bot.saveReviewConversation(owner, repoName, prNumber, reviewID, result)
+	bot.recordReview(owner, repoName, prNumber, reviewID, result)
+	bot.notifySlackReview(owner, repoName, prNumber, result)
+	if result.Err == nil {
+		bot.alerts.success(owner, repoName)
+	}
+	return nil
+}
+
+// logDryRunReview logs the review that would have been posted
+func logDryRunReview(owner, repoName string, prNumber int, result review.ReviewResult) {
+	log.Printf("Dry run - not posting review for PR #%d in %s/%s:\n%s", prNumber, owner, repoName, result.Summary)
+	for _, comment := range result.Comments {
+		log.Printf("Dry run comment on %s:%d:\n%s", comment.Path, comment.Line, comment.Body)
+	}
+}
@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cyclone/internal/review"
+)
+
+// Limits of the base branch source read for duplicate detection
+const (
+	duplicateMaxFileBytes   = 512 << 10
+	duplicateMaxSourceBytes = 32 << 20
+)
+
+// findDuplicateCode compares the blocks of code a GitHub pull request adds with the source
+// files of its base branch, and returns comments on the blocks that closely duplicate
+// existing code. Errors are logged, so the review goes ahead without the findings.
+func (bot *CycloneBot) findDuplicateCode(ctx context.Context, owner, repoName string, pr *review.PullRequest, diff, language string) []review.ReviewComment {
+	extensions := review.DuplicateCandidates(diff)
+	if extensions == nil {
+		return nil
+	}
+
+	started := time.Now()
+	ref := pr.Base.SHA
+	if ref == "" {
+		ref = pr.Base.Ref
+	}
+	include := func(path string) bool { return review.IsDuplicateSource(path, extensions) }
+	files, err := bot.githubClientFor(owner).SourceFiles(ctx, owner, repoName, ref, include, duplicateMaxFileBytes, duplicateMaxSourceBytes)
+	if err != nil {
+		log.Printf("Error reading the base branch of PR #%d in %s/%s for duplicate detection: %v", pr.Number, owner, repoName, err)
+		return nil
+	}
+
+	duplicates := review.NewDuplicateIndex(files, diff).FindDuplicates(diff)
+	log.Printf("Duplicate detection for PR #%d in %s/%s found %d duplicated blocks among %d base files in %s",
+		pr.Number, owner, repoName, len(duplicates), len(files), time.Since(started).Round(time.Millisecond))
+	return review.DuplicateComments(duplicates, pr.Base.Ref, language)
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strconv"
+
+	"cyclone/internal/review"
+)
+
+// Kinds of errors reported to the error tracker, which groups events by them
+const (
+	errorKindReviewFailed      = "review_failed"
+	errorKindGenerationFailed  = "generation_failed"
+	errorKindBatchFailed       = "batch_failed"
+	errorKindBatchReviewFailed = "batch_review_failed"
+	errorKindFollowUpFailed    = "follow_up_failed"
+)
+
+// reportError sends an error to the error tracker, if one is configured, tagged with the PR
+// and - for API errors - the provider and status code. Failed reviews count towards alerts.
+func (bot *CycloneBot) reportError(kind string, err error, owner, repoName string, prNumber int) {
+	tags := map[string]string{}
+	if owner != "" {
+		tags["org"] = owner
+		tags["repo"] = repoName
+	}
+	if prNumber > 0 {
+		tags["pr"] = strconv.Itoa(prNumber)
+	}
+
+	if provider, statusCode := review.APIErrorStatus(err); provider != "" {
+		tags["provider"] = provider
+		tags["status_code"] = strconv.Itoa(statusCode)
+	}
+
+	bot.errorTracker.CaptureError(kind, err, tags)
+	if kind != errorKindFollowUpFailed && kind != errorKindBatchFailed {
+		bot.alerts.failure(owner, repoName, prNumber, errorClass(err), err)
+	}
+}
+
+// errorClass names the cause of a failed review for alerts, e.g. "claude_529" or "github_404"
+func errorClass(err error) string {
+	if provider, statusCode := review.APIErrorStatus(err); provider != "" {
+		return fmt.Sprintf("%s_%d", provider, statusCode)
+	}
+	if errors.Is(err, review.ErrUnparsableResponse) {
+		return "unparsable_response"
+	}
+	return "other"
+}
+
+// runJob runs the work a webhook triggered. A panic is logged, reported and returned as an
+// error instead of taking down the process with all reviews in flight.
+func (bot *CycloneBot) runJob(event string, job func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			stack := debug.Stack()
+			log.Printf("Panic handling %s webhook: %v\n%s", event, value, stack)
+			bot.errorTracker.CapturePanic(value, stack, map[string]string{"event": event})
+			err = fmt.Errorf("panic: %v", value)
+		}
+	}()
+
+	return job()
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// Output tokens assumed for AI calls when a repository has no usage history yet
+const (
+	defaultReviewOutputTokens   = 2000
+	defaultCritiqueOutputTokens = 1000
+)
+
+// ReviewEstimate is the predicted usage of one AI call of a review
+type ReviewEstimate struct {
+	Stage   string       // "review", "consensus" or "critique"
+	Usage   review.Usage // Estimated tokens; Cost() gives the estimated price
+	History int          // Past calls the output tokens are averaged from, 0 if they are a default
+}
+
+// EstimateReview predicts the tokens and cost of reviewing a PR with its configured models,
+// without generating the review. Input tokens are measured on the rendered prompt, output
+// tokens are averaged from the repository's past reviews.
+func (bot *CycloneBot) EstimateReview(ctx context.Context, owner, repoName string, prNumber int) ([]ReviewEstimate, error) {
+	prepared, err := bot.prepareOnDemand(ctx, owner, repoName, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	return bot.estimatePreparedReview(prepared), nil
+}
+
+// estimatePreparedReview predicts the usage of a prepared review, see EstimateReview
+func (bot *CycloneBot) estimatePreparedReview(prepared *preparedReview) []ReviewEstimate {
+	owner, repoName, repoConfig := prepared.owner, prepared.repoName, prepared.repoConfig
+	reqBody, inputTokens := prepared.aiClient.PreviewReviewRequest(prepared.diff, prepared.pr.Title, prepared.pr.Body, repoConfig)
+
+	reviewOutput, reviewHistory := bot.averageOutputTokens(owner, repoName, store.UsageKindReview, store.UsageKindBatchReview)
+	if reviewHistory == 0 {
+		reviewOutput = defaultReviewOutputTokens
+		if reqBody.Thinking != nil {
+			reviewOutput += reqBody.Thinking.BudgetTokens
+		}
+	}
+
+	estimates := []ReviewEstimate{{
+		Stage: "review",
+		Usage: review.Usage{
+			Model:        reqBody.Model,
+			InputTokens:  inputTokens,
+			OutputTokens: reviewOutput,
+			Batch:        repoConfig.BatchMode && !prepared.quota.Exceeded,
+		},
+		History: reviewHistory,
+	}}
+
+	// Batch reviews and downgraded reviews skip the consensus model, see reviewPullRequest
+	if repoConfig.ConsensusModel != "" && !repoConfig.BatchMode && !prepared.quota.Exceeded {
+		consensusOutput, consensusHistory := bot.averageOutputTokens(owner, repoName, store.UsageKindConsensus)
+		if consensusHistory == 0 {
+			consensusOutput, consensusHistory = reviewOutput, reviewHistory
+		}
+		estimates = append(estimates, ReviewEstimate{
+			Stage: "consensus",
+			Usage: review.Usage{
+				Model:        repoConfig.ConsensusModel,
+				InputTokens:  inputTokens,
+				OutputTokens: consensusOutput,
+			},
+			History: consensusHistory,
+		})
+	}
+
+	if repoConfig.SelfCritique && !prepared.quota.Exceeded {
+		critiqueOutput, critiqueHistory := bot.averageOutputTokens(owner, repoName, store.UsageKindCritique)
+		if critiqueHistory == 0 {
+			critiqueOutput = defaultCritiqueOutputTokens
+		}
+		// The critique sees the diff and the drafted comments
+		estimates = append(estimates, ReviewEstimate{
+			Stage: "critique",
+			Usage: review.Usage{
+				Model:        reqBody.Model,
+				InputTokens:  inputTokens + reviewOutput,
+				OutputTokens: critiqueOutput,
+			},
+			History: critiqueHistory,
+		})
+	}
+
+	return estimates
+}
+
+// averageOutputTokens returns the mean output tokens of a repository's past AI calls of the
+// given kinds and the number of calls averaged
+func (bot *CycloneBot) averageOutputTokens(owner, repoName string, kinds ...string) (int, int) {
+	total, calls := 0, 0
+	for _, rec := range bot.store.ListUsage(store.UsageFilter{Org: owner, Repo: repoName}) {
+		for _, kind := range kinds {
+			if rec.Kind == kind && rec.OutputTokens > 0 {
+				total += rec.OutputTokens
+				calls++
+			}
+		}
+	}
+
+	if calls == 0 {
+		return 0, 0
+	}
+	return total / calls, calls
+}
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// Formats of the review history export
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+// reviewExportColumns are the CSV header and the order of ReviewExportRow's fields
+var reviewExportColumns = []string{
+	"time", "org", "repo", "pr_number", "review_id", "dry_run", "model", "prompt_version",
+	"prompt_variant", "input_tokens", "output_tokens", "comments", "categories", "verdict",
+}
+
+// ReviewExportRow is a review in the history export, one JSON line or CSV row per review
+type ReviewExportRow struct {
+	Time          time.Time      `json:"time"`
+	Org           string         `json:"org"`
+	Repo          string         `json:"repo"`
+	PRNumber      int            `json:"pr_number"`
+	ReviewID      int64          `json:"review_id"`
+	DryRun        bool           `json:"dry_run"`
+	Model         string         `json:"model"`
+	PromptVersion string         `json:"prompt_version"`
+	PromptVariant string         `json:"prompt_variant"`
+	InputTokens   int            `json:"input_tokens"`
+	OutputTokens  int            `json:"output_tokens"`
+	Comments      int            `json:"comments"`
+	Categories    map[string]int `json:"categories"`
+	Verdict       string         `json:"verdict"`
+}
+
+// ExportReviews writes review history records as CSV or JSON lines. Review contents are
+// left out; the export is meant for analysis in spreadsheets and BI tools.
+func ExportReviews(w io.Writer, format string, records []store.ReviewRecord) error {
+	switch format {
+	case ExportFormatCSV:
+		return exportReviewsCSV(w, records)
+	case ExportFormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, rec := range records {
+			if err := encoder.Encode(newReviewExportRow(rec)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q (use csv or jsonl)", format)
+}
+
+// newReviewExportRow converts a review record, counting uncategorized comments as "other"
+func newReviewExportRow(rec store.ReviewRecord) ReviewExportRow {
+	categories := make(map[string]int, len(rec.Categories))
+	for name, count := range rec.Categories {
+		if name == "" {
+			name = "other"
+		}
+		categories[name] += count
+	}
+
+	return ReviewExportRow{
+		Time:          rec.Time.UTC(),
+		Org:           rec.Org,
+		Repo:          rec.Repo,
+		PRNumber:      rec.PRNumber,
+		ReviewID:      rec.ReviewID,
+		DryRun:        rec.DryRun,
+		Model:         rec.Model,
+		PromptVersion: rec.PromptVersion,
+		PromptVariant: rec.PromptVariant,
+		InputTokens:   rec.InputTokens,
+		OutputTokens:  rec.OutputTokens,
+		Comments:      rec.Comments,
+		Categories:    categories,
+		Verdict:       rec.Verdict,
+	}
+}
+
+// exportReviewsCSV writes records as CSV, with the categories in a single column such as
+// "issue=2;nit=1", sorted by name
+func exportReviewsCSV(w io.Writer, records []store.ReviewRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(reviewExportColumns); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		row := newReviewExportRow(rec)
+
+		var categories []string
+		for name, count := range row.Categories {
+			categories = append(categories, fmt.Sprintf("%s=%d", name, count))
+		}
+		sort.Strings(categories)
+
+		err := writer.Write([]string{
+			row.Time.Format(time.RFC3339),
+			row.Org,
+			row.Repo,
+			strconv.Itoa(row.PRNumber),
+			strconv.FormatInt(row.ReviewID, 10),
+			strconv.FormatBool(row.DryRun),
+			row.Model,
+			row.PromptVersion,
+			row.PromptVariant,
+			strconv.Itoa(row.InputTokens),
+			strconv.Itoa(row.OutputTokens),
+			strconv.Itoa(row.Comments),
+			strings.Join(categories, ";"),
+			row.Verdict,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// handleReviewExport serves GET /api/export/reviews, the review history as CSV or JSON lines
+// filtered like the usage API
+func (bot *CycloneBot) handleReviewExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	contentType := "text/csv"
+	switch format {
+	case ExportFormatCSV:
+	case ExportFormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		http.Error(w, fmt.Sprintf("invalid format %q (use csv or jsonl)", format), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="cyclone-reviews.%s"`, format))
+	if err := ExportReviews(w, format, bot.store.ListReviews(filter)); err != nil {
+		// Headers are already sent, so the client only sees a truncated file
+		log.Printf("Error exporting review history: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package bot
+
+import "cyclone/internal/config"
+
+// featureEnabled reports whether a gradually rolled out feature is turned on for a
+// repository. Flags are read from the current review configuration, so turning a feature
+// on or off takes effect with the next reload.
+func (bot *CycloneBot) featureEnabled(owner, repoName string, feature config.Feature) bool {
+	return bot.currentReviewConfig().FeatureEnabled(owner, repoName, feature)
+}
@@ -0,0 +1,27 @@
+package bot
+
+import (
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+)
+
+// forcedReviewPrompt is appended to the custom prompt for PRs reviewed beyond the size limits
+const forcedReviewPrompt = `
+**Large PR mode:** This PR exceeds the size limits for a line-by-line review. Only provide the SUMMARY section (and the POEM section if requested above) - focus on the overall design, risky areas and what human reviewers should look at closely. Do NOT write any PR_COMMENT entries.`
+
+// forcedReviewWarning is prepended to reviews of PRs beyond the size limits
+func forcedReviewWarning(language string) string {
+	return notices.Render(language, "forced_review_warning", notices.Data{"Label": config.FORCE_REVIEW_LABEL}) + noticeSeparator
+}
+
+// forceReviewHint is appended to size skip messages
+func forceReviewHint(language string) string {
+	return "\n\n" + notices.Render(language, "force_review_hint", notices.Data{"Label": config.FORCE_REVIEW_LABEL})
+}
+
+// forcedReviewConfig returns a repository config that asks for a summary-only review
+func forcedReviewConfig(repoConfig *config.RepositoryConfig) *config.RepositoryConfig {
+	forced := *repoConfig
+	forced.CustomPrompt += forcedReviewPrompt
+	return &forced
+}
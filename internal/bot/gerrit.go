@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/logging"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// Types of the Gerrit events Cyclone handles
+const (
+	gerritPatchSetCreated = "patchset-created"
+	gerritWipStateChanged = "wip-state-changed"
+	gerritHashtagsChanged = "hashtags-changed"
+)
+
+// gerritStatusNew is the status of open Gerrit changes
+const gerritStatusNew = "NEW"
+
+// GerritEvent represents a Gerrit event as sent by the webhooks plugin, in the format of
+// "gerrit stream-events"
+type GerritEvent struct {
+	Type   string `json:"type"` // e.g. "patchset-created"
+	Change struct {
+		Project string `json:"project"` // e.g. "platform/api"
+		Number  int    `json:"number"`
+		URL     string `json:"url"`    // e.g. "https://gerrit.example.com/c/platform/api/+/123"
+		Status  string `json:"status"` // e.g. "NEW", "MERGED"
+		WIP     bool   `json:"wip"`
+	} `json:"change"`
+	PatchSet struct {
+		Number int `json:"number"`
+	} `json:"patchSet"`
+	Added []string `json:"added"` // Hashtags added by "hashtags-changed" events
+}
+
+// change identifies the event's change by organization, project and number. The organization
+// is the configured Gerrit server whose URL the change's URL starts with, "" if none does.
+func (e *GerritEvent) change(reviewCfg *config.ReviewConfig) (string, string, int) {
+	for _, org := range reviewCfg.Organizations {
+		if org.GetProvider() != config.ProviderGerrit || org.Gerrit == nil {
+			continue
+		}
+		serverURL := strings.ToLower(strings.TrimSuffix(org.Gerrit.URL, "/")) + "/"
+		if strings.HasPrefix(strings.ToLower(e.Change.URL), serverURL) {
+			return org.Name, e.Change.Project, e.Change.Number
+		}
+	}
+	return "", e.Change.Project, e.Change.Number
+}
+
+// handleGerritWebhook processes incoming Gerrit events. With WEBHOOK_SECRET set, the webhook
+// URL must carry it as the secret query parameter, since the webhooks plugin can't add headers.
+func (bot *CycloneBot) handleGerritWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if secret := bot.webhookSecret(); secret != "" &&
+		subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("secret")), []byte(secret)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading webhook body: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Gerrit events name their type in the payload and carry no delivery ID
+	var event GerritEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.Printf("Error decoding Gerrit event: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	bot.acceptDelivery(w, store.WebhookDelivery{ID: deliveryID(""), Event: event.Type, Payload: body})
+}
+
+// gerritEventJob triggers reviews for Gerrit change events: when a change is uploaded or
+// marked as ready, and when one too large to review gets the hashtag for a forced review
+func (bot *CycloneBot) gerritEventJob(eventType string, body []byte) (func() error, string, error) {
+	var event GerritEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, "", err
+	}
+
+	owner, project, number := event.change(bot.currentReviewConfig())
+	if owner == "" {
+		return nil, "ignored: not from a configured Gerrit server", nil
+	}
+	if event.Change.WIP {
+		return nil, "ignored: work-in-progress changes aren't reviewed", nil
+	}
+	if event.Change.Status != gerritStatusNew {
+		return nil, fmt.Sprintf("ignored: %s changes aren't reviewed", strings.ToLower(event.Change.Status)), nil
+	}
+
+	forceLabeled := false
+	switch eventType {
+	case gerritPatchSetCreated:
+		if event.PatchSet.Number != 1 {
+			return nil, "ignored: new patch sets don't trigger a review", nil
+		}
+	case gerritWipStateChanged:
+		if bot.store.HasReview(owner, project, number) {
+			return nil, "ignored: the change was reviewed before", nil
+		}
+	case gerritHashtagsChanged:
+		if !slices.Contains(event.Added, config.FORCE_REVIEW_LABEL) {
+			return nil, "ignored: hashtag changes don't trigger a review", nil
+		}
+		forceLabeled = true
+	}
+
+	log.Printf("Processing change %d of %s/%s: %s", number, owner, project, eventType)
+	return func() error { return bot.ProcessGerritChange(owner, project, number, forceLabeled) }, "review", nil
+}
+
+// gerritClientFor returns the Gerrit client of an organization on Gerrit
+func (bot *CycloneBot) gerritClientFor(owner string) (*review.GerritClient, error) {
+	orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner)
+	if orgConfig == nil || orgConfig.GetProvider() != config.ProviderGerrit || orgConfig.Gerrit == nil {
+		return nil, fmt.Errorf("organization %s isn't on Gerrit", owner)
+	}
+
+	bot.clientsMu.Lock()
+	defer bot.clientsMu.Unlock()
+
+	if client, ok := bot.gerritClients[owner]; ok {
+		return client, nil
+	}
+
+	token := orgConfig.Gerrit.GetToken()
+	if token == "" {
+		return nil, fmt.Errorf("no Gerrit HTTP password for organization %s", owner)
+	}
+	logging.AddSecrets(token)
+	client := review.NewGerritClient(orgConfig.Gerrit.URL, orgConfig.Gerrit.Username, token, orgConfig.Gerrit.VoteLabel)
+	bot.gerritClients[owner] = client
+	return client, nil
+}
+
+// ProcessGerritChange reviews the current patch set of a Gerrit change, voting on the
+// organization's vote label if it has one. The returned error wraps ErrReviewSkipped if the
+// change was deliberately not reviewed.
+func (bot *CycloneBot) ProcessGerritChange(owner, project string, number int, forceLabeled bool) error {
+	return bot.processHostedChange(owner, project, number, fmt.Sprintf("change %d", number), forceLabeled)
+}
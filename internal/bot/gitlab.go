@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/logging"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// gitLabMergeRequestEvent is the X-Gitlab-Event header of merge request webhooks
+const gitLabMergeRequestEvent = "Merge Request Hook"
+
+// MergeRequestPayload represents a GitLab merge request webhook payload
+type MergeRequestPayload struct {
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"` // e.g. "acme/platform/api"
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Action string `json:"action"` // e.g. "open", "update", "merge"
+		Draft  bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+		Labels *struct {
+			Previous []gitLabLabel `json:"previous"`
+			Current  []gitLabLabel `json:"current"`
+		} `json:"labels"`
+	} `json:"changes"` // Set for "update" actions
+}
+
+// gitLabLabel is a label in a GitLab webhook payload
+type gitLabLabel struct {
+	Title string `json:"title"`
+}
+
+// splitGitLabProject splits a project path into the organization - its top-level group - and
+// the repository name Cyclone knows it by, e.g. "acme/platform/api" into "acme" and "platform/api"
+func splitGitLabProject(path string) (string, string) {
+	owner, repoName, _ := strings.Cut(path, "/")
+	return owner, repoName
+}
+
+// handleGitLabWebhook processes incoming GitLab webhooks. With WEBHOOK_SECRET set, GitLab must
+// send it as the webhook's secret token.
+func (bot *CycloneBot) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if secret := bot.webhookSecret(); secret != "" &&
+		subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading webhook body: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	event := r.Header.Get("X-Gitlab-Event")
+	bot.acceptDelivery(w, store.WebhookDelivery{ID: deliveryID(r.Header.Get("X-Gitlab-Event-UUID")), Event: event, Payload: body})
+}
+
+// mergeRequestJob triggers reviews for GitLab merge request events: when a merge request is
+// opened or marked as ready, and when one too large to review is labeled for a forced review
+func (bot *CycloneBot) mergeRequestJob(body []byte) (func() error, string, error) {
+	var payload MergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+
+	owner, repoName := splitGitLabProject(payload.Project.PathWithNamespace)
+	mr := payload.ObjectAttributes
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig == nil || orgConfig.GetProvider() != config.ProviderGitLab {
+		return nil, fmt.Sprintf("ignored: %s isn't a GitLab organization", owner), nil
+	}
+	if mr.Draft {
+		return nil, "ignored: draft merge requests aren't reviewed", nil
+	}
+
+	forceLabeled := false
+	switch {
+	case mr.Action == "open":
+	case mr.Action == "update" && payload.Changes.Draft != nil && payload.Changes.Draft.Previous:
+		// Marked as ready
+	case mr.Action == "update" && payload.Changes.Labels != nil &&
+		hasGitLabLabel(payload.Changes.Labels.Current, config.FORCE_REVIEW_LABEL) &&
+		!hasGitLabLabel(payload.Changes.Labels.Previous, config.FORCE_REVIEW_LABEL):
+		forceLabeled = true
+	default:
+		return nil, fmt.Sprintf("ignored: %q actions don't trigger a review", mr.Action), nil
+	}
+
+	log.Printf("Processing merge request !%d of %s: %s", mr.IID, payload.Project.PathWithNamespace, mr.Action)
+	return func() error { return bot.ProcessMergeRequest(owner, repoName, mr.IID, forceLabeled) }, "review", nil
+}
+
+// hasGitLabLabel reports whether a label list includes the given label
+func hasGitLabLabel(labels []gitLabLabel, title string) bool {
+	for _, label := range labels {
+		if label.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+// gitlabClientFor returns the GitLab client of an organization on GitLab
+func (bot *CycloneBot) gitlabClientFor(owner string) (*review.GitLabClient, error) {
+	orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner)
+	if orgConfig == nil || orgConfig.GetProvider() != config.ProviderGitLab || orgConfig.GitLab == nil {
+		return nil, fmt.Errorf("organization %s isn't on GitLab", owner)
+	}
+
+	bot.clientsMu.Lock()
+	defer bot.clientsMu.Unlock()
+
+	if client, ok := bot.gitlabClients[owner]; ok {
+		return client, nil
+	}
+
+	token := orgConfig.GitLab.GetToken()
+	if token == "" {
+		return nil, fmt.Errorf("no GitLab token for organization %s", owner)
+	}
+	logging.AddSecrets(token)
+	client := review.NewGitLabClient(orgConfig.GitLab.URL, token)
+	bot.gitlabClients[owner] = client
+	return client, nil
+}
+
+// ProcessMergeRequest reviews a GitLab merge request. forceLabeled is set when it was just
+// labeled for a forced review, which only matters for merge requests too large to review. The
+// returned error wraps ErrReviewSkipped if the merge request was deliberately not reviewed.
+func (bot *CycloneBot) ProcessMergeRequest(owner, repoName string, iid int, forceLabeled bool) error {
+	return bot.processHostedChange(owner, repoName, iid, fmt.Sprintf("merge request !%d", iid), forceLabeled)
+}
@@ -0,0 +1,240 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// healthDigestInstructions is the system prompt of health digests
+const healthDigestInstructions = `You write the weekly health digest of a code repository for its engineering team, based on the automated code reviews Cyclone posted during the week.
+
+Write concise markdown with exactly these sections:
+### Themes
+What the week's changes and reviews were about, and how the feedback trended.
+### Recurring issues
+Problems flagged in more than one PR, with the PR numbers. Say so if nothing recurred.
+### Riskiest merges
+Merged PRs whose blocking findings or issues weren't acted upon, riskiest first, with a one-line reason each. Say so if there were none.
+
+Refer to PRs as #123. Only state what the review data supports. Stay under 400 words.`
+
+// Limits of the review data health digests and release summaries are written from
+const (
+	healthDigestMaxPRs      = 40
+	healthDigestMaxComments = 8
+	healthDigestMaxChars    = 400
+)
+
+// healthDigestConfig returns where a repository's health digest is posted: its own or its
+// organization's setting, nil if it gets none
+func (bot *CycloneBot) healthDigestConfig(owner, repoName string) *config.HealthDigestConfig {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.HealthDigest != nil {
+		return repoConfig.HealthDigest
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.HealthDigest
+	}
+	return nil
+}
+
+// postDueHealthDigests posts the health digests of the week that ended last for repositories
+// that were reviewed or had a reviewed PR merged that week, unless they were posted before
+func (bot *CycloneBot) postDueHealthDigests(now time.Time) {
+	start, end := digestPeriod(config.DigestWeekly, now)
+
+	for _, org := range bot.currentReviewConfig().Organizations {
+		for repoName, records := range weekRecords(bot.store.ListReviews(store.UsageFilter{Org: org.Name}), start, end) {
+			digest := bot.healthDigestConfig(org.Name, repoName)
+			if digest == nil {
+				continue
+			}
+
+			claimed, err := bot.store.ClaimHealthDigest(org.Name, repoName, start)
+			if err != nil {
+				log.Printf("Error claiming health digest of %s/%s: %v", org.Name, repoName, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := bot.postHealthDigest(org.Name, repoName, digest, records, start, end); err != nil {
+				log.Printf("Error posting health digest of %s/%s: %v", org.Name, repoName, err)
+				// Try again at the next check
+				if err := bot.store.ReleaseHealthDigest(org.Name, repoName, start); err != nil {
+					log.Printf("Error releasing health digest of %s/%s: %v", org.Name, repoName, err)
+				}
+			}
+		}
+	}
+}
+
+// weekRecords groups the reviews of the PRs reviewed or merged between start and end by
+// repository, keeping the latest review of each PR
+func weekRecords(records []store.ReviewRecord, start, end time.Time) map[string][]store.ReviewRecord {
+	inWeek := func(t time.Time) bool { return !t.Before(start) && t.Before(end) }
+
+	latest := make(map[string]map[int]store.ReviewRecord)
+	for _, rec := range records {
+		if !inWeek(rec.Time) && (rec.Outcome == nil || !inWeek(rec.Outcome.MergedAt)) {
+			continue
+		}
+		if latest[rec.Repo] == nil {
+			latest[rec.Repo] = make(map[int]store.ReviewRecord)
+		}
+		// Records are oldest first
+		latest[rec.Repo][rec.PRNumber] = rec
+	}
+
+	byRepo := make(map[string][]store.ReviewRecord)
+	for repoName, prs := range latest {
+		for _, rec := range prs {
+			byRepo[repoName] = append(byRepo[repoName], rec)
+		}
+		sort.Slice(byRepo[repoName], func(i, j int) bool {
+			return byRepo[repoName][i].PRNumber < byRepo[repoName][j].PRNumber
+		})
+	}
+	return byRepo
+}
+
+// postHealthDigest has the AI write a repository's digest and posts it to the configured
+// discussion category and Slack channel
+func (bot *CycloneBot) postHealthDigest(owner, repoName string, digest *config.HealthDigestConfig, records []store.ReviewRecord, start, end time.Time) error {
+	if quota := bot.checkQuota(owner, repoName, bot.repositoryConfig(owner, repoName)); quota.Exceeded {
+		log.Printf("Not writing health digest of %s/%s: monthly quota of the %s is exhausted", owner, repoName, quota.Scope)
+		return nil
+	}
+
+	text, usage, err := bot.aiClientFor(owner).Converse(healthDigestInstructions, []review.ClaudeMessage{
+		{Role: "user", Content: healthDigestData(owner, repoName, records, start, end)},
+	})
+	bot.recordUsage(owner, repoName, 0, store.UsageKindDigest, usage)
+	if err != nil {
+		return err
+	}
+
+	week := fmt.Sprintf("%s - %s", start.Format("Jan 2"), end.AddDate(0, 0, -1).Format("Jan 2 2006"))
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not posting health digest of %s/%s:\n%s", owner, repoName, text)
+		return nil
+	}
+
+	discussionURL := ""
+	if digest.DiscussionCategory != "" {
+		title := fmt.Sprintf("Cyclone health digest: %s", week)
+		body := fmt.Sprintf("%s\n\n---\n_Written by Cyclone from %d reviewed or merged PRs._", text, len(records))
+		discussionURL, err = bot.githubClientFor(owner).CreateDiscussion(context.Background(), owner, repoName, digest.DiscussionCategory, title, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	if digest.SlackChannel != "" && bot.slack != nil {
+		message := fmt.Sprintf(":cyclone: *Weekly health digest of <https://github.com/%s/%s|%s/%s>* (%s)\n\n%s",
+			owner, repoName, owner, repoName, week, slackMarkdown(text))
+		if discussionURL != "" {
+			message += fmt.Sprintf("\n\n<%s|Discuss on GitHub>", discussionURL)
+		}
+		bot.slack.enqueue(slackMessage{Channel: digest.SlackChannel, Text: message})
+	}
+
+	log.Printf("Posted health digest of %s/%s for %s", owner, repoName, week)
+	return nil
+}
+
+// healthDigestData lists the week's reviews and merges of a repository for the AI
+func healthDigestData(owner, repoName string, records []store.ReviewRecord, start, end time.Time) string {
+	var data strings.Builder
+	fmt.Fprintf(&data, "Repository: %s/%s\nWeek: %s to %s\n", owner, repoName, start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	if len(records) > healthDigestMaxPRs {
+		fmt.Fprintf(&data, "Only the latest %d of %d PRs are listed.\n", healthDigestMaxPRs, len(records))
+		records = records[len(records)-healthDigestMaxPRs:]
+	}
+
+	for _, rec := range records {
+		fmt.Fprintf(&data, "\n## PR #%d\n", rec.PRNumber)
+		if !rec.Time.Before(start) {
+			fmt.Fprintf(&data, "Reviewed %s", rec.Time.UTC().Format("Mon"))
+			if rec.Verdict != "" {
+				fmt.Fprintf(&data, ", verdict: %s", rec.Verdict)
+			}
+			data.WriteString("\n")
+		}
+		if rec.Outcome != nil && !rec.Outcome.MergedAt.Before(start) && rec.Outcome.MergedAt.Before(end) {
+			fmt.Fprintf(&data, "Merged %s", rec.Outcome.MergedAt.UTC().Format("Mon"))
+			if len(rec.Outcome.Tracked) > 0 {
+				actedUpon := formatCounts(categoryCounts(rec.Outcome.ActedUpon))
+				if actedUpon == "" {
+					actedUpon = "none"
+				}
+				fmt.Fprintf(&data, "; comments acted upon before merging: %s (of %s)", actedUpon, formatCounts(categoryCounts(rec.Outcome.Tracked)))
+			}
+			data.WriteString("\n")
+		}
+		writeReviewFindings(&data, rec)
+	}
+	return data.String()
+}
+
+// writeReviewFindings lists a review's comment counts, summary and first line comments for the AI
+func writeReviewFindings(data *strings.Builder, rec store.ReviewRecord) {
+	if len(rec.Categories) > 0 {
+		fmt.Fprintf(data, "Comments: %s\n", formatCounts(categoryCounts(rec.Categories)))
+	}
+	if rec.Summary != "" {
+		fmt.Fprintf(data, "Summary: %s\n", truncateText(rec.Summary, healthDigestMaxChars))
+	}
+	for k, comment := range rec.DraftComments {
+		if k == healthDigestMaxComments {
+			fmt.Fprintf(data, "- ... %d more comments\n", len(rec.DraftComments)-k)
+			break
+		}
+		fmt.Fprintf(data, "- %s:%d: %s\n", comment.Path, comment.Line, truncateText(comment.Body, healthDigestMaxChars))
+	}
+}
+
+// categoryCounts names the uncategorized comments of per-category counts
+func categoryCounts(counts map[string]int) map[string]int {
+	named := make(map[string]int, len(counts))
+	for category, n := range counts {
+		if category == "" {
+			category = "uncategorized"
+		}
+		named[category] += n
+	}
+	return named
+}
+
+// truncateText shortens text to a single line of at most max characters
+func truncateText(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max]) + "…"
+	}
+	return text
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	markdownBold    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// slackMarkdown converts the markdown Slack doesn't understand - headings, bold text and
+// links - to its mrkdwn
+func slackMarkdown(text string) string {
+	text = markdownHeading.ReplaceAllString(text, "*$1*")
+	text = markdownBold.ReplaceAllString(text, "*$1*")
+	return markdownLink.ReplaceAllString(text, "<$2|$1>")
+}
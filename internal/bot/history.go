@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// recordReview adds a posted review to the review history
+func (bot *CycloneBot) recordReview(owner, repoName string, prNumber int, reviewID int64, result review.ReviewResult) {
+	bot.addReviewRecord(prNumber, newReviewRecord(owner, repoName, prNumber, reviewID, result))
+}
+
+// recordDryRunReview adds a review generated in dry run to the review history
+func (bot *CycloneBot) recordDryRunReview(owner, repoName string, prNumber int, result review.ReviewResult) {
+	rec := newReviewRecord(owner, repoName, prNumber, 0, result)
+	rec.DryRun = true
+	bot.addReviewRecord(prNumber, rec)
+}
+
+func newReviewRecord(owner, repoName string, prNumber int, reviewID int64, result review.ReviewResult) store.ReviewRecord {
+	categories := make(map[string]int)
+	var comments []store.DraftComment
+	for _, comment := range result.Comments {
+		categories[strings.ToLower(comment.Category)]++
+		comments = append(comments, store.DraftComment{
+			Path:     comment.Path,
+			Line:     comment.Line,
+			Category: comment.Category,
+			Body:     comment.Body,
+		})
+	}
+
+	return store.ReviewRecord{
+		Org:           owner,
+		Repo:          repoName,
+		PRNumber:      prNumber,
+		ReviewID:      reviewID,
+		Model:         result.Usage.Model,
+		PromptVersion: result.PromptVersion,
+		PromptVariant: result.PromptVariant,
+		Comments:      len(result.Comments),
+		InputTokens:   result.Usage.InputTokens,
+		OutputTokens:  result.Usage.OutputTokens,
+		Categories:    categories,
+		Verdict:       reviewVerdict(categories),
+		Summary:       result.Summary,
+		DraftComments: comments,
+		HeadSHA:       result.HeadSHA,
+		LatencyMs:     latencyMs(result.StartedAt),
+		StageMs:       stageMs(result.Timings),
+	}
+}
+
+// latencyMs returns the milliseconds since a review started, or 0 if the start is unknown
+func latencyMs(startedAt time.Time) int64 {
+	if startedAt.IsZero() {
+		return 0
+	}
+	return time.Since(startedAt).Milliseconds()
+}
+
+// stageMs converts the timings of a review's stages to milliseconds, leaving out stages it
+// didn't go through
+func stageMs(timings review.Timings) map[string]int64 {
+	stages := make(map[string]int64)
+	for stage, duration := range map[string]time.Duration{
+		store.StageDiffFetch:  timings.DiffFetch,
+		store.StageContext:    timings.Context,
+		store.StageGeneration: timings.Generation,
+		store.StageParsing:    timings.Parsing,
+		store.StageCritique:   timings.Critique,
+		store.StagePosting:    timings.Posting,
+	} {
+		if duration > 0 {
+			stages[stage] = duration.Milliseconds()
+		}
+	}
+	return stages
+}
+
+// logReviewTimings logs how long the stages of a review took
+func logReviewTimings(prNumber int, timings review.Timings) {
+	stages := stageMs(timings)
+	var parts []string
+	for _, stage := range store.Stages {
+		if ms, ok := stages[stage]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%s", stage, time.Duration(ms)*time.Millisecond))
+		}
+	}
+	log.Printf("Review of PR #%d took %s", prNumber, strings.Join(parts, " "))
+}
+
+// reviewVerdict sums up a review by the most severe category among its comments
+func reviewVerdict(categories map[string]int) string {
+	switch {
+	case categories["blocking"] > 0:
+		return store.VerdictBlocking
+	case categories["issue"] > 0:
+		return store.VerdictIssues
+	case len(categories) > 0:
+		return store.VerdictComments
+	}
+	return store.VerdictClean
+}
+
+func (bot *CycloneBot) addReviewRecord(prNumber int, rec store.ReviewRecord) {
+	if err := bot.store.RecordReview(rec); err != nil {
+		log.Printf("Error recording review history for PR #%d: %v", prNumber, err)
+	}
+}
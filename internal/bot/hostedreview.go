@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// processHostedChange reviews a change on a code host other than GitHub, which logs refer to by
+// name, e.g. "merge request !12". Webhooks of those code hosts don't carry the change in full,
+// so it is fetched and then reviewed like a GitHub PR. forceLabeled is set when it was just
+// labeled for a forced review, which only matters for changes too large to review. The
+// returned error wraps ErrReviewSkipped if the change was deliberately not reviewed.
+func (bot *CycloneBot) processHostedChange(owner, repoName string, number int, name string, forceLabeled bool) error {
+	ctx := context.Background()
+	provider, err := bot.scmProviderFor(owner)
+	var change *review.PullRequest
+	if err == nil {
+		change, err = provider.GetPullRequest(ctx, owner, repoName, number)
+	}
+	if err == nil {
+		repo := &review.Repository{Name: repoName, FullName: owner + "/" + repoName, Owner: review.User{Login: owner}}
+		_, err = bot.reviewPullRequest(ctx, repo, change, reviewOptions{post: true, batch: true, forceLabeled: forceLabeled})
+	}
+
+	if err != nil {
+		log.Printf("%s of %s/%s not reviewed: %v", name, owner, repoName, err)
+		if !errors.Is(err, ErrReviewSkipped) && !errors.Is(err, ErrWebhookIgnored) {
+			bot.recordSkip(owner, repoName, number, store.SkipReasonReviewFailed)
+			bot.reportError(errorKindReviewFailed, err, owner, repoName, number)
+		}
+	}
+	return err
+}
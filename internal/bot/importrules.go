@@ -0,0 +1,19 @@
+package bot
+
+import (
+	"log"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+)
+
+// checkImportRules checks the imports a change adds against the repository's import rules
+// and returns blocking comments on the violations. The check is deterministic, so it runs
+// apart from the AI and its comments aren't up to the self-critique.
+func checkImportRules(owner, repoName string, number int, diff string, repoConfig *config.RepositoryConfig) []review.ReviewComment {
+	violations := review.CheckImports(diff, repoConfig.ImportRules)
+	if len(violations) > 0 {
+		log.Printf("#%d in %s/%s adds %d imports its import rules forbid", number, owner, repoName, len(violations))
+	}
+	return review.ImportRuleComments(violations, repoConfig)
+}
@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cyclone/internal/jira"
+	"cyclone/internal/review"
+)
+
+// deferCommand, replied in the thread of a blocking review comment, files the finding in Jira
+// for later: "/cyclone defer [ISSUE-KEY] [reason]". With an issue key, the existing ticket is
+// linked instead of creating one.
+const deferCommand = followUpCommand + " defer"
+
+// deferredCategory is the category of review comments that can be deferred
+const deferredCategory = "blocking"
+
+// deferredMarker starts the note added to a deferred review comment
+const deferredMarker = "⏭️ **Deferred** to "
+
+// jiraProject returns the Jira project of a repository: its own or its organization's
+func (bot *CycloneBot) jiraProject(owner, repoName string) string {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.JiraProject != "" {
+		return repoConfig.JiraProject
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.JiraProject
+	}
+	return ""
+}
+
+// HandleDeferCommand files the blocking finding of a Cyclone review comment as a Jira ticket,
+// or links an existing one, and notes the ticket on the comment. Commands that can't be
+// carried out are answered in the thread and return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) HandleDeferCommand(repo *review.Repository, pr *review.PullRequest, comment *review.PullRequestComment) error {
+	ctx := context.Background()
+
+	owner := repo.Owner.Login
+	repoName := repo.Name
+	prNumber := pr.Number
+	rootID := comment.InReplyTo
+	user := comment.User.Login
+
+	root, err := bot.cycloneThreadRoot(ctx, owner, repoName, prNumber, rootID)
+	if err != nil {
+		return err
+	}
+
+	// Answers the command in the thread when it can't be carried out
+	refuse := func(reason string) error {
+		bot.replyInThread(ctx, owner, repoName, prNumber, rootID, reason)
+		return fmt.Errorf("%w: %s", ErrWebhookIgnored, reason)
+	}
+
+	if !bot.isCategory(owner, repoName, root.Body, deferredCategory) {
+		return refuse(fmt.Sprintf("Only %s findings can be deferred.", deferredCategory))
+	}
+	if strings.Contains(root.Body, deferredMarker) {
+		return refuse("This finding is already deferred.")
+	}
+
+	key, reason := parseDeferCommand(comment.Body)
+	project := bot.jiraProject(owner, repoName)
+	switch {
+	case key != "" && bot.jira != nil:
+		exists, err := bot.jira.IssueExists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return refuse(fmt.Sprintf("Jira issue %s doesn't exist or isn't visible to Cyclone.", key))
+		}
+	case key == "" && (bot.jira == nil || project == ""):
+		return refuse(fmt.Sprintf("Jira isn't set up for this repository - link an existing ticket with `%s ISSUE-KEY`.", deferCommand))
+	}
+
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not deferring review comment %d on PR #%d to Jira", rootID, prNumber)
+		return nil
+	}
+
+	if key == "" {
+		key, err = bot.jira.CreateIssue(ctx, jira.Issue{
+			Project:     project,
+			IssueType:   bot.config.Jira.IssueType,
+			Summary:     fmt.Sprintf("Deferred review finding in %s/%s#%d: %s", owner, repoName, prNumber, root.Path),
+			Description: deferredIssueDescription(owner, repoName, prNumber, root, user, reason),
+			Labels:      []string{"cyclone"},
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created Jira issue %s for review comment %d on PR #%d", key, rootID, prNumber)
+	}
+
+	ticket := key
+	if bot.jira != nil {
+		ticket = fmt.Sprintf("[%s](%s)", key, bot.jira.IssueURL(key))
+	}
+	note := fmt.Sprintf("%s\n\n---\n%s%s by @%s", root.Body, deferredMarker, ticket, user)
+	if reason != "" {
+		note += ": " + reason
+	}
+	if err := bot.githubClientFor(owner).UpdateReviewComment(ctx, owner, repoName, rootID, note); err != nil {
+		return err
+	}
+	bot.replyInThread(ctx, owner, repoName, prNumber, rootID, fmt.Sprintf("Deferred to %s.", ticket))
+
+	log.Printf("Deferred review comment %d on PR #%d to %s", rootID, prNumber, key)
+	return nil
+}
+
+// parseDeferCommand splits a defer command into the issue key to link, if given, and the reason
+func parseDeferCommand(body string) (string, string) {
+	args := commandArgs(body, deferCommand)
+	first, rest, _ := strings.Cut(args, " ")
+	if jira.IsIssueKey(first) {
+		return first, strings.TrimSpace(rest)
+	}
+	return "", args
+}
+
+// isCategory reports whether a review comment body starts with the prefix of a category
+func (bot *CycloneBot) isCategory(owner, repoName, body, category string) bool {
+	for _, c := range bot.repositoryConfig(owner, repoName).GetCategories() {
+		if strings.EqualFold(c.Name, category) && strings.HasPrefix(body, c.Prefix()) {
+			return true
+		}
+	}
+	return false
+}
+
+// deferredIssueDescription describes a deferred finding in Jira wiki markup
+func deferredIssueDescription(owner, repoName string, prNumber int, root *review.PullRequestComment, user, reason string) string {
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "Cyclone flagged a blocking finding in [%s/%s#%d|https://github.com/%s/%s/pull/%d] which @%s deferred",
+		owner, repoName, prNumber, owner, repoName, prNumber, user)
+	if reason != "" {
+		fmt.Fprintf(&desc, ": %s", reason)
+	}
+	fmt.Fprintf(&desc, "\n\n*File:* %s, line %d\n*Comment:* %s\n\n{quote}%s{quote}\n", root.Path, root.Line, root.HTMLURL, root.Body)
+	return desc.String()
+}
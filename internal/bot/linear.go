@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cyclone/internal/linear"
+	"cyclone/internal/review"
+)
+
+// trackCommand, replied in the thread of a review comment, files the finding as a Linear issue:
+// "/cyclone track [note]"
+const trackCommand = followUpCommand + " track"
+
+// trackedMarker starts the note added to a tracked review comment
+const trackedMarker = "📌 **Tracked** in "
+
+// maxContextLines bounds the lines of the diff hunk attached to a tracked finding
+const maxContextLines = 15
+
+// linearTeam returns the Linear team of a repository: its own or its organization's
+func (bot *CycloneBot) linearTeam(owner, repoName string) string {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.LinearTeam != "" {
+		return repoConfig.LinearTeam
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.LinearTeam
+	}
+	return ""
+}
+
+// HandleTrackCommand files the finding of a Cyclone review comment as a Linear issue, with the
+// code it refers to, and notes the issue on the comment. Commands that can't be carried out
+// are answered in the thread and return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) HandleTrackCommand(repo *review.Repository, pr *review.PullRequest, comment *review.PullRequestComment) error {
+	ctx := context.Background()
+
+	owner := repo.Owner.Login
+	repoName := repo.Name
+	prNumber := pr.Number
+	rootID := comment.InReplyTo
+	user := comment.User.Login
+
+	root, err := bot.cycloneThreadRoot(ctx, owner, repoName, prNumber, rootID)
+	if err != nil {
+		return err
+	}
+
+	// Answers the command in the thread when it can't be carried out
+	refuse := func(reason string) error {
+		bot.replyInThread(ctx, owner, repoName, prNumber, rootID, reason)
+		return fmt.Errorf("%w: %s", ErrWebhookIgnored, reason)
+	}
+
+	if strings.Contains(root.Body, trackedMarker) {
+		return refuse("This finding is already tracked.")
+	}
+	team := bot.linearTeam(owner, repoName)
+	if bot.linear == nil || team == "" {
+		return refuse("Linear isn't set up for this repository.")
+	}
+
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not tracking review comment %d on PR #%d in Linear", rootID, prNumber)
+		return nil
+	}
+
+	note := commandArgs(comment.Body, trackCommand)
+	issue, err := bot.linear.CreateIssue(ctx, linear.Issue{
+		Team:        team,
+		Title:       fmt.Sprintf("Review finding in %s/%s#%d: %s", owner, repoName, prNumber, findingSummary(root.Body)),
+		Description: trackedIssueDescription(owner, repoName, prNumber, root, user, note),
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Created Linear issue %s for review comment %d on PR #%d", issue.Identifier, rootID, prNumber)
+
+	link := fmt.Sprintf("[%s](%s)", issue.Identifier, issue.URL)
+	body := fmt.Sprintf("%s\n\n---\n%s%s by @%s", root.Body, trackedMarker, link, user)
+	if err := bot.githubClientFor(owner).UpdateReviewComment(ctx, owner, repoName, rootID, body); err != nil {
+		return err
+	}
+	bot.replyInThread(ctx, owner, repoName, prNumber, rootID, fmt.Sprintf("Tracked in %s.", link))
+	return nil
+}
+
+// findingSummary shortens a review comment to its first line, without the category prefix and
+// markdown emphasis, for an issue title
+func findingSummary(body string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	if _, rest, ok := strings.Cut(line, "**:"); ok {
+		line = rest
+	}
+	line = strings.TrimSpace(strings.ReplaceAll(line, "**", ""))
+	if runes := []rune(line); len(runes) > 80 {
+		line = strings.TrimSpace(string(runes[:80])) + "…"
+	}
+	return line
+}
+
+// trackedIssueDescription describes a tracked finding in markdown, with the end of the diff
+// hunk the comment refers to
+func trackedIssueDescription(owner, repoName string, prNumber int, root *review.PullRequestComment, user, note string) string {
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "Cyclone flagged this finding in [%s/%s#%d](https://github.com/%s/%s/pull/%d), tracked by @%s",
+		owner, repoName, prNumber, owner, repoName, prNumber, user)
+	if note != "" {
+		fmt.Fprintf(&desc, ": %s", note)
+	}
+	fmt.Fprintf(&desc, "\n\n**File:** `%s`, line %d ([comment](%s))\n\n%s\n", root.Path, root.Line, root.HTMLURL, quote(root.Body))
+
+	if hunk := root.DiffHunk; hunk != "" {
+		// The hunk ends at the commented line
+		lines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+		if len(lines) > maxContextLines {
+			lines = lines[len(lines)-maxContextLines:]
+		}
+		fmt.Fprintf(&desc, "\n```diff\n%s\n```\n", strings.Join(lines, "\n"))
+	}
+	return desc.String()
+}
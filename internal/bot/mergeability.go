@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cyclone/internal/notices"
+	"cyclone/internal/review"
+)
+
+// mergeabilityChecks is how often a PR is fetched while GitHub is still computing whether it
+// can be merged, which it starts on the first request after a push
+const mergeabilityChecks = 3
+
+// mergeabilityRetryDelay is the wait between those fetches
+const mergeabilityRetryDelay = 2 * time.Second
+
+// conflictNoticeMaxFiles bounds the files a conflict notice lists
+const conflictNoticeMaxFiles = 20
+
+// postConflictNotice posts a notice on a PR that conflicts with its base branch, listing the
+// files that likely conflict, so its author resolves them before reviewers look at it
+func (bot *CycloneBot) postConflictNotice(ctx context.Context, owner, repoName string, pr *review.PullRequest, language string, dryRun bool) {
+	client := bot.githubClientFor(owner)
+	for i := 0; pr.Mergeable == nil && i < mergeabilityChecks; i++ {
+		if i > 0 {
+			time.Sleep(mergeabilityRetryDelay)
+		}
+		fetched, err := client.GetPullRequest(ctx, owner, repoName, pr.Number)
+		if err != nil {
+			log.Printf("Error checking mergeability of PR #%d: %v", pr.Number, err)
+			return
+		}
+		pr = fetched
+	}
+	if pr.MergeableState != "dirty" {
+		return
+	}
+
+	files, err := client.ConflictingFiles(ctx, owner, repoName, pr)
+	if err != nil {
+		log.Printf("Error listing conflicting files of PR #%d - posting the notice without them: %v", pr.Number, err)
+	}
+	var list strings.Builder
+	for i, file := range files {
+		if i == conflictNoticeMaxFiles {
+			fmt.Fprintf(&list, "\n- ... %d more", len(files)-i)
+			break
+		}
+		fmt.Fprintf(&list, "\n- `%s`", file)
+	}
+
+	notice := notices.Render(language, "merge_conflict", notices.Data{"Base": pr.Base.Ref, "Files": strings.TrimPrefix(list.String(), "\n")})
+	log.Printf("PR #%d conflicts with %s in %d likely files - posting conflict notice", pr.Number, pr.Base.Ref, len(files))
+	if dryRun {
+		log.Printf("Dry run - not posting conflict notice for PR #%d", pr.Number)
+	} else if err := client.PostComment(ctx, owner, repoName, pr.Number, notice); err != nil {
+		log.Printf("Error posting conflict notice: %v", err)
+	}
+}
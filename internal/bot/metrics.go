@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"cyclone/internal/store"
+)
+
+// usageSeries identifies a Prometheus series of the usage counters
+type usageSeries struct {
+	org, repo, model, kind string
+}
+
+// usageCounters are the usage ledger's totals of a series
+type usageCounters struct {
+	calls                     int
+	inputTokens, outputTokens int
+	costUSD                   float64
+}
+
+// handleMetrics serves GET /metrics, the token usage as Prometheus counters labeled by org,
+// repo, model and kind of AI call, and the durations of review stages. Both are derived from
+// the stored history, so they survive restarts.
+func (bot *CycloneBot) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	totals := make(map[usageSeries]*usageCounters)
+	for _, rec := range bot.store.ListUsage(store.UsageFilter{}) {
+		series := usageSeries{org: rec.Org, repo: rec.Repo, model: rec.Model, kind: rec.Kind}
+		counters := totals[series]
+		if counters == nil {
+			counters = &usageCounters{}
+			totals[series] = counters
+		}
+		counters.calls++
+		counters.inputTokens += rec.InputTokens
+		counters.outputTokens += rec.OutputTokens
+		counters.costUSD += rec.CostUSD
+	}
+
+	series := make([]usageSeries, 0, len(totals))
+	for s := range totals {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.org != b.org {
+			return a.org < b.org
+		}
+		if a.repo != b.repo {
+			return a.repo < b.repo
+		}
+		if a.model != b.model {
+			return a.model < b.model
+		}
+		return a.kind < b.kind
+	})
+
+	metrics := []struct {
+		name, help string
+		value      func(*usageCounters) string
+	}{
+		{"cyclone_prompt_tokens_total", "Input tokens sent to Claude.", func(c *usageCounters) string { return fmt.Sprint(c.inputTokens) }},
+		{"cyclone_completion_tokens_total", "Output tokens generated by Claude.", func(c *usageCounters) string { return fmt.Sprint(c.outputTokens) }},
+		{"cyclone_ai_calls_total", "Calls to the Claude API.", func(c *usageCounters) string { return fmt.Sprint(c.calls) }},
+		{"cyclone_cost_usd_total", "Estimated cost of the Claude API calls in USD.", func(c *usageCounters) string { return fmt.Sprintf("%g", c.costUSD) }},
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, metric := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name)
+		for _, s := range series {
+			fmt.Fprintf(w, "%s{org=%s,repo=%s,model=%s,kind=%s} %s\n", metric.name,
+				metricLabel(s.org), metricLabel(s.repo), metricLabel(s.model), metricLabel(s.kind), metric.value(totals[s]))
+		}
+	}
+
+	writeStageHistogram(w, bot.store.ListReviews(store.UsageFilter{}))
+}
+
+// stageBuckets are the upper bounds in seconds of the review stage histogram's buckets
+var stageBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// writeStageHistogram writes how long the stages of recorded reviews took as a Prometheus histogram
+func writeStageHistogram(w io.Writer, records []store.ReviewRecord) {
+	const name = "cyclone_review_stage_seconds"
+	fmt.Fprintf(w, "# HELP %s Duration of review stages.\n# TYPE %s histogram\n", name, name)
+
+	for _, stage := range store.Stages {
+		buckets := make([]int, len(stageBuckets))
+		count, sum := 0, 0.0
+		for _, rec := range records {
+			ms, ok := rec.StageMs[stage]
+			if !ok {
+				continue
+			}
+			seconds := float64(ms) / 1000
+			count++
+			sum += seconds
+			for i, bound := range stageBuckets {
+				if seconds <= bound {
+					buckets[i]++
+				}
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		label := metricLabel(stage)
+		for i, bound := range stageBuckets {
+			fmt.Fprintf(w, "%s_bucket{stage=%s,le=\"%g\"} %d\n", name, label, bound, buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{stage=%s,le=\"+Inf\"} %d\n", name, label, count)
+		fmt.Fprintf(w, "%s_sum{stage=%s} %g\n", name, label, sum)
+		fmt.Fprintf(w, "%s_count{stage=%s} %d\n", name, label, count)
+	}
+}
+
+// metricLabelEscaper escapes label values for the Prometheus text format
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricLabel quotes a label value
+func metricLabel(value string) string {
+	return `"` + metricLabelEscaper.Replace(value) + `"`
+}
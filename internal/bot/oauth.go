@@ -0,0 +1,254 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// Cookies of the GitHub sign-in
+const (
+	sessionCookie    = "cyclone_session"
+	oauthStateCookie = "cyclone_oauth_state"
+)
+
+// oauthLogin signs members of the allowed organizations and teams in with GitHub and keeps
+// them signed in with an HMAC-signed session cookie, so no session state is stored
+type oauthLogin struct {
+	config       *oauth2.Config
+	secret       []byte
+	allowedOrgs  []string
+	allowedTeams []string
+	secure       bool // Whether cookies are only sent over HTTPS
+}
+
+// newOAuthLogin sets up the GitHub sign-in, generating a session secret if none is configured
+func newOAuthLogin(cfg *config.OAuthConfig) (*oauthLogin, error) {
+	secret := []byte(cfg.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate session secret: %w", err)
+		}
+	}
+
+	return &oauthLogin{
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     github.Endpoint,
+			// read:org lets the membership checks see private memberships
+			Scopes: []string{"read:user", "read:org"},
+		},
+		secret:       secret,
+		allowedOrgs:  cfg.AllowedOrgs,
+		allowedTeams: cfg.AllowedTeams,
+		secure:       strings.HasPrefix(cfg.RedirectURL, "https://"),
+	}, nil
+}
+
+// sign returns the HMAC of a session cookie's payload
+func (o *oauthLogin) sign(payload string) string {
+	mac := hmac.New(sha256.New, o.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sessionUser returns the GitHub login of the request's session, or "" if it has no valid one
+func (o *oauthLogin) sessionUser(r *http.Request) string {
+	login, _ := o.session(r)
+	return login
+}
+
+// session returns the GitHub login of the request's session and the organizations whose data
+// it may see, or "" if it has no valid session
+func (o *oauthLogin) session(r *http.Request) (string, []string) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", nil
+	}
+
+	// Logins and organization names can't contain dots or commas, so the value is
+	// "login.org1,org2.expiry.signature"
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 4 {
+		return "", nil
+	}
+	login, orgs, expiry, signature := parts[0], parts[1], parts[2], parts[3]
+	if !hmac.Equal([]byte(signature), []byte(o.sign(login+"."+orgs+"."+expiry))) {
+		return "", nil
+	}
+
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return "", nil
+	}
+	tenants := []string{}
+	if orgs != "" {
+		tenants = strings.Split(orgs, ",")
+	}
+	return login, tenants
+}
+
+// startSession sets the session cookie for a signed-in user, who may see the data of the given
+// organizations
+func (o *oauthLogin) startSession(w http.ResponseWriter, login string, orgs []string) {
+	expires := time.Now().Add(config.SESSION_DURATION)
+	payload := fmt.Sprintf("%s.%s.%d", login, strings.Join(orgs, ","), expires.Unix())
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    payload + "." + o.sign(payload),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   o.secure,
+		// Lax keeps the cookie off cross-site POST and PUT requests, which protects the admin API
+		// against cross-site request forgery
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// memberships returns the allowed organizations a user is a member of, directly or through an
+// allowed team. Signed-in users only see the data of these organizations.
+func (o *oauthLogin) memberships(ctx context.Context, client *review.GitHubClient, login string) ([]string, error) {
+	var orgs []string
+	seen := make(map[string]bool)
+	add := func(org string) {
+		if !seen[strings.ToLower(org)] {
+			seen[strings.ToLower(org)] = true
+			orgs = append(orgs, org)
+		}
+	}
+
+	for _, org := range o.allowedOrgs {
+		member, err := client.IsOrgMember(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			add(org)
+		}
+	}
+
+	for _, team := range o.allowedTeams {
+		org, slug, _ := strings.Cut(team, "/")
+		if seen[strings.ToLower(org)] {
+			continue
+		}
+		member, err := client.IsTeamMember(ctx, org, slug, login)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			add(org)
+		}
+	}
+
+	return orgs, nil
+}
+
+// handleLogin serves GET /auth/login, redirecting to GitHub to sign in
+func (bot *CycloneBot) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    hex.EncodeToString(state),
+		Path:     "/auth",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   bot.oauth.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, bot.oauth.config.AuthCodeURL(hex.EncodeToString(state)), http.StatusFound)
+}
+
+// handleAuthCallback serves GET /auth/callback, where GitHub redirects to after signing in.
+// Only members of the allowed organizations and teams get a session.
+func (bot *CycloneBot) handleAuthCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err := r.Cookie(oauthStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(state.Value), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, "Invalid sign-in state, please sign in again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: "/auth", MaxAge: -1})
+
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		http.Error(w, fmt.Sprintf("GitHub sign-in failed: %s", reason), http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	token, err := bot.oauth.config.Exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		log.Printf("GitHub sign-in failed: %v", err)
+		http.Error(w, "GitHub sign-in failed", http.StatusUnauthorized)
+		return
+	}
+
+	client, err := review.NewGitHubClient(token.AccessToken)
+	if err != nil {
+		http.Error(w, "GitHub sign-in failed", http.StatusInternalServerError)
+		return
+	}
+	login, err := client.AuthenticatedUser(ctx)
+	if err != nil {
+		log.Printf("GitHub sign-in failed: %v", err)
+		http.Error(w, "GitHub sign-in failed", http.StatusBadGateway)
+		return
+	}
+
+	orgs, err := bot.oauth.memberships(ctx, client, login)
+	if err != nil {
+		log.Printf("Error checking memberships of %s: %v", login, err)
+		http.Error(w, "Failed to check your organization memberships", http.StatusBadGateway)
+		return
+	}
+	if len(orgs) == 0 {
+		log.Printf("Denied sign-in of %s, who isn't a member of an allowed organization or team", login)
+		http.Error(w, fmt.Sprintf("%s is not a member of an organization or team allowed to use Cyclone", login), http.StatusForbidden)
+		return
+	}
+
+	log.Printf("%s signed in with access to %s", login, strings.Join(orgs, ", "))
+	bot.oauth.startSession(w, login, orgs)
+	http.Redirect(w, r, "/dashboard", http.StatusFound)
+}
+
+// handleLogout serves GET /auth/logout, ending the session
+func (bot *CycloneBot) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
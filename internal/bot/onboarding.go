@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// OnboardRepositories registers repositories added to a GitHub App installation with the
+// default settings and opens a welcome issue in each, explaining how to configure Cyclone.
+// Repositories that already have an entry, also through a wildcard or pattern, or that are
+// excluded are left alone; if none remain, it returns an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) OnboardRepositories(owner string, repoNames []string, actor string) error {
+	registered, err := bot.registerRepositories(owner, repoNames, actor)
+	if err != nil {
+		return err
+	}
+	if len(registered) == 0 {
+		return fmt.Errorf("%w: the repositories added to %s are already configured", ErrWebhookIgnored, owner)
+	}
+
+	ctx := context.Background()
+	client := bot.githubClientFor(owner)
+	for _, repoName := range registered {
+		repoConfig := bot.repositoryConfig(owner, repoName)
+		title := notices.Render(repoConfig.Language, "welcome_title", nil)
+		body := notices.Render(repoConfig.Language, "welcome", notices.Data{
+			"Precision":  repoConfig.Precision,
+			"ConfigFile": config.REPO_CONFIG_FILE,
+			"Label":      config.FORCE_REVIEW_LABEL,
+		})
+
+		if bot.currentReviewConfig().IsDryRun(repoConfig) {
+			log.Printf("Dry run - not opening welcome issue in %s/%s", owner, repoName)
+			continue
+		}
+		url, err := client.CreateIssue(ctx, owner, repoName, title, body)
+		if err != nil {
+			// Repositories may have issues disabled; the registration stands either way
+			log.Printf("Error opening welcome issue in %s/%s: %v", owner, repoName, err)
+			continue
+		}
+		log.Printf("Opened welcome issue in %s/%s: %s", owner, repoName, url)
+	}
+	return nil
+}
+
+// registerRepositories adds default entries for the repositories without one to the
+// organization and persists it as managed, like a change through the admin API would. It
+// returns the names of the registered repositories.
+func (bot *CycloneBot) registerRepositories(owner string, repoNames []string, actor string) ([]string, error) {
+	bot.configMu.Lock()
+	defer bot.configMu.Unlock()
+
+	org := config.OrganizationConfig{Name: owner}
+	current := bot.reviewConfig.GetOrganizationConfig(owner)
+	if current != nil {
+		if current.GetProvider() != config.ProviderGitHub {
+			return nil, fmt.Errorf("%w: organization %s isn't on GitHub", ErrWebhookIgnored, owner)
+		}
+		org = *current
+		org.Repositories = append([]config.RepositoryConfig(nil), current.Repositories...)
+	}
+
+	var registered []string
+	for _, repoName := range repoNames {
+		if bot.reviewConfig.GetRepositoryConfig(owner, repoName) != nil || bot.reviewConfig.IsExcluded(owner, repoName) {
+			continue
+		}
+		org.Repositories = append(org.Repositories, *config.DefaultRepositoryConfig(repoName))
+		registered = append(registered, repoName)
+	}
+	if len(registered) == 0 {
+		return nil, nil
+	}
+
+	updated := bot.reviewConfig.WithManagedOrganizations([]config.OrganizationConfig{org})
+	if err := updated.Validate(); err != nil {
+		return nil, fmt.Errorf("registering repositories of %s makes the configuration invalid: %w", owner, err)
+	}
+	if err := bot.store.SaveManagedOrganization(org); err != nil {
+		return nil, fmt.Errorf("failed to save organization %s: %w", owner, err)
+	}
+
+	bot.reviewConfig = updated
+	log.Printf("Registered %d repositories added to the installation of %s: %v", len(registered), owner, registered)
+	repoName := ""
+	if len(registered) == 1 {
+		repoName = registered[0]
+	}
+	bot.recordAudit(store.AuditSourceInstallation, actor, owner, repoName, "register repositories", auditOrganization(current), auditOrganization(&org))
+	return registered, nil
+}
+
+// installationRepositoryNames returns the names of an installation payload's repositories
+func installationRepositoryNames(repos []*review.Repository) []string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if repo != nil && repo.Name != "" {
+			names = append(names, repo.Name)
+		}
+	}
+	return names
+}
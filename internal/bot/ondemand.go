@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+
+	"cyclone/internal/review"
+)
+
+// ReviewPullRequest reviews a PR on demand, e.g. from "cyclone review owner/repo#123", and
+// returns the review. With post the review is posted (in dry run: logged) and recorded like a
+// webhook-triggered one; otherwise GitHub is only read from. On-demand reviews never go through
+// the Message Batches queue, as nothing would be left running to post the results.
+func (bot *CycloneBot) ReviewPullRequest(ctx context.Context, owner, repoName string, prNumber int, post bool) (review.ReviewResult, error) {
+	pr, err := bot.githubClientFor(owner).GetPullRequest(ctx, owner, repoName, prNumber)
+	if err != nil {
+		return review.ReviewResult{}, err
+	}
+
+	return bot.reviewPullRequest(ctx, pr.Base.Repo, pr, reviewOptions{post: post})
+}
+
+// PreviewReviewRequest returns the request a review of a PR would send to the AI and its
+// input tokens, without sending it. Nothing is posted or recorded.
+func (bot *CycloneBot) PreviewReviewRequest(ctx context.Context, owner, repoName string, prNumber int) (review.ClaudeRequest, int, error) {
+	prepared, err := bot.prepareOnDemand(ctx, owner, repoName, prNumber)
+	if err != nil {
+		return review.ClaudeRequest{}, 0, err
+	}
+
+	reqBody, tokens := prepared.aiClient.PreviewReviewRequest(prepared.diff, prepared.pr.Title, prepared.pr.Body, prepared.repoConfig)
+	return reqBody, tokens, nil
+}
+
+// prepareOnDemand fetches a PR and prepares its review without posting anything
+func (bot *CycloneBot) prepareOnDemand(ctx context.Context, owner, repoName string, prNumber int) (*preparedReview, error) {
+	pr, err := bot.githubClientFor(owner).GetPullRequest(ctx, owner, repoName, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	return bot.preparePullRequestReview(ctx, pr.Base.Repo, pr, reviewOptions{preview: true})
+}
@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/oncall"
+)
+
+// readinessTimeout bounds all readiness checks together
+const readinessTimeout = 10 * time.Second
+
+// Incidents on-call is paged about
+const (
+	incidentNotReady  = "not-ready"
+	incidentErrorRate = "error-rate"
+)
+
+// ReadinessResponse is the JSON body returned by GET /ready
+type ReadinessResponse struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"` // "ok" or the error, by check
+}
+
+// newPagers returns the on-call services configured to be paged about outages
+func newPagers(cfg *config.Config) []oncall.Pager {
+	var pagers []oncall.Pager
+	if cfg.PagerDutyRoutingKey != "" {
+		pagers = append(pagers, oncall.NewPagerDuty(cfg.PagerDutyRoutingKey))
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		pagers = append(pagers, oncall.NewOpsgenie(cfg.OpsgenieAPIKey))
+	}
+	return pagers
+}
+
+// checkReadiness checks what reviews depend on: a writable data directory and a reachable
+// GitHub API. It returns the failed checks by name.
+func (bot *CycloneBot) checkReadiness(ctx context.Context) map[string]error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	failed := make(map[string]error)
+	if err := bot.store.CheckWritable(); err != nil {
+		failed["store"] = err
+	}
+	if err := bot.githubClient.Ping(ctx); err != nil {
+		failed["github"] = err
+	}
+	return failed
+}
+
+// handleReady serves GET /ready, which fails with 503 while Cyclone can't review PRs - for
+// load balancers and orchestrators rather than people, who have /health and /stats
+func (bot *CycloneBot) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	failed := bot.checkReadiness(r.Context())
+	resp := ReadinessResponse{Ready: len(failed) == 0, Checks: map[string]string{"store": "ok", "github": "ok"}}
+	for name, err := range failed {
+		resp.Checks[name] = err.Error()
+	}
+
+	status := http.StatusOK
+	if !resp.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// MonitorOutages pages on-call through PagerDuty or Opsgenie when readiness checks keep failing
+// or the review error rate crosses PAGE_ERROR_RATE, and resolves the incident once things
+// recover. Without an on-call service configured it returns right away; otherwise it never
+// returns.
+func (bot *CycloneBot) MonitorOutages() {
+	pagers := newPagers(bot.config)
+	if len(pagers) == 0 {
+		return
+	}
+
+	monitor := &outageMonitor{bot: bot, pagers: pagers, open: make(map[string]bool)}
+	ticker := time.NewTicker(config.OUTAGE_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		monitor.check(time.Now())
+		<-ticker.C
+	}
+}
+
+// outageMonitor tracks the incidents on-call was paged about
+type outageMonitor struct {
+	bot    *CycloneBot
+	pagers []oncall.Pager
+
+	failedChecks int             // Consecutive failed readiness checks
+	open         map[string]bool // Incidents triggered and not resolved yet
+}
+
+// check runs the readiness checks and computes the error rate, triggering or resolving incidents
+func (m *outageMonitor) check(now time.Time) {
+	failed := m.bot.checkReadiness(context.Background())
+	if len(failed) > 0 {
+		m.failedChecks++
+	} else {
+		m.failedChecks = 0
+	}
+	if m.failedChecks >= config.OUTAGE_CHECK_FAILURES {
+		details := make(map[string]string, len(failed))
+		names := make([]string, 0, len(failed))
+		for name, err := range failed {
+			details[name] = err.Error()
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		m.trigger(incidentNotReady, fmt.Sprintf("Cyclone is not ready (%s) for %d checks in a row",
+			strings.Join(names, ", "), m.failedChecks), details)
+	} else if len(failed) == 0 {
+		m.resolve(incidentNotReady)
+	}
+
+	stats := m.bot.statsWindow(config.ERROR_RATE_WINDOW.String(), now.Add(-config.ERROR_RATE_WINDOW))
+	attempts := stats.Reviews + stats.Errors
+	if attempts >= config.ERROR_RATE_MIN_ATTEMPTS && stats.ErrorRate >= m.bot.config.PageErrorRate {
+		m.trigger(incidentErrorRate, fmt.Sprintf("Cyclone reviews are failing: %d of %d in the last %d minutes",
+			stats.Errors, attempts, int(config.ERROR_RATE_WINDOW.Minutes())), map[string]string{
+			"error_rate": fmt.Sprintf("%.2f", stats.ErrorRate),
+			"threshold":  fmt.Sprintf("%.2f", m.bot.config.PageErrorRate),
+			"skips":      formatCounts(stats.Skips),
+		})
+	} else if stats.ErrorRate < m.bot.config.PageErrorRate {
+		m.resolve(incidentErrorRate)
+	}
+}
+
+// trigger pages on-call about an incident unless it is open already. Services that couldn't be
+// reached are tried again at the next check.
+func (m *outageMonitor) trigger(incident, summary string, details map[string]string) {
+	if m.open[incident] {
+		return
+	}
+
+	ok := true
+	for _, pager := range m.pagers {
+		if err := pager.Trigger(context.Background(), m.incidentKey(incident), summary, details); err != nil {
+			log.Printf("Error paging %s about %s incident: %v", pager.Name(), incident, err)
+			ok = false
+		}
+	}
+	if ok {
+		m.open[incident] = true
+		log.Printf("Paged on-call: %s", summary)
+	}
+}
+
+// resolve closes an open incident
+func (m *outageMonitor) resolve(incident string) {
+	if !m.open[incident] {
+		return
+	}
+
+	ok := true
+	for _, pager := range m.pagers {
+		if err := pager.Resolve(context.Background(), m.incidentKey(incident)); err != nil {
+			log.Printf("Error resolving %s incident in %s: %v", incident, pager.Name(), err)
+			ok = false
+		}
+	}
+	if ok {
+		delete(m.open, incident)
+		log.Printf("Resolved %s incident", incident)
+	}
+}
+
+// incidentKey identifies an incident across processes, and deployments in different
+// CYCLONE_ENVs, so several workers don't page twice
+func (m *outageMonitor) incidentKey(incident string) string {
+	if env := m.bot.config.Env; env != "" {
+		return fmt.Sprintf("cyclone-%s-%s", env, incident)
+	}
+	return "cyclone-" + incident
+}
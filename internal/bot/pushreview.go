@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// branchRefPrefix is the prefix of the refs of branches in push events
+const branchRefPrefix = "refs/heads/"
+
+// pushReviewConfig returns a repository's push review setting: its own or its organization's,
+// nil if it has none
+func (bot *CycloneBot) pushReviewConfig(owner, repoName string) *config.PushReviewConfig {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.PushReview != nil {
+		return repoConfig.PushReview
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.PushReview
+	}
+	return nil
+}
+
+// ReviewPush reviews the commits pushed directly to a protected branch, e.g. a hotfix, and
+// posts the findings as a comment on the pushed head commit. Pushes of merged pull requests
+// were reviewed there and return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) ReviewPush(repo *review.Repository, branch, before, after, message string) error {
+	ctx := context.Background()
+	owner, repoName := repo.Owner.Login, repo.Name
+	name := fmt.Sprintf("push to %s", branch)
+	client := bot.githubClientFor(owner)
+
+	if bot.currentReviewConfig().IsExcluded(owner, repoName) {
+		return fmt.Errorf("%w: repository %s/%s is excluded from reviews", ErrReviewSkipped, owner, repoName)
+	}
+
+	prs, err := client.PullRequestsWithCommit(ctx, owner, repoName, after)
+	if err != nil {
+		return err
+	}
+	for _, pr := range prs {
+		// The list endpoint leaves out the merged flag
+		if !pr.MergedAt.IsZero() {
+			return fmt.Errorf("%w: commit %s was merged with PR #%d", ErrWebhookIgnored, shortSHA(after), pr.Number)
+		}
+	}
+
+	repoConfig := bot.applyRepoConfigFile(ctx, owner, repoName, bot.repositoryConfig(owner, repoName))
+	repoConfig = bot.currentReviewConfig().WithPrecisionProfile(repoConfig)
+	dryRun := bot.currentReviewConfig().IsDryRun(repoConfig)
+
+	comparison, err := client.CompareCommits(ctx, owner, repoName, before, after)
+	if err != nil {
+		return err
+	}
+	additions, deletions := 0, 0
+	for _, file := range comparison.Files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+	if sizeCheck := checkChangeSize(len(comparison.Files), additions, deletions, repoConfig.Language); !sizeCheck.ShouldReview {
+		return fmt.Errorf("%w: %s is too large (%s)", ErrReviewSkipped, name, sizeCheck.SkipReason)
+	}
+	if quota := bot.checkQuota(owner, repoName, repoConfig); quota.Exceeded {
+		return fmt.Errorf("%w: monthly quota of the %s is exhausted", ErrReviewSkipped, quota.Scope)
+	}
+
+	diff := review.FormatDiff(comparison.Files, name, repoConfig.IgnorePaths)
+	if diff == "" {
+		return fmt.Errorf("%w: %s has no reviewable changes", ErrReviewSkipped, name)
+	}
+
+	aiClient := bot.aiClientFor(owner)
+	repoConfig = bot.applyStyleGuides(ctx, aiClient, owner, repoName, 0, repoConfig, false)
+
+	title, _, _ := strings.Cut(message, "\n")
+	result := aiClient.GenerateReview(diff, title, message, repoConfig)
+	bot.recordUsage(owner, repoName, 0, store.UsageKindReview, result.Usage)
+	if result.Err != nil {
+		bot.reportError(errorKindGenerationFailed, result.Err, owner, repoName, 0)
+		return fmt.Errorf("failed to review %s: %w", name, result.Err)
+	}
+
+	comment := pushReviewComment(result, before, after)
+	if dryRun {
+		log.Printf("Dry run - not commenting the review of the %s on %s in %s/%s:\n%s", name, shortSHA(after), owner, repoName, comment)
+		return nil
+	}
+	if err := client.PostCommitComment(ctx, owner, repoName, after, comment); err != nil {
+		return err
+	}
+
+	log.Printf("Posted review of the %s (%s...%s) in %s/%s with %d findings",
+		name, shortSHA(before), shortSHA(after), owner, repoName, len(result.Comments))
+	return nil
+}
+
+// pushReviewComment renders the commit comment of a push review: the summary followed by
+// the line comments, which commit comments can't carry individually
+func pushReviewComment(result review.ReviewResult, before, after string) string {
+	var comment strings.Builder
+	comment.WriteString(strings.TrimSpace(result.Summary))
+	if len(result.Comments) > 0 {
+		comment.WriteString("\n\n### Findings\n")
+		for _, finding := range result.Comments {
+			fmt.Fprintf(&comment, "\n- `%s:%d`: %s", finding.Path, finding.Line, strings.TrimSpace(finding.Body))
+		}
+	}
+	fmt.Fprintf(&comment, "\n\n---\n_Review by Cyclone of the commits pushed directly (%s...%s), which bypassed pull request review._",
+		shortSHA(before), shortSHA(after))
+	return comment.String()
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
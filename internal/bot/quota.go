@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+	"cyclone/internal/store"
+)
+
+// summaryOnlyPrompt is appended to the custom prompt for downgraded reviews
+const summaryOnlyPrompt = `
+**Quota mode:** Only provide the SUMMARY and POEM sections. Do NOT write any PR_COMMENT entries.`
+
+// quotaCheck describes whether a review is within its usage quota
+type quotaCheck struct {
+	Exceeded bool
+	Action   config.QuotaAction
+	Quota    *config.QuotaConfig
+	Scope    string // "organization" or "repository"
+	ResetsAt time.Time
+}
+
+// checkQuota evaluates the repository and organization quotas for the current month
+func (bot *CycloneBot) checkQuota(owner, repoName string, repoConfig *config.RepositoryConfig) quotaCheck {
+	return bot.checkQuotaPending(owner, repoName, repoConfig, store.UsageTotals{})
+}
+
+// checkQuotaPending is checkQuota counting pending usage of the repository that isn't recorded
+// yet, such as that of reviews awaiting their batch's results
+func (bot *CycloneBot) checkQuotaPending(owner, repoName string, repoConfig *config.RepositoryConfig, pending store.UsageTotals) quotaCheck {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	resetsAt := monthStart.AddDate(0, 1, 0)
+
+	if repoConfig.Quota != nil {
+		filter := store.UsageFilter{Org: owner, Repo: repoName, Since: monthStart}
+		if quotaExceeded(repoConfig.Quota, withPendingUsage(bot.store.SumUsage(filter, store.GroupByNone), pending)) {
+			return quotaCheck{Exceeded: true, Action: repoConfig.Quota.GetAction(), Quota: repoConfig.Quota, Scope: "repository", ResetsAt: resetsAt}
+		}
+	}
+
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil && orgConfig.Quota != nil {
+		filter := store.UsageFilter{Org: owner, Since: monthStart}
+		if quotaExceeded(orgConfig.Quota, withPendingUsage(bot.store.SumUsage(filter, store.GroupByNone), pending)) {
+			return quotaCheck{Exceeded: true, Action: orgConfig.Quota.GetAction(), Quota: orgConfig.Quota, Scope: "organization", ResetsAt: resetsAt}
+		}
+	}
+
+	return quotaCheck{}
+}
+
+// withPendingUsage adds pending usage to the single total of summed usage
+func withPendingUsage(totals []store.UsageTotals, pending store.UsageTotals) []store.UsageTotals {
+	if len(totals) == 0 {
+		return []store.UsageTotals{pending}
+	}
+	total := totals[0]
+	total.InputTokens += pending.InputTokens
+	total.OutputTokens += pending.OutputTokens
+	total.CostUSD += pending.CostUSD
+	return []store.UsageTotals{total}
+}
+
+// quotaExceeded reports whether the summed usage reached any of the quota's limits
+func quotaExceeded(quota *config.QuotaConfig, totals []store.UsageTotals) bool {
+	if len(totals) == 0 {
+		return false
+	}
+
+	used := totals[0]
+	if quota.MonthlyTokens > 0 && used.InputTokens+used.OutputTokens >= quota.MonthlyTokens {
+		return true
+	}
+	if quota.MonthlyCostUSD > 0 && used.CostUSD >= quota.MonthlyCostUSD {
+		return true
+	}
+	return false
+}
+
+// downgradeForQuota returns a repository config that asks for a summary-only review
+func downgradeForQuota(repoConfig *config.RepositoryConfig) *config.RepositoryConfig {
+	downgraded := *repoConfig
+	downgraded.CustomPrompt += summaryOnlyPrompt
+	downgraded.ExtendedThinking = false
+	return &downgraded
+}
+
+// quotaSkipMessage builds the notice posted when a review is skipped because of a quota
+func quotaSkipMessage(check quotaCheck, language string) string {
+	return notices.Render(language, "quota_skip", quotaNoticeData(check, language))
+}
+
+// quotaDowngradeWarning is prepended to summary-only reviews
+func quotaDowngradeWarning(check quotaCheck, language string) string {
+	return notices.Render(language, "quota_downgrade_warning", quotaNoticeData(check, language)) + noticeSeparator
+}
+
+// quotaNoticeData localizes the scope and reset date of an exhausted quota
+func quotaNoticeData(check quotaCheck, language string) notices.Data {
+	return notices.Data{
+		"Scope":    notices.Render(language, "scope_"+check.Scope, nil),
+		"ResetsAt": check.ResetsAt.Format(notices.Render(language, "date_format", nil)),
+	}
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// releaseNotesInstructions is the system prompt of release notes; %s lists the categories
+const releaseNotesInstructions = `You draft the release notes of a code repository from the pull requests merged since the previous release and the summaries of Cyclone's code reviews of them.
+
+Write concise markdown for the repository's users. Use a "### <category>" heading for each of these categories that has entries, in this order: %s. Put every PR under exactly one category, as a bullet with a one-line, user-facing description ending in its number, e.g. "- Retry failed uploads (#123)". Group closely related PRs into one bullet. Leave out PRs without any user-facing effect, e.g. CI or test-only changes, unless no other PRs remain. Only state what the data supports. Don't add an introduction or a closing remark.`
+
+// releaseNotesMaxPRs bounds the PRs whose review summaries are sent to the AI
+const releaseNotesMaxPRs = 200
+
+// tagRefPrefix is the prefix of the refs of tags in push events
+const tagRefPrefix = "refs/tags/"
+
+// releaseNotesConfig returns a repository's release notes setting: its own or its
+// organization's, nil if it has none
+func (bot *CycloneBot) releaseNotesConfig(owner, repoName string) *config.ReleaseNotesConfig {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.ReleaseNotes != nil {
+		return repoConfig.ReleaseNotes
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.ReleaseNotes
+	}
+	return nil
+}
+
+// DraftReleaseNotes writes release notes into the body of a newly created release. Releases
+// that already have notes return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) DraftReleaseNotes(repo *review.Repository, release *review.Release) error {
+	ctx := context.Background()
+	owner, repoName := repo.Owner.Login, repo.Name
+	if strings.TrimSpace(release.Body) != "" {
+		return fmt.Errorf("%w: release %s already has notes", ErrWebhookIgnored, release.TagName)
+	}
+
+	base := release.TargetCommitish
+	if base == "" || commitSHA.MatchString(base) {
+		base = repo.DefaultBranch
+	}
+	until := release.PublishedAt
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	notes, err := bot.writeReleaseNotes(ctx, owner, repoName, base, until)
+	if err != nil {
+		return err
+	}
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not writing release notes of %s in %s/%s:\n%s", release.TagName, owner, repoName, notes)
+		return nil
+	}
+
+	if err := bot.githubClientFor(owner).UpdateReleaseBody(ctx, owner, repoName, release.ID, notes); err != nil {
+		return err
+	}
+	log.Printf("Wrote release notes of %s in %s/%s", release.TagName, owner, repoName)
+	return nil
+}
+
+// DraftTagRelease creates a draft release with release notes for a pushed tag. Tags that
+// already have a release return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) DraftTagRelease(repo *review.Repository, tag string) error {
+	ctx := context.Background()
+	owner, repoName := repo.Owner.Login, repo.Name
+	client := bot.githubClientFor(owner)
+
+	existing, err := client.GetReleaseByTag(ctx, owner, repoName, tag)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: tag %s already has a release", ErrWebhookIgnored, tag)
+	}
+
+	notes, err := bot.writeReleaseNotes(ctx, owner, repoName, repo.DefaultBranch, time.Now())
+	if err != nil {
+		return err
+	}
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not creating a draft release of %s in %s/%s:\n%s", tag, owner, repoName, notes)
+		return nil
+	}
+
+	release, err := client.CreateDraftRelease(ctx, owner, repoName, tag, notes)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created draft release of %s in %s/%s: %s", tag, owner, repoName, release.HTMLURL)
+	return nil
+}
+
+// writeReleaseNotes has the AI draft release notes from the PRs merged into base between the
+// previous release and until, and the summaries of Cyclone's reviews of them. Cycles without
+// merged PRs return an error wrapping ErrWebhookIgnored.
+func (bot *CycloneBot) writeReleaseNotes(ctx context.Context, owner, repoName, base string, until time.Time) (string, error) {
+	notes := bot.releaseNotesConfig(owner, repoName)
+	if notes == nil {
+		return "", fmt.Errorf("%w: release notes aren't configured for %s/%s", ErrWebhookIgnored, owner, repoName)
+	}
+
+	client := bot.githubClientFor(owner)
+	previous, err := client.PreviousRelease(ctx, owner, repoName, until)
+	if err != nil {
+		return "", err
+	}
+	var since time.Time
+	if previous != nil {
+		since = previous.PublishedAt
+	}
+	merged, err := client.ListMergedPullRequests(ctx, owner, repoName, base, since, until)
+	if err != nil {
+		return "", err
+	}
+	if len(merged) == 0 {
+		return "", fmt.Errorf("%w: no PRs were merged into %s since the previous release", ErrWebhookIgnored, base)
+	}
+
+	if quota := bot.checkQuota(owner, repoName, bot.repositoryConfig(owner, repoName)); quota.Exceeded {
+		return "", fmt.Errorf("%w: monthly quota of the %s is exhausted", ErrReviewSkipped, quota.Scope)
+	}
+
+	// The latest review of each PR
+	latest := make(map[int]store.ReviewRecord)
+	for _, rec := range bot.store.ListReviews(store.UsageFilter{Org: owner, Repo: repoName}) {
+		latest[rec.PRNumber] = rec
+	}
+
+	var data strings.Builder
+	fmt.Fprintf(&data, "Repository: %s/%s\n", owner, repoName)
+	for i, pr := range merged {
+		fmt.Fprintf(&data, "\n## PR #%d: %s (by @%s)\n", pr.Number, pr.Title, pr.User.Login)
+		if i >= releaseNotesMaxPRs {
+			continue
+		}
+		if labels := pr.LabelNames(); len(labels) > 0 {
+			fmt.Fprintf(&data, "Labels: %s\n", strings.Join(labels, ", "))
+		}
+		if rec, ok := latest[pr.Number]; ok && rec.Summary != "" {
+			fmt.Fprintf(&data, "Review summary: %s\n", truncateText(rec.Summary, healthDigestMaxChars))
+		}
+	}
+
+	instructions := fmt.Sprintf(releaseNotesInstructions, strings.Join(notes.GetCategories(), ", "))
+	text, usage, err := bot.aiClientFor(owner).Converse(instructions, []review.ClaudeMessage{
+		{Role: "user", Content: data.String()},
+	})
+	bot.recordUsage(owner, repoName, 0, store.UsageKindDigest, usage)
+	if err != nil {
+		return "", err
+	}
+
+	footer := fmt.Sprintf("_Drafted by Cyclone from the %d PRs merged so far._", len(merged))
+	if previous != nil {
+		footer = fmt.Sprintf("_Drafted by Cyclone from the %d PRs merged since %s._", len(merged), previous.TagName)
+	}
+	return strings.TrimSpace(text) + "\n\n---\n" + footer, nil
+}
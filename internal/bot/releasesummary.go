@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// releaseSummaryInstructions is the system prompt of release summaries
+const releaseSummaryInstructions = `You write the summary of a release cycle of a code repository for its engineering leads, based on the automated code reviews Cyclone posted on the cycle's pull requests.
+
+Write a narrative in concise markdown with exactly these sections:
+### Overview
+What the cycle delivered, grouped into a few themes.
+### Review findings
+The notable problems the reviews raised and how the feedback trended, with the PR numbers.
+### Risks
+Merged PRs whose blocking findings or issues may not have been addressed, and changes that weren't reviewed. Say so if there are none.
+
+Refer to PRs as #123. Only state what the data supports. Stay under 600 words.`
+
+// commitSHA matches full commit SHAs, which releases may target instead of a branch
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// summaryPR is a pull request of a release cycle
+type summaryPR struct {
+	Number int
+	Title  string
+	State  string // e.g. "merged" or "open"
+}
+
+// releaseSummaryConfig returns a repository's release summary setting: its own or its
+// organization's, nil if it has none
+func (bot *CycloneBot) releaseSummaryConfig(owner, repoName string) *config.ReleaseSummaryConfig {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.ReleaseSummary != nil {
+		return repoConfig.ReleaseSummary
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.ReleaseSummary
+	}
+	return nil
+}
+
+// SummarizeMilestone posts the release summary of a closed milestone's PRs
+func (bot *CycloneBot) SummarizeMilestone(repo *review.Repository, milestone *review.Milestone) error {
+	owner, repoName := repo.Owner.Login, repo.Name
+
+	issues, err := bot.githubClientFor(owner).ListMilestonePullRequests(context.Background(), owner, repoName, milestone.Number)
+	if err != nil {
+		return err
+	}
+
+	prs := make([]summaryPR, len(issues))
+	for i, issue := range issues {
+		prs[i] = summaryPR{Number: issue.Number, Title: issue.Title, State: issue.State}
+	}
+
+	title := fmt.Sprintf("Milestone summary: %s", milestone.Title)
+	scope := fmt.Sprintf("the milestone [%s](%s)", milestone.Title, milestone.HTMLURL)
+	return bot.postReleaseSummary(owner, repoName, title, scope, prs)
+}
+
+// SummarizeRelease posts the release summary of the PRs merged into a published release's
+// branch since the previous release
+func (bot *CycloneBot) SummarizeRelease(repo *review.Repository, release *review.Release) error {
+	ctx := context.Background()
+	owner, repoName := repo.Owner.Login, repo.Name
+	client := bot.githubClientFor(owner)
+
+	publishedAt := release.PublishedAt
+	previous, err := client.PreviousRelease(ctx, owner, repoName, publishedAt)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if previous != nil {
+		since = previous.PublishedAt
+	}
+
+	base := release.TargetCommitish
+	if base == "" || commitSHA.MatchString(base) {
+		base = repo.DefaultBranch
+	}
+	merged, err := client.ListMergedPullRequests(ctx, owner, repoName, base, since, publishedAt)
+	if err != nil {
+		return err
+	}
+
+	prs := make([]summaryPR, len(merged))
+	for i, pr := range merged {
+		prs[i] = summaryPR{Number: pr.Number, Title: pr.Title, State: "merged"}
+	}
+
+	name := release.Name
+	if name == "" {
+		name = release.TagName
+	}
+	title := fmt.Sprintf("Release summary: %s", name)
+	scope := fmt.Sprintf("the release [%s](%s), merged into `%s`", name, release.HTMLURL, base)
+	if previous != nil {
+		scope += fmt.Sprintf(" since %s", previous.TagName)
+	}
+	return bot.postReleaseSummary(owner, repoName, title, scope, prs)
+}
+
+// postReleaseSummary has the AI write a summary of the reviews of a release cycle's PRs and
+// posts it as a discussion. Cycles without reviewed PRs return an error wrapping
+// ErrWebhookIgnored.
+func (bot *CycloneBot) postReleaseSummary(owner, repoName, title, scope string, prs []summaryPR) error {
+	summary := bot.releaseSummaryConfig(owner, repoName)
+	if summary == nil {
+		return fmt.Errorf("%w: release summaries aren't configured for %s/%s", ErrWebhookIgnored, owner, repoName)
+	}
+
+	// The latest review of each PR
+	latest := make(map[int]store.ReviewRecord)
+	for _, rec := range bot.store.ListReviews(store.UsageFilter{Org: owner, Repo: repoName}) {
+		latest[rec.PRNumber] = rec
+	}
+
+	reviewed := 0
+	var data strings.Builder
+	fmt.Fprintf(&data, "Repository: %s/%s\nRelease cycle: %s\n", owner, repoName, title)
+	for _, pr := range prs {
+		fmt.Fprintf(&data, "\n## PR #%d: %s (%s)\n", pr.Number, pr.Title, pr.State)
+		rec, ok := latest[pr.Number]
+		if !ok {
+			data.WriteString("Not reviewed by Cyclone\n")
+			continue
+		}
+		reviewed++
+		if reviewed > healthDigestMaxPRs {
+			data.WriteString("Reviewed by Cyclone, details left out for length\n")
+			continue
+		}
+		if rec.Verdict != "" {
+			fmt.Fprintf(&data, "Verdict: %s\n", rec.Verdict)
+		}
+		writeReviewFindings(&data, rec)
+	}
+	if reviewed == 0 {
+		return fmt.Errorf("%w: none of the %d PRs of %s were reviewed", ErrWebhookIgnored, len(prs), title)
+	}
+
+	if quota := bot.checkQuota(owner, repoName, bot.repositoryConfig(owner, repoName)); quota.Exceeded {
+		return fmt.Errorf("%w: monthly quota of the %s is exhausted", ErrReviewSkipped, quota.Scope)
+	}
+
+	text, usage, err := bot.aiClientFor(owner).Converse(releaseSummaryInstructions, []review.ClaudeMessage{
+		{Role: "user", Content: data.String()},
+	})
+	bot.recordUsage(owner, repoName, 0, store.UsageKindDigest, usage)
+	if err != nil {
+		return err
+	}
+
+	if bot.isDryRun(owner, repoName) {
+		log.Printf("Dry run - not posting release summary %q of %s/%s:\n%s", title, owner, repoName, text)
+		return nil
+	}
+
+	body := fmt.Sprintf("%s\n\n---\n_Written by Cyclone from its reviews of %d of the %d PRs of %s._", text, reviewed, len(prs), scope)
+	url, err := bot.githubClientFor(owner).CreateDiscussion(context.Background(), owner, repoName, summary.DiscussionCategory, title, body)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Posted release summary %q of %s/%s: %s", title, owner, repoName, url)
+	return nil
+}
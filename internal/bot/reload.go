@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// currentReviewConfig returns the active review configuration
+func (bot *CycloneBot) currentReviewConfig() *config.ReviewConfig {
+	bot.configMu.RLock()
+	defer bot.configMu.RUnlock()
+	return bot.reviewConfig
+}
+
+// ReloadReviewConfig re-reads the review configuration file. If the new file is invalid,
+// the current configuration stays active so a typo can't take the bot down. Changes are
+// recorded in the audit log with the actor triggering the reload.
+func (bot *CycloneBot) ReloadReviewConfig(actor string) error {
+	reviewCfg, err := config.LoadReviewConfig(bot.config)
+	if err != nil {
+		log.Printf("Error reloading review configuration - keeping the current one: %v", err)
+		return err
+	}
+
+	bot.configMu.Lock()
+	before := auditReviewConfig(bot.fileConfig)
+	bot.fileConfig = reviewCfg
+	bot.reviewConfig = reviewCfg.WithManagedOrganizations(bot.store.ManagedOrganizations())
+	bot.configMu.Unlock()
+	bot.recordAudit(store.AuditSourceReload, actor, "", "", "reload review configuration", before, auditReviewConfig(reviewCfg))
+
+	// Organization credentials may have changed
+	bot.clientsMu.Lock()
+	bot.orgClients = make(map[string]*review.AIClient)
+	bot.orgGitHubClients = make(map[string]*review.GitHubClient)
+	bot.gitlabClients = make(map[string]*review.GitLabClient)
+	bot.azureDevOpsClients = make(map[string]*review.AzureDevOpsClient)
+	bot.gerritClients = make(map[string]*review.GerritClient)
+	bot.clientsMu.Unlock()
+
+	return nil
+}
+
+// WatchReviewConfig reloads the review configuration on SIGHUP and whenever it changes
+func (bot *CycloneBot) WatchReviewConfig() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(bot.config.ReviewConfigPollInterval())
+	defer ticker.Stop()
+
+	version := bot.config.ReviewConfigVersion()
+	for {
+		var actor string
+		select {
+		case <-hangup:
+			log.Printf("Received SIGHUP - reloading review configuration")
+			actor = "signal:SIGHUP"
+		case <-ticker.C:
+			current := bot.config.ReviewConfigVersion()
+			if current == "" || current == version {
+				continue
+			}
+			log.Printf("Review configuration changed - reloading")
+			actor = "watcher"
+		}
+
+		bot.ReloadReviewConfig(actor)
+		version = bot.config.ReviewConfigVersion()
+	}
+}
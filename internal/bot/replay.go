@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// ErrWebhookIgnored is returned when a webhook triggers no work, e.g. a PR action Cyclone
+// doesn't review on or a thread reply to a comment that isn't Cyclone's
+var ErrWebhookIgnored = errors.New("webhook triggers no work")
+
+// captureWebhook stores an incoming webhook delivery for later replay
+func (bot *CycloneBot) captureWebhook(deliveryID, event string, body []byte) {
+	if !json.Valid(body) {
+		log.Printf("Not capturing webhook delivery %s - payload is not JSON", deliveryID)
+		return
+	}
+
+	delivery := store.WebhookDelivery{ID: deliveryID, Event: event, Payload: body}
+	if err := bot.store.SaveWebhookDelivery(delivery); err != nil {
+		log.Printf("Error capturing webhook delivery %s: %v", deliveryID, err)
+	}
+}
+
+// ReplayWebhook processes a captured webhook delivery again and waits for the work it
+// triggers, so a failed review can be reproduced without pushing new commits
+func (bot *CycloneBot) ReplayWebhook(delivery store.WebhookDelivery) error {
+	log.Printf("Replaying %s webhook delivery %s", delivery.Event, delivery.ID)
+	return bot.runWebhook(delivery)
+}
+
+// runWebhook processes a webhook delivery and waits for the work it triggers, recording the
+// decision and outcome in the delivery log
+func (bot *CycloneBot) runWebhook(delivery store.WebhookDelivery) error {
+	job, decision, err := bot.webhookJob(delivery.Event, delivery.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", delivery.Event, err)
+	}
+	bot.recordDelivery(delivery, decision, job != nil)
+	if job == nil {
+		return ErrWebhookIgnored
+	}
+
+	return bot.runDeliveryJob(delivery, job)
+}
+
+// ReplayCapturedWebhook replays a webhook delivery captured with CAPTURE_WEBHOOKS, or a failed
+// one from the delivery log
+func (bot *CycloneBot) ReplayCapturedWebhook(deliveryID string) error {
+	delivery, err := bot.replayableDelivery(deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery == nil {
+		return fmt.Errorf("no captured or failed webhook delivery %s", deliveryID)
+	}
+	bot.countReplay(delivery.ID)
+	return bot.ReplayWebhook(*delivery)
+}
+
+// replayableDelivery returns the payload of a delivery captured with CAPTURE_WEBHOOKS or kept
+// in the delivery log because it failed, or nil if there is neither
+func (bot *CycloneBot) replayableDelivery(deliveryID string) (*store.WebhookDelivery, error) {
+	delivery, err := bot.store.GetWebhookDelivery(deliveryID)
+	if err != nil || delivery != nil {
+		return delivery, err
+	}
+
+	rec, err := bot.store.GetDelivery(deliveryID)
+	if err != nil || rec == nil || rec.Payload == nil {
+		return nil, err
+	}
+	return &store.WebhookDelivery{ID: rec.ID, Event: rec.Event, Time: rec.Time, Payload: rec.Payload}, nil
+}
+
+// countReplay counts a replay in the delivery log entry of the replayed delivery
+func (bot *CycloneBot) countReplay(deliveryID string) {
+	if _, err := bot.store.UpdateDelivery(deliveryID, func(rec *store.DeliveryRecord) {
+		rec.Replays++
+	}); err != nil {
+		log.Printf("Error counting replay of delivery %s: %v", deliveryID, err)
+	}
+}
+
+// handleAdminWebhookReplay serves POST /api/admin/webhooks/{delivery-id}/replay, which
+// replays a captured or failed delivery in the background
+func (bot *CycloneBot) handleAdminWebhookReplay(w http.ResponseWriter, r *http.Request, deliveryID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if deliveryID == "" || strings.Contains(deliveryID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if requestTenants(r) != nil {
+		// Captured payloads aren't attributed to an organization, the delivery log entry is
+		rec, err := bot.store.GetDelivery(deliveryID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rec == nil || !tenantAllowed(r, rec.Org) {
+			http.Error(w, fmt.Sprintf("No delivery %s in the delivery log", deliveryID), http.StatusNotFound)
+			return
+		}
+	}
+
+	delivery, err := bot.replayableDelivery(deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if delivery == nil {
+		http.Error(w, fmt.Sprintf("No payload of webhook delivery %s - only failed deliveries keep theirs, unless CAPTURE_WEBHOOKS is enabled", deliveryID), http.StatusNotFound)
+		return
+	}
+	bot.countReplay(delivery.ID)
+
+	if bot.queueWebhooks {
+		// A fresh ID keeps the queued replay apart from the original delivery
+		replayID := fmt.Sprintf("%s-replay-%d", delivery.ID, time.Now().UnixNano())
+		if err := bot.enqueueWebhook(replayID, delivery.Event, delivery.Payload); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		go func() {
+			if err := bot.ReplayWebhook(*delivery); err != nil {
+				log.Printf("Replay of webhook delivery %s: %v", deliveryID, err)
+			}
+		}()
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"delivery_id": delivery.ID,
+		"event":       delivery.Event,
+	})
+}
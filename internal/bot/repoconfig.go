@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	"cyclone/internal/config"
+)
+
+// applyRepoConfigFile merges the repository's own config file over its central configuration.
+// A missing or invalid file leaves the central configuration unchanged.
+func (bot *CycloneBot) applyRepoConfigFile(ctx context.Context, owner, repoName string, repoConfig *config.RepositoryConfig) *config.RepositoryConfig {
+	provider, err := bot.scmProviderFor(owner)
+	if err != nil {
+		log.Printf("Error fetching %s for %s/%s - using central configuration: %v", config.REPO_CONFIG_FILE, owner, repoName, err)
+		return repoConfig
+	}
+	content, err := provider.GetFileContent(ctx, owner, repoName, config.REPO_CONFIG_FILE)
+	if err != nil {
+		log.Printf("Error fetching %s for %s/%s - using central configuration: %v", config.REPO_CONFIG_FILE, owner, repoName, err)
+		return repoConfig
+	}
+	bot.auditRepoConfigFile(ctx, owner, repoName, content)
+	if content == nil {
+		return repoConfig
+	}
+
+	file, err := bot.currentReviewConfig().ParseRepoConfigFile(content)
+	if err != nil {
+		log.Printf("Ignoring invalid %s in %s/%s: %v", config.REPO_CONFIG_FILE, owner, repoName, err)
+		return repoConfig
+	}
+
+	log.Printf("Applying %s from %s/%s", config.REPO_CONFIG_FILE, owner, repoName)
+	return repoConfig.WithRepoConfigFile(file)
+}
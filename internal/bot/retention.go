@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"log"
+	"time"
+
+	"cyclone/internal/config"
+)
+
+// PruneStoredData periodically removes stored content past the retention policy and the
+// expired part of the webhook delivery log
+func (bot *CycloneBot) PruneStoredData() {
+	policy := bot.config.Retention
+	ticker := time.NewTicker(config.RETENTION_PRUNE_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		result, err := bot.store.Prune(policy, time.Now())
+		if err != nil {
+			log.Printf("Error pruning stored data: %v", err)
+		}
+		if result.ReviewContents > 0 || result.Conversations > 0 || result.Webhooks > 0 {
+			log.Printf("Retention: dropped the content of %d reviews, %d conversations and %d captured webhooks",
+				result.ReviewContents, result.Conversations, result.Webhooks)
+		}
+		if result.Signals > 0 {
+			log.Printf("Retention: dropped %d feedback signals of conventions memories", result.Signals)
+		}
+		if result.Deliveries > 0 {
+			log.Printf("Retention: dropped %d webhook deliveries from the delivery log", result.Deliveries)
+		}
+		<-ticker.C
+	}
+}
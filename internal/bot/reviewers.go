@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+	"cyclone/internal/review"
+)
+
+// reviewerHistoryWindow is how far back the commits of changed files are looked at
+const reviewerHistoryWindow = 180 * 24 * time.Hour
+
+// reviewerHistoryFiles bounds the changed files whose commits are looked at, one request each
+const reviewerHistoryFiles = 20
+
+// reviewerHistoryCommits bounds the commits looked at per file
+const reviewerHistoryCommits = 10
+
+// reviewerSuggestions are the reviewers suggested for a PR, see suggestReviewers
+type reviewerSuggestions struct {
+	reviewers []review.SuggestedReviewer
+	request   bool // Request reviews from them, not just name them
+	language  string
+}
+
+// suggestReviewers ranks the people and teams most familiar with the files a PR changes, from
+// the repository's CODEOWNERS and the recent commits to those files. It returns nil unless the
+// repository has reviewer suggestions on, or if nobody but the PR's author and its requested
+// reviewers is familiar with them.
+func (bot *CycloneBot) suggestReviewers(ctx context.Context, owner, repoName string, pr *review.PullRequest, repoConfig *config.RepositoryConfig) *reviewerSuggestions {
+	settings := repoConfig.ReviewerSuggestions
+	if settings == nil {
+		return nil
+	}
+
+	client := bot.githubClientFor(owner)
+	changed, err := client.ListFiles(ctx, owner, repoName, pr.Number)
+	if err != nil {
+		log.Printf("Error listing files of PR #%d in %s/%s for reviewer suggestions: %v", pr.Number, owner, repoName, err)
+		return nil
+	}
+
+	var owners review.CodeOwners
+	for _, path := range review.CodeOwnersPaths {
+		content, err := client.GetFileContentAt(ctx, owner, repoName, path, pr.Base.Ref)
+		if err != nil {
+			log.Printf("Error reading %s of %s/%s: %v", path, owner, repoName, err)
+			continue
+		}
+		if content != nil {
+			owners = review.ParseCodeOwners(content)
+			break
+		}
+	}
+
+	files := make([]string, 0, len(changed))
+	recentAuthors := make(map[string][]string)
+	since := time.Now().Add(-reviewerHistoryWindow)
+	looked := 0
+	for _, file := range changed {
+		files = append(files, file.Filename)
+		// Added files have no history yet; renamed ones have it under their previous name
+		if file.Status == "added" || looked == reviewerHistoryFiles {
+			continue
+		}
+		looked++
+		path := file.Filename
+		if file.PreviousFilename != "" {
+			path = file.PreviousFilename
+		}
+		authors, err := client.RecentCommitAuthors(ctx, owner, repoName, pr.Base.Ref, path, since, reviewerHistoryCommits)
+		if err != nil {
+			log.Printf("Error reading the history of %s in %s/%s: %v", path, owner, repoName, err)
+			continue
+		}
+		recentAuthors[file.Filename] = authors
+	}
+
+	exclude := map[string]bool{strings.ToLower(pr.User.Login): true}
+	for _, user := range pr.RequestedReviewers {
+		exclude[strings.ToLower(user.Login)] = true
+	}
+	for _, team := range pr.RequestedTeams {
+		exclude[strings.ToLower(owner+"/"+team.Slug)] = true
+	}
+
+	reviewers := review.SuggestReviewers(files, owners, recentAuthors, exclude, settings.GetCount())
+	log.Printf("Suggesting %d reviewers for PR #%d in %s/%s from %d CODEOWNERS rules and the history of %d files",
+		len(reviewers), pr.Number, owner, repoName, len(owners), looked)
+	if len(reviewers) == 0 {
+		return nil
+	}
+	return &reviewerSuggestions{reviewers: reviewers, request: settings.Request, language: repoConfig.Language}
+}
+
+// reviewerNote requests reviews from the suggested reviewers if the repository asks for it and
+// returns the line naming them, for the end of the review summary. Reviews are only requested
+// on a PR's first review, so reviewers aren't asked again after each push. Teams of other
+// organizations can't be requested and are only named. Names aren't mentions, so suggested
+// reviewers aren't notified.
+func (bot *CycloneBot) reviewerNote(ctx context.Context, owner, repoName string, prNumber int, suggestions *reviewerSuggestions, post, dryRun bool) string {
+	requested := false
+	if suggestions.request && post && !bot.store.HasReview(owner, repoName, prNumber) {
+		var users, teams []string
+		for _, reviewer := range suggestions.reviewers {
+			if !reviewer.Team {
+				users = append(users, reviewer.Login)
+			} else if org, slug, _ := strings.Cut(reviewer.Login, "/"); strings.EqualFold(org, owner) {
+				teams = append(teams, slug)
+			}
+		}
+		if len(users)+len(teams) > 0 {
+			if dryRun {
+				log.Printf("Dry run - not requesting reviews of PR #%d from %v and teams %v", prNumber, users, teams)
+			} else if err := bot.githubClientFor(owner).RequestReviewers(ctx, owner, repoName, prNumber, users, teams); err != nil {
+				log.Printf("Error requesting suggested reviewers - only naming them: %v", err)
+			} else {
+				requested = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(suggestions.reviewers))
+	for _, reviewer := range suggestions.reviewers {
+		var reasons []string
+		if reviewer.OwnedFiles > 0 {
+			reasons = append(reasons, notices.Render(suggestions.language, "reviewer_code_owner", notices.Data{"Files": reviewer.OwnedFiles}))
+		}
+		if reviewer.ChangedFiles > 0 {
+			reasons = append(reasons, notices.Render(suggestions.language, "reviewer_recent_commits", notices.Data{"Files": reviewer.ChangedFiles}))
+		}
+		names = append(names, fmt.Sprintf("`@%s` (%s)", reviewer.Login, strings.Join(reasons, ", ")))
+	}
+	return notices.Render(suggestions.language, "suggested_reviewers", notices.Data{
+		"Reviewers": strings.Join(names, ", "),
+		"Requested": requested,
+	})
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cyclone/internal/store"
+)
+
+// defaultReviewsLimit is how many reviews GET /api/reviews returns without a limit parameter
+const defaultReviewsLimit = 100
+
+// ReviewsResponse is the JSON body returned by GET /api/reviews
+type ReviewsResponse struct {
+	Total   int                  `json:"total"` // Matching reviews, before limit and offset
+	Reviews []store.ReviewRecord `json:"reviews"`
+}
+
+// handleReviewsAPI serves GET /api/reviews, the review history newest first without review
+// contents, filtered by org, repo, date range and verdict
+func (bot *CycloneBot) handleReviewsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseUsageFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), filterErrorStatus(err))
+		return
+	}
+
+	query := r.URL.Query()
+	verdict := query.Get("verdict")
+	switch verdict {
+	case "", store.VerdictBlocking, store.VerdictIssues, store.VerdictComments, store.VerdictClean:
+	default:
+		http.Error(w, fmt.Sprintf("invalid verdict %q (use blocking, issues, comments or clean)", verdict), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseCount(query.Get("limit"), defaultReviewsLimit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid limit: %v", err), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseCount(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid offset: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	records := bot.store.ListReviews(filter)
+	var matching []store.ReviewRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if verdict != "" && rec.Verdict != verdict {
+			continue
+		}
+		// The content is only returned by GET /api/reviews/{id}
+		rec.Summary = ""
+		rec.DraftComments = nil
+		matching = append(matching, rec)
+	}
+
+	resp := ReviewsResponse{Total: len(matching), Reviews: []store.ReviewRecord{}}
+	if offset < len(matching) {
+		matching = matching[offset:]
+		if len(matching) > limit {
+			matching = matching[:limit]
+		}
+		resp.Reviews = matching
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleReviewAPI serves GET /api/reviews/{id}, a review with its summary and line comments
+func (bot *CycloneBot) handleReviewAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/reviews/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	rec := bot.store.GetReview(id)
+	if rec != nil && !tenantAllowed(r, rec.Org) {
+		// Reviews of other organizations don't exist for the request
+		rec = nil
+	}
+	if rec == nil {
+		http.Error(w, fmt.Sprintf("No review %d", id), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// parseCount parses a non-negative integer query parameter, returning def if it is empty
+func parseCount(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a non-negative number, got %q", value)
+	}
+	return n, nil
+}
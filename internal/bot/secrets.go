@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cyclone/internal/logging"
+	"cyclone/internal/secrets"
+)
+
+// WatchSecrets periodically re-reads credentials loaded from a secrets manager and
+// switches the clients over when they were rotated
+func (bot *CycloneBot) WatchSecrets() {
+	if len(bot.config.SecretRefs) == 0 || bot.config.SecretsRefreshInterval <= 0 {
+		return
+	}
+
+	current := make(map[string]string)
+	for name, field := range bot.config.SecretFields() {
+		current[name] = *field
+	}
+
+	ticker := time.NewTicker(bot.config.SecretsRefreshInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for name, ref := range bot.config.SecretRefs {
+			value, err := secrets.Resolve(context.Background(), ref)
+			if err != nil {
+				log.Printf("Error refreshing %s - keeping the current value: %v", name, err)
+				continue
+			}
+			if value == current[name] {
+				continue
+			}
+
+			bot.applySecret(name, value)
+			current[name] = value
+			log.Printf("Rotated %s from %s", name, ref)
+		}
+	}
+}
+
+// applySecret switches a rotated credential over to the component using it
+func (bot *CycloneBot) applySecret(name, value string) {
+	logging.AddSecrets(value)
+	switch name {
+	case "GITHUB_TOKEN":
+		bot.githubClient.RotateToken(value)
+	case "ANTHROPIC_API_KEY":
+		bot.aiClient.RotateAPIKey(value)
+	case "WEBHOOK_SECRET":
+		bot.configMu.Lock()
+		bot.config.WebhookSecret = value
+		bot.configMu.Unlock()
+	}
+}
+
+// webhookSecret returns the current WEBHOOK_SECRET, which applySecret may rotate while
+// deliveries are verified
+func (bot *CycloneBot) webhookSecret() string {
+	bot.configMu.RLock()
+	defer bot.configMu.RUnlock()
+	return bot.config.WebhookSecret
+}
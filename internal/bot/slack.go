@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+)
+
+// slackPostMessageURL is the Slack Web API method notifications are posted with
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// slackNotifier posts a short message to a repository's Slack channel when it is reviewed or a
+// PR is skipped, so teams don't have to watch their GitHub notifications
+type slackNotifier struct {
+	token      string
+	httpClient *http.Client
+	queue      chan slackMessage // Sent one at a time, so a slow Slack doesn't hold up reviews
+}
+
+// slackMessage is the JSON body of chat.postMessage
+type slackMessage struct {
+	Channel     string `json:"channel"`
+	Text        string `json:"text"`
+	UnfurlLinks bool   `json:"unfurl_links"`
+}
+
+// newSlackNotifier returns nil without a bot token, which drops all notifications
+func newSlackNotifier(token string) *slackNotifier {
+	if token == "" {
+		return nil
+	}
+	notifier := &slackNotifier{
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan slackMessage, 100),
+	}
+	go func() {
+		for msg := range notifier.queue {
+			notifier.send(msg)
+		}
+	}()
+	return notifier
+}
+
+// slackChannel returns the channel notified about a repository: its own or its organization's
+func (bot *CycloneBot) slackChannel(owner, repoName string) string {
+	if repoConfig := bot.currentReviewConfig().GetRepositoryConfig(owner, repoName); repoConfig != nil && repoConfig.SlackChannel != "" {
+		return repoConfig.SlackChannel
+	}
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil {
+		return orgConfig.SlackChannel
+	}
+	return ""
+}
+
+// notifySlackReview announces a posted review with its comment counts by category
+func (bot *CycloneBot) notifySlackReview(owner, repoName string, prNumber int, result review.ReviewResult) {
+	channel := bot.slackChannel(owner, repoName)
+	if bot.slack == nil || channel == "" {
+		return
+	}
+
+	text := fmt.Sprintf(":cyclone: Reviewed %s: %s", bot.slackPRLink(owner, repoName, prNumber),
+		commentCounts(result.Comments, bot.repositoryConfig(owner, repoName).GetCategories()))
+	if result.Err != nil {
+		text = fmt.Sprintf(":warning: Review of %s failed - a notice was posted instead", bot.slackPRLink(owner, repoName, prNumber))
+	}
+	bot.slack.enqueue(slackMessage{Channel: channel, Text: text})
+}
+
+// notifySlackSkip announces a PR that wasn't reviewed and why. Nothing is sent in dry run.
+func (bot *CycloneBot) notifySlackSkip(owner, repoName string, prNumber int, reason string) {
+	channel := bot.slackChannel(owner, repoName)
+	if bot.slack == nil || channel == "" || bot.isDryRun(owner, repoName) {
+		return
+	}
+
+	text := fmt.Sprintf(":fast_forward: Skipped %s: %s", bot.slackPRLink(owner, repoName, prNumber), strings.ReplaceAll(reason, "_", " "))
+	bot.slack.enqueue(slackMessage{Channel: channel, Text: text})
+}
+
+// slackPRLink formats a link to a PR in Slack's mrkdwn, e.g. <https://github.com/o/r/pull/1|o/r#1>.
+// PRs of organizations on other code hosts are named without a link.
+func (bot *CycloneBot) slackPRLink(owner, repoName string, prNumber int) string {
+	if orgConfig := bot.currentReviewConfig().GetOrganizationConfig(owner); orgConfig != nil && orgConfig.GetProvider() != config.ProviderGitHub {
+		return fmt.Sprintf("%s/%s#%d", owner, repoName, prNumber)
+	}
+	return fmt.Sprintf("<https://github.com/%s/%s/pull/%d|%s/%s#%d>", owner, repoName, prNumber, owner, repoName, prNumber)
+}
+
+// commentCounts lists how many comments a review has per category, in the order the categories
+// are configured, e.g. "⚠️ 2 issue, 💡 1 suggestion"
+func commentCounts(comments []review.ReviewComment, categories []config.CommentCategory) string {
+	if len(comments) == 0 {
+		return "no comments"
+	}
+
+	counts := make(map[string]int)
+	for _, comment := range comments {
+		counts[strings.ToLower(comment.Category)]++
+	}
+
+	var parts []string
+	for _, category := range categories {
+		name := strings.ToLower(category.Name)
+		if counts[name] == 0 {
+			continue
+		}
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %d %s", category.Emoji, counts[name], name)))
+		delete(counts, name)
+	}
+
+	// Comments without one of the configured categories
+	var rest []string
+	for name := range counts {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		label := name
+		if label == "" {
+			label = "uncategorized"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[name], label))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// enqueue schedules a message to be sent, dropping it if Slack can't keep up
+func (n *slackNotifier) enqueue(msg slackMessage) {
+	select {
+	case n.queue <- msg:
+	default:
+		log.Printf("Dropping Slack notification to %s: too many notifications pending", msg.Channel)
+	}
+}
+
+// send posts a message, logging failures since notifications are best effort
+func (n *slackNotifier) send(msg slackMessage) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error encoding Slack notification: %v", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slackPostMessageURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error creating Slack request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+n.token)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Error sending Slack notification to %s: %v", msg.Channel, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// Slack reports most errors, e.g. an unknown channel, with status 200 and ok false
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Printf("Error sending Slack notification to %s: status %d", msg.Channel, resp.StatusCode)
+		return
+	}
+	if !result.OK {
+		log.Printf("Error sending Slack notification to %s: %s", msg.Channel, result.Error)
+	}
+}
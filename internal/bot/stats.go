@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyclone/internal/store"
+)
+
+// defaultStatsWindows are the windows GET /stats reports without a windows parameter
+const defaultStatsWindows = "1h,24h,7d"
+
+// StatsWindow is an operational snapshot of the reviews and skips of a time window
+type StatsWindow struct {
+	Window            string             `json:"window"`
+	Reviews           int                `json:"reviews"`
+	Skips             map[string]int     `json:"skips"` // By reason, including failed reviews
+	AvgLatencySeconds float64            `json:"avg_latency_seconds"`
+	AvgStageSeconds   map[string]float64 `json:"avg_stage_seconds"` // Per stage, over the reviews that went through it
+	AvgComments       float64            `json:"avg_comments"`
+	Errors            int                `json:"errors"`     // Failed reviews
+	ErrorRate         float64            `json:"error_rate"` // Errors / (Reviews + Errors)
+}
+
+// StatsResponse is the JSON body returned by GET /stats
+type StatsResponse struct {
+	Time    time.Time     `json:"time"`
+	Windows []StatsWindow `json:"windows"`
+}
+
+// handleStats serves GET /stats, counts and averages over recent windows such as the last
+// hour and day - a quick snapshot without metrics infrastructure
+func (bot *CycloneBot) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	windows := r.URL.Query().Get("windows")
+	if windows == "" {
+		windows = defaultStatsWindows
+	}
+
+	now := time.Now()
+	resp := StatsResponse{Time: now.UTC()}
+	for _, name := range strings.Split(windows, ",") {
+		name = strings.TrimSpace(name)
+		window, err := parseStatsWindow(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp.Windows = append(resp.Windows, bot.statsWindow(name, now.Add(-window)))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// statsWindow sums up the reviews and skips since a point in time
+func (bot *CycloneBot) statsWindow(name string, since time.Time) StatsWindow {
+	filter := store.UsageFilter{Since: since}
+	stats := StatsWindow{Window: name, Skips: bot.store.CountSkips(filter), AvgStageSeconds: map[string]float64{}}
+
+	var comments int
+	var latencyMs int64
+	stageTotals := make(map[string]int64)
+	stageCounts := make(map[string]int)
+	for _, rec := range bot.store.ListReviews(filter) {
+		stats.Reviews++
+		comments += rec.Comments
+		latencyMs += rec.LatencyMs
+		for stage, ms := range rec.StageMs {
+			stageTotals[stage] += ms
+			stageCounts[stage]++
+		}
+	}
+	for stage, ms := range stageTotals {
+		stats.AvgStageSeconds[stage] = float64(ms) / float64(stageCounts[stage]) / 1000
+	}
+
+	if stats.Reviews > 0 {
+		stats.AvgComments = float64(comments) / float64(stats.Reviews)
+		stats.AvgLatencySeconds = float64(latencyMs) / float64(stats.Reviews) / 1000
+	}
+	stats.Errors = stats.Skips[store.SkipReasonReviewFailed]
+	if attempts := stats.Reviews + stats.Errors; attempts > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(attempts)
+	}
+	return stats
+}
+
+// parseStatsWindow parses a window such as "15m", "24h" or "7d"
+func parseStatsWindow(value string) (time.Duration, error) {
+	var window time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(value)
+	}
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("invalid window %q (use e.g. 15m, 24h or 7d)", value)
+	}
+	return window, nil
+}
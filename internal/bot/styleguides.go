@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// styleGuideURLTTL is how long the summary of a style guide fetched from a URL is used before
+// the URL is fetched again. In-repo guides are fetched for every review, like the config file.
+const styleGuideURLTTL = time.Hour
+
+// maxStyleGuideSize caps the bytes read of a style guide
+const maxStyleGuideSize = 1 << 20
+
+// styleGuideClient fetches style guides from URLs
+var styleGuideClient = &http.Client{Timeout: 15 * time.Second}
+
+// applyStyleGuides returns a copy of the repository configuration with the summary of its style
+// guides, summarizing those that are new or changed since they were last summarized. Guides
+// that can't be fetched or summarized are logged and left out. With cachedOnly, the cached
+// summaries are used as they are and nothing is fetched or summarized.
+func (bot *CycloneBot) applyStyleGuides(ctx context.Context, aiClient *review.AIClient, owner, repoName string, prNumber int, repoConfig *config.RepositoryConfig, cachedOnly bool) *config.RepositoryConfig {
+	if len(repoConfig.StyleGuides) == 0 {
+		return repoConfig
+	}
+
+	var summaries []string
+	for _, source := range repoConfig.StyleGuides {
+		if cachedOnly {
+			if cached := bot.store.GetStyleGuide(store.StyleGuideKey(owner, repoName, source)); cached != nil {
+				summaries = append(summaries, fmt.Sprintf("From %s:\n%s", source, cached.Summary))
+			}
+			continue
+		}
+		summary, err := bot.styleGuideSummary(ctx, aiClient, owner, repoName, prNumber, source)
+		if err != nil {
+			log.Printf("Leaving style guide %s of %s/%s out of the review: %v", source, owner, repoName, err)
+			continue
+		}
+		if summary != "" {
+			summaries = append(summaries, fmt.Sprintf("From %s:\n%s", source, summary))
+		}
+	}
+
+	merged := *repoConfig
+	merged.StyleGuide = strings.Join(summaries, "\n\n")
+	return &merged
+}
+
+// styleGuideSummary returns the summary of a style guide, from the cache unless its content changed
+func (bot *CycloneBot) styleGuideSummary(ctx context.Context, aiClient *review.AIClient, owner, repoName string, prNumber int, source string) (string, error) {
+	key := store.StyleGuideKey(owner, repoName, source)
+	cached := bot.store.GetStyleGuide(key)
+	isURL := config.IsStyleGuideURL(source)
+	if cached != nil && isURL && time.Since(cached.FetchedAt) < styleGuideURLTTL {
+		return cached.Summary, nil
+	}
+
+	content, err := bot.fetchStyleGuide(ctx, owner, repoName, source)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	if cached != nil && cached.Hash == hash {
+		if isURL {
+			cached.FetchedAt = time.Now()
+			if err := bot.store.SaveStyleGuide(*cached); err != nil {
+				log.Printf("Error saving style guide %s of %s/%s: %v", source, owner, repoName, err)
+			}
+		}
+		return cached.Summary, nil
+	}
+
+	log.Printf("Summarizing style guide %s of %s/%s", source, owner, repoName)
+	summary, usage, err := aiClient.SummarizeStyleGuide(source, string(content))
+	bot.recordUsage(owner, repoName, prNumber, store.UsageKindStyleGuide, usage)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := bot.store.SaveStyleGuide(store.StyleGuideSummary{
+		Key:       key,
+		Hash:      hash,
+		Summary:   summary,
+		Model:     usage.Model,
+		FetchedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		log.Printf("Error saving style guide %s of %s/%s: %v", source, owner, repoName, err)
+	}
+	return summary, nil
+}
+
+// fetchStyleGuide reads a style guide from the repository's default branch or from a URL
+func (bot *CycloneBot) fetchStyleGuide(ctx context.Context, owner, repoName, source string) ([]byte, error) {
+	if !config.IsStyleGuideURL(source) {
+		provider, err := bot.scmProviderFor(owner)
+		if err != nil {
+			return nil, err
+		}
+		content, err := provider.GetFileContent(ctx, owner, repoName, strings.TrimPrefix(source, "./"))
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			return nil, fmt.Errorf("%s doesn't exist on the default branch", source)
+		}
+		if len(content) > maxStyleGuideSize {
+			content = content[:maxStyleGuideSize]
+		}
+		return content, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := styleGuideClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxStyleGuideSize))
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"log"
+
+	"cyclone/internal/review"
+	"cyclone/internal/store"
+)
+
+// recordUsage writes the tokens and cost of an AI call to the usage ledger
+func (bot *CycloneBot) recordUsage(owner, repoName string, prNumber int, kind string, usage review.Usage) {
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		return
+	}
+
+	cost := usage.Cost()
+	err := bot.store.RecordUsage(store.UsageRecord{
+		Org:          owner,
+		Repo:         repoName,
+		PRNumber:     prNumber,
+		Kind:         kind,
+		Model:        usage.Model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		CostUSD:      cost,
+	})
+	if err != nil {
+		log.Printf("Error recording usage for PR #%d: %v", prNumber, err)
+		return
+	}
+
+	log.Printf("%s for PR #%d in %s/%s used %d input / %d output tokens ($%.4f)",
+		kind, prNumber, owner, repoName, usage.InputTokens, usage.OutputTokens, cost)
+}
+
+// recordSkip writes a skipped PR and its reason to the skip log
+func (bot *CycloneBot) recordSkip(owner, repoName string, prNumber int, reason string) {
+	err := bot.store.RecordSkip(store.SkipRecord{
+		Org:      owner,
+		Repo:     repoName,
+		PRNumber: prNumber,
+		Reason:   reason,
+	})
+	if err != nil {
+		log.Printf("Error recording skip for PR #%d: %v", prNumber, err)
+	}
+	bot.notifySlackSkip(owner, repoName, prNumber, reason)
+}
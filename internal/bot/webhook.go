@@ -1,54 +1,383 @@
 package bot
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
 
-	"github.com/google/go-github/v57/github"
+	"cyclone/internal/config"
+	"cyclone/internal/review"
+	"cyclone/internal/store"
 )
 
 // WebhookPayload represents the GitHub webhook payload
 type WebhookPayload struct {
 	Action      string              `json:"action"`
-	PullRequest *github.PullRequest `json:"pull_request"`
-	Repository  *github.Repository  `json:"repository"`
+	PullRequest *review.PullRequest `json:"pull_request"`
+	Repository  *review.Repository  `json:"repository"`
+	Label       *review.Label       `json:"label"` // Set for labeled actions
 }
 
-// handleWebhook processes incoming GitHub webhooks
+// ReviewCommentPayload represents a GitHub pull_request_review_comment webhook payload
+type ReviewCommentPayload struct {
+	Action      string                     `json:"action"`
+	Comment     *review.PullRequestComment `json:"comment"`
+	PullRequest *review.PullRequest        `json:"pull_request"`
+	Repository  *review.Repository         `json:"repository"`
+}
+
+// IssueCommentPayload represents a GitHub issue_comment webhook payload
+type IssueCommentPayload struct {
+	Action     string               `json:"action"`
+	Comment    *review.IssueComment `json:"comment"`
+	Issue      *review.Issue        `json:"issue"`
+	Repository *review.Repository   `json:"repository"`
+}
+
+// IssuesPayload represents a GitHub issues webhook payload
+type IssuesPayload struct {
+	Action     string             `json:"action"`
+	Issue      *review.Issue      `json:"issue"`
+	Repository *review.Repository `json:"repository"`
+}
+
+// PushPayload represents a GitHub push webhook payload
+type PushPayload struct {
+	Ref        string             `json:"ref"`
+	Before     string             `json:"before"`  // Head commit of the ref before the push, zeros if it was created
+	After      string             `json:"after"`   // Head commit of the ref after the push
+	Created    bool               `json:"created"` // The push created the ref, e.g. a new tag
+	Deleted    bool               `json:"deleted"`
+	Forced     bool               `json:"forced"`
+	HeadCommit *PushCommit        `json:"head_commit"`
+	Repository *review.Repository `json:"repository"`
+	Sender     *review.User       `json:"sender"`
+}
+
+// InstallationPayload represents a GitHub App installation or installation_repositories
+// webhook payload
+type InstallationPayload struct {
+	Action            string               `json:"action"`
+	Installation      *Installation        `json:"installation"`
+	Repositories      []*review.Repository `json:"repositories"`       // Of a new installation
+	RepositoriesAdded []*review.Repository `json:"repositories_added"` // Added to an existing installation
+	Sender            *review.User         `json:"sender"`
+}
+
+// Installation is the GitHub App installation of an account
+type Installation struct {
+	ID      int64       `json:"id"`
+	Account review.User `json:"account"` // The organization or user the App is installed on
+}
+
+// PushCommit is a commit of a push webhook payload
+type PushCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// MilestonePayload represents a GitHub milestone webhook payload
+type MilestonePayload struct {
+	Action     string             `json:"action"`
+	Milestone  *review.Milestone  `json:"milestone"`
+	Repository *review.Repository `json:"repository"`
+}
+
+// ReleasePayload represents a GitHub release webhook payload
+type ReleasePayload struct {
+	Action     string             `json:"action"`
+	Release    *review.Release    `json:"release"`
+	Repository *review.Repository `json:"repository"`
+}
+
+// handleWebhook processes incoming GitHub webhooks. With WEBHOOK_SECRET set, deliveries must be
+// signed with it, see validSignature.
 func (bot *CycloneBot) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Parse the webhook payload
-	var payload WebhookPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading webhook body: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if secret := bot.webhookSecret(); secret != "" && !validSignature(body, r.Header.Get("X-Hub-Signature-256"), secret) {
+		log.Printf("Rejecting webhook delivery %s with a missing or invalid signature", r.Header.Get("X-GitHub-Delivery"))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	bot.acceptDelivery(w, store.WebhookDelivery{ID: deliveryID(r.Header.Get("X-GitHub-Delivery")), Event: event, Payload: body})
+}
+
+// validSignature reports whether the X-Hub-Signature-256 header of a GitHub delivery is the
+// HMAC-SHA256 of its body with the webhook secret
+func validSignature(body []byte, signature, secret string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// acceptDelivery decides on a webhook delivery of any code host, records it and starts or
+// queues the work it triggers
+func (bot *CycloneBot) acceptDelivery(w http.ResponseWriter, delivery store.WebhookDelivery) {
+	event, body := delivery.Event, delivery.Payload
+	if bot.config.CaptureWebhooks {
+		bot.captureWebhook(delivery.ID, event, body)
+	}
+
+	job, decision, err := bot.webhookJob(event, body)
+	if err != nil {
 		log.Printf("Error decoding webhook payload: %v", err)
+		bot.recordDelivery(delivery, fmt.Sprintf("ignored: invalid %s payload", event), false)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
+	bot.recordDelivery(delivery, decision, job != nil)
 
-	// Only process specific actions that warrant a review
-	if !bot.shouldTriggerReview(payload.Action, payload.PullRequest) {
-		log.Printf("Ignoring action: %s for PR #%d", payload.Action, payload.PullRequest.GetNumber())
+	if job == nil {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	log.Printf("Processing PR #%d: %s", payload.PullRequest.GetNumber(), payload.Action)
+	if bot.queueWebhooks {
+		// The job is built again by the worker that claims the delivery
+		if err := bot.enqueueWebhook(delivery.ID, event, body); err != nil {
+			log.Printf("Error queueing webhook: %v", err)
+			bot.finishDelivery(delivery, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		// Do the work in a goroutine to avoid blocking the webhook
+		go bot.runDeliveryJob(delivery, job)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// webhookJob decodes a webhook and returns the work it triggers, nil if it triggers none, and
+// the decision: what the work is, or why there is none. Deliveries of organizations that
+// aren't served trigger nothing, so an exposed instance can't be used by anyone installing it.
+func (bot *CycloneBot) webhookJob(event string, body []byte) (func() error, string, error) {
+	// Invalid payloads are rejected by decoding them below
+	if org, _, _, _ := bot.describeDelivery(body); json.Valid(body) && !bot.config.ServesOrganization(org) {
+		if org == "" {
+			return nil, "ignored: no organization in the payload", nil
+		}
+		log.Printf("Ignoring %s webhook of organization %s, which isn't served", event, org)
+		return nil, fmt.Sprintf("ignored: organization %s isn't served", org), nil
+	}
 
-	// Process the PR in a goroutine to avoid blocking the webhook
-	go bot.ProcessPullRequest(payload.Repository, payload.PullRequest)
+	switch event {
+	case "pull_request_review_comment":
+		return bot.reviewCommentJob(body)
+	case "issue_comment":
+		return bot.issueCommentJob(body)
+	case "issues":
+		return bot.issuesJob(body)
+	case "push":
+		return bot.pushJob(body)
+	case "installation", "installation_repositories":
+		return bot.installationJob(body)
+	case "milestone":
+		return bot.milestoneJob(body)
+	case "release":
+		return bot.releaseJob(body)
+	case gitLabMergeRequestEvent:
+		return bot.mergeRequestJob(body)
+	case azurePullRequestCreated, azurePullRequestUpdated:
+		return bot.serviceHookJob(event, body)
+	case gerritPatchSetCreated, gerritWipStateChanged, gerritHashtagsChanged:
+		return bot.gerritEventJob(event, body)
+	default:
+		return bot.pullRequestJob(body)
+	}
+}
 
-	w.WriteHeader(http.StatusOK)
+// pullRequestJob triggers reviews for pull_request events
+func (bot *CycloneBot) pullRequestJob(body []byte) (func() error, string, error) {
+	// Parse the webhook payload
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	if payload.PullRequest == nil || payload.Repository == nil {
+		return nil, "ignored: no pull request in the payload", nil
+	}
+
+	// Changes of the GitHub config repository are validated rather than reviewed
+	repo := payload.Repository
+	if source := bot.config.ReviewConfigSource; source != nil && source.MatchesPullRequest(repo.Owner.Login, repo.Name, payload.PullRequest.Base.Ref, repo.DefaultBranch) {
+		switch payload.Action {
+		case "opened", "synchronize", "reopened", "ready_for_review":
+			return func() error { return bot.ValidateConfigChange(repo, payload.PullRequest) }, "validate configuration change", nil
+		}
+		return nil, fmt.Sprintf("ignored: %q actions don't change the proposed configuration", payload.Action), nil
+	}
+
+	// Merged PRs show which review comments were acted upon
+	if payload.Action == "closed" && payload.PullRequest.Merged {
+		return func() error {
+			bot.TrackAcceptance(payload.Repository, payload.PullRequest)
+			return nil
+		}, "track acted-upon comments of the merged PR", nil
+	}
+
+	// Only process specific actions that warrant a review
+	label := ""
+	if payload.Label != nil {
+		label = payload.Label.Name
+	}
+	if !bot.shouldTriggerReview(payload.Action, payload.PullRequest, label) {
+		log.Printf("Ignoring action: %s for PR #%d", payload.Action, payload.PullRequest.Number)
+		if payload.PullRequest.Draft {
+			return nil, "ignored: draft PRs aren't reviewed", nil
+		}
+		return nil, fmt.Sprintf("ignored: %q actions don't trigger a review", payload.Action), nil
+	}
+
+	log.Printf("Processing PR #%d: %s", payload.PullRequest.Number, payload.Action)
+	return func() error { return bot.ProcessPullRequest(payload.Repository, payload.PullRequest) }, "review", nil
+}
+
+// reviewCommentJob answers replies in threads started by Cyclone's review comments and
+// carries out defer and track commands in them. Maintainers' own review comments are
+// recorded for the conventions memory.
+func (bot *CycloneBot) reviewCommentJob(body []byte) (func() error, string, error) {
+	var payload ReviewCommentPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+
+	if payload.Action == "created" && payload.Comment != nil && payload.PullRequest != nil && payload.Repository != nil &&
+		payload.Comment.InReplyTo == 0 && isMaintainerComment(payload.Comment) &&
+		bot.featureEnabled(payload.Repository.Owner.Login, payload.Repository.Name, config.FeatureLearnedConventions) {
+		return func() error {
+			bot.recordMaintainerComment(payload.Repository, payload.PullRequest, payload.Comment)
+			return nil
+		}, "record maintainer comment for learned conventions", nil
+	}
+
+	// Only new replies matter - skip top-level comments and Cyclone's own answers
+	if payload.Action != "created" || payload.Comment == nil || payload.PullRequest == nil || payload.Repository == nil ||
+		payload.Comment.InReplyTo == 0 ||
+		strings.HasPrefix(payload.Comment.Body, cycloneReplyPrefix) {
+		return nil, "ignored: not a new reply in a review thread", nil
+	}
+
+	if isCommand(payload.Comment.Body, deferCommand) {
+		return func() error {
+			return bot.HandleDeferCommand(payload.Repository, payload.PullRequest, payload.Comment)
+		}, "defer finding to Jira", nil
+	}
+	if isCommand(payload.Comment.Body, trackCommand) {
+		return func() error {
+			return bot.HandleTrackCommand(payload.Repository, payload.PullRequest, payload.Comment)
+		}, "track finding in Linear", nil
+	}
+
+	return func() error {
+		return bot.HandleThreadReply(payload.Repository, payload.PullRequest, payload.Comment)
+	}, "answer thread reply", nil
+}
+
+// issueCommentJob answers "/cyclone" follow-up commands on pull requests
+func (bot *CycloneBot) issueCommentJob(body []byte) (func() error, string, error) {
+	var payload IssueCommentPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+
+	if payload.Action != "created" || !payload.Issue.IsPullRequest() || payload.Comment == nil || payload.Repository == nil ||
+		!strings.HasPrefix(strings.TrimSpace(payload.Comment.Body), followUpCommand) {
+		return nil, fmt.Sprintf("ignored: not a new %s command on a PR", followUpCommand), nil
+	}
+
+	return func() error {
+		return bot.HandleFollowUpCommand(payload.Repository, payload.Issue, payload.Comment)
+	}, "answer follow-up command", nil
+}
+
+// issuesJob triages newly opened issues
+func (bot *CycloneBot) issuesJob(body []byte) (func() error, string, error) {
+	var payload IssuesPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	if payload.Issue == nil || payload.Repository == nil {
+		return nil, "ignored: no issue in the payload", nil
+	}
+
+	owner, repoName := payload.Repository.Owner.Login, payload.Repository.Name
+	if payload.Action != "opened" || bot.issueTriageConfig(owner, repoName) == nil {
+		return nil, "ignored: not a new issue with issue triage configured", nil
+	}
+
+	log.Printf("Triaging issue #%d of %s/%s", payload.Issue.Number, owner, repoName)
+	return func() error {
+		return bot.TriageIssue(payload.Repository, payload.Issue)
+	}, "triage issue", nil
+}
+
+// installationJob onboards the repositories of new GitHub App installations and those added
+// to existing ones
+func (bot *CycloneBot) installationJob(body []byte) (func() error, string, error) {
+	var payload InstallationPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	if !bot.config.AutoOnboarding {
+		return nil, "ignored: automatic onboarding is disabled", nil
+	}
+	if payload.Installation == nil || payload.Installation.Account.Login == "" {
+		return nil, "ignored: no installation in the payload", nil
+	}
+
+	var repoNames []string
+	switch payload.Action {
+	case "created":
+		repoNames = installationRepositoryNames(payload.Repositories)
+	case "added":
+		repoNames = installationRepositoryNames(payload.RepositoriesAdded)
+	}
+	if len(repoNames) == 0 {
+		return nil, "ignored: no repositories were added", nil
+	}
+
+	owner := payload.Installation.Account.Login
+	actor := "github"
+	if payload.Sender != nil {
+		actor += ":" + payload.Sender.Login
+	}
+	log.Printf("%d repositories added to the installation of %s", len(repoNames), owner)
+	return func() error {
+		return bot.OnboardRepositories(owner, repoNames, actor)
+	}, "onboard repositories", nil
 }
 
 // shouldTriggerReview determines if we should review this PR based on action and state
-func (bot *CycloneBot) shouldTriggerReview(action string, pr *github.PullRequest) bool {
+func (bot *CycloneBot) shouldTriggerReview(action string, pr *review.PullRequest, label string) bool {
 	// Skip draft PRs entirely
-	if pr.GetDraft() {
+	if pr.Draft {
 		return false
 	}
 
@@ -61,6 +390,10 @@ func (bot *CycloneBot) shouldTriggerReview(action string, pr *github.PullRequest
 		// Review when PR moves from draft to ready
 		return true
 
+	case "labeled":
+		// Review a PR that was skipped for its size once it is labeled for a forced review
+		return label == config.FORCE_REVIEW_LABEL && !bot.checkPRSize(pr, "").ShouldReview
+
 	case "synchronize":
 		// Only review new commits if PR is not draft and we haven't reviewed recently
 		// You might want to add additional logic here to avoid reviewing every commit
@@ -71,3 +404,113 @@ func (bot *CycloneBot) shouldTriggerReview(action string, pr *github.PullRequest
 		return false
 	}
 }
+
+// pushJob reloads the review configuration when its GitHub config repository changes, drafts
+// releases for pushed tags and reviews direct pushes to protected branches
+func (bot *CycloneBot) pushJob(body []byte) (func() error, string, error) {
+	var payload PushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+
+	repo := payload.Repository
+	if repo != nil && payload.Created && strings.HasPrefix(payload.Ref, tagRefPrefix) {
+		notes := bot.releaseNotesConfig(repo.Owner.Login, repo.Name)
+		if notes == nil || !notes.TagPushes {
+			return nil, "ignored: not a tag push with release notes for tags configured", nil
+		}
+		tag := strings.TrimPrefix(payload.Ref, tagRefPrefix)
+		return func() error { return bot.DraftTagRelease(repo, tag) }, "draft release of the tag", nil
+	}
+
+	source := bot.config.ReviewConfigSource
+	if source == nil || repo == nil || !source.MatchesPush(repo.Owner.Login, repo.Name, payload.Ref, repo.DefaultBranch) {
+		return bot.pushReviewJob(&payload)
+	}
+
+	log.Printf("Push to config repository %s - reloading review configuration", repo.FullName)
+	return func() error {
+		actor := "github"
+		if payload.Sender != nil {
+			actor += ":" + payload.Sender.Login
+		}
+		return bot.ReloadReviewConfig(actor)
+	}, "reload review configuration", nil
+}
+
+// pushReviewJob reviews pushes to the branches push reviews are configured for. New, deleted
+// and force-pushed branches are left out, as their commits have no meaningful base.
+func (bot *CycloneBot) pushReviewJob(payload *PushPayload) (func() error, string, error) {
+	repo := payload.Repository
+	if repo == nil || !strings.HasPrefix(payload.Ref, branchRefPrefix) {
+		return nil, "ignored: not a push to a branch", nil
+	}
+	branch := strings.TrimPrefix(payload.Ref, branchRefPrefix)
+	pushReview := bot.pushReviewConfig(repo.Owner.Login, repo.Name)
+	if pushReview == nil || !pushReview.ReviewsBranch(branch, repo.DefaultBranch) {
+		return nil, "ignored: push reviews aren't configured for the branch", nil
+	}
+	if payload.Created || payload.Deleted || payload.Forced || strings.Trim(payload.Before, "0") == "" {
+		return nil, "ignored: branch was created, deleted or force-pushed", nil
+	}
+
+	message := ""
+	if payload.HeadCommit != nil {
+		message = payload.HeadCommit.Message
+	}
+	return func() error {
+		return bot.ReviewPush(repo, branch, payload.Before, payload.After, message)
+	}, "review of the push", nil
+}
+
+// milestoneJob summarizes the release cycle of closed milestones
+func (bot *CycloneBot) milestoneJob(body []byte) (func() error, string, error) {
+	var payload MilestonePayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	if payload.Milestone == nil || payload.Repository == nil {
+		return nil, "ignored: no milestone in the payload", nil
+	}
+
+	owner, repoName := payload.Repository.Owner.Login, payload.Repository.Name
+	summary := bot.releaseSummaryConfig(owner, repoName)
+	if payload.Action != "closed" || summary == nil || !summary.TriggeredBy(config.SummaryTriggerMilestone) {
+		return nil, "ignored: not a closed milestone with release summaries configured", nil
+	}
+
+	return func() error {
+		return bot.SummarizeMilestone(payload.Repository, payload.Milestone)
+	}, "summarize milestone", nil
+}
+
+// releaseJob drafts the notes of created releases and summarizes the release cycle of
+// published ones, leaving out pre-releases
+func (bot *CycloneBot) releaseJob(body []byte) (func() error, string, error) {
+	var payload ReleasePayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	if payload.Release == nil || payload.Repository == nil {
+		return nil, "ignored: no release in the payload", nil
+	}
+
+	owner, repoName := payload.Repository.Owner.Login, payload.Repository.Name
+	if payload.Action == "created" {
+		if bot.releaseNotesConfig(owner, repoName) == nil {
+			return nil, "ignored: release notes aren't configured", nil
+		}
+		return func() error {
+			return bot.DraftReleaseNotes(payload.Repository, payload.Release)
+		}, "draft release notes", nil
+	}
+
+	summary := bot.releaseSummaryConfig(owner, repoName)
+	if payload.Action != "published" || payload.Release.Prerelease || summary == nil || !summary.TriggeredBy(config.SummaryTriggerRelease) {
+		return nil, "ignored: not a published release with release summaries configured", nil
+	}
+
+	return func() error {
+		return bot.SummarizeRelease(payload.Repository, payload.Release)
+	}, "summarize release", nil
+}
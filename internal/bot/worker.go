@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/internal/store"
+)
+
+// QueueWebhooks makes the bot queue the work webhooks trigger for "cyclone worker" instead of
+// running it, so the server only ingests webhooks
+func (bot *CycloneBot) QueueWebhooks() {
+	bot.queueWebhooks = true
+}
+
+// enqueueWebhook hands a webhook delivery to the workers
+func (bot *CycloneBot) enqueueWebhook(deliveryID, event string, body []byte) error {
+	delivery := store.WebhookDelivery{ID: deliveryID, Event: event, Payload: body}
+	if err := bot.store.EnqueueWebhook(delivery); err != nil {
+		return err
+	}
+
+	log.Printf("Queued %s webhook delivery %s", event, deliveryID)
+	return nil
+}
+
+// RunWorker processes queued webhook deliveries with the given number of concurrent
+// consumers. It never returns.
+func (bot *CycloneBot) RunWorker(concurrency int) {
+	for i := 0; i < concurrency; i++ {
+		go bot.consumeWebhooks()
+	}
+
+	// Deliveries of crashed workers go back to the queue once their claim times out
+	ticker := time.NewTicker(config.QUEUE_CLAIM_TIMEOUT / 2)
+	defer ticker.Stop()
+	for {
+		requeued, err := bot.store.RequeueStaleWebhooks(config.QUEUE_CLAIM_TIMEOUT)
+		if err != nil {
+			log.Printf("Error requeueing stale webhook deliveries: %v", err)
+		} else if requeued > 0 {
+			log.Printf("Requeued %d webhook deliveries whose worker didn't finish them", requeued)
+		}
+		<-ticker.C
+	}
+}
+
+// consumeWebhooks claims and processes queued webhook deliveries one at a time
+func (bot *CycloneBot) consumeWebhooks() {
+	for {
+		job, err := bot.store.ClaimWebhook()
+		if err != nil {
+			log.Printf("Error claiming queued webhook: %v", err)
+		}
+		if job == nil {
+			time.Sleep(config.QUEUE_POLL_INTERVAL)
+			continue
+		}
+
+		log.Printf("Processing %s webhook delivery %s", job.Event, job.ID)
+		if err := bot.runWebhook(job.WebhookDelivery); err != nil && !errors.Is(err, ErrWebhookIgnored) {
+			log.Printf("Webhook delivery %s: %v", job.ID, err)
+		}
+
+		if err := bot.store.CompleteWebhook(job); err != nil {
+			log.Printf("Error completing queued webhook: %v", err)
+		}
+	}
+}
@@ -2,15 +2,54 @@ package config
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log"
+	"net"
+	"net/mail"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"cyclone/internal/logging"
+	"cyclone/internal/secrets"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Load loads both application and review configurations
 func Load() (*Config, *ReviewConfig, error) {
+	cfg, err := LoadAppConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate required configuration
+	if err := cfg.RequireCredentials(); err != nil {
+		return nil, nil, err
+	}
+
+	// Load review configuration from YAML or JSON
+	reviewCfg, err := LoadReviewConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, reviewCfg, nil
+}
+
+// LoadAppConfig loads the application configuration from the environment and the .env file.
+// Credentials are not required here, so subcommands can check for the ones they need.
+func LoadAppConfig() (*Config, error) {
 	// Load .env file if it exists
 	loadEnvFile(".env")
 
@@ -20,26 +59,378 @@ func Load() (*Config, *ReviewConfig, error) {
 		Port:           getEnv("PORT", "8080"),
 		WebhookSecret:  os.Getenv("WEBHOOK_SECRET"),
 		AnthropicToken: os.Getenv("ANTHROPIC_API_KEY"),
+		Model:          getEnv("CLAUDE_MODEL", DEFAULT_MODEL),
+		DataDir:        getEnv("DATA_DIR", "data"),
+		PromptsDir:     os.Getenv("PROMPTS_DIR"),
+		Env:            os.Getenv("CYCLONE_ENV"),
+
+		TranslationsFile: os.Getenv("TRANSLATIONS_FILE"),
+		CaptureWebhooks:  os.Getenv("CAPTURE_WEBHOOKS") == "true",
+		AutoOnboarding:   os.Getenv("AUTO_ONBOARDING") == "true",
+
+		AllowedOrganizations: splitList(os.Getenv("ALLOWED_ORGANIZATIONS")),
+		DeniedOrganizations:  splitList(os.Getenv("DENIED_ORGANIZATIONS")),
+
+		AdminToken:        os.Getenv("ADMIN_TOKEN"),
+		AdminAddr:         os.Getenv("ADMIN_ADDR"),
+		ReviewConfigToken: os.Getenv("REVIEW_CONFIG_TOKEN"),
+
+		SentryDSN:     os.Getenv("SENTRY_DSN"),
+		SentryRelease: os.Getenv("SENTRY_RELEASE"),
+
+		Debug: os.Getenv("CYCLONE_DEBUG") == "true",
+
+		AlertWebhookURL: os.Getenv("ALERT_WEBHOOK_URL"),
+
+		SlackBotToken: os.Getenv("SLACK_BOT_TOKEN"),
+
+		LinearAPIKey: os.Getenv("LINEAR_API_KEY"),
+
+		PagerDutyRoutingKey: os.Getenv("PAGERDUTY_ROUTING_KEY"),
+		OpsgenieAPIKey:      os.Getenv("OPSGENIE_API_KEY"),
 	}
 
-	// Validate required configuration
-	if cfg.GitHubToken == "" {
-		return nil, nil, fmt.Errorf("GITHUB_TOKEN environment variable is required")
+	// The organization is read from the payload, which anyone can forge without a signature
+	if (len(cfg.AllowedOrganizations) > 0 || len(cfg.DeniedOrganizations) > 0) && cfg.WebhookSecret == "" {
+		return nil, fmt.Errorf("WEBHOOK_SECRET is required with ALLOWED_ORGANIZATIONS or DENIED_ORGANIZATIONS")
 	}
 
-	if cfg.AnthropicToken == "" {
-		return nil, nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required")
+	if cfg.AdminAddr != "" {
+		if _, port, err := net.SplitHostPort(cfg.AdminAddr); err != nil || port == cfg.Port {
+			return nil, fmt.Errorf("invalid ADMIN_ADDR: expected a host and port other than PORT, e.g. 127.0.0.1:9090, got %q", cfg.AdminAddr)
+		}
 	}
 
-	// Load review configuration from JSON file
-	reviewCfg, err := loadReviewConfig("review-config.json")
+	refreshInterval, err := time.ParseDuration(getEnv("SECRETS_REFRESH_INTERVAL", DEFAULT_SECRETS_REFRESH_INTERVAL.String()))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load review configuration: %w", err)
+		return nil, fmt.Errorf("invalid SECRETS_REFRESH_INTERVAL: %w", err)
+	}
+	cfg.SecretsRefreshInterval = refreshInterval
+
+	alertAfter, err := strconv.Atoi(getEnv("ALERT_AFTER_FAILURES", strconv.Itoa(DEFAULT_ALERT_AFTER_FAILURES)))
+	if err != nil || alertAfter < 1 {
+		return nil, fmt.Errorf("invalid ALERT_AFTER_FAILURES: expected a positive number, got %q", os.Getenv("ALERT_AFTER_FAILURES"))
 	}
+	cfg.AlertAfterFailures = alertAfter
 
-	log.Printf("Loaded configuration for %d organizations", len(reviewCfg.Organizations))
+	pageErrorRate, err := strconv.ParseFloat(getEnv("PAGE_ERROR_RATE", strconv.FormatFloat(DEFAULT_PAGE_ERROR_RATE, 'f', -1, 64)), 64)
+	if err != nil || pageErrorRate <= 0 || pageErrorRate > 1 {
+		return nil, fmt.Errorf("invalid PAGE_ERROR_RATE: expected a share between 0 and 1, got %q", os.Getenv("PAGE_ERROR_RATE"))
+	}
+	cfg.PageErrorRate = pageErrorRate
 
-	return cfg, reviewCfg, nil
+	for key, field := range map[string]*time.Duration{
+		"RETENTION_REVIEW_CONTENT_DAYS": &cfg.Retention.ReviewContent,
+		"RETENTION_CONVERSATIONS_DAYS":  &cfg.Retention.Conversations,
+		"RETENTION_WEBHOOKS_DAYS":       &cfg.Retention.Webhooks,
+	} {
+		days, err := strconv.Atoi(getEnv(key, "0"))
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid %s: expected a number of days, got %q", key, os.Getenv(key))
+		}
+		*field = time.Duration(days) * 24 * time.Hour
+	}
+
+	for key, timeout := range map[string]struct {
+		field    *time.Duration
+		fallback time.Duration
+	}{
+		"HTTP_READ_HEADER_TIMEOUT": {&cfg.HTTP.ReadHeaderTimeout, DEFAULT_HTTP_READ_HEADER_TIMEOUT},
+		"HTTP_READ_TIMEOUT":        {&cfg.HTTP.ReadTimeout, DEFAULT_HTTP_READ_TIMEOUT},
+		"HTTP_WRITE_TIMEOUT":       {&cfg.HTTP.WriteTimeout, DEFAULT_HTTP_WRITE_TIMEOUT},
+		"HTTP_IDLE_TIMEOUT":        {&cfg.HTTP.IdleTimeout, DEFAULT_HTTP_IDLE_TIMEOUT},
+	} {
+		value, err := time.ParseDuration(getEnv(key, timeout.fallback.String()))
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid %s: expected a duration such as 30s, got %q", key, os.Getenv(key))
+		}
+		*timeout.field = value
+	}
+
+	maxHeaderBytes, err := strconv.Atoi(getEnv("HTTP_MAX_HEADER_BYTES", strconv.Itoa(DEFAULT_HTTP_MAX_HEADER_BYTES)))
+	if err != nil || maxHeaderBytes < 1 {
+		return nil, fmt.Errorf("invalid HTTP_MAX_HEADER_BYTES: expected a positive number, got %q", os.Getenv("HTTP_MAX_HEADER_BYTES"))
+	}
+	cfg.HTTP.MaxHeaderBytes = maxHeaderBytes
+
+	oauth, err := loadOAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.OAuth = oauth
+
+	smtp, err := loadSMTPConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.SMTP = smtp
+
+	jira, err := loadJiraConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Jira = jira
+
+	// Secrets managers may only be reachable through the proxy too
+	proxy, err := loadProxyConfig()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		password, _ := proxy.URL.User.Password()
+		logging.AddSecrets(password)
+		proxy.Install()
+	}
+	cfg.Proxy = proxy
+
+	// Credentials may reference a secrets manager instead of holding the value
+	if err := resolveSecrets(cfg); err != nil {
+		return nil, err
+	}
+	logging.AddSecrets(cfg.GitHubToken, cfg.AnthropicToken, cfg.WebhookSecret, cfg.AdminToken, cfg.ReviewConfigToken, cfg.SentryDSN, cfg.AlertWebhookURL, cfg.SlackBotToken, cfg.LinearAPIKey, cfg.PagerDutyRoutingKey, cfg.OpsgenieAPIKey)
+	if cfg.OAuth != nil {
+		logging.AddSecrets(cfg.OAuth.ClientSecret, cfg.OAuth.SessionSecret)
+	}
+	if cfg.SMTP != nil {
+		logging.AddSecrets(cfg.SMTP.Password)
+	}
+	if cfg.Jira != nil {
+		logging.AddSecrets(cfg.Jira.APIToken)
+	}
+
+	// The review configuration is read from a local file unless a remote source is configured
+	if source := os.Getenv("REVIEW_CONFIG_SOURCE"); source != "" {
+		configSource, err := ParseConfigSource(source)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ReviewConfigSource = configSource
+		if cfg.Env != "" {
+			log.Printf("Environment overlays only apply to local review configuration files - ignoring CYCLONE_ENV=%s", cfg.Env)
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadOAuthConfig reads the GitHub OAuth settings, returning nil if no OAuth app is configured
+func loadOAuthConfig() (*OAuthConfig, error) {
+	clientID := os.Getenv("GITHUB_OAUTH_CLIENT_ID")
+	if clientID == "" {
+		return nil, nil
+	}
+
+	oauth := &OAuthConfig{
+		ClientID:      clientID,
+		ClientSecret:  os.Getenv("GITHUB_OAUTH_CLIENT_SECRET"),
+		RedirectURL:   os.Getenv("GITHUB_OAUTH_REDIRECT_URL"),
+		AllowedOrgs:   splitList(os.Getenv("OAUTH_ALLOWED_ORGS")),
+		AllowedTeams:  splitList(os.Getenv("OAUTH_ALLOWED_TEAMS")),
+		SessionSecret: os.Getenv("SESSION_SECRET"),
+	}
+	if oauth.ClientSecret == "" {
+		return nil, fmt.Errorf("GITHUB_OAUTH_CLIENT_SECRET is required with GITHUB_OAUTH_CLIENT_ID")
+	}
+	// Without a restriction, anyone with a GitHub account could sign in
+	if len(oauth.AllowedOrgs) == 0 && len(oauth.AllowedTeams) == 0 {
+		return nil, fmt.Errorf("OAUTH_ALLOWED_ORGS or OAUTH_ALLOWED_TEAMS is required with GITHUB_OAUTH_CLIENT_ID")
+	}
+	for _, team := range oauth.AllowedTeams {
+		if org, slug, ok := strings.Cut(team, "/"); !ok || org == "" || slug == "" {
+			return nil, fmt.Errorf("invalid team %q in OAUTH_ALLOWED_TEAMS, expected org/team-slug", team)
+		}
+	}
+	return oauth, nil
+}
+
+// loadSMTPConfig reads the mail server for email digests, returning nil if SMTP_HOST is unset
+func loadSMTPConfig() (*SMTPConfig, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	smtp := &SMTPConfig{
+		Host:     host,
+		Port:     getEnv("SMTP_PORT", DEFAULT_SMTP_PORT),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if smtp.From == "" {
+		return nil, fmt.Errorf("SMTP_FROM is required with SMTP_HOST")
+	}
+	if _, err := mail.ParseAddress(smtp.From); err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM %q: %w", smtp.From, err)
+	}
+	return smtp, nil
+}
+
+// loadJiraConfig reads the Jira site for deferred findings, returning nil if JIRA_BASE_URL is unset
+func loadJiraConfig() (*JiraConfig, error) {
+	baseURL := os.Getenv("JIRA_BASE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	jira := &JiraConfig{
+		BaseURL:   baseURL,
+		Email:     os.Getenv("JIRA_EMAIL"),
+		APIToken:  os.Getenv("JIRA_API_TOKEN"),
+		IssueType: getEnv("JIRA_ISSUE_TYPE", DEFAULT_JIRA_ISSUE_TYPE),
+	}
+	if jira.Email == "" || jira.APIToken == "" {
+		return nil, fmt.Errorf("JIRA_EMAIL and JIRA_API_TOKEN are required with JIRA_BASE_URL")
+	}
+	return jira, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// RequireCredentials checks that the GitHub and Anthropic credentials needed for reviewing PRs are set
+func (c *Config) RequireCredentials() error {
+	if c.GitHubToken == "" {
+		return fmt.Errorf("GITHUB_TOKEN environment variable is required")
+	}
+
+	if c.AnthropicToken == "" {
+		return fmt.Errorf("ANTHROPIC_API_KEY environment variable is required")
+	}
+	return nil
+}
+
+// ServesOrganization reports whether webhooks of an organization are served: it must be on
+// the allowlist, if there is one, and not on the denylist. Names are compared case-insensitively,
+// like GitHub does.
+func (c *Config) ServesOrganization(org string) bool {
+	matches := func(names []string) bool {
+		for _, name := range names {
+			if strings.EqualFold(name, org) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(c.DeniedOrganizations) {
+		return false
+	}
+	return len(c.AllowedOrganizations) == 0 || matches(c.AllowedOrganizations)
+}
+
+// HasReviewConfig reports whether a review configuration is set up, remotely or as a local file
+func (c *Config) HasReviewConfig() bool {
+	if c.ReviewConfigSource != nil {
+		return true
+	}
+	_, err := os.Stat(ReviewConfigFile())
+	return err == nil
+}
+
+// LoadReviewConfig loads the review configuration from the remote source or the local config file
+func LoadReviewConfig(cfg *Config) (*ReviewConfig, error) {
+	var reviewCfg *ReviewConfig
+	var name string
+	var err error
+
+	if source := cfg.ReviewConfigSource; source != nil {
+		name = source.String()
+		var data []byte
+		data, err = source.fetch(cfg.GitHubToken, cfg.ReviewConfigToken)
+		if err == nil {
+			reviewCfg, err = parseReviewConfig(name, data, source.isYAML())
+		}
+	} else {
+		name = ReviewConfigFile()
+		reviewCfg, err = loadReviewConfig(name, cfg.Env)
+		if overlay := OverlayFile(name, cfg.Env); overlay != "" {
+			name += " with overlay " + overlay
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load review configuration: %w", err)
+	}
+
+	log.Printf("Loaded configuration for %d organizations from %s", len(reviewCfg.Organizations), name)
+	for _, warning := range reviewCfg.Warnings() {
+		log.Printf("Warning: %s: %s", name, warning)
+	}
+	return reviewCfg, nil
+}
+
+// SecretFields returns the credentials that may be loaded from a secrets manager, keyed by environment variable
+func (c *Config) SecretFields() map[string]*string {
+	return map[string]*string{
+		"GITHUB_TOKEN":      &c.GitHubToken,
+		"ANTHROPIC_API_KEY": &c.AnthropicToken,
+		"WEBHOOK_SECRET":    &c.WebhookSecret,
+	}
+}
+
+// resolveSecrets replaces secret references in the credentials with their current values
+// and remembers the references for rotation
+func resolveSecrets(cfg *Config) error {
+	cfg.SecretRefs = make(map[string]string)
+	for name, field := range cfg.SecretFields() {
+		if !secrets.IsReference(*field) {
+			continue
+		}
+
+		ref := *field
+		value, err := secrets.Resolve(context.Background(), ref)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", name, err)
+		}
+
+		*field = value
+		cfg.SecretRefs[name] = ref
+		log.Printf("Loaded %s from %s", name, ref)
+	}
+	return nil
+}
+
+// ReviewConfigVersion returns a fingerprint of the review configuration that changes whenever
+// the configuration does, or "" if it can't be determined right now
+func (c *Config) ReviewConfigVersion() string {
+	if source := c.ReviewConfigSource; source != nil {
+		data, err := source.fetch(c.GitHubToken, c.ReviewConfigToken)
+		if err != nil {
+			log.Printf("Error checking review configuration for changes: %v", err)
+			return ""
+		}
+		return fmt.Sprintf("%x", sha256.Sum256(data))
+	}
+
+	filename := ReviewConfigFile()
+	info, err := os.Stat(filename)
+	if err != nil {
+		return ""
+	}
+	version := filename + "@" + info.ModTime().String()
+
+	// Adding, changing or removing the overlay changes the configuration as well
+	if overlay := OverlayFile(filename, c.Env); overlay != "" {
+		if info, err := os.Stat(overlay); err == nil {
+			version += "," + overlay + "@" + info.ModTime().String()
+		}
+	}
+	return version
+}
+
+// ReviewConfigPollInterval returns how often the review configuration is checked for changes
+func (c *Config) ReviewConfigPollInterval() time.Duration {
+	if c.ReviewConfigSource != nil {
+		return REMOTE_CONFIG_POLL_INTERVAL
+	}
+	return CONFIG_POLL_INTERVAL
 }
 
 // GetRepositoryConfig finds the configuration for a specific repository
@@ -51,14 +442,21 @@ func (rc *ReviewConfig) GetRepositoryConfig(owner, repoName string) *RepositoryC
 		if org.Name == owner {
 			// Look for specific repository config
 			for _, repo := range org.Repositories {
-				if repo.Name == repoName {
+				if repo.Name == repoName && !repo.isPattern() {
+					return &repo
+				}
+			}
+
+			// Then for the first glob or regex entry matching the name
+			for _, repo := range org.Repositories {
+				if repo.isPattern() && repo.matches(repoName) && !repo.excludes(repoName) {
 					return &repo
 				}
 			}
 
 			// Look for a wildcard/default repository config
 			for _, repo := range org.Repositories {
-				if repo.Name == "*" || repo.Name == "default" {
+				if repo.isWildcard() && !repo.excludes(repoName) {
 					return &repo
 				}
 			}
@@ -69,6 +467,507 @@ func (rc *ReviewConfig) GetRepositoryConfig(owner, repoName string) *RepositoryC
 	return nil
 }
 
+// DefaultRepositoryConfig returns the settings used for repositories without a matching entry
+func DefaultRepositoryConfig(repoName string) *RepositoryConfig {
+	return &RepositoryConfig{
+		Name:         repoName,
+		Precision:    PrecisionMedium,
+		CustomPrompt: "",
+	}
+}
+
+// IsDryRun reports whether reviews for a repository are generated without posting anything,
+// either because the whole configuration or the repository's entry is in dry run
+func (rc *ReviewConfig) IsDryRun(repoConfig *RepositoryConfig) bool {
+	return rc.DryRun || repoConfig.DryRun
+}
+
+// FeatureEnabled reports whether a feature is turned on for a repository. The repository
+// entry's flag overrides its organization's, which overrides the installation-wide one;
+// features without a flag are off.
+func (rc *ReviewConfig) FeatureEnabled(owner, repoName string, feature Feature) bool {
+	if repo := rc.GetRepositoryConfig(owner, repoName); repo != nil {
+		if enabled, ok := repo.Features[feature]; ok {
+			return enabled
+		}
+	}
+	if org := rc.GetOrganizationConfig(owner); org != nil {
+		if enabled, ok := org.Features[feature]; ok {
+			return enabled
+		}
+	}
+	return rc.Features[feature]
+}
+
+// FeatureFlags returns the effective feature flags of a repository, see FeatureEnabled
+func (rc *ReviewConfig) FeatureFlags(owner, repoName string) map[Feature]bool {
+	flags := make(map[Feature]bool, len(rc.Features))
+	for feature, enabled := range rc.Features {
+		flags[feature] = enabled
+	}
+	if org := rc.GetOrganizationConfig(owner); org != nil {
+		for feature, enabled := range org.Features {
+			flags[feature] = enabled
+		}
+	}
+	if repo := rc.GetRepositoryConfig(owner, repoName); repo != nil {
+		for feature, enabled := range repo.Features {
+			flags[feature] = enabled
+		}
+	}
+	return flags
+}
+
+// IsExcluded reports whether a repository is excluded by a wildcard or pattern entry of its
+// organization and not matched by any other entry. Excluded repositories are not reviewed.
+func (rc *ReviewConfig) IsExcluded(owner, repoName string) bool {
+	org := rc.GetOrganizationConfig(owner)
+	if org == nil || rc.GetRepositoryConfig(owner, repoName) != nil {
+		return false
+	}
+
+	for _, repo := range org.Repositories {
+		if (repo.isWildcard() || (repo.isPattern() && repo.matches(repoName))) && repo.excludes(repoName) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWildcard reports whether the entry applies to all otherwise unconfigured repositories
+func (rc *RepositoryConfig) isWildcard() bool {
+	return rc.Name == "*" || rc.Name == "default"
+}
+
+// isPattern reports whether the entry's name is a glob ("service-*") or regular expression
+func (rc *RepositoryConfig) isPattern() bool {
+	return rc.Regex || (!rc.isWildcard() && strings.ContainsAny(rc.Name, "*?["))
+}
+
+// matches reports whether a pattern entry applies to the repository. Regular expressions
+// must match the whole name.
+func (rc *RepositoryConfig) matches(repoName string) bool {
+	if rc.Regex {
+		matched, _ := regexp.MatchString("^(?:"+rc.Name+")$", repoName)
+		return matched
+	}
+	matched, _ := path.Match(rc.Name, repoName)
+	return matched
+}
+
+// excludes reports whether the entry's exclude list (names or globs) covers the repository
+func (rc *RepositoryConfig) excludes(repoName string) bool {
+	for _, excluded := range rc.Exclude {
+		if matched, _ := path.Match(excluded, repoName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// WithManagedOrganizations returns a copy of the configuration in which the given organizations
+// replace configured organizations of the same name; new organizations are appended
+func (rc *ReviewConfig) WithManagedOrganizations(orgs []OrganizationConfig) *ReviewConfig {
+	merged := &ReviewConfig{
+		DryRun:            rc.DryRun,
+		PrecisionProfiles: rc.PrecisionProfiles,
+		Organizations:     append([]OrganizationConfig(nil), rc.Organizations...),
+	}
+	for _, org := range orgs {
+		replaced := false
+		for i := range merged.Organizations {
+			if merged.Organizations[i].Name == org.Name {
+				merged.Organizations[i] = org
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Organizations = append(merged.Organizations, org)
+		}
+	}
+	return merged
+}
+
+// GetOrganizationConfig finds the configuration for an organization, or nil if it isn't configured
+func (rc *ReviewConfig) GetOrganizationConfig(owner string) *OrganizationConfig {
+	for i := range rc.Organizations {
+		if rc.Organizations[i].Name == owner {
+			return &rc.Organizations[i]
+		}
+	}
+	return nil
+}
+
+// GetAnthropicAPIKey returns the organization's own Anthropic key, or "" to use the global key
+// unless the organization is isolated
+func (oc *OrganizationConfig) GetAnthropicAPIKey() string {
+	if oc.AnthropicAPIKeyEnv != "" {
+		if key := os.Getenv(oc.AnthropicAPIKeyEnv); key != "" {
+			return key
+		}
+		log.Printf("Environment variable %s for organization %s is empty - %s", oc.AnthropicAPIKeyEnv, oc.Name, oc.fallback("the global key"))
+	}
+	return oc.AnthropicAPIKey
+}
+
+// GetAPIToken returns the token granting access to the organization's own data, "" if it has none
+func (oc *OrganizationConfig) GetAPIToken() string {
+	if oc.APITokenEnv == "" {
+		return ""
+	}
+	return os.Getenv(oc.APITokenEnv)
+}
+
+// fallback describes what happens without one of the organization's own credentials
+func (oc *OrganizationConfig) fallback(global string) string {
+	if oc.Isolated {
+		return "its requests will fail, as it is isolated"
+	}
+	return "using " + global
+}
+
+// GetGitHubToken returns the organization's own GitHub token, or "" to use the global token
+// unless the organization is isolated
+func (oc *OrganizationConfig) GetGitHubToken() string {
+	if oc.GitHubTokenEnv != "" {
+		if token := os.Getenv(oc.GitHubTokenEnv); token != "" {
+			return token
+		}
+		log.Printf("Environment variable %s for organization %s is empty - %s", oc.GitHubTokenEnv, oc.Name, oc.fallback("the global GitHub token"))
+	}
+	return oc.GitHubToken
+}
+
+// GetProvider returns the code host of the organization's repositories
+func (oc *OrganizationConfig) GetProvider() string {
+	if oc.Provider == "" {
+		return ProviderGitHub
+	}
+	return oc.Provider
+}
+
+// GetToken returns the GitLab access token
+func (gc *GitLabConfig) GetToken() string {
+	if gc.TokenEnv != "" {
+		return os.Getenv(gc.TokenEnv)
+	}
+	return gc.Token
+}
+
+// GetToken returns the Azure DevOps personal access token
+func (ac *AzureDevOpsConfig) GetToken() string {
+	if ac.TokenEnv != "" {
+		return os.Getenv(ac.TokenEnv)
+	}
+	return ac.Token
+}
+
+// GetURL returns the URL of an Azure DevOps organization
+func (ac *AzureDevOpsConfig) GetURL(orgName string) string {
+	if ac.URL != "" {
+		return ac.URL
+	}
+	return "https://dev.azure.com/" + orgName
+}
+
+// GetToken returns the HTTP password of the Gerrit account
+func (gc *GerritConfig) GetToken() string {
+	if gc.TokenEnv != "" {
+		return os.Getenv(gc.TokenEnv)
+	}
+	return gc.Token
+}
+
+// PrivateKey reads the GitHub App's PEM encoded private key
+func (app *GitHubAppConfig) PrivateKey() ([]byte, error) {
+	if app.PrivateKeyEnv != "" {
+		if key := os.Getenv(app.PrivateKeyEnv); key != "" {
+			return []byte(key), nil
+		}
+		return nil, fmt.Errorf("environment variable %s is empty", app.PrivateKeyEnv)
+	}
+
+	key, err := os.ReadFile(app.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	return key, nil
+}
+
+// GetAction returns the configured action for an exhausted quota
+func (q *QuotaConfig) GetAction() QuotaAction {
+	if q.OnExceeded == QuotaActionDowngrade {
+		return QuotaActionDowngrade
+	}
+	return QuotaActionSkip
+}
+
+// TriggeredBy reports whether an event completes a release cycle, by default both do
+func (s *ReleaseSummaryConfig) TriggeredBy(trigger SummaryTrigger) bool {
+	if len(s.Triggers) == 0 {
+		return true
+	}
+	for _, t := range s.Triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewsBranch reports whether pushes to a branch are reviewed
+func (p *PushReviewConfig) ReviewsBranch(branch, defaultBranch string) bool {
+	if len(p.Branches) == 0 {
+		return branch == defaultBranch
+	}
+	for _, b := range p.Branches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCategories returns the sections of the release notes
+func (n *ReleaseNotesConfig) GetCategories() []string {
+	if len(n.Categories) == 0 {
+		return DefaultReleaseNoteCategories
+	}
+	return n.Categories
+}
+
+// GetLookbackDays returns how many days of issues are searched for duplicates
+func (t *IssueTriageConfig) GetLookbackDays() int {
+	if t.LookbackDays <= 0 {
+		return DEFAULT_TRIAGE_LOOKBACK_DAYS
+	}
+	return t.LookbackDays
+}
+
+// GetCount returns how many reviewers are suggested
+func (s *ReviewerSuggestionsConfig) GetCount() int {
+	if s.Count <= 0 {
+		return DEFAULT_SUGGESTED_REVIEWERS
+	}
+	return s.Count
+}
+
+// GetFrequency returns how often the digest is sent
+func (d *DigestConfig) GetFrequency() DigestFrequency {
+	if d.Frequency == DigestDaily {
+		return DigestDaily
+	}
+	return DigestWeekly
+}
+
+// GetDowngradeModel returns the model used for summary-only reviews
+func (q *QuotaConfig) GetDowngradeModel() string {
+	if q.DowngradeModel == "" {
+		return DEFAULT_DOWNGRADE_MODEL
+	}
+	return q.DowngradeModel
+}
+
+// AssignPromptVariant deterministically assigns a PR to the experiment variant or the control ("").
+// The same PR always lands in the same arm, so re-reviews stay comparable.
+func (pe *PromptExperiment) AssignPromptVariant(prKey string) string {
+	if pe == nil || pe.Variant == "" || pe.TrafficPercent <= 0 {
+		return ""
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(prKey))
+	if int(hash.Sum32()%100) < pe.TrafficPercent {
+		return pe.Variant
+	}
+	return ""
+}
+
+// UsesExtendedThinking reports whether reviews for this repository should enable Claude extended thinking
+func (rc *RepositoryConfig) UsesExtendedThinking() bool {
+	return rc.ExtendedThinking && rc.Precision == PrecisionStrict
+}
+
+// GetThinkingBudget returns the configured thinking token budget, clamped to API limits
+func (rc *RepositoryConfig) GetThinkingBudget() int {
+	if rc.ThinkingBudget <= 0 {
+		return DEFAULT_THINKING_BUDGET
+	}
+	if rc.ThinkingBudget < MIN_THINKING_BUDGET {
+		return MIN_THINKING_BUDGET
+	}
+	return rc.ThinkingBudget
+}
+
+// GetCommentLines returns the diff lines line comments may be placed on
+func (rc *RepositoryConfig) GetCommentLines() CommentLines {
+	if rc.CommentLines == "" {
+		return CommentLinesHunk
+	}
+	return rc.CommentLines
+}
+
+// GetTokenBudget returns the maximum number of input tokens for a single review
+func (rc *RepositoryConfig) GetTokenBudget() int {
+	if rc.TokenBudget <= 0 {
+		return DEFAULT_TOKEN_BUDGET
+	}
+	return rc.TokenBudget
+}
+
+// ParseRepoConfigFile parses the contents of an in-repo configuration file, which may
+// select one of the precision profiles of the review configuration
+func (rc *ReviewConfig) ParseRepoConfigFile(data []byte) (*RepoConfigFile, error) {
+	var file RepoConfigFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s: %w", REPO_CONFIG_FILE, err)
+	}
+
+	if !rc.validPrecision(file.Precision) {
+		return nil, fmt.Errorf("invalid precision %q in %s (use minor, medium, strict or a precision profile)", file.Precision, REPO_CONFIG_FILE)
+	}
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	validateImportRules(file.ImportRules, "import_rules", addProblem)
+	validateStyleGuides(file.StyleGuides, "style_guides", false, addProblem)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid %s: %s", REPO_CONFIG_FILE, strings.Join(problems, "; "))
+	}
+
+	return &file, nil
+}
+
+// WithRepoConfigFile returns a copy of the repository configuration with the in-repo settings applied
+func (rc *RepositoryConfig) WithRepoConfigFile(file *RepoConfigFile) *RepositoryConfig {
+	merged := *rc
+	if file.Precision != "" {
+		merged.Precision = file.Precision
+	}
+	if file.Language != "" {
+		merged.Language = file.Language
+	}
+	if file.CustomPrompt != "" {
+		merged.CustomPrompt = strings.TrimSpace(rc.CustomPrompt + "\n\n" + file.CustomPrompt)
+	}
+	if len(file.IgnorePaths) > 0 {
+		merged.IgnorePaths = append(append([]string(nil), rc.IgnorePaths...), file.IgnorePaths...)
+	}
+	if len(file.ImportRules) > 0 {
+		merged.ImportRules = append(append([]ImportRule(nil), rc.ImportRules...), file.ImportRules...)
+	}
+	if len(file.StyleGuides) > 0 {
+		merged.StyleGuides = append(append([]string(nil), rc.StyleGuides...), file.StyleGuides...)
+	}
+	return &merged
+}
+
+// IsStyleGuideURL reports whether a style guide is fetched from a URL rather than the repository
+func IsStyleGuideURL(guide string) bool {
+	return strings.Contains(guide, "://")
+}
+
+// GetCategories returns the comment categories of the repository
+func (rc *RepositoryConfig) GetCategories() []CommentCategory {
+	if len(rc.Categories) == 0 {
+		return DefaultCommentCategories
+	}
+	return rc.Categories
+}
+
+// Prefix returns the category prefix of a line comment, e.g. "⚠️ **issue**:"
+func (c CommentCategory) Prefix() string {
+	if c.Emoji == "" {
+		return fmt.Sprintf("**%s**:", c.Name)
+	}
+	return fmt.Sprintf("%s **%s**:", c.Emoji, c.Name)
+}
+
+// MatchesPath reports whether a file path matches an ignore pattern. Patterns use path.Match
+// syntax against the full path ("docs/*.md"); patterns without a slash also match the file name
+// anywhere ("*.lock"), and a trailing slash matches a whole directory ("vendor/"). For familiarity,
+// "payments/**" is the same as "payments/" and "**/*.sql" the same as "*.sql".
+func MatchesPath(pattern, filePath string) bool {
+	pattern = strings.TrimPrefix(pattern, "**/")
+	if strings.HasSuffix(pattern, "/**") {
+		pattern = strings.TrimSuffix(pattern, "**")
+	}
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(filePath, pattern) || strings.Contains(filePath, "/"+pattern)
+	}
+	if matched, _ := path.Match(pattern, filePath); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	return false
+}
+
+// GetPrecisionProfile returns the precision profile of the given name, or nil for built-in levels
+func (rc *ReviewConfig) GetPrecisionProfile(precision ReviewPrecision) *PrecisionProfile {
+	for i := range rc.PrecisionProfiles {
+		if ReviewPrecision(rc.PrecisionProfiles[i].Name) == precision {
+			return &rc.PrecisionProfiles[i]
+		}
+	}
+	return nil
+}
+
+// WithPrecisionProfile returns a copy of the repository configuration carrying the guidelines of
+// the precision profiles it uses, for the whole repository and for path overrides
+func (rc *ReviewConfig) WithPrecisionProfile(repoConfig *RepositoryConfig) *RepositoryConfig {
+	resolved := *repoConfig
+	if profile := rc.GetPrecisionProfile(repoConfig.Precision); profile != nil {
+		resolved.PrecisionGuidelines = profile.guidelines()
+	}
+
+	resolved.PathPrecision = make([]PathPrecision, len(repoConfig.PathPrecision))
+	for i, override := range repoConfig.PathPrecision {
+		if profile := rc.GetPrecisionProfile(override.Precision); profile != nil {
+			override.Guidelines = profile.guidelines()
+		}
+		resolved.PathPrecision[i] = override
+	}
+	return &resolved
+}
+
+// guidelines formats the profile's guidelines like those of the built-in precision levels
+func (p *PrecisionProfile) guidelines() string {
+	return fmt.Sprintf("**Review Focus (%s):**\n%s", p.Name, strings.TrimSpace(p.Guidelines))
+}
+
+// GetGuidelines returns the review guidelines of the override's precision level or profile
+func (pp PathPrecision) GetGuidelines() string {
+	if pp.Guidelines != "" {
+		return pp.Guidelines
+	}
+	return GetPrecisionGuidelines(pp.Precision)
+}
+
+// PathPrecisionFor returns the precision override applying to a file, or nil if there is none
+func (rc *RepositoryConfig) PathPrecisionFor(filePath string) *PathPrecision {
+	for i := range rc.PathPrecision {
+		if MatchesPath(rc.PathPrecision[i].Path, filePath) {
+			return &rc.PathPrecision[i]
+		}
+	}
+	return nil
+}
+
+// GetPrecisionGuidelines returns the review guidelines of the repository's precision level or profile
+func (rc *RepositoryConfig) GetPrecisionGuidelines() string {
+	if rc.PrecisionGuidelines != "" {
+		return rc.PrecisionGuidelines
+	}
+	return GetPrecisionGuidelines(rc.Precision)
+}
+
 // GetPrecisionGuidelines returns review guidelines based on precision level
 func GetPrecisionGuidelines(precision ReviewPrecision) string {
 	switch precision {
@@ -96,23 +995,125 @@ func GetPrecisionGuidelines(precision ReviewPrecision) string {
 	}
 }
 
-// loadReviewConfig loads review configuration from a JSON file
-func loadReviewConfig(filename string) (*ReviewConfig, error) {
-	file, err := os.Open(filename)
+// GetToneGuidelines returns tone-specific instructions for the review prompt
+func GetToneGuidelines(tone ReviewTone) string {
+	switch tone {
+	case ToneFormal:
+		return `- Professional and neutral, as in a formal code audit
+- Write complete sentences; no jokes, exclamations or casual phrasing
+- No emojis other than the required category prefixes`
+
+	case ToneTerse:
+		return `- As brief as possible: a summary of at most three short bullet points
+- One or two sentences per comment; only add code examples when the fix isn't obvious
+- No pleasantries and no emojis other than the required category prefixes`
+
+	case ToneEmojiLight:
+		return `- Friendly and collaborative, like a helpful teammate
+- No decorative emojis - only the required category prefixes`
+
+	default: // ToneFriendly
+		return `- Warm, engaging and encouraging, like a supportive teammate
+- Use emojis carefully to make the review visually appealing (🚀 ✨ 🎯 📈 🔧 etc.)`
+	}
+}
+
+// IncludesPoem reports whether reviews in this tone end with a poem
+func (tone ReviewTone) IncludesPoem() bool {
+	return tone == "" || tone == ToneFriendly
+}
+
+// reviewConfigFiles are the review configuration files looked up at startup, in order of preference
+var reviewConfigFiles = []string{"review-config.yaml", "review-config.yml", "review-config.json"}
+
+// ReviewConfigFile returns the first review configuration file that exists
+func ReviewConfigFile() string {
+	for _, filename := range reviewConfigFiles {
+		if _, err := os.Stat(filename); err == nil {
+			return filename
+		}
+	}
+	// Report the JSON file, the historical default, as missing
+	return reviewConfigFiles[len(reviewConfigFiles)-1]
+}
+
+// LoadReviewConfigFile loads and validates a specific review configuration file with the
+// overlay of an environment applied, if env is set and the overlay exists
+func LoadReviewConfigFile(filename, env string) (*ReviewConfig, error) {
+	return loadReviewConfig(filename, env)
+}
+
+// loadReviewConfig loads review configuration from a JSON or YAML file, detected by extension,
+// and applies the environment overlay
+func loadReviewConfig(filename, env string) (*ReviewConfig, error) {
+	overlay := OverlayFile(filename, env)
+	if overlay == "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config file %s: %w", filename, err)
+		}
+		return parseReviewConfig(filename, data, isYAMLFile(filename))
+	}
+
+	base, err := readConfigDocument(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open config file %s: %w", filename, err)
+		return nil, err
 	}
-	defer file.Close()
+	overlayDocument, err := readConfigDocument(overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(applyOverlay(base, overlayDocument))
+	if err != nil {
+		return nil, err
+	}
+	return parseReviewConfig(filename+" with overlay "+overlay, data, false)
+}
 
+// parseReviewConfig parses and validates a review configuration read from name
+func parseReviewConfig(name string, data []byte, isYAML bool) (*ReviewConfig, error) {
+	if isYAML {
+		var err error
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", name, err)
+		}
+	}
+
+	// Reject unknown fields so a misspelled setting doesn't silently fall back to its default
 	var config ReviewConfig
-	decoder := json.NewDecoder(file)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+		return nil, fmt.Errorf("failed to parse config file %s: %w", name, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
 	}
 
 	return &config, nil
 }
 
+// isYAMLFile reports whether a file name has a YAML extension
+func isYAMLFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// yamlToJSON converts a YAML document to JSON, so both formats share the json field names of the config types
+func yamlToJSON(data []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
 // loadEnvFile loads environment variables from a file
 func loadEnvFile(filename string) {
 	file, err := os.Open(filename)
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverlayFile returns the environment overlay of a review configuration file, e.g.
+// review-config.staging.yaml for review-config.json and env "staging". It returns ""
+// if env is empty or no overlay exists. Overlays may use a different format than the base.
+func OverlayFile(filename, env string) string {
+	if env == "" {
+		return ""
+	}
+
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		overlay := base + "." + env + ext
+		if _, err := os.Stat(overlay); err == nil {
+			return overlay
+		}
+	}
+	return ""
+}
+
+// readConfigDocument reads a configuration file as a generic JSON document
+func readConfigDocument(filename string) (interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file %s: %w", filename, err)
+	}
+
+	if isYAMLFile(filename) {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+		}
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+	return document, nil
+}
+
+// applyOverlay merges an overlay document into a base document. Objects are merged key by key
+// and a null value removes the key. Lists of named objects, such as organizations and
+// repositories, are merged by name with new entries appended; any other list is replaced.
+func applyOverlay(base, overlay interface{}) interface{} {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		baseMap, ok := base.(map[string]interface{})
+		if !ok {
+			return overlay
+		}
+		merged := make(map[string]interface{}, len(baseMap))
+		for key, value := range baseMap {
+			merged[key] = value
+		}
+		for key, value := range overlay {
+			if value == nil {
+				delete(merged, key)
+				continue
+			}
+			merged[key] = applyOverlay(baseMap[key], value)
+		}
+		return merged
+
+	case []interface{}:
+		baseList, ok := base.([]interface{})
+		if !ok || !namedObjects(baseList) || !namedObjects(overlay) {
+			return overlay
+		}
+		merged := append([]interface{}(nil), baseList...)
+		for _, entry := range overlay {
+			name := entry.(map[string]interface{})["name"]
+			index := -1
+			for i, existing := range merged {
+				if existing.(map[string]interface{})["name"] == name {
+					index = i
+					break
+				}
+			}
+			if index >= 0 {
+				merged[index] = applyOverlay(merged[index], entry)
+			} else {
+				merged = append(merged, entry)
+			}
+		}
+		return merged
+	}
+
+	return overlay
+}
+
+// namedObjects reports whether every element of a list is an object with a string name
+func namedObjects(list []interface{}) bool {
+	for _, element := range list {
+		object, ok := element.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ProxyConfig is an explicit proxy outbound requests - to Anthropic, GitHub and every other
+// service - are sent through, set with OUTBOUND_PROXY. Without it, HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY apply as usual.
+type ProxyConfig struct {
+	URL     *url.URL // OUTBOUND_PROXY, with OUTBOUND_PROXY_USERNAME and OUTBOUND_PROXY_PASSWORD
+	NoProxy []string // NO_PROXY: hosts, domains, IPs and CIDR ranges reached directly
+}
+
+// loadProxyConfig reads the explicit outbound proxy, returning nil if none is configured
+func loadProxyConfig() (*ProxyConfig, error) {
+	raw := os.Getenv("OUTBOUND_PROXY")
+	if raw == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid OUTBOUND_PROXY %q: expected a URL such as http://proxy.example.com:3128", raw)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid OUTBOUND_PROXY %q: unsupported scheme (use http, https or socks5)", raw)
+	}
+	if username := os.Getenv("OUTBOUND_PROXY_USERNAME"); username != "" {
+		proxyURL.User = url.UserPassword(username, os.Getenv("OUTBOUND_PROXY_PASSWORD"))
+	}
+
+	return &ProxyConfig{
+		URL:     proxyURL,
+		NoProxy: splitList(getEnv("NO_PROXY", os.Getenv("no_proxy"))),
+	}, nil
+}
+
+// Install routes the requests of http.DefaultTransport through the proxy. All of Cyclone's
+// clients are built on it, so it must be installed before they are created.
+func (p *ProxyConfig) Install() {
+	http.DefaultTransport.(*http.Transport).Proxy = p.proxyFor
+	log.Printf("Sending outbound requests through proxy %s", p.URL.Redacted())
+}
+
+// proxyFor implements http.Transport.Proxy, returning nil for requests to NO_PROXY hosts
+func (p *ProxyConfig) proxyFor(req *http.Request) (*url.URL, error) {
+	if p.bypasses(req.URL.Hostname()) {
+		return nil, nil
+	}
+	return p.URL, nil
+}
+
+// bypasses reports whether a host is reached directly. Like with NO_PROXY in Go and curl,
+// "*" matches all hosts, a domain also matches its subdomains, with or without a leading
+// dot, and IPs can be matched by CIDR range; ports in entries are ignored.
+func (p *ProxyConfig) bypasses(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range p.NoProxy {
+		entry = strings.ToLower(entry)
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
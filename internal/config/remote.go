@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Kinds of remote review configuration sources
+const (
+	SourceURL    = "url"    // http(s)://host/path/review-config.yaml
+	SourceS3     = "s3"     // s3://bucket/key, for objects readable without request signing
+	SourceGCS    = "gcs"    // gs://bucket/object
+	SourceGitHub = "github" // github://owner/repo/path/review-config.yaml[@ref]
+)
+
+// ConfigSource is a remote location of the review configuration, set with REVIEW_CONFIG_SOURCE
+type ConfigSource struct {
+	Raw  string
+	Kind string
+	URL  string // Fetch URL for url, s3 and gcs sources
+
+	// GitHub config repository; an empty Ref means the default branch
+	Owner string
+	Repo  string
+	Path  string
+	Ref   string
+}
+
+// configFetchTimeout bounds a single remote configuration fetch
+const configFetchTimeout = 30 * time.Second
+
+// ParseConfigSource parses a REVIEW_CONFIG_SOURCE value
+func ParseConfigSource(raw string) (*ConfigSource, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVIEW_CONFIG_SOURCE %q: %w", raw, err)
+	}
+
+	source := &ConfigSource{Raw: raw}
+	objectPath := strings.TrimPrefix(parsed.Path, "/")
+
+	switch parsed.Scheme {
+	case "http", "https":
+		source.Kind = SourceURL
+		source.URL = raw
+		source.Path = parsed.Path
+	case "s3":
+		source.Kind = SourceS3
+		source.URL = fmt.Sprintf("https://%s.s3.amazonaws.com/%s", parsed.Host, objectPath)
+		source.Path = objectPath
+	case "gs":
+		source.Kind = SourceGCS
+		source.URL = fmt.Sprintf("https://storage.googleapis.com/%s/%s", parsed.Host, objectPath)
+		source.Path = objectPath
+	case "github":
+		source.Kind = SourceGitHub
+		source.Owner = parsed.Host
+		repoAndPath := strings.SplitN(objectPath, "/", 2)
+		if len(repoAndPath) != 2 || repoAndPath[0] == "" || repoAndPath[1] == "" {
+			return nil, fmt.Errorf("invalid REVIEW_CONFIG_SOURCE %q: expected github://owner/repo/path", raw)
+		}
+		source.Repo = repoAndPath[0]
+		source.Path, source.Ref, _ = strings.Cut(repoAndPath[1], "@")
+	default:
+		return nil, fmt.Errorf("invalid REVIEW_CONFIG_SOURCE %q: unsupported scheme (use https, s3, gs or github)", raw)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" && (parsed.Host == "" || source.Path == "") {
+		return nil, fmt.Errorf("invalid REVIEW_CONFIG_SOURCE %q: missing bucket, owner or path", raw)
+	}
+	return source, nil
+}
+
+// String returns the source without its query string, which may carry credentials
+func (s *ConfigSource) String() string {
+	raw, _, _ := strings.Cut(s.Raw, "?")
+	return raw
+}
+
+// MatchesPush reports whether a push to the given repository and ref changes the configuration
+func (s *ConfigSource) MatchesPush(owner, repo, ref, defaultBranch string) bool {
+	return strings.HasPrefix(ref, "refs/heads/") && s.MatchesPullRequest(owner, repo, strings.TrimPrefix(ref, "refs/heads/"), defaultBranch)
+}
+
+// MatchesPullRequest reports whether a pull request into the given repository and base branch
+// proposes a change of the configuration
+func (s *ConfigSource) MatchesPullRequest(owner, repo, base, defaultBranch string) bool {
+	if s.Kind != SourceGitHub || !strings.EqualFold(s.Owner, owner) || !strings.EqualFold(s.Repo, repo) {
+		return false
+	}
+
+	branch := s.Ref
+	if branch == "" {
+		branch = defaultBranch
+	}
+	return base == branch
+}
+
+// Parse parses and validates a version of the source's configuration, e.g. one proposed in a
+// pull request; name refers to it in errors
+func (s *ConfigSource) Parse(name string, data []byte) (*ReviewConfig, error) {
+	return parseReviewConfig(name, data, s.isYAML())
+}
+
+// fetch downloads the configuration. githubToken authenticates GitHub sources, token (REVIEW_CONFIG_TOKEN)
+// is sent as a bearer token to url and gcs sources when set.
+func (s *ConfigSource) fetch(githubToken, token string) ([]byte, error) {
+	fetchURL := s.URL
+	if s.Kind == SourceGitHub {
+		fetchURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", s.Owner, s.Repo, s.Path)
+		if s.Ref != "" {
+			fetchURL += "?ref=" + url.QueryEscape(s.Ref)
+		}
+	}
+
+	req, err := http.NewRequest("GET", fetchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	switch s.Kind {
+	case SourceGitHub:
+		req.Header.Set("Accept", "application/vnd.github.raw")
+		req.Header.Set("Authorization", "Bearer "+githubToken)
+	case SourceURL, SourceGCS:
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	client := &http.Client{Timeout: configFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the request URL from the error, it may carry credentials
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", s, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", s, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s, err)
+	}
+	return data, nil
+}
+
+// isYAML reports whether the source holds YAML rather than JSON, judged by its extension
+func (s *ConfigSource) isYAML() bool {
+	return isYAMLFile(path.Base(s.Path))
+}
@@ -1,11 +1,135 @@
 package config
 
+import "time"
+
 // Config holds our application configuration
 type Config struct {
 	GitHubToken    string
 	Port           string
 	WebhookSecret  string
 	AnthropicToken string
+	Model          string // Claude model reviews are written with (CLAUDE_MODEL)
+	DataDir        string
+	PromptsDir     string // Optional directory overriding the embedded prompt templates
+	Env            string // Deployment environment (CYCLONE_ENV) selecting the review config overlay
+
+	TranslationsFile string // Optional translations of Cyclone's notices, merged over the built-in ones
+	CaptureWebhooks  bool   // Store incoming webhook payloads in DATA_DIR so they can be replayed
+	AutoOnboarding   bool   // Register repositories added to a GitHub App installation (AUTO_ONBOARDING)
+
+	// Organizations webhooks are served for (ALLOWED_ORGANIZATIONS), all if empty, except the
+	// denied ones (DENIED_ORGANIZATIONS); deliveries of any other organization are ignored
+	AllowedOrganizations []string
+	DeniedOrganizations  []string
+
+	AdminToken         string        // Bearer token for the admin and usage APIs, empty disables the admin API
+	AdminAddr          string        // Separate listener for the admin API, metrics and profiles (ADMIN_ADDR), e.g. 127.0.0.1:9090
+	ReviewConfigSource *ConfigSource // Remote review configuration, nil for the local file
+	ReviewConfigToken  string        // Bearer token for url and gcs config sources
+
+	SecretRefs             map[string]string // Secrets manager references of credentials, keyed by environment variable
+	SecretsRefreshInterval time.Duration     // How often referenced credentials are re-read for rotation
+
+	Retention RetentionPolicy // How long stored content is kept
+
+	HTTP HTTPServerConfig // Timeouts and limits of the webhook and admin listeners
+
+	Proxy *ProxyConfig // Explicit outbound proxy, nil to honor HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+
+	OAuth *OAuthConfig // GitHub sign-in for the dashboard and APIs, nil if not configured
+
+	SentryDSN     string // Errors and panics are reported to this Sentry project, if set
+	SentryRelease string // Release reported with errors, e.g. a version or commit
+
+	Debug bool // Serve runtime profiles under /debug/pprof/ (CYCLONE_DEBUG)
+
+	AlertWebhookURL    string // Repeated review failures are posted to this webhook, e.g. a Slack incoming webhook
+	AlertAfterFailures int    // Consecutive failed reviews of a repository that trigger an alert
+
+	SlackBotToken string // Posts reviews and skips to the slack_channel of organizations and repositories
+
+	SMTP *SMTPConfig // Mail server for email digests, nil if not configured
+
+	Jira *JiraConfig // Jira site deferred findings are filed in, nil if not configured
+
+	LinearAPIKey string // Files tracked findings in the linear_team of organizations and repositories
+
+	PagerDutyRoutingKey string  // Pages on-call through PagerDuty when Cyclone isn't ready or reviews keep failing
+	OpsgenieAPIKey      string  // Pages on-call through Opsgenie, like PagerDutyRoutingKey
+	PageErrorRate       float64 // Share of failed reviews within ERROR_RATE_WINDOW that pages on-call
+}
+
+// JiraConfig is the Jira site tickets for deferred blocking findings are created in
+type JiraConfig struct {
+	BaseURL   string // JIRA_BASE_URL, e.g. https://your-company.atlassian.net
+	Email     string // JIRA_EMAIL of the account the API token belongs to
+	APIToken  string // JIRA_API_TOKEN
+	IssueType string // JIRA_ISSUE_TYPE, defaults to DEFAULT_JIRA_ISSUE_TYPE
+}
+
+// SMTPConfig is the mail server email digests are sent through
+type SMTPConfig struct {
+	Host     string // SMTP_HOST
+	Port     string // SMTP_PORT, defaults to DEFAULT_SMTP_PORT
+	Username string // SMTP_USERNAME, empty sends without authentication
+	Password string // SMTP_PASSWORD
+	From     string // SMTP_FROM, the sender address
+}
+
+// OAuthConfig lets members of the allowed organizations and teams sign in to the dashboard
+// and the APIs with a GitHub OAuth app
+type OAuthConfig struct {
+	ClientID      string   // GITHUB_OAUTH_CLIENT_ID
+	ClientSecret  string   // GITHUB_OAUTH_CLIENT_SECRET
+	RedirectURL   string   // Callback URL, e.g. https://cyclone.example.com/auth/callback; defaults to the app's
+	AllowedOrgs   []string // Members of any of these organizations may sign in (OAUTH_ALLOWED_ORGS)
+	AllowedTeams  []string // As may members of these teams, as "org/team-slug" (OAUTH_ALLOWED_TEAMS)
+	SessionSecret string   // Signs session cookies; random if unset, which signs everyone out on restart
+}
+
+// RetentionPolicy limits how long Cyclone keeps stored content that may contain proprietary
+// code. Zero keeps it forever. Usage, skips and review metadata are aggregates and always kept.
+type RetentionPolicy struct {
+	ReviewContent time.Duration // Summaries and line comments of recorded reviews, and feedback signals (RETENTION_REVIEW_CONTENT_DAYS)
+	Conversations time.Duration // Review and thread conversations, including diffs (RETENTION_CONVERSATIONS_DAYS)
+	Webhooks      time.Duration // Captured webhook deliveries (RETENTION_WEBHOOKS_DAYS)
+}
+
+// HTTPServerConfig bounds how long clients may take and how much they may send, so slow or
+// idle connections, e.g. of a slow-loris attack, can't tie up the listeners. Zero timeouts
+// disable them.
+type HTTPServerConfig struct {
+	ReadHeaderTimeout time.Duration // HTTP_READ_HEADER_TIMEOUT
+	ReadTimeout       time.Duration // HTTP_READ_TIMEOUT, headers and body
+	WriteTimeout      time.Duration // HTTP_WRITE_TIMEOUT, from the end of the headers to the end of the response
+	IdleTimeout       time.Duration // HTTP_IDLE_TIMEOUT, between requests on a keep-alive connection
+	MaxHeaderBytes    int           // HTTP_MAX_HEADER_BYTES
+}
+
+// Feature names a behavior that is rolled out gradually: it stays off unless a feature flag
+// of the installation, the organization or the repository turns it on
+type Feature string
+
+// Features that flags can turn on
+const (
+	FeatureMigrationReview    Feature = "migration_review"
+	FeatureIaCReview          Feature = "iac_review"
+	FeatureContainerReview    Feature = "container_review"
+	FeatureSQLReview          Feature = "sql_review"
+	FeaturePerfReview         Feature = "perf_review"
+	FeatureDuplicateDetection Feature = "duplicate_detection"
+	FeatureLearnedConventions Feature = "learned_conventions"
+)
+
+// KnownFeatures lists every feature a flag can turn on. Flags of other names have no effect.
+var KnownFeatures = []Feature{
+	FeatureMigrationReview,
+	FeatureIaCReview,
+	FeatureContainerReview,
+	FeatureSQLReview,
+	FeaturePerfReview,
+	FeatureDuplicateDetection,
+	FeatureLearnedConventions,
 }
 
 // ReviewPrecision defines how strict the review should be
@@ -17,20 +141,332 @@ const (
 	PrecisionStrict ReviewPrecision = "strict"
 )
 
+// ReviewTone defines the voice Cyclone writes reviews in
+type ReviewTone string
+
+const (
+	ToneFriendly   ReviewTone = "friendly"    // Warm and playful, with emojis and a closing poem
+	ToneFormal     ReviewTone = "formal"      // Professional and neutral
+	ToneTerse      ReviewTone = "terse"       // As short as possible
+	ToneEmojiLight ReviewTone = "emoji_light" // Friendly, but without decorative emojis or the poem
+)
+
+// CommentLines selects the diff lines line comments may be placed on
+type CommentLines string
+
+const (
+	CommentLinesHunk  CommentLines = "hunk"  // Added lines and the unchanged context lines around them
+	CommentLinesAdded CommentLines = "added" // Only added lines
+)
+
 // RepositoryConfig holds configuration for a specific repository
 type RepositoryConfig struct {
-	Name         string          `json:"name"`
-	Precision    ReviewPrecision `json:"precision"`
-	CustomPrompt string          `json:"custom_prompt"`
+	Name             string            `json:"name"`    // Repository name, glob ("service-*"), "*" for all others, or a regex
+	Regex            bool              `json:"regex"`   // Treat Name as a regular expression
+	Exclude          []string          `json:"exclude"` // Repositories a wildcard entry doesn't apply to
+	Precision        ReviewPrecision   `json:"precision"`
+	CustomPrompt     string            `json:"custom_prompt"`
+	Language         string            `json:"language"`          // Natural language reviews are written in, defaults to English
+	IgnorePaths      []string          `json:"ignore_paths"`      // Files left out of the review, see MatchesPath
+	Tone             ReviewTone        `json:"tone"`              // Defaults to "friendly"
+	CommentLines     CommentLines      `json:"comment_lines"`     // Defaults to "hunk"
+	ExtendedThinking bool              `json:"extended_thinking"` // Only applied to strict precision reviews
+	ThinkingBudget   int               `json:"thinking_budget"`   // Thinking tokens, defaults to DEFAULT_THINKING_BUDGET
+	BatchMode        bool              `json:"batch_mode"`        // Review through the Message Batches API (cheaper, slower)
+	TokenBudget      int               `json:"token_budget"`      // Max input tokens per review, defaults to DEFAULT_TOKEN_BUDGET
+	Quota            *QuotaConfig      `json:"quota,omitempty"`   // Monthly usage quota for this repository
+	ConsensusModel   string            `json:"consensus_model"`   // Second model for consensus reviews, empty disables
+	ConsensusMode    ConsensusMode     `json:"consensus_mode"`    // Defaults to "agreed_only"
+	SelfCritique     bool              `json:"self_critique"`     // Let a second AI pass vet comments before posting
+	ConflictNotice   bool              `json:"conflict_notice"`   // Point out merge conflicts with the base branch when reviewing
+	LanguagePrompts  map[string]string `json:"language_prompts"`  // Guidance per language key (e.g. "go") or extension (e.g. ".proto")
+	PromptExperiment *PromptExperiment `json:"prompt_experiment,omitempty"`
+	CommentExamples  []CommentExample  `json:"comment_examples"` // House-style feedback examples injected into the prompt
+	Categories       []CommentCategory `json:"categories"`       // Replaces DefaultCommentCategories
+	PathPrecision    []PathPrecision   `json:"path_precision"`   // Precision overrides for parts of the repository
+	DryRun           bool              `json:"dry_run"`          // Generate and store reviews without posting anything
+	SlackChannel     string            `json:"slack_channel"`    // Channel notified of reviews and skips, overrides the organization's
+	JiraProject      string            `json:"jira_project"`     // Project deferred findings are filed in, overrides the organization's
+	LinearTeam       string            `json:"linear_team"`      // Team tracked findings are filed in, overrides the organization's
+	Features         map[Feature]bool  `json:"features"`         // Feature flags, override the organization's
+	ImportRules      []ImportRule      `json:"import_rules"`     // Layering rules checked against added imports
+	StyleGuides      []string          `json:"style_guides"`     // In-repo paths or https URLs of the team's style guide
+
+	HealthDigest   *HealthDigestConfig   `json:"health_digest,omitempty"`   // Weekly digest of the repository, overrides the organization's
+	ReleaseSummary *ReleaseSummaryConfig `json:"release_summary,omitempty"` // Summaries of release cycles, overrides the organization's
+	IssueTriage    *IssueTriageConfig    `json:"issue_triage,omitempty"`    // Triage of new issues, overrides the organization's
+	ReleaseNotes   *ReleaseNotesConfig   `json:"release_notes,omitempty"`   // Drafted release notes, overrides the organization's
+	PushReview     *PushReviewConfig     `json:"push_review,omitempty"`     // Reviews of direct pushes, overrides the organization's
+
+	ReviewerSuggestions *ReviewerSuggestionsConfig `json:"reviewer_suggestions,omitempty"` // Human reviewers suggested in the summary
+
+	PrecisionGuidelines string   `json:"-"` // Guidelines of a precision profile, see ReviewConfig.WithPrecisionProfile
+	StyleGuide          string   `json:"-"` // Summary of the style guides, added before a review is generated
+	PromotedConventions []string `json:"-"` // Conventions learned from the team's feedback, see the learned_conventions feature
+	DemotedConventions  []string `json:"-"` // Kinds of findings the team dismisses, see the learned_conventions feature
+}
+
+// ReviewerSuggestionsConfig suggests the human reviewers most familiar with the files a pull
+// request changes in its review summary: their code owners and who changed them recently
+type ReviewerSuggestionsConfig struct {
+	Count   int  `json:"count,omitempty"` // Reviewers suggested, defaults to DEFAULT_SUGGESTED_REVIEWERS
+	Request bool `json:"request"`         // Also request their reviews with the first review of a pull request
+}
+
+// ImportRule keeps the files under a path from importing certain packages, e.g. API handlers
+// from using the database layer directly. Violations are reported as blocking comments.
+type ImportRule struct {
+	From   string   `json:"from" yaml:"from"`     // Files the rule applies to, a pattern as in ignore_paths, e.g. "internal/api/"
+	Deny   []string `json:"deny" yaml:"deny"`     // Forbidden imports, matched by whole path segments, e.g. "internal/db"
+	Allow  []string `json:"allow" yaml:"allow"`   // Exceptions to Deny, e.g. "internal/db/models"
+	Reason string   `json:"reason" yaml:"reason"` // Why, quoted in violation comments
+}
+
+// PathPrecision reviews files matching a path pattern with a different precision than the
+// rest of the repository. The first matching entry applies to a file.
+type PathPrecision struct {
+	Path      string          `json:"path"`      // Pattern as in ignore_paths, e.g. "payments/**"
+	Precision ReviewPrecision `json:"precision"` // Built-in level or precision profile
+
+	Guidelines string `json:"-"` // Guidelines of a precision profile, see ReviewConfig.WithPrecisionProfile
+}
+
+// CommentCategory is a label line comments are prefixed with, e.g. ⚠️ **issue**
+type CommentCategory struct {
+	Name        string `json:"name"`
+	Emoji       string `json:"emoji"`
+	Description string `json:"description"` // Tells the model when to use the category
+}
+
+// DefaultCommentCategories are used for repositories that don't define their own
+var DefaultCommentCategories = []CommentCategory{
+	{Name: "nit", Emoji: "🧰", Description: "Minor style/preference issues, non-blocking"},
+	{Name: "suggestion", Emoji: "💡", Description: "Improvements that would be nice but aren't required"},
+	{Name: "issue", Emoji: "⚠️", Description: "Problems that should be addressed before merging"},
+	{Name: "blocking", Emoji: "🚫", Description: "Critical issues that must be fixed"},
+	{Name: "question", Emoji: "❓", Description: "Seeking clarification about intent or approach"},
+}
+
+// CommentExample pairs a review comment in the style the team wants with one it does not.
+// Either side may be omitted.
+type CommentExample struct {
+	Good string `json:"good"`
+	Bad  string `json:"bad"`
+	Why  string `json:"why"` // Optional explanation of what makes the good comment better
+}
+
+// PromptExperiment splits a repository's reviews between the control prompts and a variant.
+// Variant templates are named "<template>.<variant>.txt", e.g. system-prompt.b.txt.
+type PromptExperiment struct {
+	Variant        string `json:"variant"`
+	TrafficPercent int    `json:"traffic_percent"` // Share of PRs (0-100) reviewed with the variant
+}
+
+// ConsensusMode defines how findings only one model reported are handled
+type ConsensusMode string
+
+const (
+	ConsensusAgreedOnly        ConsensusMode = "agreed_only"        // Drop findings only one model reported
+	ConsensusMarkDisagreements ConsensusMode = "mark_disagreements" // Keep them, marked as single-model findings
+)
+
+// DigestConfig emails a summary of an organization's reviews, skipped PRs and cost to its
+// recipients once a day or week. Periods end at midnight UTC; weekly ones on Monday.
+type DigestConfig struct {
+	Recipients []string        `json:"recipients"`
+	Frequency  DigestFrequency `json:"frequency"` // Defaults to "weekly"
+}
+
+// HealthDigestConfig posts a weekly AI-written digest of a repository's reviews - recurring
+// themes and the riskiest merges - to a Slack channel, a GitHub Discussion or both
+type HealthDigestConfig struct {
+	SlackChannel       string `json:"slack_channel,omitempty"`       // e.g. "#payments-dev"
+	DiscussionCategory string `json:"discussion_category,omitempty"` // Discussion category of the repository, e.g. "General"
+}
+
+// ReleaseSummaryConfig posts an AI-written summary of the reviews of a release cycle's PRs as a
+// discussion when a milestone is closed or a release is published
+type ReleaseSummaryConfig struct {
+	DiscussionCategory string           `json:"discussion_category"` // Discussion category of the repository, e.g. "Announcements"
+	Triggers           []SummaryTrigger `json:"triggers,omitempty"`  // Defaults to both
+}
+
+// ReleaseNotesConfig has the AI draft categorized release notes from the PRs merged since the
+// previous release and Cyclone's reviews of them. They are written into the body of new
+// releases that don't have one and, with TagPushes, of draft releases created for pushed tags.
+type ReleaseNotesConfig struct {
+	Categories []string `json:"categories,omitempty"` // Sections of the notes, defaults to DefaultReleaseNoteCategories
+	TagPushes  bool     `json:"tag_pushes,omitempty"` // Also draft a release for tags pushed without one
+}
+
+// PushReviewConfig reviews commits pushed directly to protected branches, e.g. hotfixes that
+// bypass pull requests, and posts the findings as a commit comment. Pushes of commits that
+// belong to a merged pull request were reviewed there and are left out.
+type PushReviewConfig struct {
+	Branches []string `json:"branches,omitempty"` // Branches whose pushes are reviewed, defaults to the default branch
+}
+
+// DefaultReleaseNoteCategories are the sections of release notes without configured categories
+var DefaultReleaseNoteCategories = []string{"Features", "Fixes", "Improvements", "Other"}
+
+// IssueTriageConfig has the AI triage newly opened issues: label them, point out likely
+// duplicates among recent issues and ask for missing information
+type IssueTriageConfig struct {
+	Labels       []string `json:"labels"`                  // Labels the AI picks from, e.g. "bug", "feature", "question"
+	LookbackDays int      `json:"lookback_days,omitempty"` // How far back duplicates are searched, defaults to DEFAULT_TRIAGE_LOOKBACK_DAYS
+}
+
+// DEFAULT_TRIAGE_LOOKBACK_DAYS is how many days of issues are searched for duplicates by default
+const DEFAULT_TRIAGE_LOOKBACK_DAYS = 90
+
+// SummaryTrigger is an event that completes a release cycle
+type SummaryTrigger string
+
+const (
+	SummaryTriggerMilestone SummaryTrigger = "milestone" // A milestone is closed: its PRs
+	SummaryTriggerRelease   SummaryTrigger = "release"   // A release is published: the PRs merged into its branch since the previous one
+)
+
+// DigestFrequency defines how often a digest is sent
+type DigestFrequency string
+
+const (
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// QuotaAction defines what happens to reviews once a quota is exhausted
+type QuotaAction string
+
+const (
+	QuotaActionSkip      QuotaAction = "skip"      // Post a notice instead of reviewing
+	QuotaActionDowngrade QuotaAction = "downgrade" // Summary-only review on a cheaper model
+)
+
+// QuotaConfig limits the monthly AI usage of an organization or repository.
+// Zero limits are not enforced; the window resets at the start of each UTC month.
+type QuotaConfig struct {
+	MonthlyTokens  int         `json:"monthly_tokens"`
+	MonthlyCostUSD float64     `json:"monthly_cost_usd"`
+	OnExceeded     QuotaAction `json:"on_exceeded"`     // Defaults to "skip"
+	DowngradeModel string      `json:"downgrade_model"` // Defaults to DEFAULT_DOWNGRADE_MODEL
+}
+
+// REPO_CONFIG_FILE is the optional in-repo configuration read from a repository's default branch
+const REPO_CONFIG_FILE = ".cyclone.yml"
+
+// RepoConfigFile holds the settings repository owners may change through REPO_CONFIG_FILE.
+// Cost-related settings (models, budgets, quotas) stay under the control of the central config.
+type RepoConfigFile struct {
+	Precision    ReviewPrecision `yaml:"precision"`
+	CustomPrompt string          `yaml:"custom_prompt"` // Appended to the central custom prompt
+	IgnorePaths  []string        `yaml:"ignore_paths"`  // Added to the central ignore paths
+	ImportRules  []ImportRule    `yaml:"import_rules"`  // Added to the central import rules
+	StyleGuides  []string        `yaml:"style_guides"`  // Added to the central style guides, in-repo paths only
+	Language     string          `yaml:"language"`
 }
 
 // OrganizationConfig holds configuration for an entire organization
 type OrganizationConfig struct {
 	Name         string             `json:"name"`
 	Repositories []RepositoryConfig `json:"repositories"`
+	Quota        *QuotaConfig       `json:"quota,omitempty"`         // Monthly usage quota shared by all repositories
+	SlackChannel string             `json:"slack_channel,omitempty"` // Channel notified of reviews and skips, e.g. "#code-review"
+	Digest       *DigestConfig      `json:"digest,omitempty"`        // Email digest of the organization's review activity
+	JiraProject  string             `json:"jira_project,omitempty"`  // Jira project key deferred findings are filed in, e.g. "SEC"
+	LinearTeam   string             `json:"linear_team,omitempty"`   // Linear team key tracked findings are filed in, e.g. "ENG"
+	Features     map[Feature]bool   `json:"features,omitempty"`      // Feature flags of its repositories, override the installation's
+
+	HealthDigest   *HealthDigestConfig   `json:"health_digest,omitempty"`   // Weekly digest of each repository reviewed that week
+	ReleaseSummary *ReleaseSummaryConfig `json:"release_summary,omitempty"` // Summaries of the release cycles of its repositories
+	IssueTriage    *IssueTriageConfig    `json:"issue_triage,omitempty"`    // Triage of new issues in its repositories
+	ReleaseNotes   *ReleaseNotesConfig   `json:"release_notes,omitempty"`   // Drafted release notes of its repositories
+	PushReview     *PushReviewConfig     `json:"push_review,omitempty"`     // Reviews of direct pushes to its repositories
+
+	// Optional Anthropic key billed for this organization's reviews. Prefer
+	// AnthropicAPIKeyEnv, which names an environment variable holding the key.
+	AnthropicAPIKey    string `json:"anthropic_api_key,omitempty"`
+	AnthropicAPIKeyEnv string `json:"anthropic_api_key_env,omitempty"`
+
+	// Optional GitHub credentials for this organization instead of GITHUB_TOKEN: either a
+	// token (preferably through GitHubTokenEnv) or a GitHub App installation.
+	GitHubToken    string           `json:"github_token,omitempty"`
+	GitHubTokenEnv string           `json:"github_token_env,omitempty"`
+	GitHubApp      *GitHubAppConfig `json:"github_app,omitempty"`
+
+	// An isolated organization is a tenant of its own: its reviews only use its own Anthropic
+	// key and code host credentials, never the global ones, so they must be set
+	Isolated bool `json:"isolated,omitempty"`
+
+	// Optional environment variable holding a token for the usage, review history, export and
+	// acceptance APIs that only grants access to the organization's own data
+	APITokenEnv string `json:"api_token_env,omitempty"`
+
+	// Code host of the organization's repositories: ProviderGitHub (the default), ProviderGitLab
+	// for a top-level GitLab group, ProviderAzureDevOps for an Azure DevOps organization or
+	// ProviderGerrit for a Gerrit server, each connected through its settings below
+	Provider    string             `json:"provider,omitempty"`
+	GitLab      *GitLabConfig      `json:"gitlab,omitempty"`
+	AzureDevOps *AzureDevOpsConfig `json:"azure_devops,omitempty"`
+	Gerrit      *GerritConfig      `json:"gerrit,omitempty"`
+}
+
+// Code hosts organizations can be on
+const (
+	ProviderGitHub      = "github"
+	ProviderGitLab      = "gitlab"
+	ProviderAzureDevOps = "azure_devops"
+	ProviderGerrit      = "gerrit"
+)
+
+// GitLabConfig connects to the GitLab instance of an organization. The access token needs the
+// api scope; prefer TokenEnv, which names an environment variable holding it.
+type GitLabConfig struct {
+	URL      string `json:"url,omitempty"` // e.g. "https://gitlab.example.com", gitlab.com if empty
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+}
+
+// AzureDevOpsConfig connects to an Azure DevOps organization. The personal access token needs
+// the Code (read and write) scope; prefer TokenEnv, which names an environment variable holding it.
+type AzureDevOpsConfig struct {
+	URL      string `json:"url,omitempty"` // e.g. "https://dev.azure.com/fabrikam", from the organization name if empty
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+}
+
+// GerritConfig connects to a Gerrit server as an account with the HTTP password Token; prefer
+// TokenEnv, which names an environment variable holding it. With VoteLabel set, reviews also
+// vote on that label: -1 with blocking comments, +1 without any comments.
+type GerritConfig struct {
+	URL       string `json:"url"` // e.g. "https://gerrit.example.com"
+	Username  string `json:"username"`
+	Token     string `json:"token,omitempty"`
+	TokenEnv  string `json:"token_env,omitempty"`
+	VoteLabel string `json:"vote_label,omitempty"` // e.g. "Code-Review"
+}
+
+// GitHubAppConfig authenticates as a GitHub App installation. The private key is read
+// from PrivateKeyPath or, if set, from the environment variable named by PrivateKeyEnv.
+type GitHubAppConfig struct {
+	AppID          int64  `json:"app_id"`
+	InstallationID int64  `json:"installation_id"`
+	PrivateKeyPath string `json:"private_key_path,omitempty"`
+	PrivateKeyEnv  string `json:"private_key_env,omitempty"`
+}
+
+// PrecisionProfile is a named precision level with its own review guidelines, e.g.
+// "security-audit". Repositories select it through RepositoryConfig.Precision.
+type PrecisionProfile struct {
+	Name       string `json:"name"`
+	Guidelines string `json:"guidelines"`
 }
+
 type ReviewConfig struct {
-	Organizations []OrganizationConfig `json:"organizations"`
+	DryRun            bool                 `json:"dry_run"`            // Dry run for all repositories, see RepositoryConfig.DryRun
+	Features          map[Feature]bool     `json:"features,omitempty"` // Feature flags of all repositories, see FeatureEnabled
+	PrecisionProfiles []PrecisionProfile   `json:"precision_profiles"`
+	Organizations     []OrganizationConfig `json:"organizations"`
 }
 
 // Constants for PR size limits
@@ -44,3 +480,101 @@ const (
 	WARN_FILES_THRESHOLD     = 20
 	WARN_ADDITIONS_THRESHOLD = 400
 )
+
+// FORCE_REVIEW_LABEL makes Cyclone review a PR that exceeds the hard limits, summary-only
+const FORCE_REVIEW_LABEL = "cyclone:force-review"
+
+// Constants for per-review token budgets
+const (
+	DEFAULT_TOKEN_BUDGET = 100000 // Input tokens; the diff is trimmed when a prompt exceeds the budget
+	MAX_BUDGET_ATTEMPTS  = 3      // Re-measure rounds before giving up on fitting the budget
+)
+
+// DEFAULT_SUGGESTED_REVIEWERS is how many reviewers are suggested unless configured otherwise
+const DEFAULT_SUGGESTED_REVIEWERS = 2
+
+// MAX_STYLE_GUIDES caps the style guides of a repository, each of which is summarized by the AI
+const MAX_STYLE_GUIDES = 5
+
+// DEFAULT_MODEL is the Claude model reviews are written with unless CLAUDE_MODEL is set
+const DEFAULT_MODEL = "claude-sonnet-4-20250514"
+
+// DEFAULT_DOWNGRADE_MODEL is used for summary-only reviews once a quota is exhausted
+const DEFAULT_DOWNGRADE_MODEL = "claude-3-5-haiku-20241022"
+
+// CONSENSUS_LINE_TOLERANCE is how many lines apart two models' comments on the same file may be to count as one finding
+const CONSENSUS_LINE_TOLERANCE = 3
+
+// ACCEPTANCE_LINE_TOLERANCE is how many lines away from a comment a later change may be to count as acting upon it,
+// e.g. a guard added above the commented line
+const ACCEPTANCE_LINE_TOLERANCE = 3
+
+// Constants for Claude extended thinking
+const (
+	DEFAULT_THINKING_BUDGET = 10000
+	MIN_THINKING_BUDGET     = 1024 // Smallest budget accepted by the Claude API
+)
+
+// Constants for Message Batches processing
+const (
+	BATCH_FLUSH_INTERVAL = 5 * time.Minute // How long queued reviews wait before a batch is submitted
+	BATCH_POLL_INTERVAL  = time.Minute     // How often in-flight batches are checked for completion
+	MAX_BATCH_SIZE       = 100             // Submit early once this many reviews are queued
+	BATCH_CLAIM_TIMEOUT  = 5 * time.Minute // Batch reviews whose process stopped renewing them are resumed by another one
+)
+
+// Constants for the webhook queue between "cyclone serve" and "cyclone worker"
+const (
+	QUEUE_POLL_INTERVAL = 2 * time.Second  // How often idle workers check for new deliveries
+	QUEUE_CLAIM_TIMEOUT = 30 * time.Minute // Claimed deliveries not completed by then are requeued
+)
+
+// SESSION_DURATION is how long a GitHub sign-in to the dashboard lasts
+const SESSION_DURATION = 12 * time.Hour
+
+// DEFAULT_ALERT_AFTER_FAILURES is how many reviews of a repository must fail in a row before
+// an alert is sent
+const DEFAULT_ALERT_AFTER_FAILURES = 3
+
+// DEFAULT_SMTP_PORT is the mail submission port digests are sent to without SMTP_PORT
+const DEFAULT_SMTP_PORT = "587"
+
+// DEFAULT_JIRA_ISSUE_TYPE is the type of tickets created for deferred findings
+const DEFAULT_JIRA_ISSUE_TYPE = "Bug"
+
+// DIGEST_CHECK_INTERVAL is how often Cyclone checks whether a digest period has ended
+const DIGEST_CHECK_INTERVAL = 15 * time.Minute
+
+// Outage monitoring, see Config.PagerDutyRoutingKey
+const (
+	OUTAGE_CHECK_INTERVAL   = time.Minute      // How often readiness and the error rate are checked
+	OUTAGE_CHECK_FAILURES   = 3                // Consecutive failed readiness checks that page on-call
+	ERROR_RATE_WINDOW       = 15 * time.Minute // Window the review error rate is computed over
+	ERROR_RATE_MIN_ATTEMPTS = 5                // Reviews within the window needed to page about the error rate
+	DEFAULT_PAGE_ERROR_RATE = 0.5
+)
+
+// DELIVERY_LOG_RETENTION is how long the summaries of received webhook deliveries are kept
+const DELIVERY_LOG_RETENTION = 30 * 24 * time.Hour
+
+// RETENTION_PRUNE_INTERVAL is how often content past its retention period is removed
+const RETENTION_PRUNE_INTERVAL = time.Hour
+
+// DEFAULT_SECRETS_REFRESH_INTERVAL is how often credentials from a secrets manager are re-read
+const DEFAULT_SECRETS_REFRESH_INTERVAL = time.Hour
+
+// Defaults of HTTPServerConfig. The write timeout leaves room for 30 second CPU profiles and
+// large exports.
+const (
+	DEFAULT_HTTP_READ_HEADER_TIMEOUT = 10 * time.Second
+	DEFAULT_HTTP_READ_TIMEOUT        = 30 * time.Second
+	DEFAULT_HTTP_WRITE_TIMEOUT       = 2 * time.Minute
+	DEFAULT_HTTP_IDLE_TIMEOUT        = 2 * time.Minute
+	DEFAULT_HTTP_MAX_HEADER_BYTES    = 64 << 10
+)
+
+// How often the review configuration is checked for changes
+const (
+	CONFIG_POLL_INTERVAL        = 30 * time.Second // Local file
+	REMOTE_CONFIG_POLL_INTERVAL = 5 * time.Minute  // Remote source
+)
@@ -0,0 +1,543 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Validate checks the review configuration for mistakes that would otherwise only surface
+// at review time, and reports all of them at once
+func (rc *ReviewConfig) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	validateFeatures(rc.Features, "features", addProblem)
+
+	profileIndex := make(map[string]int)
+	for i, profile := range rc.PrecisionProfiles {
+		profilePath := fmt.Sprintf("precision_profiles[%d]", i)
+		name := ReviewPrecision(profile.Name)
+		switch {
+		case strings.TrimSpace(profile.Name) == "":
+			addProblem("%s.name: profile name is empty", profilePath)
+		case name == PrecisionMinor || name == PrecisionMedium || name == PrecisionStrict:
+			addProblem("%s.name: %q is a built-in precision level", profilePath, profile.Name)
+		default:
+			if first, ok := profileIndex[profile.Name]; ok {
+				addProblem("%s.name: profile %q is already defined in precision_profiles[%d]", profilePath, profile.Name, first)
+			}
+			profileIndex[profile.Name] = i
+		}
+		if strings.TrimSpace(profile.Guidelines) == "" {
+			addProblem("%s.guidelines: must not be empty", profilePath)
+		}
+	}
+
+	orgIndex := make(map[string]int)
+	for i, org := range rc.Organizations {
+		orgPath := fmt.Sprintf("organizations[%d]", i)
+		if strings.TrimSpace(org.Name) == "" {
+			addProblem("%s.name: organization name is empty", orgPath)
+		} else if first, ok := orgIndex[org.Name]; ok {
+			addProblem("%s.name: organization %q is already configured in organizations[%d]", orgPath, org.Name, first)
+		} else {
+			orgIndex[org.Name] = i
+		}
+
+		if org.AnthropicAPIKey != "" && org.AnthropicAPIKeyEnv != "" {
+			addProblem("%s: set either anthropic_api_key or anthropic_api_key_env, not both", orgPath)
+		}
+		if (org.GitHubToken != "" || org.GitHubTokenEnv != "") && org.GitHubApp != nil {
+			addProblem("%s: set either a GitHub token or github_app, not both", orgPath)
+		}
+		if app := org.GitHubApp; app != nil {
+			if app.AppID <= 0 || app.InstallationID <= 0 {
+				addProblem("%s.github_app: app_id and installation_id are required", orgPath)
+			}
+			if (app.PrivateKeyPath == "") == (app.PrivateKeyEnv == "") {
+				addProblem("%s.github_app: set exactly one of private_key_path and private_key_env", orgPath)
+			}
+		}
+		if org.Isolated {
+			if org.AnthropicAPIKey == "" && org.AnthropicAPIKeyEnv == "" {
+				addProblem("%s: isolated organizations need anthropic_api_key or anthropic_api_key_env", orgPath)
+			}
+			if org.GetProvider() == ProviderGitHub && org.GitHubToken == "" && org.GitHubTokenEnv == "" && org.GitHubApp == nil {
+				addProblem("%s: isolated organizations need a GitHub token or github_app", orgPath)
+			}
+		}
+		validateProvider(&org, orgPath, addProblem)
+		validateQuota(org.Quota, orgPath+".quota", addProblem)
+		validateDigest(org.Digest, orgPath+".digest", addProblem)
+		validateHealthDigest(org.HealthDigest, orgPath+".health_digest", addProblem)
+		validateReleaseSummary(org.ReleaseSummary, orgPath+".release_summary", addProblem)
+		validateIssueTriage(org.IssueTriage, orgPath+".issue_triage", addProblem)
+		validateReleaseNotes(org.ReleaseNotes, orgPath+".release_notes", addProblem)
+		validatePushReview(org.PushReview, orgPath+".push_review", addProblem)
+		validateFeatures(org.Features, orgPath+".features", addProblem)
+
+		repoIndex := make(map[string]int)
+		for j, repo := range org.Repositories {
+			repoPath := fmt.Sprintf("%s.repositories[%d]", orgPath, j)
+			if strings.TrimSpace(repo.Name) == "" {
+				addProblem("%s.name: repository name is empty (use \"*\" to match all repositories)", repoPath)
+			} else if first, ok := repoIndex[repo.Name]; ok {
+				addProblem("%s.name: repository %q is already configured in %s.repositories[%d]", repoPath, repo.Name, orgPath, first)
+			} else {
+				repoIndex[repo.Name] = j
+			}
+			if !rc.validPrecision(repo.Precision) {
+				addProblem("%s.precision: invalid value %q (use minor, medium, strict or a precision profile)", repoPath, repo.Precision)
+			}
+			for k, override := range repo.PathPrecision {
+				overridePath := fmt.Sprintf("%s.path_precision[%d]", repoPath, k)
+				if strings.TrimSpace(override.Path) == "" {
+					addProblem("%s.path: must not be empty", overridePath)
+				} else if _, err := path.Match(override.Path, ""); err != nil {
+					addProblem("%s.path: invalid glob pattern %q", overridePath, override.Path)
+				}
+				if override.Precision == "" || !rc.validPrecision(override.Precision) {
+					addProblem("%s.precision: invalid value %q (use minor, medium, strict or a precision profile)", overridePath, override.Precision)
+				}
+			}
+			validateRepository(&repo, repoPath, addProblem)
+			validateFeatures(repo.Features, repoPath+".features", addProblem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid review configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Warnings lists settings that are valid but have no effect. Unlike the problems Validate
+// reports, they don't keep the configuration from loading.
+func (rc *ReviewConfig) Warnings() []string {
+	warnings := featureWarnings(rc.Features, "features")
+	for i, org := range rc.Organizations {
+		orgPath := fmt.Sprintf("organizations[%d]", i)
+		warnings = append(warnings, featureWarnings(org.Features, orgPath+".features")...)
+		for j, repo := range org.Repositories {
+			repoPath := fmt.Sprintf("%s.repositories[%d]", orgPath, j)
+			warnings = append(warnings, repositoryWarnings(&repo, repoPath)...)
+			warnings = append(warnings, featureWarnings(repo.Features, repoPath+".features")...)
+		}
+	}
+	return warnings
+}
+
+// repositoryWarnings lists the settings of a repository entry that have no effect
+func repositoryWarnings(repo *RepositoryConfig, repoPath string) []string {
+	var warnings []string
+	// Batch reviews are a single AI call, also for PRs that arrive through webhooks
+	if repo.BatchMode {
+		if repo.SelfCritique {
+			warnings = append(warnings, fmt.Sprintf("%s.self_critique: has no effect with batch_mode, batch reviews are posted without self-critique", repoPath))
+		}
+		if repo.ConsensusModel != "" {
+			warnings = append(warnings, fmt.Sprintf("%s.consensus_model: has no effect with batch_mode, batch reviews use a single model", repoPath))
+		}
+	}
+	return warnings
+}
+
+// validateRepository checks the settings of a single repository entry
+func validateRepository(repo *RepositoryConfig, repoPath string, addProblem func(string, ...interface{})) {
+	if repo.Regex {
+		if _, err := regexp.Compile(repo.Name); err != nil {
+			addProblem("%s.name: invalid regular expression: %v", repoPath, err)
+		}
+	} else if _, err := path.Match(repo.Name, ""); err != nil {
+		addProblem("%s.name: invalid glob pattern %q", repoPath, repo.Name)
+	}
+
+	if len(repo.Exclude) > 0 && !repo.isWildcard() && !repo.isPattern() {
+		addProblem("%s.exclude: only wildcard, glob or regex entries can exclude repositories", repoPath)
+	}
+	for k, excluded := range repo.Exclude {
+		if _, err := path.Match(excluded, ""); err != nil {
+			addProblem("%s.exclude[%d]: invalid glob pattern %q", repoPath, k, excluded)
+		}
+	}
+
+	switch repo.Tone {
+	case "", ToneFriendly, ToneFormal, ToneTerse, ToneEmojiLight:
+	default:
+		addProblem("%s.tone: invalid value %q (use friendly, formal, terse or emoji_light)", repoPath, repo.Tone)
+	}
+
+	switch repo.CommentLines {
+	case "", CommentLinesHunk, CommentLinesAdded:
+	default:
+		addProblem("%s.comment_lines: invalid value %q (use hunk or added)", repoPath, repo.CommentLines)
+	}
+
+	switch repo.ConsensusMode {
+	case "", ConsensusAgreedOnly, ConsensusMarkDisagreements:
+	default:
+		addProblem("%s.consensus_mode: invalid value %q (use agreed_only or mark_disagreements)", repoPath, repo.ConsensusMode)
+	}
+
+	if repo.ThinkingBudget != 0 && repo.ThinkingBudget < MIN_THINKING_BUDGET {
+		addProblem("%s.thinking_budget: must be at least %d tokens", repoPath, MIN_THINKING_BUDGET)
+	}
+	if repo.TokenBudget < 0 {
+		addProblem("%s.token_budget: must not be negative", repoPath)
+	}
+
+	if experiment := repo.PromptExperiment; experiment != nil {
+		if strings.TrimSpace(experiment.Variant) == "" {
+			addProblem("%s.prompt_experiment.variant: variant name is empty", repoPath)
+		}
+		if experiment.TrafficPercent < 0 || experiment.TrafficPercent > 100 {
+			addProblem("%s.prompt_experiment.traffic_percent: must be between 0 and 100", repoPath)
+		}
+	}
+
+	for k, example := range repo.CommentExamples {
+		if strings.TrimSpace(example.Good) == "" && strings.TrimSpace(example.Bad) == "" {
+			addProblem("%s.comment_examples[%d]: needs a good or a bad comment", repoPath, k)
+		}
+	}
+
+	categories := make(map[string]bool)
+	for k, category := range repo.Categories {
+		name := strings.ToLower(strings.TrimSpace(category.Name))
+		switch {
+		case name == "":
+			addProblem("%s.categories[%d].name: must not be empty", repoPath, k)
+		case strings.ContainsAny(name, "*:$"):
+			addProblem("%s.categories[%d].name: %q must not contain '*', ':' or '$'", repoPath, k, category.Name)
+		case categories[name]:
+			addProblem("%s.categories[%d].name: duplicate category %q", repoPath, k, category.Name)
+		}
+		categories[name] = true
+	}
+
+	validateImportRules(repo.ImportRules, repoPath+".import_rules", addProblem)
+	validateStyleGuides(repo.StyleGuides, repoPath+".style_guides", true, addProblem)
+	validateQuota(repo.Quota, repoPath+".quota", addProblem)
+	validateHealthDigest(repo.HealthDigest, repoPath+".health_digest", addProblem)
+	validateReleaseSummary(repo.ReleaseSummary, repoPath+".release_summary", addProblem)
+	validateIssueTriage(repo.IssueTriage, repoPath+".issue_triage", addProblem)
+	validateReleaseNotes(repo.ReleaseNotes, repoPath+".release_notes", addProblem)
+	validatePushReview(repo.PushReview, repoPath+".push_review", addProblem)
+	validateReviewerSuggestions(repo.ReviewerSuggestions, repoPath+".reviewer_suggestions", addProblem)
+}
+
+// validateImportRules checks the layering rules of a repository
+func validateImportRules(rules []ImportRule, rulesPath string, addProblem func(string, ...interface{})) {
+	for k, rule := range rules {
+		rulePath := fmt.Sprintf("%s[%d]", rulesPath, k)
+		if strings.TrimSpace(rule.From) == "" {
+			addProblem("%s.from: must not be empty", rulePath)
+		} else if _, err := path.Match(rule.From, ""); err != nil {
+			addProblem("%s.from: invalid glob pattern %q", rulePath, rule.From)
+		}
+		if len(rule.Deny) == 0 {
+			addProblem("%s.deny: needs at least one import", rulePath)
+		}
+		for j, denied := range rule.Deny {
+			if strings.Trim(denied, "/ ") == "" {
+				addProblem("%s.deny[%d]: must not be empty", rulePath, j)
+			}
+		}
+		for j, allowed := range rule.Allow {
+			if strings.Trim(allowed, "/ ") == "" {
+				addProblem("%s.allow[%d]: must not be empty", rulePath, j)
+			}
+		}
+	}
+}
+
+// validateStyleGuides checks the style guides of a repository. URLs are only allowed in the
+// central configuration, so a repository's own config file can't make Cyclone fetch arbitrary
+// addresses.
+func validateStyleGuides(guides []string, guidesPath string, allowURLs bool, addProblem func(string, ...interface{})) {
+	if len(guides) > MAX_STYLE_GUIDES {
+		addProblem("%s: at most %d style guides are allowed", guidesPath, MAX_STYLE_GUIDES)
+	}
+	for k, guide := range guides {
+		guidePath := fmt.Sprintf("%s[%d]", guidesPath, k)
+		switch {
+		case strings.TrimSpace(guide) == "":
+			addProblem("%s: must not be empty", guidePath)
+		case IsStyleGuideURL(guide):
+			if !allowURLs {
+				addProblem("%s: URLs are only allowed in the central configuration", guidePath)
+			} else if parsed, err := url.Parse(guide); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				addProblem("%s: %q must be an https URL", guidePath, guide)
+			}
+		case strings.HasPrefix(guide, "/") || slices.Contains(strings.Split(guide, "/"), ".."):
+			addProblem("%s: %q must be a path relative to the repository root", guidePath, guide)
+		}
+	}
+}
+
+// validateQuota checks an optional quota configuration
+func validateQuota(quota *QuotaConfig, quotaPath string, addProblem func(string, ...interface{})) {
+	if quota == nil {
+		return
+	}
+
+	if quota.MonthlyTokens < 0 {
+		addProblem("%s.monthly_tokens: must not be negative", quotaPath)
+	}
+	if quota.MonthlyCostUSD < 0 {
+		addProblem("%s.monthly_cost_usd: must not be negative", quotaPath)
+	}
+
+	switch quota.OnExceeded {
+	case "", QuotaActionSkip, QuotaActionDowngrade:
+	default:
+		addProblem("%s.on_exceeded: invalid value %q (use skip or downgrade)", quotaPath, quota.OnExceeded)
+	}
+}
+
+// validateDigest checks an organization's email digest settings
+func validateDigest(digest *DigestConfig, digestPath string, addProblem func(string, ...interface{})) {
+	if digest == nil {
+		return
+	}
+
+	if len(digest.Recipients) == 0 {
+		addProblem("%s.recipients: at least one recipient is required", digestPath)
+	}
+	for k, recipient := range digest.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			addProblem("%s.recipients[%d]: invalid email address %q", digestPath, k, recipient)
+		}
+	}
+
+	switch digest.Frequency {
+	case "", DigestDaily, DigestWeekly:
+	default:
+		addProblem("%s.frequency: invalid value %q (use daily or weekly)", digestPath, digest.Frequency)
+	}
+}
+
+// validateHealthDigest checks that a health digest is posted somewhere
+func validateHealthDigest(digest *HealthDigestConfig, digestPath string, addProblem func(string, ...interface{})) {
+	if digest != nil && digest.SlackChannel == "" && digest.DiscussionCategory == "" {
+		addProblem("%s: set slack_channel, discussion_category or both", digestPath)
+	}
+}
+
+// validateReleaseSummary checks a release summary's discussion category and triggers
+func validateReleaseSummary(summary *ReleaseSummaryConfig, summaryPath string, addProblem func(string, ...interface{})) {
+	if summary == nil {
+		return
+	}
+
+	if summary.DiscussionCategory == "" {
+		addProblem("%s.discussion_category: must not be empty", summaryPath)
+	}
+	for k, trigger := range summary.Triggers {
+		switch trigger {
+		case SummaryTriggerMilestone, SummaryTriggerRelease:
+		default:
+			addProblem("%s.triggers[%d]: invalid value %q (use milestone or release)", summaryPath, k, trigger)
+		}
+	}
+}
+
+// validateReleaseNotes checks that release note categories have names
+func validateReleaseNotes(notes *ReleaseNotesConfig, notesPath string, addProblem func(string, ...interface{})) {
+	if notes == nil {
+		return
+	}
+	for k, category := range notes.Categories {
+		if strings.TrimSpace(category) == "" {
+			addProblem("%s.categories[%d]: must not be empty", notesPath, k)
+		}
+	}
+}
+
+// validatePushReview checks that push review branches have names
+func validatePushReview(pushReview *PushReviewConfig, pushPath string, addProblem func(string, ...interface{})) {
+	if pushReview == nil {
+		return
+	}
+	for k, branch := range pushReview.Branches {
+		if strings.TrimSpace(branch) == "" {
+			addProblem("%s.branches[%d]: must not be empty", pushPath, k)
+		}
+	}
+}
+
+// validateReviewerSuggestions checks an optional reviewer suggestions configuration
+func validateReviewerSuggestions(suggestions *ReviewerSuggestionsConfig, suggestionsPath string, addProblem func(string, ...interface{})) {
+	if suggestions == nil {
+		return
+	}
+	if suggestions.Count < 0 || suggestions.Count > 10 {
+		addProblem("%s.count: must be between 0 and 10", suggestionsPath)
+	}
+}
+
+// featureName is the format of feature flag names, e.g. "duplicate_detection"
+var featureName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateFeatures checks the names of feature flags. Names of unknown features are valid, so
+// flags can be set before the release that adds their feature, see featureWarnings.
+func validateFeatures(features map[Feature]bool, featuresPath string, addProblem func(string, ...interface{})) {
+	for _, name := range sortedFeatureNames(features) {
+		if !featureName.MatchString(name) {
+			addProblem("%s: invalid feature name %q (use lowercase letters, digits and underscores)", featuresPath, name)
+		}
+	}
+}
+
+// featureWarnings lists flags of unknown features, which are most likely misspelled
+func featureWarnings(features map[Feature]bool, featuresPath string) []string {
+	var warnings []string
+	for _, name := range sortedFeatureNames(features) {
+		if featureName.MatchString(name) && !slices.Contains(KnownFeatures, Feature(name)) {
+			warnings = append(warnings, fmt.Sprintf("%s: unknown feature %q, the flag has no effect", featuresPath, name))
+		}
+	}
+	return warnings
+}
+
+// sortedFeatureNames returns the names of feature flags in alphabetical order
+func sortedFeatureNames(features map[Feature]bool) []string {
+	names := make([]string, 0, len(features))
+	for feature := range features {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateIssueTriage checks that issue triage has labels to pick from
+func validateIssueTriage(triage *IssueTriageConfig, triagePath string, addProblem func(string, ...interface{})) {
+	if triage == nil {
+		return
+	}
+
+	if len(triage.Labels) == 0 {
+		addProblem("%s.labels: must not be empty", triagePath)
+	}
+	for k, label := range triage.Labels {
+		if strings.TrimSpace(label) == "" {
+			addProblem("%s.labels[%d]: must not be empty", triagePath, k)
+		}
+	}
+	if triage.LookbackDays < 0 {
+		addProblem("%s.lookback_days: must not be negative", triagePath)
+	}
+}
+
+// validateProvider checks an organization's code host and the settings it requires.
+// Organizations on other code hosts can't use GitHub credentials or GitHub Discussions.
+func validateProvider(org *OrganizationConfig, orgPath string, addProblem func(string, ...interface{})) {
+	provider := org.GetProvider()
+	switch provider {
+	case ProviderGitHub, ProviderGitLab, ProviderAzureDevOps, ProviderGerrit:
+	default:
+		addProblem("%s.provider: invalid value %q (use %s, %s, %s or %s)", orgPath, org.Provider, ProviderGitHub, ProviderGitLab, ProviderAzureDevOps, ProviderGerrit)
+		return
+	}
+
+	if org.GitLab != nil && provider != ProviderGitLab {
+		addProblem("%s.gitlab: only used with provider %q", orgPath, ProviderGitLab)
+	}
+	if org.AzureDevOps != nil && provider != ProviderAzureDevOps {
+		addProblem("%s.azure_devops: only used with provider %q", orgPath, ProviderAzureDevOps)
+	}
+	if org.Gerrit != nil && provider != ProviderGerrit {
+		addProblem("%s.gerrit: only used with provider %q", orgPath, ProviderGerrit)
+	}
+	if provider == ProviderGitHub {
+		return
+	}
+
+	if org.GitHubToken != "" || org.GitHubTokenEnv != "" || org.GitHubApp != nil {
+		addProblem("%s: GitHub credentials can't be used with provider %q", orgPath, provider)
+	}
+	if org.HealthDigest != nil && org.HealthDigest.DiscussionCategory != "" {
+		addProblem("%s.health_digest.discussion_category: GitHub Discussions aren't available with provider %q", orgPath, provider)
+	}
+	if org.ReleaseSummary != nil {
+		addProblem("%s.release_summary: not available with provider %q", orgPath, provider)
+	}
+	if org.IssueTriage != nil {
+		addProblem("%s.issue_triage: not available with provider %q", orgPath, provider)
+	}
+	if org.ReleaseNotes != nil {
+		addProblem("%s.release_notes: not available with provider %q", orgPath, provider)
+	}
+	if org.PushReview != nil {
+		addProblem("%s.push_review: not available with provider %q", orgPath, provider)
+	}
+	for j, repo := range org.Repositories {
+		repoPath := fmt.Sprintf("%s.repositories[%d]", orgPath, j)
+		if repo.HealthDigest != nil && repo.HealthDigest.DiscussionCategory != "" {
+			addProblem("%s.health_digest.discussion_category: GitHub Discussions aren't available with provider %q", repoPath, provider)
+		}
+		if repo.ReleaseSummary != nil {
+			addProblem("%s.release_summary: not available with provider %q", repoPath, provider)
+		}
+		if repo.IssueTriage != nil {
+			addProblem("%s.issue_triage: not available with provider %q", repoPath, provider)
+		}
+		if repo.ReleaseNotes != nil {
+			addProblem("%s.release_notes: not available with provider %q", repoPath, provider)
+		}
+		if repo.PushReview != nil {
+			addProblem("%s.push_review: not available with provider %q", repoPath, provider)
+		}
+	}
+
+	switch {
+	case provider == ProviderGitLab && org.GitLab == nil:
+		addProblem("%s.gitlab: required with provider %q", orgPath, provider)
+	case provider == ProviderGitLab:
+		validateCodeHost(org.GitLab.URL, org.GitLab.Token, org.GitLab.TokenEnv, orgPath+".gitlab", addProblem)
+	case provider == ProviderAzureDevOps && org.AzureDevOps == nil:
+		addProblem("%s.azure_devops: required with provider %q", orgPath, provider)
+	case provider == ProviderAzureDevOps:
+		validateCodeHost(org.AzureDevOps.URL, org.AzureDevOps.Token, org.AzureDevOps.TokenEnv, orgPath+".azure_devops", addProblem)
+	case provider == ProviderGerrit && org.Gerrit == nil:
+		addProblem("%s.gerrit: required with provider %q", orgPath, provider)
+	case provider == ProviderGerrit:
+		if org.Gerrit.URL == "" {
+			addProblem("%s.gerrit.url: required", orgPath)
+		}
+		if org.Gerrit.Username == "" {
+			addProblem("%s.gerrit.username: required", orgPath)
+		}
+		validateCodeHost(org.Gerrit.URL, org.Gerrit.Token, org.Gerrit.TokenEnv, orgPath+".gerrit", addProblem)
+	}
+}
+
+// validateCodeHost checks the URL and token settings of a code host other than GitHub
+func validateCodeHost(hostURL, token, tokenEnv, hostPath string, addProblem func(string, ...interface{})) {
+	if (token == "") == (tokenEnv == "") {
+		addProblem("%s: set exactly one of token and token_env", hostPath)
+	}
+	if hostURL != "" {
+		if u, err := url.Parse(hostURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			addProblem("%s.url: invalid URL %q", hostPath, hostURL)
+		}
+	}
+}
+
+// validPrecision reports whether a precision value is a built-in level or a defined
+// profile; empty selects the default
+func (rc *ReviewConfig) validPrecision(precision ReviewPrecision) bool {
+	switch precision {
+	case "", PrecisionMinor, PrecisionMedium, PrecisionStrict:
+		return true
+	}
+	return rc.GetPrecisionProfile(precision) != nil
+}
@@ -0,0 +1,163 @@
+// Package jira creates and looks up issues through the Jira REST API, authenticating with an
+// account email and API token as Jira Cloud expects
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds a single API request
+const requestTimeout = 15 * time.Second
+
+// issueKeyPattern matches issue keys such as "SEC-123"
+var issueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+
+// IsIssueKey reports whether s has the form of an issue key, e.g. "SEC-123"
+func IsIssueKey(s string) bool {
+	return issueKeyPattern.MatchString(s)
+}
+
+// Client talks to one Jira site
+type Client struct {
+	baseURL    string
+	email      string
+	apiToken   string
+	httpClient *http.Client
+}
+
+// Issue is a new issue to create
+type Issue struct {
+	Project     string // Project key, e.g. "SEC"
+	IssueType   string // e.g. "Bug"
+	Summary     string
+	Description string // Jira wiki markup
+	Labels      []string
+}
+
+// New creates a client for a site such as https://your-company.atlassian.net
+func New(baseURL, email, apiToken string) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Jira URL %q, expected e.g. https://your-company.atlassian.net", baseURL)
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		email:      email,
+		apiToken:   apiToken,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// IssueURL returns the browser URL of an issue
+func (c *Client) IssueURL(key string) string {
+	return c.baseURL + "/browse/" + key
+}
+
+// CreateIssue creates an issue and returns its key
+func (c *Client) CreateIssue(ctx context.Context, issue Issue) (string, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": issue.Project},
+		"issuetype":   map[string]string{"name": issue.IssueType},
+		"summary":     issue.Summary,
+		"description": issue.Description,
+	}
+	if len(issue.Labels) > 0 {
+		fields["labels"] = issue.Labels
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", fmt.Errorf("failed to create Jira issue in %s: %w", issue.Project, err)
+	}
+	return created.Key, nil
+}
+
+// IssueExists reports whether an issue exists and is visible to the API token's account
+func (c *Client) IssueExists(ctx context.Context, key string) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=summary", nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to look up Jira issue %s: %w", key, err)
+}
+
+// APIError is an unsuccessful response of the Jira API
+type APIError struct {
+	StatusCode int
+	Messages   []string // Error messages from the response body, if any
+}
+
+func (e *APIError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("Jira API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("Jira API returned status %d: %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into result, if
+// it isn't nil
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.email, c.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&errBody) == nil {
+			apiErr.Messages = errBody.ErrorMessages
+			for field, message := range errBody.Errors {
+				apiErr.Messages = append(apiErr.Messages, field+": "+message)
+			}
+		}
+		return apiErr
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode Jira response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package linear creates issues through the Linear GraphQL API, authenticating with a
+// personal or workspace API key
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiURL is Linear's GraphQL endpoint
+const apiURL = "https://api.linear.app/graphql"
+
+// requestTimeout bounds a single API request
+const requestTimeout = 15 * time.Second
+
+// Client talks to one Linear workspace
+type Client struct {
+	apiKey     string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	teamIDs map[string]string // Team IDs by key, looked up once
+}
+
+// Issue is a new issue to create
+type Issue struct {
+	Team        string // Team key, e.g. "ENG"
+	Title       string
+	Description string // Markdown
+}
+
+// CreatedIssue identifies a created issue
+type CreatedIssue struct {
+	Identifier string `json:"identifier"` // e.g. "ENG-123"
+	URL        string `json:"url"`
+}
+
+// New creates a client authenticating with an API key
+func New(apiKey string) *Client {
+	return &Client{
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		teamIDs:    make(map[string]string),
+	}
+}
+
+// CreateIssue creates an issue in a team
+func (c *Client) CreateIssue(ctx context.Context, issue Issue) (*CreatedIssue, error) {
+	teamID, err := c.teamID(ctx, issue.Team)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		IssueCreate struct {
+			Success bool         `json:"success"`
+			Issue   CreatedIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	err = c.do(ctx, `mutation($input: IssueCreateInput!) {
+		issueCreate(input: $input) { success issue { identifier url } }
+	}`, map[string]interface{}{
+		"input": map[string]string{
+			"teamId":      teamID,
+			"title":       issue.Title,
+			"description": issue.Description,
+		},
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Linear issue in %s: %w", issue.Team, err)
+	}
+	if !result.IssueCreate.Success {
+		return nil, fmt.Errorf("failed to create Linear issue in %s: not successful", issue.Team)
+	}
+	return &result.IssueCreate.Issue, nil
+}
+
+// teamID resolves a team key to the ID the API expects
+func (c *Client) teamID(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	id, ok := c.teamIDs[key]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var result struct {
+		Teams struct {
+			Nodes []struct {
+				ID string `json:"id"`
+			} `json:"nodes"`
+		} `json:"teams"`
+	}
+	err := c.do(ctx, `query($key: String!) {
+		teams(filter: { key: { eq: $key } }) { nodes { id } }
+	}`, map[string]interface{}{"key": key}, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up Linear team %s: %w", key, err)
+	}
+	if len(result.Teams.Nodes) == 0 {
+		return "", fmt.Errorf("Linear team %s doesn't exist or isn't visible to the API key", key)
+	}
+
+	id = result.Teams.Nodes[0].ID
+	c.mu.Lock()
+	c.teamIDs[key] = id
+	c.mu.Unlock()
+	return id, nil
+}
+
+// APIError is an unsuccessful response of the Linear API, either an HTTP error or GraphQL
+// errors in a successful response
+type APIError struct {
+	StatusCode int
+	Messages   []string // Error messages from the response body, if any
+}
+
+func (e *APIError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("Linear API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("Linear API returned status %d: %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// do runs a GraphQL query and decodes its data into result
+func (c *Client) do(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || len(body.Errors) > 0 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		for _, e := range body.Errors {
+			apiErr.Messages = append(apiErr.Messages, e.Message)
+		}
+		return apiErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode Linear response: %w", decodeErr)
+	}
+	if err := json.Unmarshal(body.Data, result); err != nil {
+		return fmt.Errorf("failed to decode Linear response: %w", err)
+	}
+	return nil
+}
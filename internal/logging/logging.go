@@ -0,0 +1,99 @@
+// Package logging keeps credentials and code out of logs. Log output goes through a writer that
+// redacts registered secrets, anything shaped like a token or credential header, and diffs.
+package logging
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Replacements of redacted content
+const (
+	redacted     = "[REDACTED]"
+	redactedDiff = "[diff content redacted]"
+)
+
+// minSecretLength keeps short values such as "true" or a port from being redacted everywhere
+const minSecretLength = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = make(map[string]bool)
+)
+
+// AddSecrets registers values that are redacted wherever they appear, e.g. API keys and
+// tokens once they are loaded or rotated. Empty and short values are ignored.
+func AddSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets[value] = true
+		}
+	}
+}
+
+// credentialPatterns match credentials that were never registered, e.g. tokens in error
+// messages of other services, with what they are replaced by
+var credentialPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// GitHub personal access, OAuth, App installation and refresh tokens
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`), redacted},
+	// Anthropic API keys
+	{regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{10,}`), redacted},
+	// Credential headers and fields, e.g. dumped requests or JSON bodies
+	{regexp.MustCompile(`(?i)((?:authorization|x-api-key|x-hub-signature(?:-256)?|x-sentry-auth|client_secret|access_token|api_key|password)["']?\s*[:=]\s*["']?)(?:(?:bearer|token|basic|sentry|sha256=)\s*)?[^\s"',;]+`), "${1}" + redacted},
+	// Passwords and keys in URLs, e.g. a Sentry DSN
+	{regexp.MustCompile(`://[^/\s:@]+(?::[^/\s@]*)?@`), "://" + redacted + "@"},
+	// Slack incoming webhook URLs carry their secret in the path
+	{regexp.MustCompile(`(hooks\.slack\.com/services/)[A-Za-z0-9/]+`), "${1}" + redacted},
+	// PEM private keys, e.g. of a GitHub App
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), redacted},
+}
+
+// diffStart matches where a unified diff starts: a file header or hunk header
+var diffStart = regexp.MustCompile(`(?m)^diff --git |@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// Redact removes secrets, credentials and diff content from a message. A diff - which
+// contains the reviewed code - is cut from its first file or hunk header to the end.
+func Redact(message string) string {
+	if loc := diffStart.FindStringIndex(message); loc != nil {
+		end := ""
+		if strings.HasSuffix(message, "\n") {
+			end = "\n"
+		}
+		message = message[:loc[0]] + redactedDiff + end
+	}
+
+	secretsMu.RLock()
+	for secret := range secrets {
+		message = strings.ReplaceAll(message, secret, redacted)
+	}
+	secretsMu.RUnlock()
+
+	for _, credential := range credentialPatterns {
+		message = credential.pattern.ReplaceAllString(message, credential.replacement)
+	}
+	return message
+}
+
+// NewWriter returns a writer redacting everything written to out, for log.SetOutput
+func NewWriter(out io.Writer) io.Writer {
+	return &redactingWriter{out: out}
+}
+
+type redactingWriter struct {
+	out io.Writer
+}
+
+// Write redacts a log entry, which the log package writes in a single call
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
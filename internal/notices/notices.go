@@ -0,0 +1,140 @@
+// Package notices renders the messages Cyclone writes itself - skip notices, warnings and
+// review headers - in the natural language configured for a repository.
+package notices
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultLanguage is used for languages and messages without a translation
+const defaultLanguage = "english"
+
+//go:embed translations.yaml
+var builtinTranslations []byte
+
+// Data holds the values a message template refers to, e.g. {{.Limit}}
+type Data map[string]interface{}
+
+var (
+	mu       sync.RWMutex
+	messages = mustParse(builtinTranslations)
+)
+
+// Load merges a translations file (YAML or JSON) over the built-in translations.
+// Messages are keyed by language and message name like the built-in ones.
+func Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read translations file: %w", err)
+	}
+
+	loaded, err := parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Reject keys the built-in messages don't have, so a misspelled key doesn't silently fall back to English
+	for language, templates := range loaded {
+		for key := range templates {
+			if messages[defaultLanguage][key] == nil {
+				return fmt.Errorf("%s: %s.%s: unknown message", filename, language, key)
+			}
+		}
+	}
+
+	merged := make(map[string]map[string]*template.Template, len(messages))
+	for language, templates := range messages {
+		merged[language] = templates
+	}
+	for language, templates := range loaded {
+		combined := make(map[string]*template.Template)
+		for key, tmpl := range merged[language] {
+			combined[key] = tmpl
+		}
+		for key, tmpl := range templates {
+			combined[key] = tmpl
+		}
+		merged[language] = combined
+	}
+	messages = merged
+
+	log.Printf("Loaded translations for %d languages from %s", len(loaded), filename)
+	return nil
+}
+
+// Render returns the message for key in the given language, falling back to English
+// for languages or messages without a translation
+func Render(language, key string, data Data) string {
+	mu.RLock()
+	tmpl, ok := messages[normalize(language)][key]
+	if !ok {
+		tmpl, ok = messages[defaultLanguage][key]
+	}
+	mu.RUnlock()
+
+	if !ok {
+		log.Printf("Unknown notice %q", key)
+		return key
+	}
+
+	var text strings.Builder
+	if err := tmpl.Execute(&text, data); err != nil {
+		log.Printf("Error rendering notice %q in %s: %v", key, tmpl.Name(), err)
+		if language != defaultLanguage {
+			return Render(defaultLanguage, key, data)
+		}
+		return key
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// parse parses a translations document
+func parse(data []byte) (map[string]map[string]*template.Template, error) {
+	var raw map[string]map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse translations: %w", err)
+	}
+
+	parsed := make(map[string]map[string]*template.Template, len(raw))
+	for language, texts := range raw {
+		language = normalize(language)
+		parsed[language] = make(map[string]*template.Template, len(texts))
+		for key, text := range texts {
+			tmpl, err := template.New(language + "." + key).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", language, key, err)
+			}
+			parsed[language][key] = tmpl
+		}
+	}
+	return parsed, nil
+}
+
+// mustParse parses the built-in translations, which must be valid
+func mustParse(data []byte) map[string]map[string]*template.Template {
+	parsed, err := parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// normalize maps a configured language to its translations key; empty selects English
+func normalize(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return defaultLanguage
+	}
+	return language
+}
@@ -0,0 +1,139 @@
+// Package oncall pages on-call engineers about incidents through PagerDuty or Opsgenie. Incidents
+// are identified by a key, so triggering one again updates it instead of paging twice.
+package oncall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// requestTimeout bounds a single API request
+const requestTimeout = 15 * time.Second
+
+// source names Cyclone as the origin of incidents
+const source = "cyclone"
+
+// Pager opens and closes incidents
+type Pager interface {
+	// Name identifies the service in logs, e.g. "PagerDuty"
+	Name() string
+	// Trigger opens an incident, or updates the open one with the same key
+	Trigger(ctx context.Context, key, summary string, details map[string]string) error
+	// Resolve closes the incident with the key, if one is open
+	Resolve(ctx context.Context, key string) error
+}
+
+// PagerDuty sends events to a service through the Events API v2
+type PagerDuty struct {
+	routingKey string
+	httpClient *http.Client
+}
+
+// pagerDutyEventsURL is the endpoint of the Events API v2
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// NewPagerDuty creates a pager for the service of an Events API v2 integration's routing key
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{routingKey: routingKey, httpClient: &http.Client{Timeout: requestTimeout}}
+}
+
+// Name implements Pager
+func (p *PagerDuty) Name() string { return "PagerDuty" }
+
+// Trigger implements Pager
+func (p *PagerDuty) Trigger(ctx context.Context, key, summary string, details map[string]string) error {
+	return post(ctx, p.httpClient, pagerDutyEventsURL, "", map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    key,
+		"payload": map[string]interface{}{
+			"summary":        summary,
+			"source":         source,
+			"severity":       "critical",
+			"custom_details": details,
+		},
+	})
+}
+
+// Resolve implements Pager
+func (p *PagerDuty) Resolve(ctx context.Context, key string) error {
+	return post(ctx, p.httpClient, pagerDutyEventsURL, "", map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "resolve",
+		"dedup_key":    key,
+	})
+}
+
+// Opsgenie creates and closes alerts through the Alert API
+type Opsgenie struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// opsgenieAlertsURL is the endpoint of the Alert API
+const opsgenieAlertsURL = "https://api.opsgenie.com/v2/alerts"
+
+// NewOpsgenie creates a pager for the team of an API integration's key
+func NewOpsgenie(apiKey string) *Opsgenie {
+	return &Opsgenie{apiKey: apiKey, httpClient: &http.Client{Timeout: requestTimeout}}
+}
+
+// Name implements Pager
+func (o *Opsgenie) Name() string { return "Opsgenie" }
+
+// Trigger implements Pager. Opsgenie deduplicates open alerts by their alias.
+func (o *Opsgenie) Trigger(ctx context.Context, key, summary string, details map[string]string) error {
+	message := summary
+	if runes := []rune(message); len(runes) > 130 {
+		message = string(runes[:129]) + "…"
+	}
+	return post(ctx, o.httpClient, opsgenieAlertsURL, "GenieKey "+o.apiKey, map[string]interface{}{
+		"message":     message,
+		"alias":       key,
+		"description": summary,
+		"details":     details,
+		"source":      source,
+		"priority":    "P1",
+	})
+}
+
+// Resolve implements Pager
+func (o *Opsgenie) Resolve(ctx context.Context, key string) error {
+	endpoint := fmt.Sprintf("%s/%s/close?identifierType=alias", opsgenieAlertsURL, url.PathEscape(key))
+	return post(ctx, o.httpClient, endpoint, "GenieKey "+o.apiKey, map[string]interface{}{"source": source})
+}
+
+// post sends a JSON request, treating any status but 2xx as an error
+func post(ctx context.Context, client *http.Client, endpoint, authorization string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
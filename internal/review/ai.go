@@ -4,232 +4,275 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
 	"cyclone/internal/config"
 )
 
+// claudeAPIBaseURL is the base URL of the Claude API
+const claudeAPIBaseURL = "https://api.anthropic.com/v1"
+
 // AIClient handles all AI/Claude API operations
 type AIClient struct {
-	apiKey string
-	model  string
+	apiKey        *credential // Shared with copies made by WithModel and WithPromptVariant
+	model         string
+	promptsDir    string // Optional directory overriding the embedded prompt templates
+	promptVariant string // Prompt experiment variant, empty for the control prompts
+	prompts       *promptCache
+	httpClient    *http.Client
 }
 
 // ClaudeResponse represents the response from Claude API
 type ClaudeResponse struct {
 	Content []struct {
+		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage apiUsage `json:"usage"`
+}
+
+// ThinkingConfig enables Claude extended thinking with a token budget
+type ThinkingConfig struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 // ClaudeRequest represents a request to Claude API
 type ClaudeRequest struct {
-	Model     string `json:"model"`
-	MaxTokens int    `json:"max_tokens"`
-	Messages  []struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	} `json:"messages"`
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens"`
+	Stream    bool            `json:"stream,omitempty"`
+	Thinking  *ThinkingConfig `json:"thinking,omitempty"`
+	System    string          `json:"system,omitempty"`
+	Messages  []ClaudeMessage `json:"messages"`
+
+	// Review metadata kept with the request, never sent to the API
+	PromptVersion string                   `json:"-"`
+	PromptVariant string                   `json:"-"`
+	Categories    []config.CommentCategory `json:"-"` // Categories the response is parsed with
+	Language      string                   `json:"-"` // Natural language of the review
+	CommentLines  config.CommentLines      `json:"-"` // Diff lines line comments may be placed on
 }
 
-// PromptData holds the parameters for prompt template substitution
-type PromptData struct {
-	Title        string
-	Body         string
-	Precision    string
-	Diff         string
-	CustomPrompt string
+// ClaudeMessage is a single conversation turn sent to Claude API
+type ClaudeMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
-// NewAIClient creates a new AI client with the provided API key and model
-func NewAIClient(apiKey, model string) *AIClient {
+// NewAIClient creates a new AI client with the provided API key, model and optional prompts directory
+func NewAIClient(apiKey, model, promptsDir string) *AIClient {
+	// No overall timeout: long reviews can stream for several minutes. We only
+	// bound the wait for response headers so a hung connection still fails fast.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 60 * time.Second
+
 	return &AIClient{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:     newCredential(apiKey),
+		model:      model,
+		promptsDir: promptsDir,
+		prompts:    newPromptCache(),
+		httpClient: &http.Client{Transport: transport},
 	}
 }
 
-// loadPromptTemplate loads and processes the system prompt template
-func (ai *AIClient) loadPromptTemplate(data PromptData) string {
-	// Try to load from file first
-	promptPath := "prompts/system-prompt.txt"
-	if content, err := os.ReadFile(promptPath); err == nil {
-		template := string(content)
-		return ai.substitutePromptVariables(template, data)
-	}
+// WithModel returns a copy of the client that uses a different model
+func (ai *AIClient) WithModel(model string) *AIClient {
+	clone := *ai
+	clone.model = model
+	return &clone
+}
 
-	// Fallback to hardcoded prompt if file doesn't exist
-	log.Printf("Could not load prompt template from %s, using fallback", promptPath)
-	return ai.getFallbackPrompt(data)
+// WithPromptVariant returns a copy of the client that renders the given prompt experiment variant
+func (ai *AIClient) WithPromptVariant(variant string) *AIClient {
+	clone := *ai
+	clone.promptVariant = variant
+	return &clone
 }
 
-// substitutePromptVariables replaces template variables with actual values
-func (ai *AIClient) substitutePromptVariables(template string, data PromptData) string {
-	result := template
-	result = strings.ReplaceAll(result, "{{.Title}}", data.Title)
-	result = strings.ReplaceAll(result, "{{.Body}}", data.Body)
-	result = strings.ReplaceAll(result, "{{.Precision}}", data.Precision)
-	result = strings.ReplaceAll(result, "{{.Diff}}", data.Diff)
-	result = strings.ReplaceAll(result, "{{.CustomPrompt}}", data.CustomPrompt)
-	return result
+// WithAPIKey returns a copy of the client that authenticates with a different API key
+func (ai *AIClient) WithAPIKey(apiKey string) *AIClient {
+	clone := *ai
+	clone.apiKey = newCredential(apiKey)
+	return &clone
 }
 
-// getFallbackPrompt provides a hardcoded fallback prompt
-func (ai *AIClient) getFallbackPrompt(data PromptData) string {
-	return fmt.Sprintf(`You are Cyclone, an AI code review assistant. Please review this GitHub pull request and provide constructive feedback.
-
-**PR Title:** %s
-
-**PR Description:** %s
-
-**Review Precision**: %s
- 
-**Code Changes:**
-%s
-
-Please provide:
-1. A brief overall summary of the changes
-2. Specific feedback categorized by type and priority
-3. End with a short, lighthearted poem (2-4 lines) based on the changes made
-
-**Review Guidelines:**
-- Be constructive and actionable - explain the "why" behind suggestions
-- Include code examples when suggesting alternatives
-- Use collaborative language ("we could" vs "you should")
-- Focus on logic correctness, security, maintainability, and team conventions
-- Acknowledge good patterns when present
-
-**Comment Categories - Use these prefixes:**
-- 🧰 **nit**: Minor style/preference issues, non-blocking
-- 💡 **suggestion**: Improvements that would be nice but aren't required
-- ⚠️ **issue**: Problems that should be addressed before merging
-- 🚫 **blocking**: Critical issues that must be fixed
-- ❓ **question**: Seeking clarification about intent or approach
-
-**Focus Areas - Use these prefixes when relevant:**
-- 🎨 **style**: Formatting, naming conventions
-- ⚡ **perf**: Performance concerns
-- 🔒 **security**: Security-related issues
-- 📚 **docs**: Documentation needs
-- 🧪 **test**: Testing coverage or quality
-- 🔧 **refactor**: Code organization improvements
-
-**Response Structure:**
-Please structure your response EXACTLY as follows:
-
-SUMMARY: $$
-**A warm, engaging summary** with emojis and thoughtful analysis (not just bullet points) including:**
-- Brief overall analysis of what this PR accomplishes
-- Key changes made 
-- Impact assessment (what this means for the codebase)
-- Good patterns you noticed (acknowledge positive aspects)
-- Any overarching concerns or recommendations
-- Use emojis carefully to make it visually appealing (🚀 ✨ 🎯 📈 🔧 etc.). 
-$$
-
-POEM: $$
-A short, lighthearted poem (2-4 lines) inspired by the changes made formatted in italic.
-Make it fun and relevant to the code changes.
-$$
-
-For any line-specific comments, use this EXACT format:
-PR_COMMENT:filename:line_number: [emoji] **[category]**: $$ 
-your comment here (can be multiple lines)
-include code examples
-end your comment
-$$
-Examples:
-PR_COMMENT:main.go:45: 🔍 **nit**: Consider using a more descriptive variable name like 'userCount' instead of 'cnt'
-PR_COMMENT:utils.js:123: ⚠️ **issue**: This function needs error handling for the API call
-PR_COMMENT:api/handler.py:67: 🚫 **blocking**: 🔒 **security**: Potential SQL injection vulnerability - use parameterized queries
-
-
-**IMPORTANT Rules:**
-- Use SINGLE line numbers only, NOT ranges like "75-82"
-- Always include the colon after **[category]**:
-- Always use the $$ delimiters for all sections
-- Keep general analysis in SUMMARY, use PR_COMMENT only for specific line feedback
-- Include code examples in PR_COMMENT when suggesting alternatives
-
-%s
-
-Be constructive, helpful, and focus on actionable feedback.`, data.Title, data.Body, data.Precision, data.Diff, data.CustomPrompt)
+// RotateAPIKey replaces the API key of this client and of all copies sharing it
+func (ai *AIClient) RotateAPIKey(apiKey string) {
+	ai.apiKey.set(apiKey)
 }
 
 // GenerateReview generates an AI review using Claude with repository-specific configuration
 func (ai *AIClient) GenerateReview(diff, title, body string, repoConfig *config.RepositoryConfig) ReviewResult {
-	claudeReview := ai.callClaudeAPI(diff, title, body, repoConfig)
-	return ai.parseClaudeResponse(claudeReview, diff)
+	started := time.Now()
+	reqBody, diff, _ := ai.prepareReviewRequest(diff, title, body, repoConfig)
+	prepared := time.Now()
+	claudeReview, usage, err := ai.callClaudeAPI(reqBody)
+	generated := time.Now()
+
+	result := ai.parseClaudeResponse(claudeReview, diff, reqBody.Categories, reqBody.Language, reqBody.CommentLines)
+	result.Timings = Timings{
+		Context:    prepared.Sub(started),
+		Generation: generated.Sub(prepared),
+		Parsing:    time.Since(generated),
+	}
+	result.Conversation = Conversation{System: reqBody.System, Diff: diff}
+	result.Usage = usage
+	result.Err = err
+	if err == nil {
+		result.Err = parseError(claudeReview, result)
+	}
+	result.PromptVersion = reqBody.PromptVersion
+	result.PromptVariant = reqBody.PromptVariant
+	return result
 }
 
-// callClaudeAPI makes a request to Claude API with repository-specific configuration
-func (ai *AIClient) callClaudeAPI(diff, title, body string, repoConfig *config.RepositoryConfig) string {
+// PreviewReviewRequest returns the request GenerateReview would send for a diff - rendered
+// templates, injected context and token budget trimming included - without generating a
+// review, together with its input tokens. Only the token count is requested from the API.
+func (ai *AIClient) PreviewReviewRequest(diff, title, body string, repoConfig *config.RepositoryConfig) (ClaudeRequest, int) {
+	reqBody, _, tokens := ai.prepareReviewRequest(diff, title, body, repoConfig)
+	return reqBody, tokens
+}
+
+// Converse continues a conversation with Claude and returns the assistant's reply
+func (ai *AIClient) Converse(system string, messages []ClaudeMessage) (string, Usage, error) {
+	reqBody := ClaudeRequest{
+		Model:     ai.model,
+		MaxTokens: 2000,
+		System:    system,
+		Messages:  messages,
+	}
+
+	return ai.streamClaudeRequest(reqBody)
+}
+
+// buildClaudeRequest assembles the Messages API request body for a review
+func (ai *AIClient) buildClaudeRequest(diff, title, body string, repoConfig *config.RepositoryConfig) ClaudeRequest {
 	promptData := PromptData{
-		Title:        title,
-		Body:         body,
-		Precision:    config.GetPrecisionGuidelines(repoConfig.Precision),
-		Diff:         diff,
-		CustomPrompt: repoConfig.CustomPrompt,
+		Title:              title,
+		Body:               body,
+		Precision:          repoConfig.GetPrecisionGuidelines(),
+		PathPrecision:      buildPathPrecisionGuidelines(diff, repoConfig),
+		Tone:               config.GetToneGuidelines(repoConfig.Tone),
+		Poem:               repoConfig.Tone.IncludesPoem(),
+		Language:           repoConfig.Language,
+		Diff:               diff,
+		CustomPrompt:       repoConfig.CustomPrompt,
+		LanguageGuidelines: ai.buildLanguageGuidelines(diff, repoConfig),
+		StyleGuide:         repoConfig.StyleGuide,
+		Promoted:           repoConfig.PromotedConventions,
+		Demoted:            repoConfig.DemotedConventions,
+		Examples:           repoConfig.CommentExamples,
+		Categories:         repoConfig.GetCategories(),
+		AddedLinesOnly:     repoConfig.GetCommentLines() == config.CommentLinesAdded,
 	}
+	sanitizePromptData(&promptData)
 
-	prompt := ai.loadPromptTemplate(promptData)
+	systemPrompt, systemVersion := ai.loadPromptTemplate(systemPromptName, promptData)
+	userPrompt, userVersion := ai.loadPromptTemplate(userPromptName, promptData)
 
 	reqBody := ClaudeRequest{
 		Model:     ai.model, // configurable: claude-sonnet-4-20250514, claude-3-5-sonnet-20241022, claude-3-haiku-20240307
 		MaxTokens: 8000,
-		Messages: []struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		}{
+		System:    systemPrompt,
+		Messages: []ClaudeMessage{
 			{
 				Role:    "user",
-				Content: prompt,
+				Content: userPrompt,
 			},
 		},
+		PromptVersion: fmt.Sprintf("system@%s,user@%s", systemVersion, userVersion),
+		PromptVariant: ai.promptVariant,
+		Categories:    promptData.Categories,
+		Language:      repoConfig.Language,
+		CommentLines:  repoConfig.GetCommentLines(),
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		log.Printf("Error marshaling request: %v", err)
-		return "Error generating AI review"
+	if repoConfig.UsesExtendedThinking() {
+		budget := repoConfig.GetThinkingBudget()
+		reqBody.Thinking = &ThinkingConfig{
+			Type:         "enabled",
+			BudgetTokens: budget,
+		}
+		// max_tokens must cover both the thinking budget and the visible review
+		reqBody.MaxTokens += budget
+		log.Printf("Extended thinking enabled with a budget of %d tokens", budget)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	return reqBody
+}
+
+// newClaudeHTTPRequest creates an authenticated request against the Claude API
+func (ai *AIClient) newClaudeHTTPRequest(method, url string, payload interface{}) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		log.Printf("Error creating request: %v", err)
-		return "Error generating AI review"
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", ai.apiKey)
+	req.Header.Set("x-api-key", ai.apiKey.get())
 	req.Header.Set("anthropic-version", "2023-06-01")
+	return req, nil
+}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
+// callClaudeAPI makes a review request to Claude API, returning a placeholder text and the
+// error on failure
+func (ai *AIClient) callClaudeAPI(reqBody ClaudeRequest) (string, Usage, error) {
+	text, usage, err := ai.streamClaudeRequest(reqBody)
 	if err != nil {
-		log.Printf("Error calling Claude API: %v", err)
-		return "Error generating AI review"
+		log.Printf("Error generating AI review: %v", err)
+		return "Error generating AI review", usage, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Claude API returned status %d", resp.StatusCode)
-		return "Error generating AI review"
+	if text == "" {
+		return "No response from Claude", usage, nil
+	}
+
+	return text, usage, nil
+}
+
+// streamClaudeRequest sends a streaming request to Claude API and returns the generated text and token usage
+func (ai *AIClient) streamClaudeRequest(reqBody ClaudeRequest) (string, Usage, error) {
+	reqBody.Stream = true
+	usage := Usage{Model: reqBody.Model}
+
+	req, err := ai.newClaudeHTTPRequest("POST", claudeAPIBaseURL+"/messages", reqBody)
+	if err != nil {
+		return "", usage, err
 	}
 
-	var claudeResp ClaudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
-		log.Printf("Error decoding response: %v", err)
-		return "Error generating AI review"
+	resp, err := ai.httpClient.Do(req)
+	if err != nil {
+		return "", usage, fmt.Errorf("failed to call Claude API: %w", err)
 	}
+	defer resp.Body.Close()
 
-	if len(claudeResp.Content) > 0 {
-		return claudeResp.Content[0].Text
+	if resp.StatusCode != http.StatusOK {
+		return "", usage, &APIError{API: "Claude", StatusCode: resp.StatusCode}
+	}
+
+	text, streamUsage, err := readClaudeStream(resp.Body)
+	streamUsage.Model = reqBody.Model
+	if err != nil {
+		if text == "" {
+			return "", streamUsage, fmt.Errorf("failed to read Claude stream: %w", err)
+		}
+		// Salvage whatever we received - the parser only picks up complete sections
+		log.Printf("Claude stream aborted after %d characters, using partial output: %v", len(text), err)
 	}
 
-	return "No response from Claude"
+	return text, streamUsage, nil
 }
@@ -0,0 +1,443 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// azureDevOpsAPIVersion is the REST API version requested from Azure DevOps
+const azureDevOpsAPIVersion = "7.1"
+
+// azureRequestTimeout bounds a single Azure DevOps API request
+const azureRequestTimeout = 30 * time.Second
+
+// Limits of the files of an Azure DevOps pull request that are diffed
+const (
+	azureMaxFileBytes = 1 << 20
+	azureMaxFileEdits = 1000
+)
+
+// AzureDevOpsClient handles the Azure Repos REST API operations of pull request reviews.
+// Repositories are named by project and repository, e.g. "Fabrikam/api".
+type AzureDevOpsClient struct {
+	baseURL    string // Organization URL, e.g. "https://dev.azure.com/fabrikam"
+	token      string // Personal access token with the Code (read and write) scope
+	httpClient *http.Client
+}
+
+// AzureDevOpsClient is the SCMProvider of organizations on Azure DevOps
+var _ SCMProvider = (*AzureDevOpsClient)(nil)
+
+// azurePullRequest is the part of an Azure Repos pull request reviews need
+type azurePullRequest struct {
+	PullRequestID int    `json:"pullRequestId"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	IsDraft       bool   `json:"isDraft"`
+	Labels        []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	LastMergeSourceCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeSourceCommit"`
+}
+
+// azureIteration is a push to a pull request's source branch, which diffs and comments refer to
+type azureIteration struct {
+	ID int `json:"id"`
+}
+
+// azureChangeEntry is a file an iteration changed compared to the target branch
+type azureChangeEntry struct {
+	ChangeTrackingID int    `json:"changeTrackingId"`
+	ChangeType       string `json:"changeType"` // e.g. "add", "edit", "delete", "edit, rename"
+	Item             struct {
+		Path             string `json:"path"` // With a leading slash, e.g. "/src/main.go"
+		GitObjectType    string `json:"gitObjectType"`
+		ObjectID         string `json:"objectId"`
+		OriginalObjectID string `json:"originalObjectId"`
+	} `json:"item"`
+}
+
+// AzureDevOpsAPIError is returned for unsuccessful Azure DevOps API responses
+type AzureDevOpsAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *AzureDevOpsAPIError) Error() string {
+	return fmt.Sprintf("Azure DevOps API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// NewAzureDevOpsClient creates a client for an Azure DevOps organization URL
+func NewAzureDevOpsClient(baseURL, token string) *AzureDevOpsClient {
+	return &AzureDevOpsClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: azureRequestTimeout},
+	}
+}
+
+// GetPullRequest fetches a pull request; owner is the organization the client is for
+func (a *AzureDevOpsClient) GetPullRequest(ctx context.Context, owner, repo string, id int) (*PullRequest, error) {
+	project, repoName := splitAzureRepository(repo)
+	var azurePR azurePullRequest
+	if err := a.do(ctx, http.MethodGet, pullRequestPath(project, repoName, id), nil, &azurePR); err != nil {
+		return nil, fmt.Errorf("failed to get pull request %d: %w", id, err)
+	}
+
+	pr := &PullRequest{
+		Number: azurePR.PullRequestID,
+		Title:  azurePR.Title,
+		Body:   azurePR.Description,
+		Draft:  azurePR.IsDraft,
+	}
+	for _, label := range azurePR.Labels {
+		pr.Labels = append(pr.Labels, Label{Name: label.Name})
+	}
+	pr.Head.SHA = azurePR.LastMergeSourceCommit.CommitID
+	return pr, nil
+}
+
+// ListFiles fetches the files the latest iteration of a pull request changed compared to the
+// target branch and diffs their versions, as Azure Repos doesn't return patches
+func (a *AzureDevOpsClient) ListFiles(ctx context.Context, owner, repo string, id int) ([]ChangedFile, error) {
+	project, repoName := splitAzureRepository(repo)
+	_, entries, err := a.latestChanges(ctx, project, repoName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ChangedFile
+	for _, entry := range entries {
+		file := ChangedFile{Filename: strings.TrimPrefix(entry.Item.Path, "/"), Status: "modified"}
+		switch {
+		case strings.Contains(entry.ChangeType, "add"):
+			file.Status = "added"
+		case strings.Contains(entry.ChangeType, "delete"):
+			file.Status = "removed"
+		}
+		if isBinaryFile(file.Filename) {
+			files = append(files, file)
+			continue
+		}
+
+		var oldText, newText string
+		if file.Status != "added" && entry.Item.OriginalObjectID != "" {
+			if oldText, err = a.getBlob(ctx, project, repoName, entry.Item.OriginalObjectID); err != nil {
+				return nil, err
+			}
+		}
+		if file.Status != "removed" {
+			if newText, err = a.getBlob(ctx, project, repoName, entry.Item.ObjectID); err != nil {
+				return nil, err
+			}
+		}
+
+		if diff, ok := UnifiedDiff(oldText, newText, azureMaxFileEdits); ok {
+			file.Patch = diff
+			file.Additions, file.Deletions = countDiffLines(diff)
+		} else {
+			// Too different to diff: count every line as changed
+			file.Additions, file.Deletions = len(splitLines(newText)), len(splitLines(oldText))
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// GetDiff fetches the diff of a pull request, leaving out files matching any of the ignore patterns
+func (a *AzureDevOpsClient) GetDiff(ctx context.Context, owner, repo string, id int, ignorePaths []string) (string, error) {
+	files, err := a.ListFiles(ctx, owner, repo, id)
+	if err != nil {
+		return "", err
+	}
+	return FormatDiff(files, fmt.Sprintf("pull request %d", id), ignorePaths), nil
+}
+
+// GetFileContent fetches a file from the repository's default branch, returning nil if it doesn't exist
+func (a *AzureDevOpsClient) GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error) {
+	project, repoName := splitAzureRepository(repo)
+	content, err := a.getRaw(ctx, fmt.Sprintf("%s/items?path=%s&$format=octetstream", repositoryPath(project, repoName), url.QueryEscape("/"+path)))
+	var apiErr *AzureDevOpsAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// latestChanges fetches the latest iteration of a pull request and the files it changed
+// compared to the target branch
+func (a *AzureDevOpsClient) latestChanges(ctx context.Context, project, repo string, id int) (*azureIteration, []azureChangeEntry, error) {
+	var iterations struct {
+		Value []azureIteration `json:"value"`
+	}
+	if err := a.do(ctx, http.MethodGet, pullRequestPath(project, repo, id)+"/iterations", nil, &iterations); err != nil {
+		return nil, nil, fmt.Errorf("failed to get iterations of pull request %d: %w", id, err)
+	}
+	if len(iterations.Value) == 0 {
+		return nil, nil, fmt.Errorf("pull request %d has no iterations", id)
+	}
+	latest := iterations.Value[0]
+	for _, iteration := range iterations.Value {
+		if iteration.ID > latest.ID {
+			latest = iteration
+		}
+	}
+
+	var entries []azureChangeEntry
+	path := fmt.Sprintf("%s/iterations/%d/changes", pullRequestPath(project, repo, id), latest.ID)
+	for skip := 0; ; {
+		var page struct {
+			ChangeEntries []azureChangeEntry `json:"changeEntries"`
+			NextSkip      int                `json:"nextSkip"`
+		}
+		if err := a.do(ctx, http.MethodGet, fmt.Sprintf("%s?$top=2000&$skip=%d", path, skip), nil, &page); err != nil {
+			return nil, nil, fmt.Errorf("failed to get changes of pull request %d: %w", id, err)
+		}
+		for _, entry := range page.ChangeEntries {
+			// Folders are listed too
+			if entry.Item.GitObjectType == "" || entry.Item.GitObjectType == "blob" {
+				entries = append(entries, entry)
+			}
+		}
+		if page.NextSkip == 0 {
+			return &latest, entries, nil
+		}
+		skip = page.NextSkip
+	}
+}
+
+// getBlob fetches the content of a file version
+func (a *AzureDevOpsClient) getBlob(ctx context.Context, project, repo, objectID string) (string, error) {
+	content, err := a.getRaw(ctx, fmt.Sprintf("%s/blobs/%s?$format=octetstream", repositoryPath(project, repo), url.PathEscape(objectID)))
+	if err != nil {
+		return "", fmt.Errorf("failed to get blob %s: %w", objectID, err)
+	}
+	return string(content), nil
+}
+
+// countDiffLines counts the lines unified diff hunks add and delete
+func countDiffLines(diff string) (additions, deletions int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// PostComment starts a comment thread on a pull request
+func (a *AzureDevOpsClient) PostComment(ctx context.Context, owner, repo string, id int, body string) error {
+	project, repoName := splitAzureRepository(repo)
+	_, err := a.postThread(ctx, project, repoName, id, body, nil)
+	return err
+}
+
+// ListLabels fetches the names of a pull request's labels
+func (a *AzureDevOpsClient) ListLabels(ctx context.Context, owner, repo string, id int) ([]string, error) {
+	project, repoName := splitAzureRepository(repo)
+	var labels struct {
+		Value []struct {
+			Name string `json:"name"`
+		} `json:"value"`
+	}
+	if err := a.do(ctx, http.MethodGet, pullRequestPath(project, repoName, id)+"/labels", nil, &labels); err != nil {
+		return nil, fmt.Errorf("failed to list labels of pull request %d: %w", id, err)
+	}
+
+	names := make([]string, len(labels.Value))
+	for i, label := range labels.Value {
+		names[i] = label.Name
+	}
+	return names, nil
+}
+
+// AddLabels adds labels to a pull request; Azure DevOps creates those the project doesn't have yet
+func (a *AzureDevOpsClient) AddLabels(ctx context.Context, owner, repo string, id int, labels []string) error {
+	project, repoName := splitAzureRepository(repo)
+	for _, label := range labels {
+		var created struct {
+			ID string `json:"id"`
+		}
+		if err := a.do(ctx, http.MethodPost, pullRequestPath(project, repoName, id)+"/labels", map[string]string{"name": label}, &created); err != nil {
+			return fmt.Errorf("failed to add label %q to pull request %d: %w", label, id, err)
+		}
+	}
+	return nil
+}
+
+// PostReview posts a review on a pull request: the summary as a thread and each line comment
+// as a thread on its line of the latest iteration. Comments Azure DevOps can't place on a
+// line are posted as threads naming the line instead. It returns the ID of the summary thread.
+func (a *AzureDevOpsClient) PostReview(ctx context.Context, owner, repo string, id int, review ReviewResult) (int64, error) {
+	project, repoName := splitAzureRepository(repo)
+	iteration, entries, err := a.latestChanges(ctx, project, repoName, id)
+	if err != nil {
+		return 0, err
+	}
+
+	threadID, err := a.postThread(ctx, project, repoName, id, review.Summary, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post review summary: %w", err)
+	}
+
+	trackingIDs := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		trackingIDs[strings.TrimPrefix(entry.Item.Path, "/")] = entry.ChangeTrackingID
+	}
+
+	for _, comment := range review.Comments {
+		position := map[string]int{"line": comment.Line, "offset": 1}
+		fileContext := map[string]interface{}{"filePath": "/" + comment.Path}
+		if comment.Side == "LEFT" {
+			fileContext["leftFileStart"], fileContext["leftFileEnd"] = position, position
+		} else {
+			fileContext["rightFileStart"], fileContext["rightFileEnd"] = position, position
+		}
+		threadContext := map[string]interface{}{
+			"threadContext": fileContext,
+			"pullRequestThreadContext": map[string]interface{}{
+				"changeTrackingId": trackingIDs[comment.Path],
+				"iterationContext": map[string]int{"firstComparingIteration": 1, "secondComparingIteration": iteration.ID},
+			},
+		}
+
+		_, err := a.postThread(ctx, project, repoName, id, comment.Body, threadContext)
+		if err == nil {
+			continue
+		}
+		log.Printf("Error positioning comment on %s:%d in pull request %d - posting it unpositioned: %v", comment.Path, comment.Line, id, err)
+		body := fmt.Sprintf("`%s:%d`\n\n%s", comment.Path, comment.Line, comment.Body)
+		if _, err := a.postThread(ctx, project, repoName, id, body, nil); err != nil {
+			return threadID, fmt.Errorf("failed to post comment on %s:%d: %w", comment.Path, comment.Line, err)
+		}
+	}
+	return threadID, nil
+}
+
+// postThread starts an active comment thread, on a file line if threadContext is set
+func (a *AzureDevOpsClient) postThread(ctx context.Context, project, repo string, id int, body string, threadContext map[string]interface{}) (int64, error) {
+	thread := map[string]interface{}{
+		"comments": []map[string]interface{}{{"parentCommentId": 0, "content": body, "commentType": 1}},
+		"status":   1,
+	}
+	for key, value := range threadContext {
+		thread[key] = value
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := a.do(ctx, http.MethodPost, pullRequestPath(project, repo, id)+"/threads", thread, &created); err != nil {
+		return 0, fmt.Errorf("failed to create thread: %w", err)
+	}
+	return created.ID, nil
+}
+
+// splitAzureRepository splits a repository name into its project and repository, e.g.
+// "Fabrikam/api" into "Fabrikam" and "api"
+func splitAzureRepository(repo string) (string, string) {
+	project, name, _ := strings.Cut(repo, "/")
+	return project, name
+}
+
+// repositoryPath is the API path of a repository
+func repositoryPath(project, repo string) string {
+	return fmt.Sprintf("%s/_apis/git/repositories/%s", url.PathEscape(project), url.PathEscape(repo))
+}
+
+// pullRequestPath is the API path of a pull request
+func pullRequestPath(project, repo string, id int) string {
+	return fmt.Sprintf("%s/pullRequests/%d", repositoryPath(project, repo), id)
+}
+
+// newRequest creates an API request authenticated with the personal access token
+func (a *AzureDevOpsClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+"/"+path+separator+"api-version="+azureDevOpsAPIVersion, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+a.token)))
+	return req, nil
+}
+
+// do sends an API request with a JSON body, if not nil, and decodes the JSON response into result
+func (a *AzureDevOpsClient) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := a.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Rejected tokens get a 203 with a sign-in page
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		return azureDevOpsError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode Azure DevOps response: %w", err)
+	}
+	return nil
+}
+
+// getRaw sends a GET request and returns the raw response body, up to azureMaxFileBytes
+func (a *AzureDevOpsClient) getRaw(ctx context.Context, path string) ([]byte, error) {
+	req, err := a.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, azureDevOpsError(resp)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, azureMaxFileBytes))
+}
+
+// azureDevOpsError builds the error of an unsuccessful response
+func azureDevOpsError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &AzureDevOpsAPIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+}
@@ -0,0 +1,178 @@
+package review
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+)
+
+// Batch processing states reported by the Message Batches API
+const (
+	BatchStatusInProgress = "in_progress"
+	BatchStatusCanceling  = "canceling"
+	BatchStatusEnded      = "ended"
+)
+
+// BatchRequest is a single review request inside a message batch
+type BatchRequest struct {
+	CustomID string        `json:"custom_id"`
+	Params   ClaudeRequest `json:"params"`
+}
+
+// Batch represents a message batch as returned by the Claude API
+type Batch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	ResultsURL       string `json:"results_url"`
+}
+
+// BatchResult is a single line of a batch results file
+type BatchResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string         `json:"type"` // succeeded, errored, canceled or expired
+		Message ClaudeResponse `json:"message"`
+	} `json:"result"`
+}
+
+// TextContent joins all text blocks of the response, dropping thinking blocks
+func (cr *ClaudeResponse) TextContent() string {
+	var text strings.Builder
+	for _, block := range cr.Content {
+		if block.Type == "" || block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String()
+}
+
+// NewBatchRequest builds a batch entry for a review with repository-specific configuration
+func (ai *AIClient) NewBatchRequest(customID, diff, title, body string, repoConfig *config.RepositoryConfig) BatchRequest {
+	params, _, _ := ai.prepareReviewRequest(diff, title, body, repoConfig)
+	return BatchRequest{
+		CustomID: customID,
+		Params:   params,
+	}
+}
+
+// SubmitBatch submits review requests to the Message Batches API
+func (ai *AIClient) SubmitBatch(requests []BatchRequest) (*Batch, error) {
+	payload := struct {
+		Requests []BatchRequest `json:"requests"`
+	}{Requests: requests}
+
+	req, err := ai.newClaudeHTTPRequest("POST", claudeAPIBaseURL+"/messages/batches", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch Batch
+	if err := ai.doJSON(req, &batch); err != nil {
+		return nil, fmt.Errorf("failed to submit batch: %w", err)
+	}
+
+	return &batch, nil
+}
+
+// GetBatch fetches the current processing state of a batch
+func (ai *AIClient) GetBatch(batchID string) (*Batch, error) {
+	req, err := ai.newClaudeHTTPRequest("GET", claudeAPIBaseURL+"/messages/batches/"+batchID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch Batch
+	if err := ai.doJSON(req, &batch); err != nil {
+		return nil, fmt.Errorf("failed to get batch %s: %w", batchID, err)
+	}
+
+	return &batch, nil
+}
+
+// GetBatchResults downloads the results of an ended batch
+func (ai *AIClient) GetBatchResults(batch *Batch) ([]BatchResult, error) {
+	if batch.ResultsURL == "" {
+		return nil, fmt.Errorf("batch %s has no results yet", batch.ID)
+	}
+
+	req, err := ai.newClaudeHTTPRequest("GET", batch.ResultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ai.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download batch results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download batch results: %w", &APIError{API: "Claude", StatusCode: resp.StatusCode})
+	}
+
+	// Results are returned as JSONL, one line per request
+	var results []BatchResult
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var result BatchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse batch result: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch results: %w", err)
+	}
+
+	return results, nil
+}
+
+// ParseBatchResult converts a succeeded batch result into a review
+func (ai *AIClient) ParseBatchResult(result BatchResult, request BatchRequest, diff string) (ReviewResult, error) {
+	if result.Result.Type != "succeeded" {
+		return ReviewResult{}, fmt.Errorf("batch request %s %s", result.CustomID, result.Result.Type)
+	}
+
+	started := time.Now()
+	text := result.Result.Message.TextContent()
+	reviewResult := ai.parseClaudeResponse(text, diff, request.Params.Categories, request.Params.Language, request.Params.CommentLines)
+	reviewResult.Timings.Parsing = time.Since(started)
+	reviewResult.Err = parseError(text, reviewResult)
+	reviewResult.Conversation = Conversation{System: request.Params.System, Diff: diff}
+	reviewResult.PromptVersion = request.Params.PromptVersion
+	reviewResult.PromptVariant = request.Params.PromptVariant
+	reviewResult.Usage = Usage{
+		Model:        request.Params.Model,
+		InputTokens:  result.Result.Message.Usage.totalInput(),
+		OutputTokens: result.Result.Message.Usage.OutputTokens,
+		Batch:        true,
+	}
+	return reviewResult, nil
+}
+
+// doJSON executes a request and decodes a JSON response body
+func (ai *AIClient) doJSON(req *http.Request, out interface{}) error {
+	resp, err := ai.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{API: "Claude", StatusCode: resp.StatusCode}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
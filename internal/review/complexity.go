@@ -0,0 +1,119 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loopHeader matches a line that opens a loop: loop statements, and iteration methods whose
+// callback body follows on the next lines
+var loopHeader = regexp.MustCompile(`^\s*(for\b|while\b|foreach\b|do\s*\{|loop\s*\{)|\.(forEach|each|each_with_index|map|flatMap|filter|reduce|iter\(\)\.for_each)\s*\(?.*(\{|\(|=>|\bdo\b(\s*\|[^|]*\|)?)\s*$`)
+
+// iterationCall matches a call iterating a collection with a callback on the same line
+var iterationCall = regexp.MustCompile(`\.(filter|map|forEach|some|every|find|reduce|flatMap)\(`)
+
+// Patterns that are cheap once but add up inside a loop, by the hint they raise
+var (
+	loopAllocation = regexp.MustCompile(`\bmake\(|\bnew\((\w|\[)|fmt\.Sprintf\(|regexp\.(Must)?Compile\(|re\.compile\(|new RegExp\(|Pattern\.compile\(|json\.Marshal\(|JSON\.parse\(JSON\.stringify\(|copy\.deepcopy\(|\.concat\(|\[\.\.\.\w+|new (ArrayList|HashMap|HashSet|Map|Set)\b|\+=\s*["'` + "`" + `]`)
+	linearSearch   = regexp.MustCompile(`\.(includes|indexOf|find|findIndex|filter|some|index)\(|slices\.(Contains|Index)\(|\.contains\(|\bif .*\bin [\w.]+:`)
+	loopSort       = regexp.MustCompile(`\bsort\.\w+\(|slices\.Sort\w*\(|\.sort\(|\bsorted\(`)
+)
+
+// openLoop is a loop whose body the scan of a hunk is in
+type openLoop struct {
+	indent int
+	line   int
+	added  bool
+}
+
+// complexitySignals scans the added lines of a file's diff for simple signs of a
+// performance cost: loops nested in loops, allocations, linear searches and sorts inside
+// loops, and searches inside iteration callbacks. Loop bodies are told by indentation, so
+// the signals are leads for the model to check, not findings.
+func complexitySignals(filename, fileDiff string) []string {
+	if !sourceExtensions[strings.ToLower(path.Ext(filename))] {
+		return nil
+	}
+
+	var signals []string
+	signal := func(line int, format string, args ...any) {
+		signals = append(signals, fmt.Sprintf("%s:%d: %s", filename, line, fmt.Sprintf(format, args...)))
+	}
+
+	var loops []openLoop
+	current := 0
+	for _, line := range strings.Split(fileDiff, "\n") {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			current, _ = strconv.Atoi(match[2])
+			loops = nil
+			continue
+		}
+		if current == 0 || line == "" || line[0] == '-' || line[0] == '\\' {
+			continue
+		}
+
+		added := line[0] == '+'
+		code := line[1:]
+		lineNumber := current
+		current++
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+
+		indent := indentWidth(code)
+		for len(loops) > 0 && loops[len(loops)-1].indent >= indent {
+			loops = loops[:len(loops)-1]
+		}
+		var enclosing *openLoop
+		if len(loops) > 0 {
+			enclosing = &loops[len(loops)-1]
+		}
+
+		if loopHeader.MatchString(code) {
+			if enclosing != nil && (added || enclosing.added) {
+				signal(lineNumber, "loop nested inside the loop at line %d", enclosing.line)
+			}
+			loops = append(loops, openLoop{indent: indent, line: lineNumber, added: added})
+			continue
+		}
+		if !added {
+			continue
+		}
+
+		if enclosing != nil {
+			if match := loopAllocation.FindString(code); match != "" {
+				signal(lineNumber, "allocation (`%s`) inside the loop at line %d", strings.TrimSpace(match), enclosing.line)
+			}
+			if match := linearSearch.FindString(code); match != "" {
+				signal(lineNumber, "linear search (`%s`) inside the loop at line %d - quadratic if both grow", match, enclosing.line)
+			}
+			if match := loopSort.FindString(code); match != "" {
+				signal(lineNumber, "sort (`%s`) inside the loop at line %d", match, enclosing.line)
+			}
+		} else if call := iterationCall.FindStringIndex(code); call != nil {
+			if match := linearSearch.FindString(code[call[1]:]); match != "" {
+				signal(lineNumber, "linear search (`%s`) inside an iteration callback on the same line - quadratic if both grow", match)
+			}
+		}
+	}
+	return signals
+}
+
+// indentWidth returns the width of a line's leading whitespace, counting tabs as 4
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
@@ -0,0 +1,72 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+)
+
+// MergeConsensus combines the reviews of two models into one. Comments on the same
+// file within CONSENSUS_LINE_TOLERANCE lines count as agreement and the primary
+// model's wording is kept; the secondary model's summary is attached as a second opinion.
+// Cyclone's notes are written in the review's language.
+func MergeConsensus(primary, secondary ReviewResult, secondaryModel string, mode config.ConsensusMode, language string) ReviewResult {
+	merged := primary
+	merged.Comments = nil
+
+	matchedSecondary := make([]bool, len(secondary.Comments))
+	for _, comment := range primary.Comments {
+		match := findAgreeingComment(comment, secondary.Comments, matchedSecondary)
+		if match >= 0 {
+			matchedSecondary[match] = true
+			merged.Comments = append(merged.Comments, comment)
+			continue
+		}
+
+		if mode == config.ConsensusMarkDisagreements {
+			comment.Body = singleModelNote(primary.Usage.Model, language) + comment.Body
+			merged.Comments = append(merged.Comments, comment)
+		}
+	}
+
+	if mode == config.ConsensusMarkDisagreements {
+		for i, comment := range secondary.Comments {
+			if !matchedSecondary[i] {
+				comment.Body = singleModelNote(secondaryModel, language) + comment.Body
+				merged.Comments = append(merged.Comments, comment)
+			}
+		}
+	}
+
+	secondOpinion := strings.TrimPrefix(secondary.Summary, reviewHeader(language))
+	merged.Summary += fmt.Sprintf("\n\n---\n\n<details>\n<summary>%s</summary>\n\n%s\n\n</details>",
+		notices.Render(language, "second_opinion", notices.Data{"Model": secondaryModel}), secondOpinion)
+
+	return merged
+}
+
+// findAgreeingComment returns the index of an unmatched comment close to the given one, or -1
+func findAgreeingComment(comment ReviewComment, candidates []ReviewComment, matched []bool) int {
+	best, bestDistance := -1, config.CONSENSUS_LINE_TOLERANCE+1
+	for i, candidate := range candidates {
+		if matched[i] || candidate.Path != comment.Path {
+			continue
+		}
+
+		distance := candidate.Line - comment.Line
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// singleModelNote marks a finding only one model reported
+func singleModelNote(model, language string) string {
+	return notices.Render(language, "single_model_finding", notices.Data{"Model": model}) + "\n\n"
+}
@@ -0,0 +1,36 @@
+package review
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Markers of container specs in the diff of a Kubernetes manifest: pod templates and the
+// fields of their containers
+var podSpec = regexp.MustCompile(`(?m)^[ +-]\s*(-\s*)?(containers|initContainers|image|securityContext|livenessProbe|readinessProbe):`)
+
+// isContainerFile reports whether a file defines container images or how they run:
+// Dockerfiles and Containerfiles, Docker Compose files, and Kubernetes manifests whose diff
+// touches a pod spec
+func isContainerFile(filename, fileDiff string) bool {
+	base := strings.ToLower(path.Base(filename))
+	switch {
+	case base == "dockerfile", base == "containerfile",
+		strings.HasPrefix(base, "dockerfile."), strings.HasSuffix(base, ".dockerfile"):
+		return true
+	case isComposeFile(base):
+		return true
+	}
+	return isIaCFile(filename, fileDiff) && podSpec.MatchString(fileDiff)
+}
+
+// isComposeFile reports whether a file name is one of Docker Compose's, e.g.
+// docker-compose.yml, compose.yaml or docker-compose.prod.yml
+func isComposeFile(base string) bool {
+	ext := path.Ext(base)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	return strings.HasPrefix(base, "docker-compose") || base == "compose"+ext || strings.HasPrefix(base, "compose.")
+}
@@ -0,0 +1,34 @@
+package review
+
+import (
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// credential is a secret shared by a client and its copies, so rotating it updates all of them
+type credential struct {
+	mu    sync.RWMutex
+	value string
+}
+
+func newCredential(value string) *credential {
+	return &credential{value: value}
+}
+
+func (c *credential) get() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.value
+}
+
+func (c *credential) set(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = value
+}
+
+// Token implements oauth2.TokenSource with the current value
+func (c *credential) Token() (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: c.get()}, nil
+}
@@ -0,0 +1,199 @@
+package review
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cyclone/internal/config"
+)
+
+// FormatUnifiedDiff converts a unified diff, as written by git diff or diff -u, into the
+// per-file format FormatDiff produces, so local changes go through the same review pipeline.
+// Binary files and files matching ignorePaths are left out.
+func FormatUnifiedDiff(patch string, ignorePaths []string) string {
+	var diffBuilder strings.Builder
+	var filename string
+	var hunks []string
+
+	flush := func() {
+		if filename != "" && len(hunks) > 0 && !isBinaryFile(filename) {
+			if pattern := matchingPattern(ignorePaths, filename); pattern != "" {
+				log.Printf("Ignoring %s (matches %q)", filename, pattern)
+			} else {
+				diffBuilder.WriteString(fmt.Sprintf("=== %s ===\n", filename))
+				diffBuilder.WriteString(strings.TrimRight(strings.Join(hunks, "\n"), "\n"))
+				diffBuilder.WriteString("\n\n")
+			}
+		}
+		filename, hunks = "", nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// A "--- old" line followed by "+++ new" starts the next file
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			flush()
+			filename = diffFilename(lines[i+1])
+			if filename == "" {
+				// Deleted files only have the old name
+				filename = diffFilename(line)
+			}
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			continue
+		}
+
+		// Hunks start at the first @@ header; git's extended headers before it are skipped
+		if strings.HasPrefix(line, "@@") || (len(hunks) > 0 && isHunkLine(line)) {
+			hunks = append(hunks, line)
+		}
+	}
+	flush()
+
+	return diffBuilder.String()
+}
+
+// hunkHeader matches a hunk's "@@ -old,count +new,count @@" header, capturing the old and new
+// start lines
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ChangedLines returns the lines of the old version of a file that a patch changes: removed
+// lines, and the lines new ones are inserted before
+func ChangedLines(patch string) []int {
+	var changed []int
+	old := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			old, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '-':
+			changed = append(changed, old)
+			old++
+		case '+':
+			changed = append(changed, old)
+		case '\\':
+			// "\ No newline at end of file"
+		default:
+			old++
+		}
+	}
+	return changed
+}
+
+// CommentableLines returns the lines of the new version of each file in a diff built by
+// FormatDiff that line comments may be placed on: added lines, and with
+// config.CommentLinesHunk also the unchanged context lines of the hunks
+func CommentableLines(diff string, commentLines config.CommentLines) map[string]map[int]bool {
+	commentable := make(map[string]map[int]bool)
+	for _, section := range splitDiffSections(diff) {
+		lines := make(map[int]bool)
+		current := 0
+		for _, line := range strings.Split(section.content, "\n") {
+			if match := hunkHeader.FindStringSubmatch(line); match != nil {
+				current, _ = strconv.Atoi(match[2])
+				continue
+			}
+			if current == 0 || line == "" {
+				continue
+			}
+
+			switch line[0] {
+			case '+':
+				lines[current] = true
+				current++
+			case ' ':
+				if commentLines != config.CommentLinesAdded {
+					lines[current] = true
+				}
+				current++
+			}
+		}
+		commentable[section.filename] = lines
+	}
+	return commentable
+}
+
+// diffFilename extracts the path from a "--- a/path" or "+++ b/path" line, or "" for /dev/null
+func diffFilename(line string) string {
+	name := strings.TrimSpace(line[4:])
+	// diff -u appends a tab and timestamp
+	if tab := strings.IndexByte(name, '\t'); tab >= 0 {
+		name = name[:tab]
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		name = name[2:]
+	}
+	return name
+}
+
+// isHunkLine reports whether a line belongs to a hunk body
+func isHunkLine(line string) bool {
+	if line == "" {
+		return true
+	}
+	switch line[0] {
+	case ' ', '+', '-', '\\':
+		return true
+	}
+	return false
+}
+
+// DiffHunk returns the hunk of a file in a diff built by FormatDiff that contains a line of the
+// file's new version, or of its old version for side "LEFT", "" if none does
+func DiffHunk(diff, path string, line int, side string) string {
+	start := 2
+	if side == "LEFT" {
+		start = 1
+	}
+
+	for _, section := range splitDiffSections(diff) {
+		if section.filename != path {
+			continue
+		}
+
+		var hunk []string
+		current := 0
+		found := false
+		for _, text := range strings.Split(section.content, "\n") {
+			if match := hunkHeader.FindStringSubmatch(text); match != nil {
+				if found {
+					break
+				}
+				current, _ = strconv.Atoi(match[start])
+				hunk = []string{text}
+				continue
+			}
+			if hunk == nil || text == "" {
+				continue
+			}
+
+			hunk = append(hunk, text)
+			if text[0] == ' ' || (text[0] == '-' && side == "LEFT") || (text[0] == '+' && side != "LEFT") {
+				found = found || current == line
+				current++
+			}
+		}
+		if found {
+			return strings.Join(hunk, "\n")
+		}
+	}
+	return ""
+}
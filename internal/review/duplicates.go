@@ -0,0 +1,434 @@
+package review
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+)
+
+// Thresholds of duplicate code detection
+const (
+	duplicateMinLines   = 6   // Non-blank lines an added block needs to be checked
+	duplicateMinTokens  = 50  // Tokens an added block needs to be checked
+	duplicateSimilarity = 0.8 // Share of a block's fingerprints found in one place to count as a duplicate
+	fingerprintK        = 15  // Tokens per fingerprinted k-gram
+	fingerprintWindow   = 8   // k-grams per winnowing window
+)
+
+// duplicateCategory is the comment category of duplicate code findings
+var duplicateCategory = config.CommentCategory{Name: "duplicate", Emoji: "♻️"}
+
+// Files left out of duplicate detection on both sides: tests, where repetition is often
+// deliberate, and vendored, generated or minified code
+var duplicateExcluded = regexp.MustCompile(`(^|/)(vendor|node_modules|third_party|testdata|tests?|__tests__|dist|build)/|_test\.go$|\.(test|spec)\.[jt]sx?$|(^|/)test_[^/]*\.py$|_test\.py$|_spec\.rb$|\.pb\.go$|_gen\.go$|\.min\.js$`)
+
+// Duplicate is a block of added code that closely duplicates code on the base branch
+type Duplicate struct {
+	Path         string  // File of the added block
+	Line         int     // First line of the added block
+	EndLine      int     // Last line of the added block
+	OriginalPath string  // File of the existing code
+	OriginalLine int     // First line of the existing code
+	OriginalEnd  int     // Last line of the existing code
+	Similarity   float64 // Share of the block's fingerprints found in the existing code
+}
+
+// codeLocation is a span of lines of a file on the base branch
+type codeLocation struct {
+	path      string
+	line, end int
+}
+
+// DuplicateIndex holds fingerprints of the source files on a pull request's base branch.
+// Code is compared by its tokens with literals and local names normalized, so duplicates
+// are found despite changed formatting, comments, literals and renamed variables.
+type DuplicateIndex struct {
+	fingerprints map[uint64][]codeLocation
+	removed      map[string]map[int]bool // Lines of base files the pull request removes
+}
+
+// addedBlock is a run of added lines in a file's diff
+type addedBlock struct {
+	path  string
+	lines []int    // New line numbers of the block's lines
+	code  []string // The lines' code, without the leading "+"
+}
+
+// DuplicateCandidates returns the extensions of files the added code of a diff may
+// duplicate - those of files with blocks large enough to check - or nil if there are none
+func DuplicateCandidates(diff string) map[string]bool {
+	var extensions map[string]bool
+	for _, block := range addedBlocks(diff) {
+		if extensions == nil {
+			extensions = make(map[string]bool)
+		}
+		extensions[strings.ToLower(path.Ext(block.path))] = true
+	}
+	return extensions
+}
+
+// IsDuplicateSource reports whether a base branch file with one of the extensions is
+// indexed for duplicate detection
+func IsDuplicateSource(filename string, extensions map[string]bool) bool {
+	return extensions[strings.ToLower(path.Ext(filename))] && !duplicateExcluded.MatchString(filename)
+}
+
+// NewDuplicateIndex fingerprints the source files of a base branch, keyed by path. Lines
+// the diff removes are left out of matches, so code moved by the pull request isn't
+// reported as duplicated.
+func NewDuplicateIndex(files map[string]string, diff string) *DuplicateIndex {
+	index := &DuplicateIndex{
+		fingerprints: make(map[uint64][]codeLocation),
+		removed:      removedLines(diff),
+	}
+	for filename, content := range files {
+		for _, fp := range winnow(tokenize(filename, strings.Split(content, "\n"), nil)) {
+			index.fingerprints[fp.hash] = append(index.fingerprints[fp.hash], codeLocation{path: filename, line: fp.line, end: fp.end})
+		}
+	}
+	return index
+}
+
+// FindDuplicates returns the blocks of added code in a diff that closely duplicate code
+// on the base branch, each matched to the place it shares the most fingerprints with
+func (index *DuplicateIndex) FindDuplicates(diff string) []Duplicate {
+	var duplicates []Duplicate
+	for _, block := range addedBlocks(diff) {
+		blockPrints := winnow(tokenize(block.path, block.code, block.lines))
+		if len(blockPrints) == 0 {
+			continue
+		}
+
+		// Fingerprints each base file shares with the block, and the lines they span
+		matched := make(map[string]int)
+		spans := make(map[string][]int)
+		for _, hash := range distinctHashes(blockPrints) {
+			seen := make(map[string]bool)
+			for _, location := range index.fingerprints[hash] {
+				if index.removed[location.path][location.line] {
+					continue
+				}
+				if !seen[location.path] {
+					seen[location.path] = true
+					matched[location.path]++
+				}
+				spans[location.path] = append(spans[location.path], location.line, location.end)
+			}
+		}
+
+		best, bestCount := "", 0
+		for filename, count := range matched {
+			if count > bestCount || (count == bestCount && filename < best) {
+				best, bestCount = filename, count
+			}
+		}
+		similarity := float64(bestCount) / float64(len(distinctHashes(blockPrints)))
+		if best == "" || similarity < duplicateSimilarity {
+			continue
+		}
+
+		// Fingerprints rarely cover a block's first and last lines, so the span of the
+		// existing code is widened by as many lines as the block's fingerprints leave out
+		first, last := block.lines[0], block.lines[len(block.lines)-1]
+		start, end := densestRange(spans[best], len(block.lines)*2)
+		duplicates = append(duplicates, Duplicate{
+			Path:         block.path,
+			Line:         first,
+			EndLine:      last,
+			OriginalPath: best,
+			OriginalLine: max(start-(blockPrints[0].line-first), 1),
+			OriginalEnd:  end + (last - blockPrints[len(blockPrints)-1].end),
+			Similarity:   similarity,
+		})
+	}
+	return duplicates
+}
+
+// DuplicateComments renders duplicates as line comments on the first line of each block
+func DuplicateComments(duplicates []Duplicate, baseRef, language string) []ReviewComment {
+	var comments []ReviewComment
+	for _, duplicate := range duplicates {
+		text := notices.Render(language, "duplicate_code", notices.Data{
+			"Lines":      duplicate.EndLine - duplicate.Line + 1,
+			"Original":   fmt.Sprintf("%s:%d-%d", duplicate.OriginalPath, duplicate.OriginalLine, duplicate.OriginalEnd),
+			"Base":       baseRef,
+			"Similarity": int(duplicate.Similarity * 100),
+		})
+		comments = append(comments, ReviewComment{
+			Path:     duplicate.Path,
+			Line:     duplicate.Line,
+			Side:     "RIGHT",
+			Body:     fmt.Sprintf("%s\n\n%s", duplicateCategory.Prefix(), text),
+			Category: duplicateCategory.Name,
+		})
+	}
+	return comments
+}
+
+// addedBlocks returns the runs of added lines in the source files of a diff that are large
+// enough to check for duplicates. Blank added lines don't end a run.
+func addedBlocks(diff string) []addedBlock {
+	var blocks []addedBlock
+	for _, section := range splitDiffSections(diff) {
+		if !sourceExtensions[strings.ToLower(path.Ext(section.filename))] || duplicateExcluded.MatchString(section.filename) {
+			continue
+		}
+
+		var block addedBlock
+		flush := func() {
+			if countNonBlank(block.code) >= duplicateMinLines && len(tokenize(block.path, block.code, block.lines)) >= duplicateMinTokens {
+				blocks = append(blocks, block)
+			}
+			block = addedBlock{path: section.filename}
+		}
+		flush()
+
+		current := 0
+		for _, line := range strings.Split(section.content, "\n") {
+			if match := hunkHeader.FindStringSubmatch(line); match != nil {
+				flush()
+				current, _ = strconv.Atoi(match[2])
+				continue
+			}
+			if current == 0 || line == "" {
+				continue
+			}
+			switch line[0] {
+			case '+':
+				block.lines = append(block.lines, current)
+				block.code = append(block.code, line[1:])
+				current++
+			case ' ':
+				flush()
+				current++
+			}
+		}
+		flush()
+	}
+	return blocks
+}
+
+// removedLines returns the old line numbers of the lines a diff removes, by file
+func removedLines(diff string) map[string]map[int]bool {
+	removed := make(map[string]map[int]bool)
+	for _, section := range splitDiffSections(diff) {
+		lines := make(map[int]bool)
+		current := 0
+		for _, line := range strings.Split(section.content, "\n") {
+			if match := oldHunkHeader.FindStringSubmatch(line); match != nil {
+				current, _ = strconv.Atoi(match[1])
+				continue
+			}
+			if current == 0 || line == "" {
+				continue
+			}
+			switch line[0] {
+			case '-':
+				lines[current] = true
+				current++
+			case ' ':
+				current++
+			}
+		}
+		removed[section.filename] = lines
+	}
+	return removed
+}
+
+// oldHunkHeader matches a hunk's header, capturing the old start line
+var oldHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// keywords are kept by the tokenizer while other names outside of calls and selectors are
+// normalized; common keywords of the languages in sourceExtensions
+var keywords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "foreach": true, "while": true, "do": true,
+	"switch": true, "case": true, "default": true, "break": true, "continue": true, "return": true,
+	"func": true, "function": true, "def": true, "fn": true, "class": true, "struct": true,
+	"interface": true, "type": true, "var": true, "let": true, "const": true, "val": true,
+	"new": true, "range": true, "in": true, "of": true, "try": true, "catch": true, "except": true,
+	"finally": true, "throw": true, "raise": true, "defer": true, "go": true, "async": true,
+	"await": true, "yield": true, "import": true, "package": true, "nil": true, "null": true,
+	"None": true, "true": true, "false": true, "True": true, "False": true, "this": true,
+	"self": true, "and": true, "or": true, "not": true, "is": true, "end": true, "match": true,
+}
+
+// token is a normalized token of code and the line it is on
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits lines of code into tokens, dropping comments and whitespace and
+// normalizing string and number literals and local names - those that aren't keywords,
+// called or selected with a dot - to a placeholder. lines holds the line numbers of the code; nil
+// numbers them from 1.
+func tokenize(filename string, code []string, lines []int) []token {
+	hashComments := false
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".py", ".rb":
+		hashComments = true
+	}
+
+	var tokens []token
+	inBlockComment := false
+	for i, text := range code {
+		line := i + 1
+		if lines != nil {
+			line = lines[i]
+		}
+
+		for pos := 0; pos < len(text); {
+			c := text[pos]
+			rest := text[pos:]
+			switch {
+			case inBlockComment:
+				end := strings.Index(rest, "*/")
+				if end == -1 {
+					pos = len(text)
+					continue
+				}
+				inBlockComment = false
+				pos += end + 2
+			case strings.HasPrefix(rest, "/*"):
+				inBlockComment = true
+				pos += 2
+			case strings.HasPrefix(rest, "//"), hashComments && c == '#':
+				pos = len(text)
+			case c == ' ' || c == '\t' || c == '\r':
+				pos++
+			case c == '"' || c == '\'' || c == '`':
+				pos += literalLength(rest)
+				tokens = append(tokens, token{text: `"`, line: line})
+			case isIdentifierByte(c):
+				end := pos
+				for end < len(text) && isIdentifierByte(text[end]) {
+					end++
+				}
+				word := text[pos:end]
+				selected := len(tokens) > 0 && tokens[len(tokens)-1].text == "."
+				called := strings.HasPrefix(strings.TrimLeft(text[end:], " \t"), "(")
+				switch {
+				case c >= '0' && c <= '9':
+					word = "0"
+				case !keywords[word] && !selected && !called:
+					word = "_"
+				}
+				tokens = append(tokens, token{text: word, line: line})
+				pos = end
+			default:
+				tokens = append(tokens, token{text: text[pos : pos+1], line: line})
+				pos++
+			}
+		}
+	}
+	return tokens
+}
+
+// literalLength returns the length of the string literal at the start of text, up to the
+// end of the line if it isn't closed on it
+func literalLength(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// isIdentifierByte reports whether a byte can be part of an identifier or number
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// fingerprint is the hash of a k-gram of tokens and the lines it starts and ends on
+type fingerprint struct {
+	hash      uint64
+	line, end int
+}
+
+// winnow selects the fingerprints of code by winnowing: the smallest k-gram hash of every
+// window of k-grams. Any run of shared tokens at least fingerprintK+fingerprintWindow-1 long
+// is then guaranteed to share a fingerprint, at a fraction of the hashes.
+func winnow(tokens []token) []fingerprint {
+	if len(tokens) < fingerprintK {
+		return nil
+	}
+	grams := make([]fingerprint, 0, len(tokens)-fingerprintK+1)
+	for i := 0; i+fingerprintK <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+fingerprintK] {
+			h.Write([]byte(t.text))
+			h.Write([]byte{0})
+		}
+		grams = append(grams, fingerprint{hash: h.Sum64(), line: tokens[i].line, end: tokens[i+fingerprintK-1].line})
+	}
+
+	var selected []fingerprint
+	window := min(fingerprintWindow, len(grams))
+	last := -1
+	for start := 0; start+window <= len(grams); start++ {
+		smallest := start
+		for i := start; i < start+window; i++ {
+			if grams[i].hash <= grams[smallest].hash {
+				smallest = i
+			}
+		}
+		if smallest != last {
+			selected = append(selected, grams[smallest])
+			last = smallest
+		}
+	}
+	return selected
+}
+
+// distinctHashes returns the distinct hashes of fingerprints
+func distinctHashes(prints []fingerprint) []uint64 {
+	seen := make(map[uint64]bool)
+	var hashes []uint64
+	for _, fp := range prints {
+		if !seen[fp.hash] {
+			seen[fp.hash] = true
+			hashes = append(hashes, fp.hash)
+		}
+	}
+	return hashes
+}
+
+// densestRange returns the first and last line of the span of at most span lines that
+// holds the most of the given lines
+func densestRange(lines []int, span int) (int, int) {
+	sort.Ints(lines)
+	bestStart, bestEnd, bestCount := 0, 0, 0
+	end := 0
+	for start := range lines {
+		for end < len(lines) && lines[end]-lines[start] <= span {
+			end++
+		}
+		if end-start > bestCount {
+			bestStart, bestEnd, bestCount = start, end-1, end-start
+		}
+	}
+	return lines[bestStart], lines[bestEnd]
+}
+
+// countNonBlank returns the number of lines with more than whitespace
+func countNonBlank(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
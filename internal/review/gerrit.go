@@ -0,0 +1,363 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// gerritRequestTimeout bounds a single Gerrit API request
+const gerritRequestTimeout = 30 * time.Second
+
+// gerritJSONPrefix guards Gerrit's JSON responses against being run as scripts
+const gerritJSONPrefix = ")]}'"
+
+// GerritClient handles the Gerrit REST API operations of change reviews. Repositories are
+// Gerrit projects, e.g. "platform/api"; the owner is the organization the client is for.
+type GerritClient struct {
+	baseURL    string // e.g. "https://gerrit.example.com", without the /a/ prefix
+	username   string
+	token      string // HTTP password of the account
+	voteLabel  string // Label reviews vote on, e.g. "Code-Review"; no votes if empty
+	httpClient *http.Client
+}
+
+// GerritClient is the SCMProvider of organizations on Gerrit
+var _ SCMProvider = (*GerritClient)(nil)
+
+// gerritChange is the part of a Gerrit change reviews need, with its current patch set
+type gerritChange struct {
+	Project         string   `json:"project"`
+	Number          int      `json:"_number"`
+	Subject         string   `json:"subject"`
+	WorkInProgress  bool     `json:"work_in_progress"`
+	Hashtags        []string `json:"hashtags"`
+	CurrentRevision string   `json:"current_revision"`
+	Revisions       map[string]struct {
+		Number int `json:"_number"`
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	} `json:"revisions"`
+}
+
+// description returns the commit message of the current patch set without its subject line
+func (c *gerritChange) description() string {
+	_, body, _ := strings.Cut(c.Revisions[c.CurrentRevision].Commit.Message, "\n")
+	return strings.TrimSpace(body)
+}
+
+// GerritAPIError is returned for unsuccessful Gerrit API responses
+type GerritAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *GerritAPIError) Error() string {
+	return fmt.Sprintf("Gerrit API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// NewGerritClient creates a Gerrit client authenticating with an account's HTTP password.
+// Reviews vote on voteLabel unless it's empty.
+func NewGerritClient(baseURL, username, token, voteLabel string) *GerritClient {
+	return &GerritClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		token:      token,
+		voteLabel:  voteLabel,
+		httpClient: &http.Client{Timeout: gerritRequestTimeout},
+	}
+}
+
+// GetPullRequest fetches a change with its current patch set. Its hashtags are its labels.
+func (g *GerritClient) GetPullRequest(ctx context.Context, owner, project string, number int) (*PullRequest, error) {
+	change, err := g.getChange(ctx, project, number)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PullRequest{
+		Number: change.Number,
+		Title:  change.Subject,
+		Body:   change.description(),
+		Draft:  change.WorkInProgress,
+	}
+	for _, hashtag := range change.Hashtags {
+		pr.Labels = append(pr.Labels, Label{Name: hashtag})
+	}
+	pr.Head.SHA = change.CurrentRevision
+	return pr, nil
+}
+
+// getChange fetches a change of a project with its current patch set
+func (g *GerritClient) getChange(ctx context.Context, project string, number int) (*gerritChange, error) {
+	var change gerritChange
+	if err := g.doJSON(ctx, http.MethodGet, changePath(project, number)+"?o=CURRENT_REVISION&o=CURRENT_COMMIT", nil, &change); err != nil {
+		return nil, fmt.Errorf("failed to get change %d: %w", number, err)
+	}
+	return &change, nil
+}
+
+// ListFiles fetches the files the current patch set of a change changes compared to its parent
+func (g *GerritClient) ListFiles(ctx context.Context, owner, project string, number int) ([]ChangedFile, error) {
+	data, err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/revisions/current/patch", changePath(project, number)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patch of change %d: %w", number, err)
+	}
+	patch, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode patch of change %d: %w", number, err)
+	}
+	return splitPatch(string(patch)), nil
+}
+
+// splitPatch splits a git patch into the hunks of each file
+func splitPatch(patch string) []ChangedFile {
+	var files []ChangedFile
+	var current *ChangedFile
+	var hunks []string
+	flush := func() {
+		if current != nil {
+			current.Patch = strings.Join(hunks, "\n")
+			current.Additions, current.Deletions = countDiffLines(current.Patch)
+			files = append(files, *current)
+		}
+		hunks = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			// Ambiguous for paths with " b/" - replaced by the "+++" or "rename to" line, if any
+			_, path, _ := strings.Cut(line, " b/")
+			current = &ChangedFile{Filename: path, Status: "modified"}
+		case current == nil:
+			// Commit message header
+		case len(hunks) > 0 || strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, line)
+		case strings.HasPrefix(line, "new file mode "):
+			current.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode "):
+			current.Status = "removed"
+		case strings.HasPrefix(line, "+++ b/"):
+			current.Filename = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "rename from "):
+			current.Status = "renamed"
+			current.PreviousFilename = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.Filename = strings.TrimPrefix(line, "rename to ")
+		}
+	}
+	flush()
+
+	// The patch ends with the git version after the signature separator
+	if n := len(files); n > 0 {
+		last := &files[n-1]
+		if i := strings.LastIndex(last.Patch, "\n-- \n"); i >= 0 {
+			last.Patch = last.Patch[:i]
+			last.Additions, last.Deletions = countDiffLines(last.Patch)
+		}
+	}
+	return files
+}
+
+// GetDiff fetches the diff of a change's current patch set, leaving out files matching any of
+// the ignore patterns
+func (g *GerritClient) GetDiff(ctx context.Context, owner, project string, number int, ignorePaths []string) (string, error) {
+	files, err := g.ListFiles(ctx, owner, project, number)
+	if err != nil {
+		return "", err
+	}
+	return FormatDiff(files, fmt.Sprintf("change %d", number), ignorePaths), nil
+}
+
+// GetFileContent fetches a file from the project's HEAD branch, returning nil if it doesn't exist
+func (g *GerritClient) GetFileContent(ctx context.Context, owner, project, path string) ([]byte, error) {
+	data, err := g.do(ctx, http.MethodGet, fmt.Sprintf("projects/%s/branches/HEAD/files/%s/content", url.PathEscape(project), url.PathEscape(path)), nil)
+	var apiErr *GerritAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// PostComment posts a change message on the current patch set
+func (g *GerritClient) PostComment(ctx context.Context, owner, project string, number int, body string) error {
+	return g.postReview(ctx, project, number, "current", map[string]interface{}{"message": body})
+}
+
+// ListLabels fetches the hashtags of a change, which stand in for labels
+func (g *GerritClient) ListLabels(ctx context.Context, owner, project string, number int) ([]string, error) {
+	var hashtags []string
+	if err := g.doJSON(ctx, http.MethodGet, changePath(project, number)+"/hashtags", nil, &hashtags); err != nil {
+		return nil, fmt.Errorf("failed to get hashtags of change %d: %w", number, err)
+	}
+	return hashtags, nil
+}
+
+// AddLabels adds hashtags to a change
+func (g *GerritClient) AddLabels(ctx context.Context, owner, project string, number int, labels []string) error {
+	if err := g.doJSON(ctx, http.MethodPost, changePath(project, number)+"/hashtags", map[string][]string{"add": labels}, nil); err != nil {
+		return fmt.Errorf("failed to add hashtags to change %d: %w", number, err)
+	}
+	return nil
+}
+
+// PostReview posts a review on the patch set it was made for: the summary as the change
+// message, each line comment as an unresolved inline comment and the review's vote, see
+// votes. If Gerrit rejects the inline comments, e.g. for lines a file doesn't have, they are
+// added to the message instead. Gerrit reviews have no ID of their own, so it returns 0.
+func (g *GerritClient) PostReview(ctx context.Context, owner, project string, number int, review ReviewResult) (int64, error) {
+	revision := review.HeadSHA
+	if revision == "" {
+		revision = "current"
+	}
+
+	message := review.Summary
+	comments := make(map[string][]map[string]interface{})
+	var unplaced []ReviewComment
+	for _, comment := range review.Comments {
+		if comment.Path == "" || comment.Line <= 0 {
+			unplaced = append(unplaced, comment)
+			continue
+		}
+		inline := map[string]interface{}{
+			"line":       comment.Line,
+			"message":    comment.Body,
+			"unresolved": true,
+		}
+		if comment.Side == "LEFT" {
+			inline["side"] = "PARENT"
+		}
+		comments[comment.Path] = append(comments[comment.Path], inline)
+	}
+
+	input := map[string]interface{}{
+		"message":  message + unplacedComments(unplaced),
+		"tag":      "autogenerated:cyclone",
+		"comments": comments,
+	}
+	if labels := g.votes(review); len(labels) > 0 {
+		input["labels"] = labels
+	}
+	err := g.postReview(ctx, project, number, revision, input)
+	var apiErr *GerritAPIError
+	if err == nil || len(comments) == 0 || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return 0, err
+	}
+
+	log.Printf("Error posting inline comments on change %d - adding them to the message: %v", number, err)
+	delete(input, "comments")
+	input["message"] = message + unplacedComments(review.Comments)
+	return 0, g.postReview(ctx, project, number, revision, input)
+}
+
+// votes returns the votes a review casts on the vote label: -1 with blocking comments and +1
+// for full reviews without any comments. Failed reviews don't vote.
+func (g *GerritClient) votes(review ReviewResult) map[string]int {
+	if g.voteLabel == "" || review.Err != nil {
+		return nil
+	}
+	for _, comment := range review.Comments {
+		if strings.EqualFold(comment.Category, "blocking") {
+			return map[string]int{g.voteLabel: -1}
+		}
+	}
+	if len(review.Comments) == 0 && !review.SummaryOnly {
+		return map[string]int{g.voteLabel: 1}
+	}
+	return nil
+}
+
+// unplacedComments renders line comments that aren't posted inline for the review message
+func unplacedComments(comments []ReviewComment) string {
+	var text strings.Builder
+	for _, comment := range comments {
+		fmt.Fprintf(&text, "\n\n%s:%d\n%s", comment.Path, comment.Line, comment.Body)
+	}
+	return text.String()
+}
+
+// postReview sets a review on a patch set
+func (g *GerritClient) postReview(ctx context.Context, project string, number int, revision string, input map[string]interface{}) error {
+	if err := g.doJSON(ctx, http.MethodPost, fmt.Sprintf("%s/revisions/%s/review", changePath(project, number), revision), input, nil); err != nil {
+		return fmt.Errorf("failed to post review on change %d: %w", number, err)
+	}
+	return nil
+}
+
+// changePath is the API path of a change, identified by project and number
+func changePath(project string, number int) string {
+	return fmt.Sprintf("changes/%s~%d", url.PathEscape(project), number)
+}
+
+// doJSON sends an API request with a JSON body, if not nil, and decodes the JSON response into
+// result, if not nil
+func (g *GerritClient) doJSON(ctx context.Context, method, path string, body, result interface{}) error {
+	data, err := g.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	data = bytes.TrimPrefix(data, []byte(gerritJSONPrefix))
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode Gerrit response: %w", err)
+	}
+	return nil
+}
+
+// do sends an authenticated API request with a JSON body, if not nil, and returns the response body
+func (g *GerritClient) do(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+"/a/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(g.username, g.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &GerritAPIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	return io.ReadAll(resp.Body)
+}
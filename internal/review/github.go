@@ -1,9 +1,21 @@
 package review
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"cyclone/internal/config"
 
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
@@ -12,52 +24,462 @@ import (
 // GitHubClient handles all GitHub API operations
 type GitHubClient struct {
 	client *github.Client
+	token  *credential // nil for GitHub App clients, which renew their own tokens
 }
 
 // NewGitHubClient creates a new GitHub client with the provided token
 func NewGitHubClient(token string) (*GitHubClient, error) {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	// The token source isn't wrapped in oauth2.ReuseTokenSource, so a rotated
+	// token is used from the next request on
+	cred := newCredential(token)
+	tc := &http.Client{
+		Transport: &oauth2.Transport{Source: cred},
+	}
 
 	return &GitHubClient{
 		client: github.NewClient(tc),
+		token:  cred,
 	}, nil
 }
 
-// GetPRDiff fetches the diff for a pull request
-func (g *GitHubClient) GetPRDiff(ctx context.Context, owner, repo string, prNumber int) (string, error) {
-	// Get the PR files
-	files, _, err := g.client.PullRequests.ListFiles(ctx, owner, repo, prNumber, nil)
+// RotateToken replaces the token used for subsequent requests
+func (g *GitHubClient) RotateToken(token string) {
+	if g.token != nil {
+		g.token.set(token)
+	}
+}
+
+// GitHubClient is the SCMProvider of organizations on GitHub
+var _ SCMProvider = (*GitHubClient)(nil)
+
+// ListFiles fetches the files a pull request changes
+func (g *GitHubClient) ListFiles(ctx context.Context, owner, repo string, prNumber int) ([]ChangedFile, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var files []ChangedFile
+	for {
+		page, resp, err := g.client.PullRequests.ListFiles(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PR files: %w", err)
+		}
+		for _, file := range page {
+			files = append(files, newChangedFile(file))
+		}
+
+		if resp.NextPage == 0 {
+			return files, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetDiff fetches the diff for a pull request, leaving out files matching any of the ignore patterns
+func (g *GitHubClient) GetDiff(ctx context.Context, owner, repo string, prNumber int, ignorePaths []string) (string, error) {
+	files, err := g.ListFiles(ctx, owner, repo, prNumber)
+	if err != nil {
+		return "", err
+	}
+	return FormatDiff(files, fmt.Sprintf("PR #%d", prNumber), ignorePaths), nil
+}
+
+// GetFileContent fetches a file from the repository's default branch, returning nil if it doesn't exist
+func (g *GitHubClient) GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error) {
+	return g.GetFileContentAt(ctx, owner, repo, path, "")
+}
+
+// GetFileContentAt fetches a file at a commit, branch or tag, the default branch if ref is
+// empty, returning nil if it doesn't exist
+func (g *GitHubClient) GetFileContentAt(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	file, _, resp, err := g.client.Repositories.GetContents(ctx, owner, repo, path, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return []byte(content), nil
+}
+
+// LastCommitAuthor returns the login of who last changed a file on the default branch, or the
+// commit author's name if the commit isn't linked to a GitHub account
+func (g *GitHubClient) LastCommitAuthor(ctx context.Context, owner, repo, path string) (string, error) {
+	commits, _, err := g.client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		Path:        path,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list commits of %s: %w", path, err)
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits of %s", path)
+	}
+	if login := commits[0].GetAuthor().GetLogin(); login != "" {
+		return login, nil
+	}
+	return commits[0].GetCommit().GetAuthor().GetName(), nil
+}
+
+// RecentCommitAuthors returns the logins of who changed a file on a branch since a time, once
+// each, most recent first. Commits not linked to a GitHub account and those of apps are left out.
+func (g *GitHubClient) RecentCommitAuthors(ctx context.Context, owner, repo, ref, path string, since time.Time, limit int) ([]string, error) {
+	commits, _, err := g.client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		SHA:         ref,
+		Path:        path,
+		Since:       since,
+		ListOptions: github.ListOptions{PerPage: limit},
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get PR files: %w", err)
+		return nil, fmt.Errorf("failed to list commits of %s: %w", path, err)
 	}
 
-	var diffBuilder strings.Builder
-	for _, file := range files {
-		// Skip binary files and very large files
-		if file.GetPatch() == "" || file.GetChanges() > 500 {
+	seen := make(map[string]bool)
+	var authors []string
+	for _, commit := range commits {
+		login := commit.GetAuthor().GetLogin()
+		if login == "" || commit.GetAuthor().GetType() == "Bot" || seen[login] {
 			continue
 		}
+		seen[login] = true
+		authors = append(authors, login)
+	}
+	return authors, nil
+}
+
+// Ping checks that the API is reachable and accepts the token. Rate limit requests don't count
+// against the rate limit.
+func (g *GitHubClient) Ping(ctx context.Context) error {
+	if _, _, err := g.client.RateLimit.Get(ctx); err != nil {
+		return fmt.Errorf("GitHub API unavailable: %w", err)
+	}
+	return nil
+}
+
+// GetPullRequest fetches a single pull request
+func (g *GitHubClient) GetPullRequest(ctx context.Context, owner, repo string, prNumber int) (*PullRequest, error) {
+	pr, _, err := g.client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
+	}
+
+	return newPullRequest(pr), nil
+}
+
+// AuthenticatedUser returns the login of the user the client's token belongs to
+func (g *GitHubClient) AuthenticatedUser(ctx context.Context) (string, error) {
+	user, _, err := g.client.Users.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return user.GetLogin(), nil
+}
+
+// IsOrgMember reports whether the authenticated user is an active member of an organization
+func (g *GitHubClient) IsOrgMember(ctx context.Context, org string) (bool, error) {
+	membership, resp, err := g.client.Organizations.GetOrgMembership(ctx, "", org)
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get membership in %s: %w", org, err)
+	}
+
+	return membership.GetState() == "active", nil
+}
+
+// IsTeamMember reports whether a user is an active member of a team, identified by its slug
+func (g *GitHubClient) IsTeamMember(ctx context.Context, org, teamSlug, user string) (bool, error) {
+	membership, resp, err := g.client.Teams.GetTeamMembershipBySlug(ctx, org, teamSlug, user)
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get membership in %s/%s: %w", org, teamSlug, err)
+	}
+
+	return membership.GetState() == "active", nil
+}
+
+// CompareCommits compares two commits. The changed files come with their patches, except for
+// large files, and at most 300 files are listed.
+func (g *GitHubClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*Comparison, error) {
+	comparison, _, err := g.client.Repositories.CompareCommits(ctx, owner, repo, base, head, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+	}
+
+	result := &Comparison{Status: comparison.GetStatus(), MergeBaseSHA: comparison.GetMergeBaseCommit().GetSHA()}
+	for _, file := range comparison.Files {
+		result.Files = append(result.Files, newChangedFile(file))
+	}
+	return result, nil
+}
+
+// ConflictingFiles returns the files both a PR and its base branch changed since they diverged,
+// sorted. GitHub doesn't tell which files conflict, so these are the likely ones.
+func (g *GitHubClient) ConflictingFiles(ctx context.Context, owner, repo string, pr *PullRequest) ([]string, error) {
+	head, err := g.CompareCommits(ctx, owner, repo, pr.Base.Ref, pr.Head.SHA)
+	if err != nil {
+		return nil, err
+	}
+	base, err := g.CompareCommits(ctx, owner, repo, head.MergeBaseSHA, pr.Base.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	changedOnBase := make(map[string]bool, len(base.Files))
+	for _, file := range base.Files {
+		changedOnBase[file.Filename] = true
+	}
+	var files []string
+	for _, file := range head.Files {
+		if changedOnBase[file.Filename] {
+			files = append(files, file.Filename)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ListPullRequests lists a repository's pull requests in the given state ("open", "closed" or
+// "all") created at or after since, newest first
+func (g *GitHubClient) ListPullRequests(ctx context.Context, owner, repo, state string, since time.Time) ([]*PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State:       state,
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var prs []*PullRequest
+	for {
+		page, resp, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PRs: %w", err)
+		}
+
+		for _, pr := range page {
+			if pr.GetCreatedAt().Before(since) {
+				return prs, nil
+			}
+			prs = append(prs, newPullRequest(pr))
+		}
+
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListMergedPullRequests lists the pull requests merged into a base branch after since and up to
+// until, most recently updated first
+func (g *GitHubClient) ListMergedPullRequests(ctx context.Context, owner, repo, base string, since, until time.Time) ([]*PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State:       "closed",
+		Base:        base,
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var prs []*PullRequest
+	for {
+		page, resp, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PRs: %w", err)
+		}
+
+		for _, pr := range page {
+			// A PR is updated when it is merged, so the rest were merged before since
+			if !pr.GetUpdatedAt().After(since) {
+				return prs, nil
+			}
+			if mergedAt := pr.GetMergedAt().Time; mergedAt.After(since) && !mergedAt.After(until) {
+				prs = append(prs, newPullRequest(pr))
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListMilestonePullRequests lists the open and closed pull requests of a milestone
+func (g *GitHubClient) ListMilestonePullRequests(ctx context.Context, owner, repo string, milestone int) ([]*Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(milestone),
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var prs []*Issue
+	for {
+		page, resp, err := g.client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues of milestone %d: %w", milestone, err)
+		}
+
+		for _, issue := range page {
+			if issue.IsPullRequest() {
+				prs = append(prs, newIssue(issue))
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListIssues fetches the issues of a repository opened since the given time, newest first,
+// leaving out pull requests
+func (g *GitHubClient) ListIssues(ctx context.Context, owner, repo string, since time.Time) ([]*Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "all",
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var issues []*Issue
+	for {
+		page, resp, err := g.client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues: %w", err)
+		}
+
+		for _, issue := range page {
+			if issue.GetCreatedAt().Time.Before(since) {
+				return issues, nil
+			}
+			if !issue.IsPullRequest() {
+				issues = append(issues, newIssue(issue))
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return issues, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CreateIssue opens an issue and returns its URL
+func (g *GitHubClient) CreateIssue(ctx context.Context, owner, repo, title, body string) (string, error) {
+	issue, _, err := g.client.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open issue in %s/%s: %w", owner, repo, err)
+	}
+	return issue.GetHTMLURL(), nil
+}
+
+// ListLabels fetches the names of the labels of an issue or pull request
+func (g *GitHubClient) ListLabels(ctx context.Context, owner, repo string, number int) ([]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var names []string
+	for {
+		labels, resp, err := g.client.Issues.ListLabelsByIssue(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list labels of #%d: %w", number, err)
+		}
+		for _, label := range labels {
+			names = append(names, label.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// AddLabels adds labels to an issue or pull request, creating labels the repository doesn't have yet
+func (g *GitHubClient) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	if _, _, err := g.client.Issues.AddLabelsToIssue(ctx, owner, repo, number, labels); err != nil {
+		return fmt.Errorf("failed to add labels to #%d: %w", number, err)
+	}
+	return nil
+}
 
-		// Additional check for binary files by file extension
-		filename := file.GetFilename()
-		if isBinaryFile(filename) {
+// PreviousRelease returns the latest full release published before a time, nil if there is none
+func (g *GitHubClient) PreviousRelease(ctx context.Context, owner, repo string, before time.Time) (*Release, error) {
+	releases, _, err := g.client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var previous *github.RepositoryRelease
+	for _, release := range releases {
+		publishedAt := release.GetPublishedAt().Time
+		if release.GetDraft() || release.GetPrerelease() || !publishedAt.Before(before) {
 			continue
 		}
+		if previous == nil || publishedAt.After(previous.GetPublishedAt().Time) {
+			previous = release
+		}
+	}
+	if previous == nil {
+		return nil, nil
+	}
+	return newRelease(previous), nil
+}
 
-		diffBuilder.WriteString(fmt.Sprintf("=== %s ===\n", filename))
-		diffBuilder.WriteString(file.GetPatch())
-		diffBuilder.WriteString("\n\n")
+// GetReleaseByTag fetches the release of a tag, nil if the tag has none
+func (g *GitHubClient) GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*Release, error) {
+	release, _, err := g.client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	if githubErrorStatus(err) == http.StatusNotFound {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release of %s: %w", tag, err)
+	}
+	return newRelease(release), nil
+}
+
+// CreateDraftRelease creates a draft release of an existing tag
+func (g *GitHubClient) CreateDraftRelease(ctx context.Context, owner, repo, tag, body string) (*Release, error) {
+	release, _, err := g.client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+		TagName: github.String(tag),
+		Name:    github.String(tag),
+		Body:    github.String(body),
+		Draft:   github.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release of %s: %w", tag, err)
+	}
+	return newRelease(release), nil
+}
 
-	return diffBuilder.String(), nil
+// UpdateReleaseBody replaces the release notes of a release
+func (g *GitHubClient) UpdateReleaseBody(ctx context.Context, owner, repo string, id int64, body string) error {
+	if _, _, err := g.client.Repositories.EditRelease(ctx, owner, repo, id, &github.RepositoryRelease{Body: github.String(body)}); err != nil {
+		return fmt.Errorf("failed to update release %d: %w", id, err)
+	}
+	return nil
 }
 
-// PostReview posts a complete PR review with line-specific comments
-func (g *GitHubClient) PostReview(ctx context.Context, owner, repo string, prNumber int, review ReviewResult) error {
+// PostReview posts a complete PR review with line-specific comments and returns the review ID
+func (g *GitHubClient) PostReview(ctx context.Context, owner, repo string, prNumber int, review ReviewResult) (int64, error) {
 	// Prepare review comments for line-specific feedback
 	var reviewComments []*github.DraftReviewComment
 
@@ -77,11 +499,23 @@ func (g *GitHubClient) PostReview(ctx context.Context, owner, repo string, prNum
 		Comments: reviewComments,
 	}
 
-	_, _, err := g.client.PullRequests.CreateReview(ctx, owner, repo, prNumber, reviewRequest)
+	created, _, err := g.client.PullRequests.CreateReview(ctx, owner, repo, prNumber, reviewRequest)
 	if err != nil {
-		return fmt.Errorf("failed to create review: %w", err)
+		return 0, fmt.Errorf("failed to create review: %w", err)
 	}
 
+	return created.GetID(), nil
+}
+
+// RequestReviewers requests reviews of a pull request from users and teams, given by their slug
+func (g *GitHubClient) RequestReviewers(ctx context.Context, owner, repo string, prNumber int, users, teams []string) error {
+	_, _, err := g.client.PullRequests.RequestReviewers(ctx, owner, repo, prNumber, github.ReviewersRequest{
+		Reviewers:     users,
+		TeamReviewers: teams,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request reviewers of PR #%d: %w", prNumber, err)
+	}
 	return nil
 }
 
@@ -99,6 +533,352 @@ func (g *GitHubClient) PostComment(ctx context.Context, owner, repo string, prNu
 	return nil
 }
 
+// SourceFiles downloads a repository at a ref as a tarball and returns the contents of the
+// files include accepts, keyed by path. Files larger than maxFileBytes are skipped, and
+// files stop being added once maxBytes are read, so huge repositories are only partly read.
+func (g *GitHubClient) SourceFiles(ctx context.Context, owner, repo, ref string, include func(path string) bool, maxFileBytes, maxBytes int64) (map[string]string, error) {
+	link, _, err := g.client.Repositories.GetArchiveLink(ctx, owner, repo, github.Tarball, &github.RepositoryContentGetOptions{Ref: ref}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive link: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download archive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download archive: status %d", resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	archive := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	var total int64
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxFileBytes {
+			continue
+		}
+		// Entries are prefixed with a directory named after the repository and commit
+		_, name, found := strings.Cut(header.Name, "/")
+		if !found || !include(name) {
+			continue
+		}
+		if total+header.Size > maxBytes {
+			log.Printf("Stopped reading the %s archive of %s/%s after %d bytes", ref, owner, repo, total)
+			return files, nil
+		}
+
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", name, err)
+		}
+		files[name] = string(content)
+		total += header.Size
+	}
+}
+
+// ListComments lists the comments in a pull request's or issue's conversation, oldest first
+func (g *GitHubClient) ListComments(ctx context.Context, owner, repo string, number int) ([]*IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	var comments []*IssueComment
+	for {
+		page, resp, err := g.client.Issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, comment := range page {
+			comments = append(comments, &IssueComment{
+				ID:   comment.GetID(),
+				Body: comment.GetBody(),
+				User: User{Login: comment.GetUser().GetLogin()},
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// SetCommitStatus sets the status of a commit for a context, e.g. "cyclone/config". state is
+// "pending", "success", "failure" or "error".
+func (g *GitHubClient) SetCommitStatus(ctx context.Context, owner, repo, sha, statusContext, state, description string) error {
+	status := &github.RepoStatus{
+		Context:     github.String(statusContext),
+		State:       github.String(state),
+		Description: github.String(description),
+	}
+	if _, _, err := g.client.Repositories.CreateStatus(ctx, owner, repo, sha, status); err != nil {
+		return fmt.Errorf("failed to set status %s of commit %s: %w", statusContext, sha, err)
+	}
+	return nil
+}
+
+// PostCommitComment posts a comment on a commit
+func (g *GitHubClient) PostCommitComment(ctx context.Context, owner, repo, sha, body string) error {
+	if _, _, err := g.client.Repositories.CreateComment(ctx, owner, repo, sha, &github.RepositoryComment{Body: github.String(body)}); err != nil {
+		return fmt.Errorf("failed to comment on commit %s: %w", sha, err)
+	}
+	return nil
+}
+
+// PullRequestsWithCommit lists the pull requests a commit belongs to
+func (g *GitHubClient) PullRequestsWithCommit(ctx context.Context, owner, repo, sha string) ([]*PullRequest, error) {
+	page, _, err := g.client.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PRs of commit %s: %w", sha, err)
+	}
+	prs := make([]*PullRequest, len(page))
+	for i, pr := range page {
+		prs[i] = newPullRequest(pr)
+	}
+	return prs, nil
+}
+
+// GetReviewComment fetches a single line-specific review comment
+func (g *GitHubClient) GetReviewComment(ctx context.Context, owner, repo string, commentID int64) (*PullRequestComment, error) {
+	comment, _, err := g.client.PullRequests.GetComment(ctx, owner, repo, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review comment %d: %w", commentID, err)
+	}
+
+	return &PullRequestComment{
+		ID:                  comment.GetID(),
+		InReplyTo:           comment.GetInReplyTo(),
+		PullRequestReviewID: comment.GetPullRequestReviewID(),
+		Path:                comment.GetPath(),
+		Line:                comment.GetLine(),
+		Body:                comment.GetBody(),
+		DiffHunk:            comment.GetDiffHunk(),
+		HTMLURL:             comment.GetHTMLURL(),
+		User:                User{Login: comment.GetUser().GetLogin(), Type: comment.GetUser().GetType()},
+		AuthorAssociation:   comment.GetAuthorAssociation(),
+	}, nil
+}
+
+// ReplyToReviewComment posts a reply in the thread of a line-specific review comment
+func (g *GitHubClient) ReplyToReviewComment(ctx context.Context, owner, repo string, prNumber int, commentID int64, body string) error {
+	_, _, err := g.client.PullRequests.CreateCommentInReplyTo(ctx, owner, repo, prNumber, body, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to reply to review comment %d: %w", commentID, err)
+	}
+
+	return nil
+}
+
+// UpdateReviewComment replaces the body of a line-specific review comment
+func (g *GitHubClient) UpdateReviewComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	_, _, err := g.client.PullRequests.EditComment(ctx, owner, repo, commentID, &github.PullRequestComment{Body: github.String(body)})
+	if err != nil {
+		return fmt.Errorf("failed to update review comment %d: %w", commentID, err)
+	}
+
+	return nil
+}
+
+// CreateDiscussion starts a discussion in a category of a repository, found by name, and returns
+// its URL
+func (g *GitHubClient) CreateDiscussion(ctx context.Context, owner, repo, category, title, body string) (string, error) {
+	var lookup struct {
+		Repository struct {
+			ID                   string `json:"id"`
+			DiscussionCategories struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+	err := g.graphQL(ctx, `query($owner: String!, $repo: String!) {
+		repository(owner: $owner, name: $repo) { id discussionCategories(first: 100) { nodes { id name } } }
+	}`, map[string]interface{}{"owner": owner, "repo": repo}, &lookup)
+	if err != nil {
+		return "", fmt.Errorf("failed to get discussion categories: %w", err)
+	}
+
+	categoryID := ""
+	for _, node := range lookup.Repository.DiscussionCategories.Nodes {
+		if strings.EqualFold(node.Name, category) {
+			categoryID = node.ID
+		}
+	}
+	if categoryID == "" {
+		return "", fmt.Errorf("no discussion category %q in %s/%s - are discussions enabled?", category, owner, repo)
+	}
+
+	var created struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				URL string `json:"url"`
+			} `json:"discussion"`
+		} `json:"createDiscussion"`
+	}
+	err = g.graphQL(ctx, `mutation($input: CreateDiscussionInput!) {
+		createDiscussion(input: $input) { discussion { url } }
+	}`, map[string]interface{}{"input": map[string]string{
+		"repositoryId": lookup.Repository.ID,
+		"categoryId":   categoryID,
+		"title":        title,
+		"body":         body,
+	}}, &created)
+	if err != nil {
+		return "", fmt.Errorf("failed to create discussion: %w", err)
+	}
+
+	return created.CreateDiscussion.Discussion.URL, nil
+}
+
+// graphQL runs a query against the GitHub GraphQL API, which some features such as
+// discussions are only available in, and decodes its data into result
+func (g *GitHubClient) graphQL(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	req, err := g.client.NewRequest(http.MethodPost, "graphql", map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := g.client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(messages, "; "))
+	}
+	return json.Unmarshal(resp.Data, result)
+}
+
+// newPullRequest converts a pull request of the GitHub API
+func newPullRequest(pr *github.PullRequest) *PullRequest {
+	converted := &PullRequest{
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		Body:         pr.GetBody(),
+		State:        pr.GetState(),
+		Draft:        pr.GetDraft(),
+		Merged:       pr.GetMerged(),
+		HTMLURL:      pr.GetHTMLURL(),
+		User:         User{Login: pr.GetUser().GetLogin()},
+		CreatedAt:    pr.GetCreatedAt().Time,
+		UpdatedAt:    pr.GetUpdatedAt().Time,
+		MergedAt:     pr.GetMergedAt().Time,
+		ChangedFiles: pr.GetChangedFiles(),
+		Additions:    pr.GetAdditions(),
+		Deletions:    pr.GetDeletions(),
+
+		Mergeable:      pr.Mergeable,
+		MergeableState: pr.GetMergeableState(),
+	}
+	for _, label := range pr.Labels {
+		converted.Labels = append(converted.Labels, Label{Name: label.GetName()})
+	}
+	for _, user := range pr.RequestedReviewers {
+		converted.RequestedReviewers = append(converted.RequestedReviewers, User{Login: user.GetLogin(), Type: user.GetType()})
+	}
+	for _, team := range pr.RequestedTeams {
+		converted.RequestedTeams = append(converted.RequestedTeams, Team{Slug: team.GetSlug()})
+	}
+	converted.Head.SHA = pr.GetHead().GetSHA()
+	converted.Head.Ref = pr.GetHead().GetRef()
+	converted.Base.SHA = pr.GetBase().GetSHA()
+	converted.Base.Ref = pr.GetBase().GetRef()
+	repo := pr.GetBase().GetRepo()
+	converted.Base.Repo = &Repository{
+		Name:          repo.GetName(),
+		FullName:      repo.GetFullName(),
+		Owner:         User{Login: repo.GetOwner().GetLogin()},
+		DefaultBranch: repo.GetDefaultBranch(),
+	}
+	return converted
+}
+
+// newIssue converts an issue of the GitHub API
+func newIssue(issue *github.Issue) *Issue {
+	converted := &Issue{
+		Number:    issue.GetNumber(),
+		Title:     issue.GetTitle(),
+		Body:      issue.GetBody(),
+		State:     issue.GetState(),
+		HTMLURL:   issue.GetHTMLURL(),
+		User:      User{Login: issue.GetUser().GetLogin()},
+		CreatedAt: issue.GetCreatedAt().Time,
+	}
+	for _, label := range issue.Labels {
+		converted.Labels = append(converted.Labels, Label{Name: label.GetName()})
+	}
+	if issue.IsPullRequest() {
+		converted.PullRequestLinks = &struct{}{}
+	}
+	return converted
+}
+
+// newRelease converts a release of the GitHub API
+func newRelease(release *github.RepositoryRelease) *Release {
+	return &Release{
+		ID:              release.GetID(),
+		TagName:         release.GetTagName(),
+		Name:            release.GetName(),
+		TargetCommitish: release.GetTargetCommitish(),
+		Body:            release.GetBody(),
+		HTMLURL:         release.GetHTMLURL(),
+		Draft:           release.GetDraft(),
+		Prerelease:      release.GetPrerelease(),
+		PublishedAt:     release.GetPublishedAt().Time,
+	}
+}
+
+// newChangedFile converts a changed file of the GitHub API
+func newChangedFile(file *github.CommitFile) ChangedFile {
+	return ChangedFile{
+		Filename:         file.GetFilename(),
+		PreviousFilename: file.GetPreviousFilename(),
+		Status:           file.GetStatus(),
+		Patch:            file.GetPatch(),
+		Additions:        file.GetAdditions(),
+		Deletions:        file.GetDeletions(),
+	}
+}
+
+// githubErrorStatus returns the status code of a failed GitHub API call, 0 for other errors
+func githubErrorStatus(err error) int {
+	var githubErr *github.ErrorResponse
+	var rateLimitErr *github.RateLimitError
+	switch {
+	case errors.As(err, &githubErr) && githubErr.Response != nil:
+		return githubErr.Response.StatusCode
+	case errors.As(err, &rateLimitErr) && rateLimitErr.Response != nil:
+		return rateLimitErr.Response.StatusCode
+	}
+	return 0
+}
+
 // isBinaryFile checks if a file is likely binary based on its extension
 func isBinaryFile(filename string) bool {
 	binaryExtensions := []string{
@@ -118,3 +898,13 @@ func isBinaryFile(filename string) bool {
 	}
 	return false
 }
+
+// matchingPattern returns the first ignore pattern matching the file, or "" if none does
+func matchingPattern(patterns []string, filename string) string {
+	for _, pattern := range patterns {
+		if config.MatchesPath(pattern, filename) {
+			return pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,395 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGitLabURL is the GitLab instance used when an organization doesn't name its own
+const DefaultGitLabURL = "https://gitlab.com"
+
+// gitLabRequestTimeout bounds a single GitLab API request
+const gitLabRequestTimeout = 30 * time.Second
+
+// GitLabClient handles the GitLab REST API operations of merge request reviews. Organizations
+// on GitLab are top-level groups and repositories the paths of projects below them, e.g.
+// "platform/api" in "acme".
+type GitLabClient struct {
+	baseURL    string // e.g. "https://gitlab.example.com", without the API path
+	token      string // Personal, group or project access token with the api scope
+	httpClient *http.Client
+}
+
+// GitLabClient is the SCMProvider of organizations on GitLab
+var _ SCMProvider = (*GitLabClient)(nil)
+
+// mergeRequest is the part of a GitLab merge request reviews need
+type mergeRequest struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Draft       bool     `json:"draft"`
+	Labels      []string `json:"labels"`
+	WebURL      string   `json:"web_url"`
+	DiffRefs    diffRefs `json:"diff_refs"`
+}
+
+// diffRefs are the commits a merge request's diff is between, which line comments are
+// positioned against
+type diffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	StartSHA string `json:"start_sha"`
+	HeadSHA  string `json:"head_sha"`
+}
+
+// mergeRequestDiff is the diff of one file of a merge request
+type mergeRequestDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"` // Unified diff hunks, like a GitHub patch
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// GitLabAPIError is returned for unsuccessful GitLab API responses
+type GitLabAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *GitLabAPIError) Error() string {
+	return fmt.Sprintf("GitLab API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// NewGitLabClient creates a GitLab client for an instance, gitlab.com if baseURL is empty
+func NewGitLabClient(baseURL, token string) *GitLabClient {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	return &GitLabClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: gitLabRequestTimeout},
+	}
+}
+
+// GetPullRequest fetches a merge request
+func (g *GitLabClient) GetPullRequest(ctx context.Context, owner, repo string, iid int) (*PullRequest, error) {
+	mr, err := g.getMergeRequest(ctx, owner+"/"+repo, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PullRequest{
+		Number:  mr.IID,
+		Title:   mr.Title,
+		Body:    mr.Description,
+		Draft:   mr.Draft,
+		HTMLURL: mr.WebURL,
+	}
+	for _, label := range mr.Labels {
+		pr.Labels = append(pr.Labels, Label{Name: label})
+	}
+	pr.Head.SHA = mr.DiffRefs.HeadSHA
+	return pr, nil
+}
+
+// ListFiles fetches the files a merge request changes
+func (g *GitLabClient) ListFiles(ctx context.Context, owner, repo string, iid int) ([]ChangedFile, error) {
+	diffs, err := g.listMergeRequestDiffs(ctx, owner+"/"+repo, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]ChangedFile, len(diffs))
+	for i, diff := range diffs {
+		additions, deletions := countDiffLines(diff.Diff)
+		files[i] = ChangedFile{
+			Filename:  diff.NewPath,
+			Status:    "modified",
+			Patch:     diff.Diff,
+			Additions: additions,
+			Deletions: deletions,
+		}
+		switch {
+		case diff.NewFile:
+			files[i].Status = "added"
+		case diff.DeletedFile:
+			files[i].Status = "removed"
+		case diff.OldPath != diff.NewPath:
+			files[i].Status = "renamed"
+			files[i].PreviousFilename = diff.OldPath
+		}
+	}
+	return files, nil
+}
+
+// GetDiff fetches the diff of a merge request, leaving out files matching any of the ignore patterns
+func (g *GitLabClient) GetDiff(ctx context.Context, owner, repo string, iid int, ignorePaths []string) (string, error) {
+	files, err := g.ListFiles(ctx, owner, repo, iid)
+	if err != nil {
+		return "", err
+	}
+	return FormatDiff(files, fmt.Sprintf("merge request !%d", iid), ignorePaths), nil
+}
+
+// GetFileContent fetches a file from the project's default branch, returning nil if it doesn't exist
+func (g *GitLabClient) GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error) {
+	project := owner + "/" + repo
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := g.do(ctx, http.MethodGet, "projects/"+url.PathEscape(project), nil, &info); err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", project, err)
+	}
+
+	filePath := fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", url.PathEscape(project), url.PathEscape(path), url.QueryEscape(info.DefaultBranch))
+	content, err := g.send(ctx, http.MethodGet, filePath, nil)
+	var apiErr *GitLabAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// getMergeRequest fetches a merge request of a project, given by its full path, e.g. "acme/platform/api"
+func (g *GitLabClient) getMergeRequest(ctx context.Context, project string, iid int) (*mergeRequest, error) {
+	var mr mergeRequest
+	if _, err := g.do(ctx, http.MethodGet, mergeRequestPath(project, iid), nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get merge request !%d: %w", iid, err)
+	}
+	return &mr, nil
+}
+
+// listMergeRequestDiffs fetches the file diffs of a merge request
+func (g *GitLabClient) listMergeRequestDiffs(ctx context.Context, project string, iid int) ([]mergeRequestDiff, error) {
+	var diffs []mergeRequestDiff
+	for page := "1"; page != ""; {
+		var batch []mergeRequestDiff
+		resp, err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/diffs?per_page=100&page=%s", mergeRequestPath(project, iid), page), nil, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diffs of merge request !%d: %w", iid, err)
+		}
+		diffs = append(diffs, batch...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return diffs, nil
+}
+
+// PostComment posts a note on a merge request
+func (g *GitLabClient) PostComment(ctx context.Context, owner, repo string, iid int, body string) error {
+	_, err := g.postNote(ctx, owner+"/"+repo, iid, body)
+	return err
+}
+
+// postNote posts a comment on a merge request and returns its ID
+func (g *GitLabClient) postNote(ctx context.Context, project string, iid int, body string) (int64, error) {
+	var note struct {
+		ID int64 `json:"id"`
+	}
+	if _, err := g.do(ctx, http.MethodPost, mergeRequestPath(project, iid)+"/notes", map[string]string{"body": body}, &note); err != nil {
+		return 0, fmt.Errorf("failed to create note: %w", err)
+	}
+	return note.ID, nil
+}
+
+// ListLabels fetches the labels of a merge request
+func (g *GitLabClient) ListLabels(ctx context.Context, owner, repo string, iid int) ([]string, error) {
+	mr, err := g.getMergeRequest(ctx, owner+"/"+repo, iid)
+	if err != nil {
+		return nil, err
+	}
+	return mr.Labels, nil
+}
+
+// AddLabels adds labels to a merge request; GitLab creates those the project doesn't have yet
+func (g *GitLabClient) AddLabels(ctx context.Context, owner, repo string, iid int, labels []string) error {
+	body := map[string]string{"add_labels": strings.Join(labels, ",")}
+	if _, err := g.do(ctx, http.MethodPut, mergeRequestPath(owner+"/"+repo, iid), body, nil); err != nil {
+		return fmt.Errorf("failed to add labels to merge request !%d: %w", iid, err)
+	}
+	return nil
+}
+
+// PostReview posts a review on a merge request: the summary as a note and each line comment as
+// a discussion positioned on its diff line. Comments GitLab can't place on a line are posted
+// as discussions naming the line instead. It returns the ID of the summary note.
+func (g *GitLabClient) PostReview(ctx context.Context, owner, repo string, iid int, review ReviewResult) (int64, error) {
+	project := owner + "/" + repo
+	mr, err := g.getMergeRequest(ctx, project, iid)
+	if err != nil {
+		return 0, err
+	}
+	diffs, err := g.listMergeRequestDiffs(ctx, project, iid)
+	if err != nil {
+		return 0, err
+	}
+
+	noteID, err := g.postNote(ctx, project, mr.IID, review.Summary)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post review summary: %w", err)
+	}
+
+	byPath := make(map[string]mergeRequestDiff, len(diffs))
+	for _, diff := range diffs {
+		byPath[diff.NewPath] = diff
+	}
+
+	path := mergeRequestPath(project, mr.IID) + "/discussions"
+	for _, comment := range review.Comments {
+		diff := byPath[comment.Path]
+		oldLine, newLine := diffLinePosition(diff.Diff, comment.Line, comment.Side)
+		position := map[string]interface{}{
+			"position_type": "text",
+			"base_sha":      mr.DiffRefs.BaseSHA,
+			"start_sha":     mr.DiffRefs.StartSHA,
+			"head_sha":      mr.DiffRefs.HeadSHA,
+			"old_path":      diff.OldPath,
+			"new_path":      comment.Path,
+		}
+		if diff.OldPath == "" {
+			position["old_path"] = comment.Path
+		}
+		if oldLine > 0 {
+			position["old_line"] = oldLine
+		}
+		if newLine > 0 {
+			position["new_line"] = newLine
+		}
+
+		_, err := g.do(ctx, http.MethodPost, path, map[string]interface{}{"body": comment.Body, "position": position}, nil)
+		if err == nil {
+			continue
+		}
+		log.Printf("Error positioning comment on %s:%d in merge request !%d - posting it unpositioned: %v", comment.Path, comment.Line, mr.IID, err)
+		body := fmt.Sprintf("`%s:%d`\n\n%s", comment.Path, comment.Line, comment.Body)
+		if _, err := g.do(ctx, http.MethodPost, path, map[string]string{"body": body}, nil); err != nil {
+			return noteID, fmt.Errorf("failed to post comment on %s:%d: %w", comment.Path, comment.Line, err)
+		}
+	}
+	return noteID, nil
+}
+
+// diffLinePosition returns the old and new line numbers GitLab positions a comment on a line of
+// a file diff with: unchanged lines need both, added lines only the new and deleted lines only
+// the old one. Lines outside the diff are positioned on the commented side only.
+func diffLinePosition(diff string, line int, side string) (oldLine, newLine int) {
+	left := side == "LEFT"
+	oldNum, newNum := 0, 0
+	for _, text := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(text, "@@") {
+			oldNum, newNum = hunkStart(text)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+"):
+			if !left && newNum == line {
+				return 0, line
+			}
+			newNum++
+		case strings.HasPrefix(text, "-"):
+			if left && oldNum == line {
+				return line, 0
+			}
+			oldNum++
+		case strings.HasPrefix(text, "\\"):
+			// "\ No newline at end of file"
+		default:
+			if (left && oldNum == line) || (!left && newNum == line) {
+				return oldNum, newNum
+			}
+			oldNum++
+			newNum++
+		}
+	}
+
+	if left {
+		return line, 0
+	}
+	return 0, line
+}
+
+// hunkStart parses the first old and new line numbers of a hunk header, e.g. "@@ -10,7 +12,8 @@"
+func hunkStart(header string) (oldStart, newStart int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	parse := func(field string) int {
+		start, _, _ := strings.Cut(field[1:], ",")
+		n, _ := strconv.Atoi(start)
+		return n
+	}
+	return parse(fields[1]), parse(fields[2])
+}
+
+// mergeRequestPath is the API path of a merge request
+func mergeRequestPath(project string, iid int) string {
+	return fmt.Sprintf("projects/%s/merge_requests/%d", url.PathEscape(project), iid)
+}
+
+// do sends an API request with a JSON body, if not nil, and decodes the JSON response into
+// result, if not nil
+func (g *GitLabClient) do(ctx context.Context, method, path string, body, result interface{}) (*http.Response, error) {
+	resp, data, err := g.request(ctx, method, path, body)
+	if err != nil || result == nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return resp, fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+	return resp, nil
+}
+
+// send sends an API request with a JSON body, if not nil, and returns the raw response body
+func (g *GitLabClient) send(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	_, data, err := g.request(ctx, method, path, body)
+	return data, err
+}
+
+// request sends an API request with a JSON body, if not nil, and reads the response
+func (g *GitLabClient) request(ctx context.Context, method, path string, body interface{}) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+"/api/v4/"+path, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp, nil, &GitLabAPIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	data, err := io.ReadAll(resp.Body)
+	return resp, data, err
+}
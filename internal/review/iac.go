@@ -0,0 +1,102 @@
+package review
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxPlanLength caps the terraform plan output passed to a review, in bytes
+const maxPlanLength = 30000
+
+// Directories that usually hold Kubernetes manifests or CloudFormation templates
+var iacDir = regexp.MustCompile(`(^|/)(k8s|kubernetes|manifests|deploy|cloudformation|cfn)/|(^|/)templates/[^/]+\.ya?ml$`)
+
+// Markers of Kubernetes manifests and CloudFormation templates in a file's diff
+var (
+	kubernetesManifest     = regexp.MustCompile(`(?m)^[ +-]\s*apiVersion:\s*\S+`)
+	cloudFormationTemplate = regexp.MustCompile(`AWSTemplateFormatVersion|"?Type"?:\s*"?AWS::`)
+)
+
+// planMarkers identify a comment with terraform plan output, as posted by CI workflows,
+// Atlantis or tfcmt
+var planMarkers = []string{
+	"will perform the following actions",
+	"No changes. Your infrastructure matches the configuration",
+	"Ran Plan for",
+}
+
+// isIaCFile reports whether a file is infrastructure as code: Terraform and OpenTofu
+// configuration and variables, Terragrunt files, CloudFormation templates and Kubernetes
+// manifests. Since YAML and JSON can be anything, those are recognized by their directory
+// or by the markers in their diff.
+func isIaCFile(filename, fileDiff string) bool {
+	base := path.Base(filename)
+	switch {
+	case strings.HasSuffix(base, ".tf"), strings.HasSuffix(base, ".tf.json"),
+		strings.HasSuffix(base, ".tfvars"), strings.HasSuffix(base, ".tfvars.json"),
+		base == "terragrunt.hcl":
+		return true
+	case base == "kustomization.yaml", base == "kustomization.yml":
+		return true
+	}
+
+	switch path.Ext(base) {
+	case ".yaml", ".yml", ".json", ".template":
+	default:
+		return false
+	}
+	return iacDir.MatchString(filename) || kubernetesManifest.MatchString(fileDiff) || cloudFormationTemplate.MatchString(fileDiff)
+}
+
+// TerraformPlan returns the terraform plan output in the most recent comment of a pull
+// request that has one, e.g. posted by a CI workflow or Atlantis, "" if there is none. Plans
+// in fenced code blocks are extracted from the comment's markdown.
+func TerraformPlan(comments []*IssueComment) string {
+	for i := len(comments) - 1; i >= 0; i-- {
+		body := comments[i].Body
+		if !hasPlanMarker(body) {
+			continue
+		}
+		plan := body
+		if blocks := fencedBlocks(body); len(blocks) > 0 {
+			plan = strings.Join(blocks, "\n")
+		}
+		if len(plan) > maxPlanLength {
+			plan = strings.ToValidUTF8(plan[:maxPlanLength], "") + "\n[plan truncated]"
+		}
+		return strings.TrimSpace(plan)
+	}
+	return ""
+}
+
+// hasPlanMarker reports whether a comment contains terraform plan output
+func hasPlanMarker(body string) bool {
+	for _, marker := range planMarkers {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// fencedBlocks returns the contents of the fenced code blocks of markdown
+func fencedBlocks(markdown string) []string {
+	var blocks []string
+	var block []string
+	inBlock := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inBlock {
+				blocks = append(blocks, strings.Join(block, "\n"))
+				block = nil
+			}
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock {
+			block = append(block, line)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,210 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
+)
+
+// Import statements by language, each capturing the imported path
+var (
+	goImport     = regexp.MustCompile(`^\s*(?:import\s+)?(?:[\w.]+\s+)?"([^"]+)"\s*(?://.*)?$`)
+	jsImport     = regexp.MustCompile(`(?:^|\s)(?:import|export)\s[^'"]*?from\s+['"]([^'"]+)['"]|^\s*import\s+['"]([^'"]+)['"]|\b(?:require|import)\(\s*['"]([^'"]+)['"]\s*\)`)
+	pythonImport = regexp.MustCompile(`^\s*(?:from\s+(\.*[\w.]*)\s+import\b|import\s+([\w.]+(?:\s*,\s*[\w.]+)*))`)
+	javaImport   = regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+)`)
+	csharpImport = regexp.MustCompile(`^\s*(?:global\s+)?using\s+(?:static\s+)?([\w.]+)\s*;`)
+	rustImport   = regexp.MustCompile(`^\s*(?:pub(?:\([\w:]+\))?\s+)?use\s+([\w:]+)`)
+)
+
+// ImportViolation is an import added by a pull request that an import rule forbids
+type ImportViolation struct {
+	Path   string            // File with the import
+	Line   int               // Line of the import
+	Import string            // Imported path, as a slash-separated path
+	Denied string            // Entry of the rule's deny list it matches
+	Rule   config.ImportRule // Violated rule
+}
+
+// CheckImports returns the imports added by a diff that the rules forbid, one per import
+// and rule. Imports are read from the added lines of Go, JavaScript, TypeScript, Python,
+// Java, Kotlin, Scala, C# and Rust files; relative imports are resolved against the file.
+func CheckImports(diff string, rules []config.ImportRule) []ImportViolation {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var violations []ImportViolation
+	for _, section := range splitDiffSections(diff) {
+		var applicable []config.ImportRule
+		for _, rule := range rules {
+			if config.MatchesPath(rule.From, section.filename) {
+				applicable = append(applicable, rule)
+			}
+		}
+		if len(applicable) == 0 {
+			continue
+		}
+
+		current := 0
+		for _, line := range strings.Split(section.content, "\n") {
+			if match := hunkHeader.FindStringSubmatch(line); match != nil {
+				current, _ = strconv.Atoi(match[2])
+				continue
+			}
+			if current == 0 || line == "" {
+				continue
+			}
+			lineNumber := current
+			switch line[0] {
+			case '+':
+				current++
+			case ' ':
+				current++
+				continue
+			default:
+				continue
+			}
+
+			for _, imported := range addedImports(section.filename, line[1:]) {
+				for _, rule := range applicable {
+					if denied := deniedBy(rule, imported); denied != "" {
+						violations = append(violations, ImportViolation{
+							Path:   section.filename,
+							Line:   lineNumber,
+							Import: imported,
+							Denied: denied,
+							Rule:   rule,
+						})
+					}
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// ImportRuleComments renders import rule violations as comments in the repository's
+// blocking category
+func ImportRuleComments(violations []ImportViolation, repoConfig *config.RepositoryConfig) []ReviewComment {
+	category := blockingCategory(repoConfig.GetCategories())
+	var comments []ReviewComment
+	for _, violation := range violations {
+		text := notices.Render(repoConfig.Language, "import_rule_violation", notices.Data{
+			"File":   path.Base(violation.Path),
+			"Import": violation.Import,
+			"From":   violation.Rule.From,
+			"Denied": violation.Denied,
+			"Reason": violation.Rule.Reason,
+		})
+		comments = append(comments, ReviewComment{
+			Path:     violation.Path,
+			Line:     violation.Line,
+			Side:     "RIGHT",
+			Body:     fmt.Sprintf("%s\n\n%s", category.Prefix(), text),
+			Category: category.Name,
+		})
+	}
+	return comments
+}
+
+// blockingCategory returns the category named "blocking" among a repository's categories,
+// or the default one if the repository defines its own without it
+func blockingCategory(categories []config.CommentCategory) config.CommentCategory {
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, "blocking") {
+			return category
+		}
+	}
+	for _, category := range config.DefaultCommentCategories {
+		if category.Name == "blocking" {
+			return category
+		}
+	}
+	return config.CommentCategory{Name: "blocking"}
+}
+
+// addedImports returns the paths imported by a line of code, slash-separated whatever the
+// language's own notation
+func addedImports(filename, code string) []string {
+	dir := path.Dir(filename)
+	var imports []string
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".go":
+		if match := goImport.FindStringSubmatch(code); match != nil {
+			imports = append(imports, match[1])
+		}
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		for _, match := range jsImport.FindAllStringSubmatch(code, -1) {
+			spec := match[1] + match[2] + match[3]
+			if strings.HasPrefix(spec, ".") {
+				spec = path.Join(dir, spec)
+			}
+			imports = append(imports, spec)
+		}
+	case ".py":
+		if match := pythonImport.FindStringSubmatch(code); match != nil {
+			if match[1] != "" {
+				imports = append(imports, pythonModulePath(dir, match[1]))
+			}
+			for _, module := range strings.Split(match[2], ",") {
+				if module = strings.TrimSpace(module); module != "" {
+					imports = append(imports, strings.ReplaceAll(module, ".", "/"))
+				}
+			}
+		}
+	case ".java", ".kt", ".scala":
+		if match := javaImport.FindStringSubmatch(code); match != nil {
+			imports = append(imports, strings.ReplaceAll(match[1], ".", "/"))
+		}
+	case ".cs":
+		if match := csharpImport.FindStringSubmatch(code); match != nil {
+			imports = append(imports, strings.ReplaceAll(match[1], ".", "/"))
+		}
+	case ".rs":
+		if match := rustImport.FindStringSubmatch(code); match != nil {
+			imports = append(imports, strings.ReplaceAll(strings.TrimSuffix(match[1], "::"), "::", "/"))
+		}
+	}
+	return imports
+}
+
+// pythonModulePath converts a module of a from-import to a path. Relative modules, with
+// leading dots, are resolved against the directory of the importing file.
+func pythonModulePath(dir, module string) string {
+	dots := len(module) - len(strings.TrimLeft(module, "."))
+	modulePath := strings.ReplaceAll(module[dots:], ".", "/")
+	if dots == 0 {
+		return modulePath
+	}
+	for i := 1; i < dots; i++ {
+		dir = path.Dir(dir)
+	}
+	return path.Join(dir, modulePath)
+}
+
+// deniedBy returns the entry of a rule's deny list an import matches, "" if the rule allows it
+func deniedBy(rule config.ImportRule, imported string) string {
+	for _, allowed := range rule.Allow {
+		if importMatches(imported, allowed) {
+			return ""
+		}
+	}
+	for _, denied := range rule.Deny {
+		if importMatches(imported, denied) {
+			return denied
+		}
+	}
+	return ""
+}
+
+// importMatches reports whether an imported path contains a pattern as whole path segments,
+// so "internal/db" matches "example.com/app/internal/db/queries" but not "internal/dbutil"
+func importMatches(imported, pattern string) bool {
+	pattern = strings.Trim(strings.TrimPrefix(pattern, "./"), "/")
+	return pattern != "" && strings.Contains("/"+strings.Trim(imported, "/")+"/", "/"+pattern+"/")
+}
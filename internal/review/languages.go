@@ -0,0 +1,91 @@
+package review
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/prompts"
+)
+
+// languageExtensions maps file extensions to the language keys of prompt snippets
+var languageExtensions = map[string]string{
+	".go":     "go",
+	".ts":     "typescript",
+	".tsx":    "typescript",
+	".js":     "javascript",
+	".jsx":    "javascript",
+	".mjs":    "javascript",
+	".py":     "python",
+	".sql":    "sql",
+	".tf":     "terraform",
+	".tfvars": "terraform",
+	".java":   "java",
+	".rs":     "rust",
+}
+
+// languageForFile returns the language key of a file: a known language name, or
+// the lowercase extension for languages without a built-in snippet
+func languageForFile(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if lang, ok := languageExtensions[ext]; ok {
+		return lang
+	}
+	return ext
+}
+
+// detectLanguages returns the sorted, de-duplicated language keys of the files in a diff
+func detectLanguages(diff string) []string {
+	seen := make(map[string]bool)
+	var languages []string
+	for _, section := range splitDiffSections(diff) {
+		lang := languageForFile(section.filename)
+		if lang != "" && !seen[lang] {
+			seen[lang] = true
+			languages = append(languages, lang)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// buildLanguageGuidelines assembles the snippets for every language present in the diff
+func (ai *AIClient) buildLanguageGuidelines(diff string, repoConfig *config.RepositoryConfig) string {
+	var snippets []string
+	for _, lang := range detectLanguages(diff) {
+		if snippet := ai.loadLanguageSnippet(lang, repoConfig); snippet != "" {
+			snippets = append(snippets, snippet)
+		}
+	}
+	return strings.Join(snippets, "\n\n")
+}
+
+// loadLanguageSnippet returns the guidance for a language. Repository configuration takes
+// precedence over PROMPTS_DIR/languages/<lang>.txt, which takes precedence over the embedded snippet.
+func (ai *AIClient) loadLanguageSnippet(lang string, repoConfig *config.RepositoryConfig) string {
+	if snippet, ok := repoConfig.LanguagePrompts[lang]; ok {
+		return strings.TrimSpace(snippet)
+	}
+
+	name := path.Join("languages", strings.TrimPrefix(lang, ".")+".txt")
+	if ai.promptsDir != "" {
+		content, err := os.ReadFile(filepath.Join(ai.promptsDir, name))
+		if err == nil {
+			return strings.TrimSpace(string(content))
+		}
+		if !os.IsNotExist(err) {
+			log.Printf("Could not read language snippet %s: %v", name, err)
+		}
+	}
+
+	content, err := prompts.FS.ReadFile(name)
+	if err != nil {
+		// No snippet for this language
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,160 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines around the changes of a hunk, like git's default
+const diffContextLines = 3
+
+// lineEdit is a line of a diff: kept (' '), deleted ('-') or added ('+')
+type lineEdit struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff builds the unified diff hunks between two versions of a file, for code hosts
+// whose APIs don't return patches and for the audit log. It returns false if the versions
+// differ in more than maxEdits lines, which aren't worth diffing.
+func UnifiedDiff(oldText, newText string, maxEdits int) (string, bool) {
+	edits, ok := diffLines(splitLines(oldText), splitLines(newText), maxEdits)
+	if !ok {
+		return "", false
+	}
+	return formatHunks(edits), true
+}
+
+// splitLines splits file content into lines, without the empty line after a final newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
+
+// diffLines finds the shortest edit script from a to b with Myers' algorithm, giving up after
+// maxEdits edits
+func diffLines(a, b []string, maxEdits int) ([]lineEdit, bool) {
+	n, m := len(a), len(b)
+	limit := n + m
+	if maxEdits < limit {
+		limit = maxEdits
+	}
+
+	// v holds the furthest x reached on each diagonal k = x - y, offset to be non-negative;
+	// trace keeps it before each round for backtracking
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackEdits(a, b, trace, offset), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrackEdits walks the trace of diffLines back from the end of both inputs
+func backtrackEdits(a, b []string, trace [][]int, offset int) []lineEdit {
+	var edits []lineEdit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, lineEdit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, lineEdit{'+', b[y-1]})
+			} else {
+				edits = append(edits, lineEdit{'-', a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// formatHunks writes an edit script as unified diff hunks, merging changes whose context overlaps
+func formatHunks(edits []lineEdit) string {
+	// Line numbers before each edit
+	oldLines := make([]int, len(edits)+1)
+	newLines := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		oldLines[i+1], newLines[i+1] = oldLines[i], newLines[i]
+		if edit.op != '+' {
+			oldLines[i+1]++
+		}
+		if edit.op != '-' {
+			newLines[i+1]++
+		}
+	}
+
+	var hunks strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share context
+		last := i
+		for j := i + 1; j < len(edits) && j-last <= 2*diffContextLines; j++ {
+			if edits[j].op != ' ' {
+				last = j
+			}
+		}
+		start := max(i-diffContextLines, 0)
+		end := min(last+diffContextLines+1, len(edits))
+
+		oldStart, oldCount := oldLines[start]+1, oldLines[end]-oldLines[start]
+		newStart, newCount := newLines[start]+1, newLines[end]-newLines[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		if hunks.Len() > 0 {
+			hunks.WriteString("\n")
+		}
+		fmt.Fprintf(&hunks, "@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)
+		for _, edit := range edits[start:end] {
+			hunks.WriteString("\n")
+			hunks.WriteByte(edit.op)
+			hunks.WriteString(edit.text)
+		}
+		i = end
+	}
+	return hunks.String()
+}
@@ -0,0 +1,195 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		maxEdits int
+		want     string
+		wantOK   bool
+	}{
+		{
+			name: "identical",
+			old:  "a\nb\nc\n", new: "a\nb\nc\n",
+			maxEdits: 10, want: "", wantOK: true,
+		},
+		{
+			name: "insert",
+			old:  "a\nb\nc\n", new: "a\nb\nx\nc\n",
+			maxEdits: 10, want: "@@ -1,3 +1,4 @@\n a\n b\n+x\n c", wantOK: true,
+		},
+		{
+			name: "delete",
+			old:  "a\nb\nc\n", new: "a\nc\n",
+			maxEdits: 10, want: "@@ -1,3 +1,2 @@\n a\n-b\n c", wantOK: true,
+		},
+		{
+			name: "replace",
+			old:  "a\nb\nc\n", new: "a\nB\nc\n",
+			maxEdits: 10, want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c", wantOK: true,
+		},
+		{
+			name: "new file",
+			old:  "", new: "a\nb\n",
+			maxEdits: 10, want: "@@ -0,0 +1,2 @@\n+a\n+b", wantOK: true,
+		},
+		{
+			name: "deleted file",
+			old:  "a\nb\n", new: "",
+			maxEdits: 10, want: "@@ -1,2 +0,0 @@\n-a\n-b", wantOK: true,
+		},
+		{
+			name: "first line",
+			old:  numberedLines(1, 10), new: strings.Replace(numberedLines(1, 10), "1\n", "X\n", 1),
+			maxEdits: 10, want: "@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4", wantOK: true,
+		},
+		{
+			name: "last line",
+			old:  numberedLines(1, 10), new: numberedLines(1, 9) + "X\n",
+			maxEdits: 10, want: "@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+X", wantOK: true,
+		},
+		{
+			name: "appended without final newline",
+			old:  "a\nb", new: "a\nb\nc",
+			maxEdits: 10, want: "@@ -1,2 +1,3 @@\n a\n b\n+c", wantOK: true,
+		},
+		{
+			name: "changes sharing context",
+			old:  numberedLines(1, 12), new: replaceLines(numberedLines(1, 12), "2", "8"),
+			maxEdits: 10,
+			want:     "@@ -1,11 +1,11 @@\n 1\n-2\n+X2\n 3\n 4\n 5\n 6\n 7\n-8\n+X8\n 9\n 10\n 11",
+			wantOK:   true,
+		},
+		{
+			name: "separate hunks",
+			old:  numberedLines(1, 20), new: replaceLines(numberedLines(1, 20), "2", "18"),
+			maxEdits: 10,
+			want:     "@@ -1,5 +1,5 @@\n 1\n-2\n+X2\n 3\n 4\n 5\n@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+X18\n 19\n 20",
+			wantOK:   true,
+		},
+		{
+			name: "CRLF line endings are ignored",
+			old:  "a\r\nb\r\nc\r\n", new: "a\nb\nc\n",
+			maxEdits: 10, want: "", wantOK: true,
+		},
+		{
+			name: "CRLF change",
+			old:  "a\r\nb\r\nc\r\n", new: "a\r\nB\r\nc\r\n",
+			maxEdits: 10, want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c", wantOK: true,
+		},
+		{
+			name: "at maxEdits",
+			old:  "a\nb\n", new: "c\nd\n",
+			maxEdits: 4, want: "@@ -1,2 +1,2 @@\n-a\n-b\n+c\n+d", wantOK: true,
+		},
+		{
+			name: "over maxEdits",
+			old:  "a\nb\n", new: "c\nd\n",
+			maxEdits: 3, want: "", wantOK: false,
+		},
+		{
+			name: "maxEdits 0 with changes",
+			old:  "a\n", new: "b\n",
+			maxEdits: 0, want: "", wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := UnifiedDiff(tt.old, tt.new, tt.maxEdits)
+			if ok != tt.wantOK {
+				t.Fatalf("UnifiedDiff() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []string
+		wantEdits int // Added and deleted lines of the shortest edit script
+	}{
+		{name: "both empty", a: nil, b: nil, wantEdits: 0},
+		{name: "identical", a: []string{"a", "b"}, b: []string{"a", "b"}, wantEdits: 0},
+		{name: "insert at start", a: []string{"b", "c"}, b: []string{"a", "b", "c"}, wantEdits: 1},
+		{name: "insert at end", a: []string{"a", "b"}, b: []string{"a", "b", "c"}, wantEdits: 1},
+		{name: "delete at start", a: []string{"a", "b", "c"}, b: []string{"b", "c"}, wantEdits: 1},
+		{name: "delete at end", a: []string{"a", "b", "c"}, b: []string{"a", "b"}, wantEdits: 1},
+		{name: "replace", a: []string{"a", "b", "c"}, b: []string{"a", "x", "c"}, wantEdits: 2},
+		{name: "all new", a: nil, b: []string{"a", "b"}, wantEdits: 2},
+		{name: "all deleted", a: []string{"a", "b"}, b: nil, wantEdits: 2},
+		{name: "move", a: []string{"a", "b", "c", "d"}, b: []string{"b", "c", "d", "a"}, wantEdits: 2},
+		{name: "repeated lines", a: []string{"x", "a", "x", "a"}, b: []string{"a", "x", "a", "x"}, wantEdits: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits, ok := diffLines(tt.a, tt.b, len(tt.a)+len(tt.b))
+			if !ok {
+				t.Fatal("diffLines() gave up within len(a)+len(b) edits")
+			}
+
+			// Keeping and deleting lines must give a, keeping and adding them b
+			var before, after []string
+			changed := 0
+			for _, edit := range edits {
+				if edit.op != '+' {
+					before = append(before, edit.text)
+				}
+				if edit.op != '-' {
+					after = append(after, edit.text)
+				}
+				if edit.op != ' ' {
+					changed++
+				}
+			}
+			if strings.Join(before, "\n") != strings.Join(tt.a, "\n") || len(before) != len(tt.a) {
+				t.Errorf("edits don't start from a: got %q, want %q", before, tt.a)
+			}
+			if strings.Join(after, "\n") != strings.Join(tt.b, "\n") || len(after) != len(tt.b) {
+				t.Errorf("edits don't lead to b: got %q, want %q", after, tt.b)
+			}
+			if changed != tt.wantEdits {
+				t.Errorf("diffLines() made %d edits, want %d", changed, tt.wantEdits)
+			}
+
+			if tt.wantEdits > 0 {
+				if _, ok := diffLines(tt.a, tt.b, tt.wantEdits-1); ok {
+					t.Errorf("diffLines() succeeded with maxEdits %d below the %d edits needed", tt.wantEdits-1, tt.wantEdits)
+				}
+			}
+		})
+	}
+}
+
+// numberedLines returns the lines from to to, each holding its number
+func numberedLines(from, to int) string {
+	var lines strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&lines, "%d\n", i)
+	}
+	return lines.String()
+}
+
+// replaceLines prefixes the given lines with X
+func replaceLines(text string, lines ...string) string {
+	split := strings.Split(text, "\n")
+	for i, line := range split {
+		for _, replaced := range lines {
+			if line == replaced {
+				split[i] = "X" + line
+			}
+		}
+	}
+	return strings.Join(split, "\n")
+}
@@ -1,14 +1,26 @@
 package review
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+
+	"cyclone/internal/config"
+	"cyclone/internal/notices"
 )
 
-// parseClaudeResponse converts Claude's text response into structured comments
-func (ai *AIClient) parseClaudeResponse(claudeText, diff string) ReviewResult {
+// reviewHeader returns the branding that starts every review summary
+func reviewHeader(language string) string {
+	return notices.Render(language, "review_header", nil) + "\n\n"
+}
+
+// parseClaudeResponse converts Claude's text response into structured comments, recognizing
+// the comment categories the review was requested with and dropping comments on lines the
+// repository's comment_lines setting rules out. Cyclone's own headings are written in the
+// review's language.
+func (ai *AIClient) parseClaudeResponse(claudeText, diff string, categories []config.CommentCategory, language string, commentLines config.CommentLines) ReviewResult {
 	var comments []ReviewComment
 	var summary string
 	var poem string
@@ -20,22 +32,28 @@ func (ai *AIClient) parseClaudeResponse(claudeText, diff string) ReviewResult {
 	poem = ai.extractSection(claudeText, "POEM:")
 
 	// Extract PR_COMMENT sections
+	commentable := CommentableLines(diff, commentLines)
 	parts := strings.Split(claudeText, "PR_COMMENT:")
 	for i := 1; i < len(parts); i++ {
-		comment := ai.parsePRCommentBlock(parts[i])
-		if comment != nil {
-			comments = append(comments, *comment)
+		comment := ai.parsePRCommentBlock(parts[i], categories)
+		if comment == nil {
+			continue
+		}
+		if !commentable[comment.Path][comment.Line] {
+			log.Printf("Dropping comment on %s:%d, which isn't a commentable %s line of the diff", comment.Path, comment.Line, commentLines)
+			continue
 		}
+		comments = append(comments, *comment)
 	}
 
 	// Combine summary and poem
 	finalSummary := summary
 	if poem != "" {
-		finalSummary += "\n\n---\n\n**And now, a little poem about your changes 🌪️✨**\n" + poem
+		finalSummary += "\n\n---\n\n" + notices.Render(language, "poem_intro", nil) + "\n" + poem
 	}
 
 	// Add Cyclone branding if not present
-	finalSummary = "## 🌪️ Cyclone AI Code Review\n\n" + finalSummary
+	finalSummary = reviewHeader(language) + finalSummary
 
 	return ReviewResult{
 		Summary:  finalSummary,
@@ -43,6 +61,18 @@ func (ai *AIClient) parseClaudeResponse(claudeText, diff string) ReviewResult {
 	}
 }
 
+// ErrUnparsableResponse is the error of reviews whose response had neither a summary nor comments,
+// e.g. because Claude didn't follow the output format
+var ErrUnparsableResponse = errors.New("could not parse Claude's response")
+
+// parseError returns ErrUnparsableResponse if nothing could be parsed from a response
+func parseError(claudeText string, result ReviewResult) error {
+	if len(result.Comments) == 0 && !strings.Contains(claudeText, "SUMMARY:") {
+		return ErrUnparsableResponse
+	}
+	return nil
+}
+
 // extractSection extracts content between $$ delimiters for a given section
 func (ai *AIClient) extractSection(text, sectionHeader string) string {
 	// Find the section start
@@ -71,7 +101,7 @@ func (ai *AIClient) extractSection(text, sectionHeader string) string {
 }
 
 // parsePRCommentBlock parses a single PR_COMMENT block into a ReviewComment
-func (ai *AIClient) parsePRCommentBlock(block string) *ReviewComment {
+func (ai *AIClient) parsePRCommentBlock(block string, categories []config.CommentCategory) *ReviewComment {
 	// Find the content between $$ delimiters
 	startDelim := strings.Index(block, "$$")
 	if startDelim == -1 {
@@ -107,10 +137,38 @@ func (ai *AIClient) parsePRCommentBlock(block string) *ReviewComment {
 	}
 
 	// The categoryPart contains: "emoji **category**:"
+	categoryPart, category := categorize(categoryPart, categories)
 	return &ReviewComment{
-		Path: file,
-		Line: lineNum,
-		Side: "RIGHT",
-		Body: fmt.Sprintf("%s\n\n%s", categoryPart, content),
+		Path:     file,
+		Line:     lineNum,
+		Side:     "RIGHT",
+		Body:     fmt.Sprintf("%s\n\n%s", categoryPart, content),
+		Category: category,
 	}
 }
+
+// categorize finds the configured category a comment header starts with and rewrites the
+// header to use the category's configured emoji and spelling. Headers with an unknown
+// category are kept as written, with an empty category name.
+func categorize(categoryPart string, categories []config.CommentCategory) (string, string) {
+	start := strings.Index(categoryPart, "**")
+	if start == -1 {
+		return categoryPart, ""
+	}
+	end := strings.Index(categoryPart[start+2:], "**")
+	if end == -1 {
+		return categoryPart, ""
+	}
+	name := strings.TrimSpace(categoryPart[start+2 : start+2+end])
+	// Further prefixes such as a focus area ("🔒 **security**:") follow the category
+	rest := strings.TrimPrefix(strings.TrimSpace(categoryPart[start+2+end+2:]), ":")
+
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, name) {
+			return strings.TrimSpace(category.Prefix() + " " + strings.TrimSpace(rest)), category.Name
+		}
+	}
+
+	log.Printf("PR_COMMENT uses unknown category %q", name)
+	return categoryPart, ""
+}
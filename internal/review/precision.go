@@ -0,0 +1,34 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"cyclone/internal/config"
+)
+
+// buildPathPrecisionGuidelines assembles the guidelines of every path precision override
+// that applies to at least one file in the diff, in configuration order
+func buildPathPrecisionGuidelines(diff string, repoConfig *config.RepositoryConfig) string {
+	if len(repoConfig.PathPrecision) == 0 {
+		return ""
+	}
+
+	applied := make(map[string]bool)
+	for _, section := range splitDiffSections(diff) {
+		if override := repoConfig.PathPrecisionFor(section.filename); override != nil {
+			applied[override.Path] = true
+		}
+	}
+
+	var sections []string
+	for _, override := range repoConfig.PathPrecision {
+		if !applied[override.Path] {
+			continue
+		}
+		// Only report each pattern once, even if it is configured twice
+		delete(applied, override.Path)
+		sections = append(sections, fmt.Sprintf("Files matching `%s`:\n%s", override.Path, override.GetGuidelines()))
+	}
+	return strings.Join(sections, "\n\n")
+}
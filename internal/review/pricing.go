@@ -0,0 +1,49 @@
+package review
+
+import "strings"
+
+// ModelPricing holds the list price of a model in USD per million tokens
+type ModelPricing struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// modelPricing maps model name prefixes to their list prices
+var modelPricing = []struct {
+	prefix  string
+	pricing ModelPricing
+}{
+	{"claude-opus-4", ModelPricing{InputPerMTok: 15, OutputPerMTok: 75}},
+	{"claude-sonnet-4", ModelPricing{InputPerMTok: 3, OutputPerMTok: 15}},
+	{"claude-3-7-sonnet", ModelPricing{InputPerMTok: 3, OutputPerMTok: 15}},
+	{"claude-3-5-sonnet", ModelPricing{InputPerMTok: 3, OutputPerMTok: 15}},
+	{"claude-3-5-haiku", ModelPricing{InputPerMTok: 0.8, OutputPerMTok: 4}},
+	{"claude-3-haiku", ModelPricing{InputPerMTok: 0.25, OutputPerMTok: 1.25}},
+}
+
+// batchDiscount is the price multiplier for requests sent through the Message Batches API
+const batchDiscount = 0.5
+
+// GetModelPricing returns the pricing for a model, or false if the model is unknown
+func GetModelPricing(model string) (ModelPricing, bool) {
+	for _, entry := range modelPricing {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.pricing, true
+		}
+	}
+	return ModelPricing{}, false
+}
+
+// Cost computes the USD cost of the tokens consumed by an AI call
+func (u Usage) Cost() float64 {
+	pricing, ok := GetModelPricing(u.Model)
+	if !ok {
+		return 0
+	}
+
+	cost := (float64(u.InputTokens)*pricing.InputPerMTok + float64(u.OutputTokens)*pricing.OutputPerMTok) / 1_000_000
+	if u.Batch {
+		cost *= batchDiscount
+	}
+	return cost
+}
@@ -0,0 +1,201 @@
+package review
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"cyclone/internal/config"
+	"cyclone/prompts"
+)
+
+// PromptData holds the variables available to prompt templates
+type PromptData struct {
+	Title              string
+	Body               string
+	Precision          string
+	PathPrecision      string // Guidelines of path precision overrides matching files in the diff
+	Tone               string
+	Poem               bool   // Whether the review ends with a poem
+	Language           string // Natural language of the review, empty for English
+	Diff               string
+	CustomPrompt       string
+	LanguageGuidelines string
+	StyleGuide         string   // Summary of the team's style guides, see config.RepositoryConfig.StyleGuides
+	Promoted           []string // Conventions learned from the team's feedback, see LearnConventions
+	Demoted            []string // Kinds of findings the team dismisses, see LearnConventions
+	Examples           []config.CommentExample
+	Categories         []config.CommentCategory // Prefixes line comments are labelled with
+	AddedLinesOnly     bool                     // Line comments may only be placed on added lines, not on context lines
+	SuspectedInjection bool                     // The title or description seems to address instructions to the reviewer
+}
+
+// Prompt template names, embedded from the prompts package
+const (
+	systemPromptName = "system-prompt.txt"
+	userPromptName   = "user-prompt.txt"
+)
+
+// promptFuncs are the helper functions available to prompt templates
+var promptFuncs = template.FuncMap{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+}
+
+// defaultTemplates holds the embedded prompt templates, parsed once at startup so a broken default fails loudly
+var defaultTemplates = template.Must(newPromptTemplate("defaults").ParseFS(prompts.FS, "*.txt"))
+
+// newPromptTemplate creates an empty prompt template with Cyclone's helper functions
+func newPromptTemplate(name string) *template.Template {
+	return template.New(name).Funcs(promptFuncs).Option("missingkey=error")
+}
+
+// unversionedPrompt is recorded for templates without a version tag
+const unversionedPrompt = "unversioned"
+
+// promptVersionPattern matches the version tag at the top of a template, e.g. {{- /* version: 3 */ -}}
+var promptVersionPattern = regexp.MustCompile(`\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/\s*-?\}\}`)
+
+// loadPromptTemplate renders the named prompt template and returns it together with its version.
+// A file with the same name in the external prompts directory overrides the embedded default;
+// if the override cannot be used, the embedded default is used instead. When the client runs a
+// prompt experiment variant, "<name>.<variant>.txt" is preferred over the base template.
+func (ai *AIClient) loadPromptTemplate(name string, data PromptData) (string, string) {
+	candidates := []string{name}
+	if ai.promptVariant != "" {
+		candidates = []string{variantFileName(name, ai.promptVariant), name}
+	}
+
+	for _, candidate := range candidates {
+		if ai.promptsDir != "" {
+			path := filepath.Join(ai.promptsDir, candidate)
+			if tmpl, version, ok := ai.prompts.get(path); ok {
+				var builder strings.Builder
+				err := tmpl.Execute(&builder, data)
+				if err == nil {
+					return builder.String(), version
+				}
+				log.Printf("Invalid prompt template %s, using embedded default: %v", path, err)
+			}
+		}
+
+		tmpl := defaultTemplates.Lookup(candidate)
+		if tmpl == nil {
+			continue
+		}
+
+		var builder strings.Builder
+		if err := tmpl.Execute(&builder, data); err != nil {
+			// Embedded templates only reference PromptData fields, so this is a programming error
+			log.Printf("Error rendering embedded prompt template %s: %v", candidate, err)
+			continue
+		}
+
+		content, _ := prompts.FS.ReadFile(candidate)
+		return builder.String(), promptVersion(string(content))
+	}
+
+	log.Printf("No usable prompt template found for %s", name)
+	return "", unversionedPrompt
+}
+
+// variantFileName returns the template name of an experiment variant, e.g. system-prompt.b.txt
+func variantFileName(name, variant string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + variant + ext
+}
+
+// promptVersion extracts the version tag of a template
+func promptVersion(content string) string {
+	if match := promptVersionPattern.FindStringSubmatch(content); match != nil {
+		return match[1]
+	}
+	return unversionedPrompt
+}
+
+// promptCache holds the parsed templates of the external prompts directory. Files are
+// re-parsed only when their modification time or size changes, so edits are picked up by
+// the next review without a restart.
+type promptCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedPrompt
+}
+
+// cachedPrompt is the last successfully parsed version of a template file
+type cachedPrompt struct {
+	modTime time.Time
+	size    int64
+	tmpl    *template.Template // nil if the file has never parsed successfully
+	version string
+}
+
+// newPromptCache creates an empty template cache
+func newPromptCache() *promptCache {
+	return &promptCache{entries: make(map[string]*cachedPrompt)}
+}
+
+// get returns the parsed template at path and its version, reloading it if the file changed.
+// If an edited file fails to parse, the previously loaded version keeps being served.
+func (c *promptCache) get(path string) (*template.Template, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entries[path]
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if entry != nil {
+			log.Printf("Prompt template %s was removed, using embedded default", path)
+			delete(c.entries, path)
+		}
+		return nil, "", false
+	}
+	if err != nil {
+		log.Printf("Could not read prompt template %s: %v", path, err)
+		if entry == nil || entry.tmpl == nil {
+			return nil, "", false
+		}
+		return entry.tmpl, entry.version, true
+	}
+
+	if entry != nil && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.tmpl, entry.version, entry.tmpl != nil
+	}
+
+	if entry == nil {
+		entry = &cachedPrompt{}
+		c.entries[path] = entry
+	}
+	// Remember the stat even if parsing fails so a broken file is only reported once
+	entry.modTime = info.ModTime()
+	entry.size = info.Size()
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		var tmpl *template.Template
+		tmpl, err = newPromptTemplate(path).Parse(string(content))
+		if err == nil {
+			reloaded := entry.tmpl != nil
+			entry.tmpl = tmpl
+			entry.version = promptVersion(string(content))
+			if reloaded {
+				log.Printf("Reloaded prompt template %s (version %s)", path, entry.version)
+			}
+			return entry.tmpl, entry.version, true
+		}
+	}
+
+	if entry.tmpl != nil {
+		log.Printf("Invalid prompt template %s, keeping previous version %s: %v", path, entry.version, err)
+		return entry.tmpl, entry.version, true
+	}
+	log.Printf("Invalid prompt template %s, using embedded default: %v", path, err)
+	return nil, "", false
+}
@@ -0,0 +1,159 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CodeOwnersPaths are where GitHub looks for a CODEOWNERS file, in the order it does
+var CodeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeOwnersRule is a line of a CODEOWNERS file
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string // "@user" or "@org/team"; email owners are left out
+}
+
+// CodeOwners are the rules of a CODEOWNERS file. For each path the last matching rule applies.
+type CodeOwners []codeOwnersRule
+
+// ParseCodeOwners parses a CODEOWNERS file, skipping comments and lines it can't read
+func ParseCodeOwners(content []byte) CodeOwners {
+	var rules CodeOwners
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		rule := codeOwnersRule{pattern: pattern}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "@") {
+				rule.owners = append(rule.owners, owner)
+			}
+		}
+		// A rule without owners still applies: it leaves its files unowned
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Owners returns the owners of a file, nil if it has none
+func (c CodeOwners) Owners(path string) []string {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].pattern.MatchString(path) {
+			return c[i].owners
+		}
+	}
+	return nil
+}
+
+// codeOwnersPattern compiles a CODEOWNERS pattern, which follows gitignore: patterns with a
+// slash other than a trailing one are relative to the repository root, others match at any
+// depth, and a pattern matching a directory matches everything in it
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if directory {
+		expr.WriteString("/.*$")
+	} else {
+		expr.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(expr.String())
+}
+
+// SuggestedReviewer is a person or team suggested to review a pull request
+type SuggestedReviewer struct {
+	Login        string // User login, or "org/team" for teams
+	Team         bool
+	OwnedFiles   int // Changed files it is a code owner of
+	ChangedFiles int // Changed files it changed recently
+}
+
+// score weighs code ownership over recent changes
+func (r SuggestedReviewer) score() int {
+	return 2*r.OwnedFiles + r.ChangedFiles
+}
+
+// SuggestReviewers ranks who is most familiar with the files a pull request changes: their
+// code owners and the recent authors of each file, given by path. Logins in exclude, such as
+// the pull request's author, are left out. Users rank before teams of the same score.
+func SuggestReviewers(files []string, owners CodeOwners, recentAuthors map[string][]string, exclude map[string]bool, count int) []SuggestedReviewer {
+	candidates := make(map[string]*SuggestedReviewer)
+	candidate := func(login string, team bool) *SuggestedReviewer {
+		entry, ok := candidates[strings.ToLower(login)]
+		if !ok {
+			entry = &SuggestedReviewer{Login: login, Team: team}
+			candidates[strings.ToLower(login)] = entry
+		}
+		return entry
+	}
+
+	for _, file := range files {
+		for _, owner := range owners.Owners(file) {
+			login := strings.TrimPrefix(owner, "@")
+			if !exclude[strings.ToLower(login)] {
+				candidate(login, strings.Contains(login, "/")).OwnedFiles++
+			}
+		}
+		for _, login := range recentAuthors[file] {
+			if !exclude[strings.ToLower(login)] {
+				candidate(login, false).ChangedFiles++
+			}
+		}
+	}
+
+	ranked := make([]SuggestedReviewer, 0, len(candidates))
+	for _, entry := range candidates {
+		ranked = append(ranked, *entry)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score() != ranked[j].score() {
+			return ranked[i].score() > ranked[j].score()
+		}
+		if ranked[i].Team != ranked[j].Team {
+			return !ranked[i].Team
+		}
+		return ranked[i].Login < ranked[j].Login
+	})
+	if len(ranked) > count {
+		ranked = ranked[:count]
+	}
+	return ranked
+}
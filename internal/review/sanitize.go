@@ -0,0 +1,51 @@
+package review
+
+import (
+	"log"
+	"regexp"
+	"strings"
+)
+
+// delimiterTagPattern matches the tags that wrap untrusted content in our prompts, so
+// content cannot close its own block and smuggle text outside of it
+var delimiterTagPattern = regexp.MustCompile(`(?i)<(\s*/?\s*(?:pr_title|pr_description|code_changes|draft_comments|terraform_plan|static_signals|style_guide|learned_conventions|review_feedback)\b)`)
+
+// injectionPatterns match common attempts to give the reviewer instructions from inside a PR
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[^.\n]{0,40}\b(previous|prior|above|earlier|all|system|your)\b[^.\n]{0,20}\b(instructions?|prompts?|rules|guidelines)\b`),
+	regexp.MustCompile(`(?i)\byou are now\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|real)\s+(system\s+)?instructions\s*:`),
+	regexp.MustCompile(`(?i)\bdo not (report|flag|mention|comment on)\b[^.\n]{0,40}\b(issues?|problems?|bugs?|vulnerabilit(y|ies))\b`),
+	regexp.MustCompile(`(?i)</?\s*(system|assistant|human)\s*>`),
+	regexp.MustCompile(`(?im)^\s*(system|assistant|human)\s*:`),
+}
+
+// escapeDelimiters neutralizes prompt delimiter tags inside untrusted content
+func escapeDelimiters(content string) string {
+	return delimiterTagPattern.ReplaceAllString(content, "&lt;$1")
+}
+
+// detectInjection returns the first suspected prompt-injection phrase in the given fields, or "" if none
+func detectInjection(fields ...string) string {
+	for _, field := range fields {
+		for _, pattern := range injectionPatterns {
+			if match := pattern.FindString(field); match != "" {
+				return strings.TrimSpace(match)
+			}
+		}
+	}
+	return ""
+}
+
+// sanitizePromptData escapes untrusted PR content and flags suspected injection attempts.
+// Only the title and description are scanned; diffs legitimately contain prompt-like text.
+func sanitizePromptData(data *PromptData) {
+	if match := detectInjection(data.Title, data.Body); match != "" {
+		log.Printf("Possible prompt injection in PR title/description: %q", match)
+		data.SuspectedInjection = true
+	}
+
+	data.Title = escapeDelimiters(data.Title)
+	data.Body = escapeDelimiters(data.Body)
+	data.Diff = escapeDelimiters(data.Diff)
+}
@@ -0,0 +1,256 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// SCMProvider is what reviewing pull requests needs from a code host. GitHubClient,
+// GitLabClient, AzureDevOpsClient and GerritClient implement it, so the bot works with pull
+// requests, merge requests and changes alike. owner and repo name a repository the way the
+// review configuration does, number the pull request within it.
+type SCMProvider interface {
+	// GetPullRequest fetches a pull request. Code hosts that don't report its size leave
+	// ChangedFiles, Additions and Deletions zero; ListFiles has them.
+	GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error)
+
+	// ListFiles fetches the files a pull request changes, with their patches
+	ListFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error)
+
+	// GetDiff fetches the diff of a pull request sent to the AI, see FormatDiff
+	GetDiff(ctx context.Context, owner, repo string, number int, ignorePaths []string) (string, error)
+
+	// GetFileContent fetches a file from the repository's default branch, nil if it doesn't exist
+	GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error)
+
+	// PostReview posts a review with its line comments and returns its ID, 0 if the code host
+	// doesn't give reviews one
+	PostReview(ctx context.Context, owner, repo string, number int, review ReviewResult) (int64, error)
+
+	// PostComment posts a comment on a pull request, e.g. a skip notice
+	PostComment(ctx context.Context, owner, repo string, number int, body string) error
+
+	// ListLabels fetches the names of a pull request's labels
+	ListLabels(ctx context.Context, owner, repo string, number int) ([]string, error)
+
+	// AddLabels adds labels to a pull request, creating those the repository doesn't have yet
+	AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error
+}
+
+// The types below are what the bot knows of a code host's objects. Their JSON tags follow
+// GitHub's webhook payloads, which they are decoded from.
+
+// User is a code host account
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User", or "Bot" for apps
+}
+
+// Repository is a code host repository
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"` // e.g. "acme/api"
+	Owner         User   `json:"owner"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// Label is a label of a pull request; hashtags on Gerrit
+type Label struct {
+	Name string `json:"name"`
+}
+
+// PullRequest is a pull request, or a merge request or change on code hosts calling it that
+type PullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"` // e.g. "open", "closed"
+	Draft     bool      `json:"draft"`
+	Merged    bool      `json:"merged"`
+	HTMLURL   string    `json:"html_url"`
+	User      User      `json:"user"`
+	Labels    []Label   `json:"labels"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	MergedAt  time.Time `json:"merged_at"` // Zero unless merged
+
+	Head struct {
+		SHA string `json:"sha"`
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		SHA  string      `json:"sha"`
+		Ref  string      `json:"ref"`
+		Repo *Repository `json:"repo"`
+	} `json:"base"`
+
+	ChangedFiles int `json:"changed_files"`
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+
+	Mergeable      *bool  `json:"mergeable"`       // Nil while the code host is still computing it
+	MergeableState string `json:"mergeable_state"` // e.g. "clean", "blocked" or "dirty" for conflicts
+
+	RequestedReviewers []User `json:"requested_reviewers"`
+	RequestedTeams     []Team `json:"requested_teams"`
+}
+
+// Team is a team of a code host organization
+type Team struct {
+	Slug string `json:"slug"` // e.g. "payments-team"
+}
+
+// HasLabel reports whether the pull request carries the given label
+func (pr *PullRequest) HasLabel(name string) bool {
+	for _, label := range pr.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelNames returns the names of the pull request's labels
+func (pr *PullRequest) LabelNames() []string {
+	names := make([]string, len(pr.Labels))
+	for i, label := range pr.Labels {
+		names[i] = label.Name
+	}
+	return names
+}
+
+// ChangedFile is a file changed by a pull request or between two commits
+type ChangedFile struct {
+	Filename         string
+	PreviousFilename string // Set for renamed files
+	Status           string // e.g. "added", "modified", "removed", "renamed"
+	Patch            string // Unified diff hunks, empty for binary files and some large ones
+	Additions        int
+	Deletions        int
+}
+
+// Changes is the number of lines the file adds and deletes
+func (f ChangedFile) Changes() int {
+	return f.Additions + f.Deletions
+}
+
+// PullRequestComment is a line comment in a pull request's review threads
+type PullRequestComment struct {
+	ID                  int64  `json:"id"`
+	InReplyTo           int64  `json:"in_reply_to_id"`         // Thread root, 0 for roots
+	PullRequestReviewID int64  `json:"pull_request_review_id"` // Review the comment was posted with
+	Path                string `json:"path"`
+	Line                int    `json:"line"`
+	Body                string `json:"body"`
+	DiffHunk            string `json:"diff_hunk"`
+	HTMLURL             string `json:"html_url"`
+	User                User   `json:"user"`
+	AuthorAssociation   string `json:"author_association"` // e.g. "MEMBER" or "CONTRIBUTOR"
+}
+
+// Issue is an issue or, if PullRequestLinks is set, a pull request as the issues API lists it
+type Issue struct {
+	Number           int       `json:"number"`
+	Title            string    `json:"title"`
+	Body             string    `json:"body"`
+	State            string    `json:"state"`
+	HTMLURL          string    `json:"html_url"`
+	User             User      `json:"user"`
+	Labels           []Label   `json:"labels"`
+	CreatedAt        time.Time `json:"created_at"`
+	PullRequestLinks *struct{} `json:"pull_request"`
+}
+
+// IsPullRequest reports whether the issue is a pull request
+func (i *Issue) IsPullRequest() bool {
+	return i != nil && i.PullRequestLinks != nil
+}
+
+// IssueComment is a comment in a pull request's or issue's conversation
+type IssueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User User   `json:"user"`
+}
+
+// Milestone is a repository milestone
+type Milestone struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Release is a repository release
+type Release struct {
+	ID              int64     `json:"id"`
+	TagName         string    `json:"tag_name"`
+	Name            string    `json:"name"`
+	TargetCommitish string    `json:"target_commitish"` // Branch or commit the tag is created from
+	Body            string    `json:"body"`             // Release notes
+	HTMLURL         string    `json:"html_url"`
+	Draft           bool      `json:"draft"`
+	Prerelease      bool      `json:"prerelease"`
+	PublishedAt     time.Time `json:"published_at"`
+}
+
+// Comparison is how two commits differ
+type Comparison struct {
+	Status       string // "ahead", "behind", "identical" or "diverged"
+	MergeBaseSHA string // Latest common ancestor of the two commits
+	Files        []ChangedFile
+}
+
+// FormatDiff builds the diff sent to the AI from the files a pull request changes, leaving out
+// binary and very large files and those matching any of the ignore patterns. name refers to
+// the pull request in logs, e.g. "PR #12".
+func FormatDiff(files []ChangedFile, name string, ignorePaths []string) string {
+	var diffBuilder strings.Builder
+	for _, file := range files {
+		// Skip binary files and very large files
+		if file.Patch == "" || file.Changes() > 500 {
+			continue
+		}
+
+		// Additional check for binary files by file extension
+		if isBinaryFile(file.Filename) {
+			continue
+		}
+
+		if pattern := matchingPattern(ignorePaths, file.Filename); pattern != "" {
+			log.Printf("Ignoring %s in %s (matches %q)", file.Filename, name, pattern)
+			continue
+		}
+
+		diffBuilder.WriteString(fmt.Sprintf("=== %s ===\n", file.Filename))
+		diffBuilder.WriteString(file.Patch)
+		diffBuilder.WriteString("\n\n")
+	}
+	return diffBuilder.String()
+}
+
+// APIErrorStatus returns the API ("claude", "github", "gitlab", "azure_devops" or "gerrit")
+// and status code of a failed API call, or an empty API for other errors
+func APIErrorStatus(err error) (string, int) {
+	var apiErr *APIError
+	var gitlabErr *GitLabAPIError
+	var azureErr *AzureDevOpsAPIError
+	var gerritErr *GerritAPIError
+	switch {
+	case errors.As(err, &apiErr):
+		return strings.ToLower(apiErr.API), apiErr.StatusCode
+	case errors.As(err, &gitlabErr):
+		return "gitlab", gitlabErr.StatusCode
+	case errors.As(err, &azureErr):
+		return "azure_devops", azureErr.StatusCode
+	case errors.As(err, &gerritErr):
+		return "gerrit", gerritErr.StatusCode
+	}
+	if statusCode := githubErrorStatus(err); statusCode != 0 {
+		return "github", statusCode
+	}
+	return "", 0
+}
@@ -0,0 +1,46 @@
+package review
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SQL statements in string literals of code, e.g. "SELECT id FROM users WHERE ..."
+var sqlStatement = regexp.MustCompile("(?i)[\"'`](\\s*(WITH\\s+\\w+\\s+AS\\s*\\(\\s*)?)(SELECT\\s.+\\sFROM|INSERT\\s+INTO|UPDATE\\s+[\\w.\"`]+\\s+SET|DELETE\\s+FROM|MERGE\\s+INTO)\\b")
+
+// Calls that run raw SQL or splice it into query builders, across common drivers and ORMs
+var sqlCall = regexp.MustCompile(`\.(Query|QueryRow|QueryContext|QueryRowContext|Exec|ExecContext|Raw|NamedExec|NamedQuery|execute|executemany|executescript|raw|extra|whereRaw|selectRaw|orderByRaw|havingRaw|find_by_sql|query|\$queryRawUnsafe|\$executeRawUnsafe)\(`)
+
+// Extensions of source files in programming languages, which the SQL and performance
+// passes check
+var sourceExtensions = map[string]bool{
+	".go": true, ".py": true, ".rb": true, ".js": true, ".ts": true, ".jsx": true, ".tsx": true,
+	".java": true, ".kt": true, ".cs": true, ".php": true, ".rs": true, ".scala": true,
+}
+
+// containsSQL reports whether a file's changes add SQL queries: query files such as those
+// of sqlc, and code whose added lines hold SQL statements in string literals or call raw SQL
+// and query builder APIs. Migrations are left to the migration checklist.
+func containsSQL(filename, fileDiff string) bool {
+	if isMigrationFile(filename) {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == ".sql" {
+		return true
+	}
+	if !sourceExtensions[ext] {
+		return false
+	}
+
+	for _, line := range strings.Split(fileDiff, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		if sqlStatement.MatchString(line) || sqlCall.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
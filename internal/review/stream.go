@@ -0,0 +1,120 @@
+package review
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// progressLogInterval controls how often (in characters) stream progress is logged
+const progressLogInterval = 2000
+
+// apiUsage is the token usage object reported by Claude API
+type apiUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// totalInput returns all input tokens, including cached ones
+func (u apiUsage) totalInput() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// streamEvent represents a single server-sent event from the Claude streaming API
+type streamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage apiUsage `json:"usage"`
+	} `json:"message"`
+	Usage        apiUsage `json:"usage"`
+	ContentBlock struct {
+		Type string `json:"type"`
+	} `json:"content_block"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readClaudeStream consumes a Claude SSE stream and accumulates the generated text
+// and token usage. Thinking blocks are dropped so only the visible review reaches
+// the parser. If the stream aborts before message_stop, the text received so far
+// is returned together with the error so callers can salvage partial output.
+func readClaudeStream(body io.Reader) (string, Usage, error) {
+	var text strings.Builder
+	var usage Usage
+	lastLogged := 0
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// Skip "event:" lines, comments and blank separators
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			log.Printf("Skipping malformed stream event: %v", err)
+			continue
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.totalInput()
+			usage.OutputTokens = event.Message.Usage.OutputTokens
+
+		case "content_block_start":
+			if event.ContentBlock.Type == "thinking" {
+				log.Printf("Claude is thinking before writing the review")
+			}
+
+		case "content_block_delta":
+			// thinking_delta and signature_delta belong to thinking blocks and are discarded
+			if event.Delta.Type == "text_delta" {
+				text.WriteString(event.Delta.Text)
+			}
+			if text.Len()-lastLogged >= progressLogInterval {
+				log.Printf("Claude stream progress: %d characters received", text.Len())
+				lastLogged = text.Len()
+			}
+
+		case "message_delta":
+			// Output tokens are reported cumulatively
+			usage.OutputTokens = event.Usage.OutputTokens
+			if event.Delta.StopReason == "max_tokens" {
+				log.Printf("Claude stream hit max_tokens - review may be incomplete")
+			}
+
+		case "message_stop":
+			log.Printf("Claude stream completed: %d characters received", text.Len())
+			return text.String(), usage, nil
+
+		case "error":
+			if event.Error != nil {
+				return text.String(), usage, fmt.Errorf("stream error %s: %s", event.Error.Type, event.Error.Message)
+			}
+			return text.String(), usage, fmt.Errorf("stream error")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return text.String(), usage, fmt.Errorf("stream aborted: %w", err)
+	}
+
+	return text.String(), usage, fmt.Errorf("stream ended without message_stop")
+}
@@ -0,0 +1,161 @@
+package review
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"cyclone/internal/config"
+)
+
+// charsPerToken is a rough estimate used when the count_tokens endpoint is unavailable
+const charsPerToken = 4
+
+// countTokensRequest is the request body of the count_tokens endpoint
+type countTokensRequest struct {
+	Model    string          `json:"model"`
+	System   string          `json:"system,omitempty"`
+	Thinking *ThinkingConfig `json:"thinking,omitempty"`
+	Messages []ClaudeMessage `json:"messages"`
+}
+
+// diffSection is the patch of a single file within a PR diff
+type diffSection struct {
+	filename string
+	content  string
+}
+
+// CountTokens measures the input tokens of a request using the count_tokens endpoint
+func (ai *AIClient) CountTokens(reqBody ClaudeRequest) (int, error) {
+	payload := countTokensRequest{
+		Model:    reqBody.Model,
+		System:   reqBody.System,
+		Thinking: reqBody.Thinking,
+		Messages: reqBody.Messages,
+	}
+
+	req, err := ai.newClaudeHTTPRequest("POST", claudeAPIBaseURL+"/messages/count_tokens", payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := ai.doJSON(req, &resp); err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+
+	return resp.InputTokens, nil
+}
+
+// measureTokens counts the input tokens of a request, falling back to a character-based estimate
+func (ai *AIClient) measureTokens(reqBody ClaudeRequest) int {
+	tokens, err := ai.CountTokens(reqBody)
+	if err == nil {
+		return tokens
+	}
+
+	log.Printf("Token counting unavailable, estimating instead: %v", err)
+	return requestChars(reqBody) / charsPerToken
+}
+
+// prepareReviewRequest builds a review request that fits the repository's token budget.
+// When the prompt is too large, whole file sections are dropped from the diff - largest
+// first, ties broken by filename - so the same PR is always trimmed the same way. It returns
+// the request, the diff it contains and its measured input tokens.
+func (ai *AIClient) prepareReviewRequest(diff, title, body string, repoConfig *config.RepositoryConfig) (ClaudeRequest, string, int) {
+	budget := repoConfig.GetTokenBudget()
+	reqBody := ai.buildClaudeRequest(diff, title, body, repoConfig)
+	tokens := ai.measureTokens(reqBody)
+	if tokens <= budget {
+		log.Printf("Review prompt uses %d of %d budgeted tokens", tokens, budget)
+		return reqBody, diff, tokens
+	}
+
+	sections := splitDiffSections(diff)
+	sort.SliceStable(sections, func(i, j int) bool {
+		if len(sections[i].content) != len(sections[j].content) {
+			return len(sections[i].content) > len(sections[j].content)
+		}
+		return sections[i].filename < sections[j].filename
+	})
+
+	var omitted []string
+	trimmedDiff := diff
+	for attempt := 0; attempt < config.MAX_BUDGET_ATTEMPTS && tokens > budget && len(sections) > 0; attempt++ {
+		// Estimate how many characters we need to drop based on the measured ratio
+		tokensPerChar := float64(tokens) / float64(requestChars(reqBody))
+		excessChars := int(float64(tokens-budget) / tokensPerChar)
+
+		removed := 0
+		for removed < excessChars && len(sections) > 0 {
+			removed += len(sections[0].content)
+			omitted = append(omitted, sections[0].filename)
+			sections = sections[1:]
+		}
+
+		trimmedDiff = joinDiffSections(sections, omitted)
+		reqBody = ai.buildClaudeRequest(trimmedDiff, title, body, repoConfig)
+		tokens = ai.measureTokens(reqBody)
+	}
+
+	if tokens > budget {
+		log.Printf("Review prompt still uses %d tokens after trimming (budget %d)", tokens, budget)
+	} else {
+		log.Printf("Trimmed %d file(s) from the diff to fit the token budget (%d of %d tokens)", len(omitted), tokens, budget)
+	}
+
+	return reqBody, trimmedDiff, tokens
+}
+
+// splitDiffSections splits a diff built by FormatDiff into per-file sections
+func splitDiffSections(diff string) []diffSection {
+	var sections []diffSection
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		trimmed := strings.TrimSuffix(line, "\n")
+		if strings.HasPrefix(trimmed, "=== ") && strings.HasSuffix(trimmed, " ===") {
+			sections = append(sections, diffSection{
+				filename: strings.TrimSuffix(strings.TrimPrefix(trimmed, "=== "), " ==="),
+			})
+		}
+
+		if len(sections) > 0 {
+			sections[len(sections)-1].content += line
+		}
+	}
+	return sections
+}
+
+// joinDiffSections rebuilds a diff from the kept sections in filename order and notes omitted files
+func joinDiffSections(sections []diffSection, omitted []string) string {
+	kept := append([]diffSection(nil), sections...)
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].filename < kept[j].filename
+	})
+
+	var diffBuilder strings.Builder
+	for _, section := range kept {
+		diffBuilder.WriteString(section.content)
+	}
+
+	if len(omitted) > 0 {
+		diffBuilder.WriteString(fmt.Sprintf("[%d file(s) omitted to stay within the review token budget: %s]\n",
+			len(omitted), strings.Join(omitted, ", ")))
+	}
+
+	return diffBuilder.String()
+}
+
+// requestChars returns the number of prompt characters in a request
+func requestChars(reqBody ClaudeRequest) int {
+	total := len(reqBody.System)
+	for _, m := range reqBody.Messages {
+		total += len(m.Content)
+	}
+	if total == 0 {
+		return 1
+	}
+	return total
+}
@@ -1,19 +1,83 @@
 package review
 
+import (
+	"fmt"
+	"time"
+)
+
 type ReviewComment struct {
-	Path string
-	Line int
-	Body string
-	Side string
+	Path     string
+	Line     int
+	Body     string
+	Side     string
+	Category string // Name of the comment's category, empty if it used none of the configured ones
 }
 
 type ReviewResult struct {
 	Summary  string
 	Comments []ReviewComment
+	// Conversation holds the system prompt and diff so follow-ups can reuse the review context
+	Conversation  Conversation
+	Usage         Usage
+	PromptVersion string    // Versions of the prompt templates used, e.g. "system@3,user@1"
+	PromptVariant string    // Prompt experiment variant, empty for the control prompts
+	HeadSHA       string    // Commit of the pull request the review was written for
+	SummaryOnly   bool      // Line comments were left out by design, e.g. for forced reviews of large PRs
+	StartedAt     time.Time // When reviewing the pull request began, for latency stats
+	Timings       Timings
+	Err           error // Why generating the review failed; the summary is then a placeholder
+}
+
+// APIError is an unsuccessful response of an API Cyclone calls
+type APIError struct {
+	API        string // e.g. "Claude"
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API returned status %d", e.API, e.StatusCode)
+}
+
+// Timings are how long the stages of a review took, zero for stages it didn't go through.
+// The AI client times the stages it runs; the others are timed by the caller.
+type Timings struct {
+	DiffFetch  time.Duration // Fetching the PR's diff from the code host
+	Context    time.Duration // Assembling the prompt: templates, injected context and token budget trimming
+	Generation time.Duration // The Claude API call
+	Parsing    time.Duration // Parsing the response into a summary and line comments
+	Critique   time.Duration // The self-critique pass
+	Posting    time.Duration // Posting the review to GitHub
+}
+
+// Slowest returns the longer duration of each stage, e.g. for reviews generated in parallel
+func (t Timings) Slowest(other Timings) Timings {
+	return Timings{
+		DiffFetch:  max(t.DiffFetch, other.DiffFetch),
+		Context:    max(t.Context, other.Context),
+		Generation: max(t.Generation, other.Generation),
+		Parsing:    max(t.Parsing, other.Parsing),
+		Critique:   max(t.Critique, other.Critique),
+		Posting:    max(t.Posting, other.Posting),
+	}
+}
+
+// Usage reports the tokens consumed by a single AI call
+type Usage struct {
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Batch        bool // Billed at the discounted Message Batches rate
+}
+
+// Conversation is the context of a review that follow-ups about it reuse
+type Conversation struct {
+	System string
+	Diff   string // The diff as reviewed, which the hunks of line comments are taken from
 }
 
 type PRSizeCheck struct {
 	ShouldReview   bool
 	WarningMessage string
 	SkipMessage    string
+	SkipReason     string
 }
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// resolveAWS reads an AWS Secrets Manager secret, e.g. "prod/cyclone#github_token". Credentials and
+// region come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, the optional AWS_SESSION_TOKEN and
+// AWS_REGION (or AWS_DEFAULT_REGION).
+func resolveAWS(ctx context.Context, ref string) (string, error) {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if accessKey == "" || secretKey == "" || region == "" {
+		return "", fmt.Errorf("AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_REGION must be set")
+	}
+
+	secretID, key := splitKey(ref)
+	if secretID == "" {
+		return "", fmt.Errorf("expected awssm://<secret-id>[#<json-key>]")
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+
+	host := fmt.Sprintf("secretsmanager.%s.amazonaws.com", region)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	signAWSRequest(req, body, host, region, "secretsmanager", accessKey, secretKey, time.Now().UTC())
+
+	var secret struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doJSON(req, &secret); err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		return jsonField(secret.SecretString, key)
+	}
+	return secret.SecretString, nil
+}
+
+// signAWSRequest adds an AWS Signature Version 4 Authorization header to a request
+// whose only other signed headers are Content-Type, X-Amz-Target and the optional session token
+func signAWSRequest(req *http.Request, body []byte, host, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-date:%s\nx-amz-target:%s\n",
+		req.Header.Get("Content-Type"), host, amzDate, req.Header.Get("X-Amz-Target"))
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		signedHeaders = "content-type;host;x-amz-date;x-amz-security-token;x-amz-target"
+		canonicalHeaders = fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-date:%s\nx-amz-security-token:%s\nx-amz-target:%s\n",
+			req.Header.Get("Content-Type"), host, amzDate, token, req.Header.Get("X-Amz-Target"))
+	}
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := fmt.Sprintf("%s\n/\n\n%s\n%s\n%s", req.Method, canonicalHeaders, signedHeaders, hex.EncodeToString(payloadHash[:]))
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := fmt.Sprintf("AWS4-HMAC-SHA256\n%s\n%s\n%s", amzDate, scope, hex.EncodeToString(requestHash[:]))
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
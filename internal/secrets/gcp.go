@@ -0,0 +1,76 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// gcpMetadataTokenURL serves access tokens for the attached service account on GCP
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// resolveGCP reads a GCP Secret Manager secret version, e.g. "projects/my-project/secrets/github-token".
+// It authenticates with GOOGLE_OAUTH_ACCESS_TOKEN or, on GCP, the metadata server.
+func resolveGCP(ctx context.Context, ref string) (string, error) {
+	name, key := splitKey(ref)
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return "", fmt.Errorf("expected gcpsm://projects/<project>/secrets/<secret>[/versions/<version>]")
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := gcpAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doJSON(req, &version); err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if key != "" {
+		return jsonField(string(data), key)
+	}
+	return string(data), nil
+}
+
+// gcpAccessToken returns an OAuth access token for Secret Manager
+func gcpAccessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &token); err != nil {
+		return "", fmt.Errorf("no GOOGLE_OAUTH_ACCESS_TOKEN and the metadata server is unavailable: %w", err)
+	}
+	return token.AccessToken, nil
+}
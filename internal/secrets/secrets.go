@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Reference schemes pointing to a secrets manager instead of a literal value
+const (
+	SchemeVault = "vault://" // vault://<mount>/<path>#<key> (KV version 2)
+	SchemeAWS   = "awssm://" // awssm://<secret-id>[#<json-key>]
+	SchemeGCP   = "gcpsm://" // gcpsm://projects/<project>/secrets/<secret>[/versions/<version>]
+)
+
+// requestTimeout bounds a single call to a secrets manager
+const requestTimeout = 30 * time.Second
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// IsReference reports whether a configuration value points to a secrets manager
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, SchemeVault) ||
+		strings.HasPrefix(value, SchemeAWS) ||
+		strings.HasPrefix(value, SchemeGCP)
+}
+
+// Resolve fetches the current value of a secret reference
+func Resolve(ctx context.Context, ref string) (string, error) {
+	var value string
+	var err error
+
+	switch {
+	case strings.HasPrefix(ref, SchemeVault):
+		value, err = resolveVault(ctx, strings.TrimPrefix(ref, SchemeVault))
+	case strings.HasPrefix(ref, SchemeAWS):
+		value, err = resolveAWS(ctx, strings.TrimPrefix(ref, SchemeAWS))
+	case strings.HasPrefix(ref, SchemeGCP):
+		value, err = resolveGCP(ctx, strings.TrimPrefix(ref, SchemeGCP))
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q", ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("secret %s is empty", ref)
+	}
+	return value, nil
+}
+
+// splitKey splits "path#key" into its parts; key is empty without a "#"
+func splitKey(ref string) (string, string) {
+	path, key, _ := strings.Cut(ref, "#")
+	return path, key
+}
+
+// jsonField extracts a string field from a JSON object secret
+func jsonField(secret, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, can't select key %q", key)
+	}
+
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %q", key)
+	}
+	return value, nil
+}
+
+// doJSON sends a request and decodes a JSON response body into v
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// resolveVault reads a key from a HashiCorp Vault KV version 2 secret, e.g. "secret/cyclone#github_token".
+// The server and token come from VAULT_ADDR, VAULT_TOKEN and the optional VAULT_NAMESPACE.
+func resolveVault(ctx context.Context, ref string) (string, error) {
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	path, key := splitKey(ref)
+	mount, secretPath, ok := strings.Cut(path, "/")
+	if !ok || key == "" {
+		return "", fmt.Errorf("expected vault://<mount>/<path>#<key>")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/%s/data/%s", addr, mount, secretPath), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := doJSON(req, &secret); err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string key %q", key)
+	}
+	return value, nil
+}
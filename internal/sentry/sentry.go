@@ -0,0 +1,161 @@
+// Package sentry reports errors and panics to Sentry, or a service speaking its protocol such
+// as GlitchTip, through the store API
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"cyclone/internal/logging"
+)
+
+// requestTimeout bounds sending a single event
+const requestTimeout = 10 * time.Second
+
+// Client sends events to a project. A nil *Client drops all events, so callers don't need to
+// check whether error tracking is configured.
+type Client struct {
+	storeURL    string
+	auth        string // X-Sentry-Auth header
+	environment string
+	release     string
+	serverName  string
+	httpClient  *http.Client
+}
+
+// Event is an error report
+type Event struct {
+	Kind  string            // Groups events, e.g. "review_failed"
+	Err   error             // The error, or nil for panics
+	Panic interface{}       // The recovered value of a panic
+	Stack []byte            // Stack trace of a panic, as returned by debug.Stack
+	Tags  map[string]string // Searchable tags, e.g. org, repo, pr and status_code
+}
+
+// New creates a client for a DSN such as https://<key>@o0.ingest.sentry.io/<project>
+func New(dsn, environment, release string) (*Client, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.User == nil || parsed.User.Username() == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN, expected https://<key>@<host>/<project>")
+	}
+
+	// The project ID is the last path segment; anything before it is a path prefix
+	path := strings.TrimSuffix(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=cyclone/1.0, sentry_key=%s", parsed.User.Username())
+	if secret, ok := parsed.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	hostname, _ := os.Hostname()
+	return &Client{
+		storeURL:    fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, path[:slash], projectID),
+		auth:        auth,
+		environment: environment,
+		release:     release,
+		serverName:  hostname,
+		httpClient:  &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// CaptureError reports an error in the background
+func (c *Client) CaptureError(kind string, err error, tags map[string]string) {
+	if c == nil || err == nil {
+		return
+	}
+	go c.send(Event{Kind: kind, Err: err, Tags: tags})
+}
+
+// CapturePanic reports a recovered panic, waiting until it is sent in case the process exits next
+func (c *Client) CapturePanic(value interface{}, stack []byte, tags map[string]string) {
+	if c == nil {
+		return
+	}
+	c.send(Event{Kind: "panic", Panic: value, Stack: stack, Tags: tags})
+}
+
+// send posts an event, logging failures since there is nowhere else to report them
+func (c *Client) send(event Event) {
+	body, err := json.Marshal(c.payload(event))
+	if err != nil {
+		log.Printf("Error encoding Sentry event: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.storeURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error sending Sentry event: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Error sending Sentry event: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Error sending Sentry event: status %d", resp.StatusCode)
+	}
+}
+
+// payload builds the JSON body of the store API
+func (c *Client) payload(event Event) map[string]interface{} {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	level, value := "error", ""
+	extra := map[string]interface{}{}
+	if event.Panic != nil {
+		level, value = "fatal", logging.Redact(fmt.Sprint(event.Panic))
+		extra["stack"] = string(event.Stack)
+	} else {
+		value = logging.Redact(event.Err.Error())
+	}
+
+	payload := map[string]interface{}{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+		"platform":    "go",
+		"level":       level,
+		"logger":      "cyclone",
+		"server_name": c.serverName,
+		"environment": c.environment,
+		"release":     c.release,
+		"exception": map[string]interface{}{
+			"values": []map[string]string{{"type": event.Kind, "value": value}},
+		},
+		"tags":  event.Tags,
+		"extra": extra,
+	}
+
+	// Error messages include PR numbers, so errors are grouped by kind and status instead.
+	// Panics keep the default grouping by message.
+	if event.Panic == nil {
+		fingerprint := []string{event.Kind}
+		if event.Tags["provider"] != "" {
+			fingerprint = append(fingerprint, event.Tags["provider"], event.Tags["status_code"])
+		}
+		payload["fingerprint"] = fingerprint
+	}
+	return payload
+}
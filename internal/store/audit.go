@@ -0,0 +1,76 @@
+package store
+
+import "time"
+
+const auditFile = "audit.json"
+
+// Where configuration changes come from
+const (
+	AuditSourceAdminAPI       = "admin_api"        // Changes through /api/admin/...
+	AuditSourceReload         = "reload"           // Reloads of the review configuration file or remote source
+	AuditSourceRepoConfigFile = "repo_config_file" // Changes of a repository's own config file
+	AuditSourceInstallation   = "installation"     // Repositories registered when added to a GitHub App installation
+)
+
+// AuditRecord is a change of the review configuration. Audit records are never pruned.
+type AuditRecord struct {
+	ID     int       `json:"id"`
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Actor  string    `json:"actor"`          // Who made the change, e.g. "github:octocat" or "admin-token"
+	Org    string    `json:"org,omitempty"`  // Empty for changes of the whole configuration
+	Repo   string    `json:"repo,omitempty"` // Set for repository entries and config files
+	Action string    `json:"action"`         // What changed, e.g. "update organization"
+	Before string    `json:"before"`         // The configuration before, with credentials redacted; empty if added
+	After  string    `json:"after"`          // And after; empty if removed
+	Diff   string    `json:"diff"`           // Unified diff hunks from Before to After, empty if they differ too much
+}
+
+// RecordAudit appends a configuration change to the audit log
+func (s *Store) RecordAudit(rec AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(auditFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.ID = len(s.audit) + 1
+
+	s.audit = append(s.audit, rec)
+	return s.save(auditFile, s.audit)
+}
+
+// ListAudit returns the audit records matching the filter, oldest first
+func (s *Store) ListAudit(filter UsageFilter) []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(auditFile)
+
+	var records []AuditRecord
+	for _, rec := range s.audit {
+		if filter.matches(UsageRecord{Time: rec.Time, Org: rec.Org, Repo: rec.Repo}) {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// LatestAudit returns the most recent audit record of a source and repository, or nil
+func (s *Store) LatestAudit(source, org, repo string) *AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(auditFile)
+
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		rec := s.audit[i]
+		if rec.Source == source && rec.Org == org && rec.Repo == repo {
+			return &rec
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Reviews queued for the Message Batches API are kept in batchesDir, one file per request,
+// until their result is posted. The process that queued or resumed a review owns it and
+// touches its file on every poll; files left untouched for longer than the claim timeout, e.g.
+// those of a process that was restarted or crashed, are claimed by another one.
+const batchesDir = "batches"
+
+// validBatchID matches the custom IDs of batch requests, which name their files
+var validBatchID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// BatchItem is a PR review queued for, or submitted in, a message batch
+type BatchItem struct {
+	CustomID       string          `json:"custom_id"`
+	BatchID        string          `json:"batch_id,omitempty"` // Empty until the request is submitted
+	Org            string          `json:"org"`
+	Repo           string          `json:"repo"`
+	PRNumber       int             `json:"pr_number"`
+	Diff           string          `json:"diff"`
+	WarningMessage string          `json:"warning_message,omitempty"`
+	PromptVariant  string          `json:"prompt_variant,omitempty"`
+	HeadSHA        string          `json:"head_sha"`
+	StartedAt      time.Time       `json:"started_at"`
+	DiffFetch      time.Duration   `json:"diff_fetch"`
+	Context        time.Duration   `json:"context"`
+	DryRun         bool            `json:"dry_run,omitempty"`
+	RuleComments   []BatchComment  `json:"rule_comments,omitempty"`
+	Reviewers      *BatchReviewers `json:"reviewers,omitempty"`
+	Request        json.RawMessage `json:"request"` // The batch request as sent to the API
+}
+
+// BatchComment is a comment posted along with a batch review
+type BatchComment struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Body     string `json:"body"`
+	Side     string `json:"side,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// BatchReviewers are the reviewers named at the end of a batch review's summary
+type BatchReviewers struct {
+	Reviewers []BatchReviewer `json:"reviewers"`
+	Request   bool            `json:"request,omitempty"`
+	Language  string          `json:"language,omitempty"`
+}
+
+// BatchReviewer is a suggested reviewer of a batch review
+type BatchReviewer struct {
+	Login        string `json:"login"`
+	Team         bool   `json:"team,omitempty"`
+	OwnedFiles   int    `json:"owned_files,omitempty"`
+	ChangedFiles int    `json:"changed_files,omitempty"`
+}
+
+// SaveBatchItem stores a queued batch review, or updates it once it was submitted
+func (s *Store) SaveBatchItem(item BatchItem) error {
+	if !validBatchID.MatchString(item.CustomID) {
+		return fmt.Errorf("invalid batch request ID %q", item.CustomID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dir, batchesDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	return s.save(filepath.Join(batchesDir, item.CustomID+".json"), item)
+}
+
+// DeleteBatchItem removes a batch review once its result was handled
+func (s *Store) DeleteBatchItem(customID string) error {
+	if !validBatchID.MatchString(customID) {
+		return fmt.Errorf("invalid batch request ID %q", customID)
+	}
+	err := os.Remove(filepath.Join(s.dir, batchesDir, customID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete batch request %s: %w", customID, err)
+	}
+	return nil
+}
+
+// TouchBatchItems renews the claim of the process owning the given batch reviews
+func (s *Store) TouchBatchItems(customIDs []string) error {
+	now := time.Now()
+	for _, customID := range customIDs {
+		if !validBatchID.MatchString(customID) {
+			continue
+		}
+		err := os.Chtimes(filepath.Join(s.dir, batchesDir, customID+".json"), now, now)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to renew batch request %s: %w", customID, err)
+		}
+	}
+	return nil
+}
+
+// ClaimStaleBatchItems takes over the batch reviews whose owner hasn't touched them for longer
+// than timeout. Each is claimed by exactly one of the processes sharing the data directory.
+func (s *Store) ClaimStaleBatchItems(timeout time.Duration) ([]BatchItem, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, batchesDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch requests: %w", err)
+	}
+
+	var claimed []BatchItem
+	cutoff := time.Now().Add(-timeout)
+	for _, entry := range entries {
+		// Skip files save is still writing and those another process is claiming
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		item, err := s.claimBatchItem(entry.Name(), cutoff)
+		if err != nil {
+			return claimed, err
+		}
+		if item != nil {
+			claimed = append(claimed, *item)
+		}
+	}
+
+	return claimed, nil
+}
+
+// claimBatchItem moves a stale batch review aside while renewing its claim, so no other
+// process can claim it at the same time. It returns nil if another process got there first.
+func (s *Store) claimBatchItem(name string, cutoff time.Time) (*BatchItem, error) {
+	path := filepath.Join(s.dir, batchesDir, name)
+	claiming := path + ".claiming"
+	err := os.Rename(path, claiming)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim batch request %s: %w", name, err)
+	}
+
+	// Another process claimed it between listing and renaming: give it back untouched
+	if info, err := os.Stat(claiming); err == nil && info.ModTime().After(cutoff) {
+		return nil, os.Rename(claiming, path)
+	}
+
+	var item BatchItem
+	if err := s.load(filepath.Join(batchesDir, name+".claiming"), &item); err != nil {
+		// A corrupt entry would otherwise be claimed forever
+		os.Remove(claiming)
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(claiming, now, now); err != nil {
+		return nil, fmt.Errorf("failed to claim batch request %s: %w", name, err)
+	}
+	if err := os.Rename(claiming, path); err != nil {
+		return nil, fmt.Errorf("failed to claim batch request %s: %w", name, err)
+	}
+	return &item, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"sort"
+
+	"cyclone/internal/config"
+)
+
+const managedConfigFile = "managed-config.json"
+
+// ManagedOrganizations returns the organizations configured through the admin API, sorted by name
+func (s *Store) ManagedOrganizations() []config.OrganizationConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(managedConfigFile)
+
+	orgs := make([]config.OrganizationConfig, 0, len(s.managedOrgs))
+	for _, org := range s.managedOrgs {
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].Name < orgs[j].Name
+	})
+	return orgs
+}
+
+// SaveManagedOrganization creates or replaces an organization configured through the admin API
+func (s *Store) SaveManagedOrganization(org config.OrganizationConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(managedConfigFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.managedOrgs[org.Name] = org
+	return s.save(managedConfigFile, s.managedOrgs)
+}
+
+// DeleteManagedOrganization removes an organization configured through the admin API.
+// It reports false if the organization wasn't managed.
+func (s *Store) DeleteManagedOrganization(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(managedConfigFile)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if _, ok := s.managedOrgs[name]; !ok {
+		return false, nil
+	}
+
+	delete(s.managedOrgs, name)
+	return true, s.save(managedConfigFile, s.managedOrgs)
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+const conventionsFile = "conventions.json"
+
+// MaxConventionSignals is how many of a repository's most recent feedback signals are kept
+// to learn its conventions from
+const MaxConventionSignals = 200
+
+// Kinds of feedback the conventions memory learns from
+const (
+	SignalAccepted   = "accepted"   // A Cyclone comment whose lines changed before the merge
+	SignalDismissed  = "dismissed"  // A Cyclone comment whose lines didn't change before the merge
+	SignalPushback   = "pushback"   // A maintainer's reply to a Cyclone comment
+	SignalMaintainer = "maintainer" // A maintainer's own review comment
+)
+
+// ConventionSignal is a piece of feedback on a repository's reviews
+type ConventionSignal struct {
+	Kind     string    `json:"kind"`
+	Time     time.Time `json:"time"`
+	PRNumber int       `json:"pr_number"`
+	Path     string    `json:"path,omitempty"`
+	Category string    `json:"category,omitempty"` // Category of Cyclone's comment
+	Comment  string    `json:"comment"`            // Cyclone's comment, or the maintainer's own
+	Reply    string    `json:"reply,omitempty"`    // The maintainer's reply to Cyclone's comment, for pushback
+}
+
+// Conventions is the conventions memory of a repository: what its reviews learned from the
+// team's feedback, and the recent signals it was learned from
+type Conventions struct {
+	Org       string             `json:"org"`
+	Repo      string             `json:"repo"`
+	Promoted  []string           `json:"promoted"` // Conventions the maintainers enforce
+	Demoted   []string           `json:"demoted"`  // Kinds of findings the team dismisses
+	Signals   []ConventionSignal `json:"signals"`  // Oldest first
+	Pending   int                `json:"pending"`  // Signals recorded since the conventions were last learned
+	LearnedAt time.Time          `json:"learned_at,omitempty"`
+}
+
+// conventionsKey returns the key of a repository's conventions memory
+func conventionsKey(org, repo string) string {
+	return fmt.Sprintf("%s/%s", org, repo)
+}
+
+// GetConventions returns a copy of a repository's conventions memory, or nil if there is none
+func (s *Store) GetConventions(org, repo string) *Conventions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(conventionsFile)
+
+	conventions, ok := s.conventions[conventionsKey(org, repo)]
+	if !ok {
+		return nil
+	}
+	copied := *conventions
+	copied.Promoted = append([]string(nil), conventions.Promoted...)
+	copied.Demoted = append([]string(nil), conventions.Demoted...)
+	copied.Signals = append([]ConventionSignal(nil), conventions.Signals...)
+	return &copied
+}
+
+// AddConventionSignals records feedback on a repository's reviews, keeping the most recent
+// MaxConventionSignals, and returns how many signals are pending
+func (s *Store) AddConventionSignals(org, repo string, signals ...ConventionSignal) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(conventionsFile)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	key := conventionsKey(org, repo)
+	conventions, ok := s.conventions[key]
+	if !ok {
+		conventions = &Conventions{Org: org, Repo: repo}
+		s.conventions[key] = conventions
+	}
+
+	now := time.Now()
+	for _, signal := range signals {
+		if signal.Time.IsZero() {
+			signal.Time = now
+		}
+		conventions.Signals = append(conventions.Signals, signal)
+	}
+	if excess := len(conventions.Signals) - MaxConventionSignals; excess > 0 {
+		conventions.Signals = append([]ConventionSignal(nil), conventions.Signals[excess:]...)
+	}
+	conventions.Pending = min(conventions.Pending+len(signals), len(conventions.Signals))
+	return conventions.Pending, s.save(conventionsFile, s.conventions)
+}
+
+// SetLearnedConventions replaces what a repository's reviews learned, from the given number of
+// pending signals
+func (s *Store) SetLearnedConventions(org, repo string, promoted, demoted []string, learnedFrom int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(conventionsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	conventions, ok := s.conventions[conventionsKey(org, repo)]
+	if !ok {
+		return fmt.Errorf("no conventions memory for %s/%s", org, repo)
+	}
+	conventions.Promoted = promoted
+	conventions.Demoted = demoted
+	conventions.Pending = max(conventions.Pending-learnedFrom, 0)
+	conventions.LearnedAt = time.Now()
+	return s.save(conventionsFile, s.conventions)
+}
+
+// DeleteConventions forgets a repository's conventions memory and its signals
+func (s *Store) DeleteConventions(org, repo string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(conventionsFile)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	key := conventionsKey(org, repo)
+	if _, ok := s.conventions[key]; !ok {
+		return false, nil
+	}
+	delete(s.conventions, key)
+	return true, s.save(conventionsFile, s.conventions)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+const conversationsFile = "conversations.json"
+
+// Message is a single turn of an AI conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Conversation holds the context of a review or the message history of a follow-up thread.
+// Reviews keep their summary and line comments with the diff hunks they are on rather than
+// the prompt with the full diff. Thread conversations only store their own turns and are
+// replayed on top of the review conversation they belong to.
+type Conversation struct {
+	Key       string            `json:"key"`
+	ReviewID  int64             `json:"review_id,omitempty"`
+	System    string            `json:"system,omitempty"`
+	Summary   string            `json:"summary,omitempty"`
+	Comments  []ReviewedComment `json:"comments,omitempty"`
+	Messages  []Message         `json:"messages"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ReviewedComment is a posted line comment of a review with the diff hunk it is on
+type ReviewedComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Body string `json:"body"`
+	Hunk string `json:"hunk,omitempty"`
+}
+
+// ReviewKey returns the conversation key for the review of a pull request
+func ReviewKey(owner, repo string, prNumber int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, prNumber)
+}
+
+// ThreadKey returns the conversation key for a thread under a review.
+// Root ID 0 is used for follow-up commands on the PR itself.
+func ThreadKey(reviewKey string, rootCommentID int64) string {
+	return fmt.Sprintf("%s/thread/%d", reviewKey, rootCommentID)
+}
+
+// GetConversation returns a copy of the conversation stored under key, or nil if there is none
+func (s *Store) GetConversation(key string) *Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(conversationsFile)
+
+	conv, ok := s.conversations[key]
+	if !ok {
+		return nil
+	}
+
+	copied := *conv
+	copied.Comments = append([]ReviewedComment(nil), conv.Comments...)
+	copied.Messages = append([]Message(nil), conv.Messages...)
+	return &copied
+}
+
+// SaveConversation stores (or replaces) a conversation
+func (s *Store) SaveConversation(conv Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(conversationsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	conv.UpdatedAt = time.Now()
+	s.conversations[conv.Key] = &conv
+	return s.save(conversationsFile, s.conversations)
+}
+
+// AppendMessages appends turns to a conversation, creating it if needed
+func (s *Store) AppendMessages(key string, messages ...Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(conversationsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	conv, ok := s.conversations[key]
+	if !ok {
+		conv = &Conversation{Key: key}
+		s.conversations[key] = conv
+	}
+
+	conv.Messages = append(conv.Messages, messages...)
+	conv.UpdatedAt = time.Now()
+	return s.save(conversationsFile, s.conversations)
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deliveriesDir holds one file per received webhook delivery. Unlike the other records, the
+// delivery log isn't cached: the server records deliveries and workers their outcomes.
+const deliveriesDir = "deliveries"
+
+// Outcomes of webhook deliveries
+const (
+	DeliveryIgnored   = "ignored"   // The delivery triggers no work
+	DeliveryPending   = "pending"   // Its work is queued or running
+	DeliveryCompleted = "completed" // Its work is done, e.g. the PR was reviewed
+	DeliverySkipped   = "skipped"   // The PR was deliberately not reviewed, e.g. because it is too large
+	DeliveryFailed    = "failed"
+)
+
+// DeliveryRecord summarizes a webhook delivery: what it was about, what Cyclone decided to do
+// and how that went
+type DeliveryRecord struct {
+	ID         string          `json:"id"` // X-GitHub-Delivery header
+	Time       time.Time       `json:"time"`
+	Event      string          `json:"event"`
+	Action     string          `json:"action,omitempty"`
+	Org        string          `json:"org,omitempty"`
+	Repo       string          `json:"repo,omitempty"`
+	PRNumber   int             `json:"pr_number,omitempty"`
+	Decision   string          `json:"decision"` // What the delivery triggers, or why it triggers nothing
+	Outcome    string          `json:"outcome"`
+	Detail     string          `json:"detail,omitempty"` // Skip reason or error
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Replays    int             `json:"replays,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"` // Kept for failed deliveries, so they can be replayed
+}
+
+// SaveDelivery creates or replaces the record of a webhook delivery
+func (s *Store) SaveDelivery(rec DeliveryRecord) error {
+	if !validDeliveryID.MatchString(rec.ID) {
+		return fmt.Errorf("invalid delivery ID %q", rec.ID)
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dir, deliveriesDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create delivery log directory: %w", err)
+	}
+	return s.save(filepath.Join(deliveriesDir, rec.ID+".json"), rec)
+}
+
+// UpdateDelivery applies a change to the record of a webhook delivery. It reports false if
+// there is no record of the delivery.
+func (s *Store) UpdateDelivery(id string, update func(*DeliveryRecord)) (bool, error) {
+	if !validDeliveryID.MatchString(id) {
+		return false, fmt.Errorf("invalid delivery ID %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rec *DeliveryRecord
+	if err := s.load(filepath.Join(deliveriesDir, id+".json"), &rec); err != nil {
+		return false, err
+	}
+	if rec == nil {
+		return false, nil
+	}
+	update(rec)
+	return true, s.save(filepath.Join(deliveriesDir, id+".json"), rec)
+}
+
+// GetDelivery returns the record of a webhook delivery, or nil if there is none
+func (s *Store) GetDelivery(id string) (*DeliveryRecord, error) {
+	if !validDeliveryID.MatchString(id) {
+		return nil, fmt.Errorf("invalid delivery ID %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rec *DeliveryRecord
+	if err := s.load(filepath.Join(deliveriesDir, id+".json"), &rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ListDeliveries returns the delivery records matching the filter, oldest first
+func (s *Store) ListDeliveries(filter UsageFilter) ([]DeliveryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, deliveriesDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery log: %w", err)
+	}
+
+	var records []DeliveryRecord
+	for _, entry := range entries {
+		// Skip files save is still writing
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		var rec DeliveryRecord
+		if err := s.load(filepath.Join(deliveriesDir, entry.Name()), &rec); err != nil {
+			return nil, err
+		}
+		if filter.matches(UsageRecord{Time: rec.Time, Org: rec.Org, Repo: rec.Repo}) {
+			records = append(records, rec)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// pruneDeliveries deletes delivery records received before cutoff and drops the payloads of
+// those received before payloadCutoff, if set. Callers must hold s.mu.
+func (s *Store) pruneDeliveries(cutoff, payloadCutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, deliveriesDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list delivery log: %w", err)
+	}
+
+	pruned := 0
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		name := filepath.Join(deliveriesDir, entry.Name())
+		var rec DeliveryRecord
+		if err := s.load(name, &rec); err != nil {
+			return pruned, err
+		}
+
+		switch {
+		case rec.Time.Before(cutoff):
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+				return pruned, fmt.Errorf("failed to delete delivery record %s: %w", entry.Name(), err)
+			}
+			pruned++
+		case !payloadCutoff.IsZero() && rec.Time.Before(payloadCutoff) && rec.Payload != nil:
+			rec.Payload = nil
+			if err := s.save(name, rec); err != nil {
+				return pruned, err
+			}
+		}
+	}
+	return pruned, nil
+}
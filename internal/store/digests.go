@@ -0,0 +1,103 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// digestsDir holds a marker file per digest sent. Creating it is atomic, so of several
+// processes sharing the data directory only one sends each digest.
+const digestsDir = "digests"
+
+// validDigestOrg matches the organization names GitHub allows
+var validDigestOrg = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// validDigestRepo matches the repository names GitHub allows
+var validDigestRepo = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ClaimDigest reserves sending an organization's digest for the period starting at start. It
+// reports false if the digest was claimed before, by this or another process.
+func (s *Store) ClaimDigest(org, frequency string, start time.Time) (bool, error) {
+	name, err := digestMarker(org, frequency, start)
+	if err != nil {
+		return false, err
+	}
+	return s.claimMarker(name)
+}
+
+// ReleaseDigest gives up the claim of a digest that couldn't be sent, so it is tried again
+func (s *Store) ReleaseDigest(org, frequency string, start time.Time) error {
+	name, err := digestMarker(org, frequency, start)
+	if err != nil {
+		return err
+	}
+	return s.releaseMarker(name)
+}
+
+// ClaimHealthDigest reserves posting a repository's health digest for the week starting at
+// start, like ClaimDigest
+func (s *Store) ClaimHealthDigest(org, repo string, start time.Time) (bool, error) {
+	name, err := healthDigestMarker(org, repo, start)
+	if err != nil {
+		return false, err
+	}
+	return s.claimMarker(name)
+}
+
+// ReleaseHealthDigest gives up the claim of a health digest that couldn't be posted
+func (s *Store) ReleaseHealthDigest(org, repo string, start time.Time) error {
+	name, err := healthDigestMarker(org, repo, start)
+	if err != nil {
+		return err
+	}
+	return s.releaseMarker(name)
+}
+
+// claimMarker creates a marker file in the digest directory, reporting false if it exists
+func (s *Store) claimMarker(name string) (bool, error) {
+	path := filepath.Join(s.dir, digestsDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create digest directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest %s: %w", name, err)
+	}
+	return true, file.Close()
+}
+
+// releaseMarker removes a marker file from the digest directory
+func (s *Store) releaseMarker(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, digestsDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release digest %s: %w", name, err)
+	}
+	return nil
+}
+
+// digestMarker names the marker file of a digest, e.g. "octo-org-weekly-2025-06-02"
+func digestMarker(org, frequency string, start time.Time) (string, error) {
+	if !validDigestOrg.MatchString(org) {
+		return "", fmt.Errorf("invalid organization name %q", org)
+	}
+	return fmt.Sprintf("%s-%s-%s", org, frequency, start.UTC().Format("2006-01-02")), nil
+}
+
+// healthDigestMarker names the marker file of a health digest, e.g.
+// "health/octo-org/payments-service-2025-06-02"
+func healthDigestMarker(org, repo string, start time.Time) (string, error) {
+	if !validDigestOrg.MatchString(org) {
+		return "", fmt.Errorf("invalid organization name %q", org)
+	}
+	if !validDigestRepo.MatchString(repo) || repo == "." || repo == ".." {
+		return "", fmt.Errorf("invalid repository name %q", repo)
+	}
+	return filepath.Join("health", org, fmt.Sprintf("%s-%s", repo, start.UTC().Format("2006-01-02"))), nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// lockFile is a no-op where flock isn't available, so only one process may use a data
+// directory there
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile releases the lock of lockFile
+func unlockFile(file *os.File) {}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Queued webhook deliveries wait in queuePendingDir until a worker claims one by moving it to
+// queueProcessingDir, and are deleted once processed. Renames are atomic, so any number of
+// processes sharing the data directory can produce and consume the queue.
+const (
+	queuePendingDir    = "queue/pending"
+	queueProcessingDir = "queue/processing"
+)
+
+// QueuedWebhook is a webhook delivery claimed from the queue
+type QueuedWebhook struct {
+	WebhookDelivery
+	file string // Name within the queue directories
+}
+
+// EnqueueWebhook adds a webhook delivery to the queue consumed by ClaimWebhook
+func (s *Store) EnqueueWebhook(delivery WebhookDelivery) error {
+	if !validDeliveryID.MatchString(delivery.ID) {
+		return fmt.Errorf("invalid delivery ID %q", delivery.ID)
+	}
+	if delivery.Time.IsZero() {
+		delivery.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dir, queuePendingDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+	// The zero-padded timestamp keeps the directory listing in arrival order
+	file := fmt.Sprintf("%020d-%s.json", delivery.Time.UnixNano(), delivery.ID)
+	return s.save(filepath.Join(queuePendingDir, file), delivery)
+}
+
+// ClaimWebhook takes the oldest delivery off the queue, or returns nil if the queue is empty.
+// The delivery stays claimed until CompleteWebhook is called or RequeueStaleWebhooks returns it.
+func (s *Store) ClaimWebhook() (*QueuedWebhook, error) {
+	if err := os.MkdirAll(filepath.Join(s.dir, queueProcessingDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, queuePendingDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list webhook queue: %w", err)
+	}
+
+	for _, entry := range entries {
+		// Skip files save is still writing
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		claimed := filepath.Join(s.dir, queueProcessingDir, entry.Name())
+		err := os.Rename(filepath.Join(s.dir, queuePendingDir, entry.Name()), claimed)
+		if errors.Is(err, os.ErrNotExist) {
+			// Another worker claimed it first
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim queued webhook %s: %w", entry.Name(), err)
+		}
+
+		// The modification time marks the claim for RequeueStaleWebhooks
+		now := time.Now()
+		if err := os.Chtimes(claimed, now, now); err != nil {
+			return nil, fmt.Errorf("failed to claim queued webhook %s: %w", entry.Name(), err)
+		}
+
+		job := &QueuedWebhook{file: entry.Name()}
+		if err := s.load(filepath.Join(queueProcessingDir, entry.Name()), &job.WebhookDelivery); err != nil {
+			// A corrupt entry would otherwise be retried forever
+			os.Remove(claimed)
+			return nil, err
+		}
+		return job, nil
+	}
+
+	return nil, nil
+}
+
+// CompleteWebhook removes a processed delivery from the queue
+func (s *Store) CompleteWebhook(job *QueuedWebhook) error {
+	err := os.Remove(filepath.Join(s.dir, queueProcessingDir, job.file))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to complete queued webhook %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// RequeueStaleWebhooks returns deliveries claimed longer than timeout ago to the queue,
+// e.g. those of a worker that crashed, and returns how many it requeued
+func (s *Store) RequeueStaleWebhooks(timeout time.Duration) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, queueProcessingDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list claimed webhooks: %w", err)
+	}
+
+	requeued := 0
+	cutoff := time.Now().Add(-timeout)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		err = os.Rename(filepath.Join(s.dir, queueProcessingDir, entry.Name()), filepath.Join(s.dir, queuePendingDir, entry.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			// Completed or requeued by another worker in the meantime
+			continue
+		}
+		if err != nil {
+			return requeued, fmt.Errorf("failed to requeue webhook %s: %w", entry.Name(), err)
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// QueuedWebhookCount returns how many deliveries are waiting to be claimed
+func (s *Store) QueuedWebhookCount() (int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, queuePendingDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list webhook queue: %w", err)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			count++
+		}
+	}
+	return count, nil
+}
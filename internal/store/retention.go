@@ -0,0 +1,131 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cyclone/internal/config"
+)
+
+// PruneResult counts what Prune removed
+type PruneResult struct {
+	ReviewContents int // Reviews whose summary and comments were dropped
+	Signals        int // Feedback signals of conventions memories, which hold comments too
+	Conversations  int
+	Webhooks       int
+	Deliveries     int // Records of the delivery log, which are kept for config.DELIVERY_LOG_RETENTION
+}
+
+// Prune removes stored content older than the retention policy allows, and the delivery log
+// older than config.DELIVERY_LOG_RETENTION. Review records themselves are kept, only their
+// content is dropped.
+func (s *Store) Prune(policy config.RetentionPolicy, now time.Time) (PruneResult, error) {
+	var result PruneResult
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(reviewsFile, conventionsFile, conversationsFile)
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	if policy.ReviewContent > 0 {
+		cutoff := now.Add(-policy.ReviewContent)
+		for i := range s.reviews {
+			rec := &s.reviews[i]
+			if rec.Time.Before(cutoff) && (rec.Summary != "" || len(rec.DraftComments) > 0) {
+				rec.Summary = ""
+				rec.DraftComments = nil
+				result.ReviewContents++
+			}
+		}
+		if result.ReviewContents > 0 {
+			if err := s.save(reviewsFile, s.reviews); err != nil {
+				return result, err
+			}
+		}
+
+		for _, conventions := range s.conventions {
+			kept := conventions.Signals[:0]
+			for _, signal := range conventions.Signals {
+				if signal.Time.Before(cutoff) {
+					result.Signals++
+					continue
+				}
+				kept = append(kept, signal)
+			}
+			conventions.Signals = kept
+			conventions.Pending = min(conventions.Pending, len(kept))
+		}
+		if result.Signals > 0 {
+			if err := s.save(conventionsFile, s.conventions); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if policy.Conversations > 0 {
+		cutoff := now.Add(-policy.Conversations)
+		for key, conv := range s.conversations {
+			if conv.UpdatedAt.Before(cutoff) {
+				delete(s.conversations, key)
+				result.Conversations++
+			}
+		}
+		if result.Conversations > 0 {
+			if err := s.save(conversationsFile, s.conversations); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	var payloadCutoff time.Time
+	if policy.Webhooks > 0 {
+		payloadCutoff = now.Add(-policy.Webhooks)
+		pruned, err := s.pruneWebhookDeliveries(payloadCutoff)
+		result.Webhooks = pruned
+		if err != nil {
+			return result, err
+		}
+	}
+
+	pruned, err := s.pruneDeliveries(now.Add(-config.DELIVERY_LOG_RETENTION), payloadCutoff)
+	result.Deliveries = pruned
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// pruneWebhookDeliveries deletes webhook deliveries captured before cutoff. Callers must hold s.mu.
+func (s *Store) pruneWebhookDeliveries(cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, webhooksDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list captured webhooks: %w", err)
+	}
+
+	pruned := 0
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		// Deliveries are written once when captured
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, webhooksDir, entry.Name())); err != nil {
+			return pruned, fmt.Errorf("failed to delete captured webhook %s: %w", entry.Name(), err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
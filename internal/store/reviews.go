@@ -0,0 +1,192 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+const reviewsFile = "reviews.json"
+
+// Review verdicts, derived from the categories of a review's line comments
+const (
+	VerdictBlocking = "blocking" // At least one blocking comment
+	VerdictIssues   = "issues"   // Issues, but nothing blocking
+	VerdictComments = "comments" // Only other comments, such as nits and suggestions
+	VerdictClean    = "clean"    // No line comments
+)
+
+// ReviewRecord is a review Cyclone posted on a pull request, or generated in dry run
+type ReviewRecord struct {
+	ID            int       `json:"id"` // Assigned by RecordReview
+	Time          time.Time `json:"time"`
+	Org           string    `json:"org"`
+	Repo          string    `json:"repo"`
+	PRNumber      int       `json:"pr_number"`
+	ReviewID      int64     `json:"review_id"`
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"prompt_version"`
+	PromptVariant string    `json:"prompt_variant,omitempty"`
+	Comments      int       `json:"comments"`
+
+	InputTokens  int            `json:"input_tokens,omitempty"`
+	OutputTokens int            `json:"output_tokens,omitempty"`
+	Categories   map[string]int `json:"categories,omitempty"` // Line comments per category, "" for uncategorized ones
+	Verdict      string         `json:"verdict,omitempty"`
+
+	// Dry-run reviews weren't posted (ReviewID is 0). The content is kept for all reviews.
+	DryRun        bool           `json:"dry_run,omitempty"`
+	Summary       string         `json:"summary,omitempty"`
+	DraftComments []DraftComment `json:"draft_comments,omitempty"`
+
+	HeadSHA   string           `json:"head_sha,omitempty"`   // Commit the review was written for
+	LatencyMs int64            `json:"latency_ms,omitempty"` // From starting the review to posting it, including any wait for batch results
+	StageMs   map[string]int64 `json:"stage_ms,omitempty"`   // Milliseconds per stage the review went through, see Stages
+	Outcome   *ReviewOutcome   `json:"outcome,omitempty"`    // Set once the PR is merged
+}
+
+// Stages of a review, the keys of ReviewRecord.StageMs
+const (
+	StageDiffFetch  = "diff_fetch"
+	StageContext    = "context"
+	StageGeneration = "generation"
+	StageParsing    = "parsing"
+	StageCritique   = "critique"
+	StagePosting    = "posting"
+)
+
+// Stages lists the review stages in the order they run
+var Stages = []string{StageDiffFetch, StageContext, StageGeneration, StageParsing, StageCritique, StagePosting}
+
+// ReviewOutcome records which of a review's line comments were acted upon, i.e. whether the
+// lines they were on changed between the reviewed commit and the merge
+type ReviewOutcome struct {
+	MergedAt  time.Time      `json:"merged_at"`
+	Tracked   map[string]int `json:"tracked"`    // Comments per category whose lines could be followed
+	ActedUpon map[string]int `json:"acted_upon"` // Tracked comments per category whose lines changed
+}
+
+// DraftComment is a line comment of a recorded review
+type DraftComment struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Category string `json:"category,omitempty"`
+	Body     string `json:"body"`
+}
+
+// RecordReview appends a review to the review history
+func (s *Store) RecordReview(rec ReviewRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(reviewsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.ID = s.nextReviewID()
+
+	s.reviews = append(s.reviews, rec)
+	return s.save(reviewsFile, s.reviews)
+}
+
+// HasReview reports whether a review of a PR was recorded, in dry run or not
+func (s *Store) HasReview(org, repo string, prNumber int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(reviewsFile)
+
+	for _, rec := range s.reviews {
+		if rec.Org == org && rec.Repo == repo && rec.PRNumber == prNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// ListReviews returns the reviews matching the filter, oldest first
+func (s *Store) ListReviews(filter UsageFilter) []ReviewRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(reviewsFile)
+
+	var records []ReviewRecord
+	for _, rec := range s.reviews {
+		if filter.matches(UsageRecord{Time: rec.Time, Org: rec.Org, Repo: rec.Repo}) {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// GetReview returns the review with the given ID, or nil if there is none
+func (s *Store) GetReview(id int) *ReviewRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(reviewsFile)
+
+	for _, rec := range s.reviews {
+		if rec.ID == id {
+			return &rec
+		}
+	}
+	return nil
+}
+
+// ListPullRequestReviews returns the reviews of a pull request, oldest first
+func (s *Store) ListPullRequestReviews(org, repo string, prNumber int) []ReviewRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(reviewsFile)
+
+	var records []ReviewRecord
+	for _, rec := range s.reviews {
+		if rec.Org == org && rec.Repo == repo && rec.PRNumber == prNumber {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// SetReviewOutcome records the outcome of the review with the given ID
+func (s *Store) SetReviewOutcome(id int, outcome ReviewOutcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(reviewsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range s.reviews {
+		if s.reviews[i].ID == id {
+			s.reviews[i].Outcome = &outcome
+			return s.save(reviewsFile, s.reviews)
+		}
+	}
+	return fmt.Errorf("review %d not found", id)
+}
+
+// nextReviewID returns the ID for a new review record. Callers must hold s.mu.
+func (s *Store) nextReviewID() int {
+	next := 1
+	for _, rec := range s.reviews {
+		if rec.ID >= next {
+			next = rec.ID + 1
+		}
+	}
+	return next
+}
+
+// assignReviewIDs numbers review records stored before they had IDs, in the order they were recorded
+func (s *Store) assignReviewIDs() {
+	next := s.nextReviewID()
+	for i := range s.reviews {
+		if s.reviews[i].ID == 0 {
+			s.reviews[i].ID = next
+			next++
+		}
+	}
+}
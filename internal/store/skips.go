@@ -0,0 +1,71 @@
+package store
+
+import "time"
+
+const skipsFile = "skips.json"
+
+// Reasons a PR was not reviewed
+const (
+	SkipReasonTooManyFiles     = "too_many_files"
+	SkipReasonTooManyAdditions = "too_many_additions"
+	SkipReasonTooManyChanges   = "too_many_changes"
+	SkipReasonQuotaExceeded    = "quota_exceeded"
+	SkipReasonAllFilesIgnored  = "all_files_ignored"
+	SkipReasonReviewFailed     = "review_failed" // An error, e.g. from the GitHub or Claude API
+)
+
+// SkipRecord is a PR that Cyclone decided not to review, or failed to
+type SkipRecord struct {
+	Time     time.Time `json:"time"`
+	Org      string    `json:"org"`
+	Repo     string    `json:"repo"`
+	PRNumber int       `json:"pr_number"`
+	Reason   string    `json:"reason"`
+}
+
+// RecordSkip appends a skipped PR to the skip log
+func (s *Store) RecordSkip(rec SkipRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(skipsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	s.skips = append(s.skips, rec)
+	return s.save(skipsFile, s.skips)
+}
+
+// CountSkips counts the skipped PRs matching the filter by reason
+func (s *Store) CountSkips(filter UsageFilter) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(skipsFile)
+
+	counts := make(map[string]int)
+	for _, rec := range s.skips {
+		if filter.matches(UsageRecord{Time: rec.Time, Org: rec.Org, Repo: rec.Repo}) {
+			counts[rec.Reason]++
+		}
+	}
+	return counts
+}
+
+// HasSkip reports whether a PR was skipped, or failed to be reviewed, before
+func (s *Store) HasSkip(org, repo string, prNumber int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(skipsFile)
+
+	for _, rec := range s.skips {
+		if rec.Org == org && rec.Repo == repo && rec.PRNumber == prNumber {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cyclone/internal/config"
+)
+
+// Store persists Cyclone's state as JSON files in a data directory. Several processes can share
+// the directory: the files Open loads are read again once another process replaced them, and
+// changes are made under a file lock on the current content, see lockFiles.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+
+	conversations map[string]*Conversation
+	usage         []UsageRecord
+	skips         []SkipRecord
+	reviews       []ReviewRecord
+	managedOrgs   map[string]config.OrganizationConfig // Keyed by organization name
+	audit         []AuditRecord
+	styleGuides   map[string]StyleGuideSummary // Keyed by StyleGuideKey
+	conventions   map[string]*Conventions      // Keyed by "org/repo"
+
+	versions map[string]os.FileInfo // Of the cached files as last read or written, nil if missing
+}
+
+// cachedFiles are the files whose content the store keeps in memory
+var cachedFiles = []string{
+	conversationsFile, usageFile, skipsFile, reviewsFile,
+	managedConfigFile, auditFile, styleGuidesFile, conventionsFile,
+}
+
+// Open opens the store in the given directory, creating it if needed, and loads existing state
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", dir, err)
+	}
+
+	s := &Store{dir: dir, versions: make(map[string]os.FileInfo)}
+	for _, name := range cachedFiles {
+		s.reset(name)
+		if err := s.reload(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// reset empties the field a cached file is loaded into and returns a pointer to it. Callers
+// must hold s.mu.
+func (s *Store) reset(name string) interface{} {
+	switch name {
+	case conversationsFile:
+		s.conversations = make(map[string]*Conversation)
+		return &s.conversations
+	case usageFile:
+		s.usage = nil
+		return &s.usage
+	case skipsFile:
+		s.skips = nil
+		return &s.skips
+	case reviewsFile:
+		s.reviews = nil
+		return &s.reviews
+	case managedConfigFile:
+		s.managedOrgs = make(map[string]config.OrganizationConfig)
+		return &s.managedOrgs
+	case auditFile:
+		s.audit = nil
+		return &s.audit
+	case styleGuidesFile:
+		s.styleGuides = make(map[string]StyleGuideSummary)
+		return &s.styleGuides
+	case conventionsFile:
+		s.conventions = make(map[string]*Conventions)
+		return &s.conventions
+	}
+	panic("store: " + name + " isn't a cached file")
+}
+
+// reload reads a cached file again if another process replaced it since it was last read or
+// written. Callers must hold s.mu.
+func (s *Store) reload(name string) error {
+	info, err := os.Stat(filepath.Join(s.dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if sameVersion(s.versions[name], info) {
+		return nil
+	}
+
+	if err := s.load(name, s.reset(name)); err != nil {
+		return err
+	}
+	if name == reviewsFile {
+		s.assignReviewIDs()
+	}
+	s.versions[name] = info
+	return nil
+}
+
+// refresh reloads the cached files a read uses, keeping what is in memory if one can't be
+// read. Callers must hold s.mu.
+func (s *Store) refresh(names ...string) {
+	for _, name := range names {
+		if err := s.reload(name); err != nil {
+			log.Printf("Error reading %s written by another process - using the state read before: %v", name, err)
+		}
+	}
+}
+
+// lockFiles takes the cross-process locks of cached files and reloads them, so a change is
+// made to their current content. The returned function releases the locks. Callers must hold
+// s.mu until then.
+func (s *Store) lockFiles(names ...string) (func(), error) {
+	var locks []*os.File
+	unlock := func() {
+		for _, lock := range locks {
+			unlockFile(lock)
+			lock.Close()
+		}
+	}
+
+	for _, name := range names {
+		lock, err := os.OpenFile(filepath.Join(s.dir, name+".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+		if err != nil {
+			unlock()
+			return nil, fmt.Errorf("failed to open lock of %s: %w", name, err)
+		}
+		if err := lockFile(lock); err != nil {
+			lock.Close()
+			unlock()
+			return nil, fmt.Errorf("failed to lock %s: %w", name, err)
+		}
+		locks = append(locks, lock)
+	}
+
+	for _, name := range names {
+		if err := s.reload(name); err != nil {
+			unlock()
+			return nil, err
+		}
+	}
+	return unlock, nil
+}
+
+// sameVersion reports whether two stats are of the same version of a file. save replaces files
+// rather than rewriting them, so each version is a new file.
+func sameVersion(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// CheckWritable verifies that files can be written to the data directory
+func (s *Store) CheckWritable() error {
+	file, err := os.CreateTemp(s.dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("data directory %s isn't writable: %w", s.dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// load reads a JSON file from the data directory into v; a missing file is not an error
+func (s *Store) load(name string, v interface{}) error {
+	path := filepath.Join(s.dir, name)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// save atomically writes v as JSON to the data directory. Callers must hold s.mu, and the
+// lock of cached files, see lockFiles.
+func (s *Store) save(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	// A temporary file of its own, as other processes may be writing the same file
+	path := filepath.Join(s.dir, name)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	if _, cached := s.versions[name]; cached {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		s.versions[name] = info
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+const styleGuidesFile = "style_guides.json"
+
+// StyleGuideSummary caches the AI summary of a repository's style guide. It is only redone
+// once the guide's content changes.
+type StyleGuideSummary struct {
+	Key       string    `json:"key"`
+	Hash      string    `json:"hash"` // SHA-256 of the summarized content
+	Summary   string    `json:"summary"`
+	Model     string    `json:"model"`
+	FetchedAt time.Time `json:"fetched_at"` // When the content was last fetched and compared
+	UpdatedAt time.Time `json:"updated_at"` // When the summary was written
+}
+
+// StyleGuideKey returns the cache key of a repository's style guide, given as a path or URL
+func StyleGuideKey(owner, repo, source string) string {
+	return fmt.Sprintf("%s/%s:%s", owner, repo, source)
+}
+
+// GetStyleGuide returns the cached summary of a style guide, or nil if there is none
+func (s *Store) GetStyleGuide(key string) *StyleGuideSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(styleGuidesFile)
+
+	summary, ok := s.styleGuides[key]
+	if !ok {
+		return nil
+	}
+	return &summary
+}
+
+// SaveStyleGuide stores (or replaces) the summary of a style guide
+func (s *Store) SaveStyleGuide(summary StyleGuideSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(styleGuidesFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.styleGuides[summary.Key] = summary
+	return s.save(styleGuidesFile, s.styleGuides)
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const usageFile = "usage.json"
+
+// Kinds of AI calls recorded in the usage ledger
+const (
+	UsageKindReview      = "review"
+	UsageKindBatchReview = "batch_review"
+	UsageKindFollowUp    = "follow_up"
+	UsageKindConsensus   = "consensus" // The second model of a consensus review
+	UsageKindCritique    = "critique"
+	UsageKindDigest      = "digest"
+	UsageKindTriage      = "triage"
+	UsageKindChecklist   = "checklist"
+	UsageKindStyleGuide  = "style_guide"
+	UsageKindConventions = "conventions"
+)
+
+// Grouping keys for usage totals
+const (
+	GroupByNone  = ""
+	GroupByOrg   = "org"
+	GroupByRepo  = "repo"
+	GroupByModel = "model"
+	GroupByDay   = "day"
+	GroupByMonth = "month"
+)
+
+// UsageRecord is a single AI call in the cost ledger
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Org          string    `json:"org"`
+	Repo         string    `json:"repo"`
+	PRNumber     int       `json:"pr_number,omitempty"`
+	Kind         string    `json:"kind"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+}
+
+// UsageFilter narrows usage queries; zero values match everything
+type UsageFilter struct {
+	Org   string
+	Orgs  []string // If not nil, only these organizations
+	Repo  string
+	Since time.Time // Inclusive
+	Until time.Time // Exclusive
+}
+
+// UsageTotals aggregates the usage records sharing a group key
+type UsageTotals struct {
+	Key          string  `json:"key"`
+	Reviews      int     `json:"reviews"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// RecordUsage appends an AI call to the usage ledger
+func (s *Store) RecordUsage(rec UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lockFiles(usageFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	s.usage = append(s.usage, rec)
+	return s.save(usageFile, s.usage)
+}
+
+// ListUsage returns the usage records matching the filter, oldest first
+func (s *Store) ListUsage(filter UsageFilter) []UsageRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(usageFile)
+
+	var records []UsageRecord
+	for _, rec := range s.usage {
+		if filter.matches(rec) {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// SumUsage aggregates the usage records matching the filter by the given group key
+func (s *Store) SumUsage(filter UsageFilter, groupBy string) []UsageTotals {
+	totals := make(map[string]*UsageTotals)
+	for _, rec := range s.ListUsage(filter) {
+		key := usageGroupKey(rec, groupBy)
+		total, ok := totals[key]
+		if !ok {
+			total = &UsageTotals{Key: key}
+			totals[key] = total
+		}
+
+		total.Calls++
+		if rec.Kind == UsageKindReview || rec.Kind == UsageKindBatchReview {
+			total.Reviews++
+		}
+		total.InputTokens += rec.InputTokens
+		total.OutputTokens += rec.OutputTokens
+		total.CostUSD += rec.CostUSD
+	}
+
+	result := make([]UsageTotals, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// matches reports whether a record passes the filter
+func (f UsageFilter) matches(rec UsageRecord) bool {
+	if f.Org != "" && rec.Org != f.Org {
+		return false
+	}
+	if f.Orgs != nil && !containsFold(f.Orgs, rec.Org) {
+		return false
+	}
+	if f.Repo != "" && rec.Repo != f.Repo {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !rec.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// usageGroupKey returns the grouping key of a record
+func usageGroupKey(rec UsageRecord, groupBy string) string {
+	switch groupBy {
+	case GroupByOrg:
+		return rec.Org
+	case GroupByRepo:
+		return rec.Org + "/" + rec.Repo
+	case GroupByModel:
+		return rec.Model
+	case GroupByDay:
+		return rec.Time.UTC().Format("2006-01-02")
+	case GroupByMonth:
+		return rec.Time.UTC().Format("2006-01")
+	default:
+		return "total"
+	}
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// webhooksDir holds one file per captured webhook delivery
+const webhooksDir = "webhooks"
+
+// validDeliveryID matches the delivery IDs GitHub sends (GUIDs) and the ones Cyclone generates
+var validDeliveryID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidDeliveryID reports whether id can name a stored delivery
+func ValidDeliveryID(id string) bool {
+	return validDeliveryID.MatchString(id)
+}
+
+// WebhookDelivery is a captured webhook request that can be replayed
+type WebhookDelivery struct {
+	ID      string          `json:"id"`    // X-GitHub-Delivery or X-Gitlab-Event-UUID header, or the ID of the payload
+	Event   string          `json:"event"` // X-GitHub-Event or X-Gitlab-Event header, or the type of the payload
+	Time    time.Time       `json:"time"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SaveWebhookDelivery stores a captured webhook delivery
+func (s *Store) SaveWebhookDelivery(delivery WebhookDelivery) error {
+	if !validDeliveryID.MatchString(delivery.ID) {
+		return fmt.Errorf("invalid delivery ID %q", delivery.ID)
+	}
+	if delivery.Time.IsZero() {
+		delivery.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dir, webhooksDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create webhook capture directory: %w", err)
+	}
+	return s.save(filepath.Join(webhooksDir, delivery.ID+".json"), delivery)
+}
+
+// GetWebhookDelivery returns a captured webhook delivery, or nil if none was captured under the ID
+func (s *Store) GetWebhookDelivery(id string) (*WebhookDelivery, error) {
+	if !validDeliveryID.MatchString(id) {
+		return nil, fmt.Errorf("invalid delivery ID %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var delivery *WebhookDelivery
+	if err := s.load(filepath.Join(webhooksDir, id+".json"), &delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,10 @@
+package prompts
+
+import "embed"
+
+// FS holds the default prompt templates, language snippets and checklists compiled into the
+// binary. Files with the same path in the directory set by PROMPTS_DIR override
+// them at runtime.
+//
+//go:embed *.txt languages/*.txt checklists/*.txt
+var FS embed.FS